@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli/v2"
+	"reichard.io/antholume/api"
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/database"
+)
+
+// passwordFlag is optional, as passwords on the command line end up in shell
+// history & process listings. See requirePassword.
+var passwordFlag = &cli.StringFlag{
+	Name:    "password",
+	Usage:   "User password (read from stdin when omitted).",
+	EnvVars: []string{"ANTHOLUME_PASSWORD"},
+}
+
+// adminCommands operate directly on the configured DB & data paths, and don't
+// require a running server.
+var adminCommands = []*cli.Command{
+	{
+		Name:  "user",
+		Usage: "Manage users.",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "Create a user.",
+				ArgsUsage: "<username>",
+				Flags: []cli.Flag{
+					passwordFlag,
+					&cli.BoolFlag{Name: "admin", Usage: "Grant admin."},
+				},
+				Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+					username, err := requireArg(ctx, "username")
+					if err != nil {
+						return err
+					}
+					password, err := requirePassword(ctx)
+					if err != nil {
+						return err
+					}
+					if err := a.CreateUser(ctx.Context, username, password, ctx.Bool("admin")); err != nil {
+						return err
+					}
+					fmt.Fprintf(ctx.App.Writer, "Created user %s\n", username)
+					return nil
+				}),
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a user (a DB backup is saved first).",
+				ArgsUsage: "<username>",
+				Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+					username, err := requireArg(ctx, "username")
+					if err != nil {
+						return err
+					}
+					if err := a.DeleteUser(ctx.Context, username); err != nil {
+						return err
+					}
+					fmt.Fprintf(ctx.App.Writer, "Deleted user %s\n", username)
+					return nil
+				}),
+			},
+			{
+				Name:      "set-password",
+				Usage:     "Set the password of a user.",
+				ArgsUsage: "<username>",
+				Flags:     []cli.Flag{passwordFlag},
+				Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+					username, err := requireArg(ctx, "username")
+					if err != nil {
+						return err
+					}
+					password, err := requirePassword(ctx)
+					if err != nil {
+						return err
+					}
+					if err := a.SetPassword(ctx.Context, username, password); err != nil {
+						return err
+					}
+					fmt.Fprintf(ctx.App.Writer, "Updated password of %s\n", username)
+					return nil
+				}),
+			},
+			{
+				Name:      "set-admin",
+				Usage:     "Grant or revoke (--admin=false) admin of a user.",
+				ArgsUsage: "<username>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "admin", Usage: "Whether the user is an admin.", Value: true},
+				},
+				Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+					username, err := requireArg(ctx, "username")
+					if err != nil {
+						return err
+					}
+					if err := a.SetAdmin(ctx.Context, username, ctx.Bool("admin")); err != nil {
+						return err
+					}
+					fmt.Fprintf(ctx.App.Writer, "Updated admin of %s to %t\n", username, ctx.Bool("admin"))
+					return nil
+				}),
+			},
+		},
+	},
+	{
+		Name:      "import",
+		Usage:     "Import all documents within a directory.",
+		ArgsUsage: "<directory>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "copy", Usage: "Copy the documents into the data path."},
+			&cli.BoolFlag{Name: "direct", Usage: "Reference the documents in place."},
+		},
+		Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+			directory, err := requireArg(ctx, "directory")
+			if err != nil {
+				return err
+			}
+			if ctx.Bool("copy") == ctx.Bool("direct") {
+				return cli.Exit("exactly one of --copy or --direct is required", 1)
+			}
+
+			importResults, err := a.ImportDirectory(ctx.Context, directory, ctx.Bool("copy"))
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STATUS\tPATH\tNAME\tERROR")
+			for _, result := range importResults {
+				var resultError string
+				if result.Error != nil {
+					resultError = result.Error.Error()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Status, result.Path, result.Name, resultError)
+			}
+			return w.Flush()
+		}),
+	},
+	{
+		Name:  "backup",
+		Usage: "Save a backup of the DB, covers and documents.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "out", Usage: "Backup ZIP file path.", Required: true},
+		},
+		Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+			backupFile, err := os.Create(ctx.String("out"))
+			if err != nil {
+				return err
+			}
+			defer backupFile.Close()
+
+			w := bufio.NewWriter(backupFile)
+			if err := a.Backup(ctx.Context, w); err != nil {
+				return err
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.App.Writer, "Saved backup to %s\n", ctx.String("out"))
+			return backupFile.Close()
+		}),
+	},
+	{
+		Name:  "restore",
+		Usage: "Restore a backup (a backup of the current data is saved first).",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "from", Usage: "Backup ZIP file path.", Required: true},
+		},
+		Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+			if err := a.Restore(ctx.Context, ctx.String("from")); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.App.Writer, "Restored backup from %s\n", ctx.String("from"))
+			return nil
+		}),
+	},
+	{
+		Name:  "cache-tables",
+		Usage: "Recalculate the cached statistics (streaks & document statistics).",
+		Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+			if err := a.CacheTempTables(ctx.Context); err != nil {
+				return err
+			}
+			fmt.Fprintln(ctx.App.Writer, "Cached tables")
+			return nil
+		}),
+	},
+	{
+		Name:  "migrate",
+		Usage: "Manage DB migrations.",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "List the migrations and whether they're applied.",
+				Action: withDB(func(ctx *cli.Context, db *sql.DB) error {
+					allStatus, err := database.GetMigrationStatus(ctx.Context, db)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+					for _, status := range allStatus {
+						fmt.Fprintf(w, "%d\t%s\t%t\n", status.Version, status.Name, status.Applied)
+					}
+					return w.Flush()
+				}),
+			},
+			{
+				Name:  "up",
+				Usage: "Apply all pending migrations.",
+				Action: withDB(func(ctx *cli.Context, db *sql.DB) error {
+					return database.MigrateUp(ctx.Context, db)
+				}),
+			},
+			{
+				Name:  "down",
+				Usage: "Roll back the most recent migration.",
+				Action: withDB(func(ctx *cli.Context, db *sql.DB) error {
+					return database.MigrateDown(ctx.Context, db)
+				}),
+			},
+		},
+	},
+	{
+		Name:  "documents",
+		Usage: "Manage documents.",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "scan-missing",
+				Usage: "List documents whose file is missing on disk.",
+				Action: withAPI(func(ctx *cli.Context, a *api.API) error {
+					missingDocuments, err := a.ScanMissingDocuments(ctx.Context)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tAUTHOR\tTITLE\tPATH")
+					for _, document := range missingDocuments {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", document.ID, document.Author, document.Title, document.FilePath)
+					}
+					if err := w.Flush(); err != nil {
+						return err
+					}
+					fmt.Fprintf(ctx.App.Writer, "\n%d missing document(s)\n", len(missingDocuments))
+					return nil
+				}),
+			},
+		},
+	},
+}
+
+// withAPI loads the config, DB & API (without starting the server) for the
+// action.
+func withAPI(action func(*cli.Context, *api.API) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// Silence Route Debug Output
+		gin.SetMode(gin.ReleaseMode)
+
+		db := database.NewMgr(c)
+		defer db.DB.Close()
+
+		return action(ctx, api.NewApi(db, c, embeddedAssets))
+	}
+}
+
+// withDB opens the configured DB without initializing the schema or running
+// migrations.
+func withDB(action func(*cli.Context, *sql.DB) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		db, err := database.OpenDB(c)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return action(ctx, db)
+	}
+}
+
+func loadConfig() (*config.Config, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, cli.Exit(fmt.Sprintf("Invalid configuration:\n%v", err), 1)
+	}
+	return c, nil
+}
+
+func requireArg(ctx *cli.Context, name string) (string, error) {
+	if ctx.NArg() != 1 || ctx.Args().First() == "" {
+		return "", cli.Exit(fmt.Sprintf("expected a single <%s> argument", name), 1)
+	}
+	return ctx.Args().First(), nil
+}
+
+// requirePassword returns the --password flag (or ANTHOLUME_PASSWORD), falling
+// back to the first line of stdin.
+func requirePassword(ctx *cli.Context) (string, error) {
+	if ctx.IsSet("password") {
+		return ctx.String("password"), nil
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(ctx.App.ErrWriter, "Password: ")
+	}
+	password, err := bufio.NewReader(ctx.App.Reader).ReadString('\n')
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return "", cli.Exit("a password is required (--password, ANTHOLUME_PASSWORD or stdin)", 1)
+	}
+	return password, nil
+}
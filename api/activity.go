@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"reichard.io/antholume/database"
+)
+
+const (
+	// Longer page durations are treated as the device being left open, and
+	// would otherwise skew reading statistics (e.g. WPM).
+	maxActivityDuration = time.Hour
+
+	// Allowed device clock drift for activity start times.
+	maxActivityClockSkew = 5 * time.Minute
+
+	// Maximum duplicate groups shown in the activity health report.
+	activityDuplicatesLimit = 100
+)
+
+// activityHealth summarizes activity that would be rejected or deduplicated
+// by the current ingest rules.
+type activityHealth struct {
+	Total      int64
+	Duplicates int64
+	Invalid    int64
+	Groups     []database.GetActivityDuplicatesRow
+}
+
+// validate verifies an activity item is plausible, returning the reason it
+// isn't.
+func (item activityItem) validate(now time.Time) error {
+	if item.DocumentID == "" {
+		return errors.New("missing document")
+	} else if item.Pages <= 0 {
+		return fmt.Errorf("invalid pages: %d", item.Pages)
+	} else if item.Page < 0 || item.Page > item.Pages {
+		return fmt.Errorf("invalid page: %d of %d", item.Page, item.Pages)
+	} else if item.Duration <= 0 || item.Duration > int64(maxActivityDuration.Seconds()) {
+		return fmt.Errorf("invalid duration: %d", item.Duration)
+	} else if item.StartTime <= 0 || time.Unix(item.StartTime, 0).After(now.Add(maxActivityClockSkew)) {
+		return fmt.Errorf("invalid start time: %d", item.StartTime)
+	}
+	return nil
+}
+
+// percentages returns the start & end percentage of a validated item. The
+// last page ends at 100%.
+func (item activityItem) percentages() (float64, float64) {
+	start := float64(item.Page) / float64(item.Pages)
+	end := math.Min(float64(item.Page+1)/float64(item.Pages), 1)
+	return start, end
+}
+
+// getActivityHealth returns the duplicate & invalid activity counts along with
+// the largest duplicate groups.
+func (api *API) getActivityHealth(ctx context.Context) (*activityHealth, error) {
+	health, err := api.db.Queries.GetActivityHealth(ctx, int64(maxActivityDuration.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("GetActivityHealth DB Error: %w", err)
+	}
+
+	groups, err := api.db.Queries.GetActivityDuplicates(ctx, activityDuplicatesLimit)
+	if err != nil {
+		return nil, fmt.Errorf("GetActivityDuplicates DB Error: %w", err)
+	}
+
+	return &activityHealth{
+		Total:      health.Total,
+		Duplicates: health.Duplicates,
+		Invalid:    health.Invalid,
+		Groups:     groups,
+	}, nil
+}
+
+// mergeDuplicateActivity collapses activity sharing a user, device, document &
+// start time into the earliest row, keeping the longest duration and widest
+// percentage range. Returns the number of removed rows.
+func (api *API) mergeDuplicateActivity(ctx context.Context) (int64, error) {
+	tx, err := api.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("Transaction Begin DB Error: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := api.db.Queries.WithTx(tx)
+
+	if _, err := qtx.MergeDuplicateActivity(ctx, database.MergeDuplicateActivityParams{}); err != nil {
+		return 0, fmt.Errorf("MergeDuplicateActivity DB Error: %w", err)
+	}
+
+	removed, err := qtx.DeleteDuplicateActivity(ctx, database.DeleteDuplicateActivityParams{})
+	if err != nil {
+		return 0, fmt.Errorf("DeleteDuplicateActivity DB Error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Transaction Commit DB Error: %w", err)
+	}
+
+	return removed, api.CacheTempTables(ctx)
+}
+
+// deleteInvalidActivity removes activity that wouldn't pass ingest validation.
+// Returns the number of removed rows.
+func (api *API) deleteInvalidActivity(ctx context.Context) (int64, error) {
+	removed, err := api.db.Queries.DeleteInvalidActivity(ctx, int64(maxActivityDuration.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("DeleteInvalidActivity DB Error: %w", err)
+	}
+
+	return removed, api.CacheTempTables(ctx)
+}
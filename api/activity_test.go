@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func koActivity(startTime, duration, page, pages int64) gin.H {
+	return gin.H{
+		"document":   "document-00",
+		"start_time": startTime,
+		"duration":   duration,
+		"page":       page,
+		"pages":      pages,
+	}
+}
+
+func TestAddActivity(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	now := time.Now().Unix()
+
+	request := gin.H{
+		"device":    "kindle",
+		"device_id": "kindle-id",
+		"activity": []gin.H{
+			koActivity(now-120, 30, 1, 10),
+			koActivity(now-60, 30, 10, 10),
+			koActivity(now-60, 30, 10, 10),   // Duplicate In Batch
+			koActivity(now-30, 30, 1, 0),     // Zero Pages
+			koActivity(now-30, -5, 1, 10),    // Negative Duration
+			koActivity(now-30, 86400, 1, 10), // Huge Duration
+			koActivity(now+3600, 30, 1, 10),  // Future
+		},
+	}
+
+	code, body := koRequest(t, server, http.MethodPost, "/api/ko/activity", request)
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 2, body["added"])
+	assert.EqualValues(t, 1, body["duplicates"])
+	assert.EqualValues(t, 4, body["rejected"])
+
+	// Replay - Idempotent
+	code, body = koRequest(t, server, http.MethodPost, "/api/ko/activity", request)
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 0, body["added"])
+	assert.EqualValues(t, 3, body["duplicates"])
+
+	var count int
+	var maxEnd float64
+	err := api.db.DB.QueryRow("SELECT COUNT(*), MAX(end_percentage) FROM activity").Scan(&count, &maxEnd)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1.0, maxEnd, "last page should end at 100%")
+}
+
+func TestActivityHealth(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	now := time.Now().Unix()
+
+	code, _ := koRequest(t, server, http.MethodPost, "/api/ko/activity", gin.H{
+		"device":    "kindle",
+		"device_id": "kindle-id",
+		"activity":  []gin.H{koActivity(now-120, 30, 1, 10)},
+	})
+	require.Equal(t, http.StatusOK, code)
+
+	// Legacy Duplicates (Prior To Unique Index) & Invalid Activity
+	_, err := api.db.DB.Exec("DROP INDEX activity_user_id_device_id_document_id_start_time")
+	require.NoError(t, err)
+	startTime := time.Unix(now-120, 0).UTC().Format(time.RFC3339)
+	for _, row := range []struct {
+		startTime string
+		duration  int64
+		start     float64
+		end       float64
+	}{
+		{startTime, 45, 0.05, 0.2},
+		{startTime, 10, 0.1, 0.2},
+		{startTime, 86400, 0.3, 0.4},
+	} {
+		_, err := api.db.DB.Exec(`
+			INSERT INTO activity (user_id, document_id, device_id, start_time, duration, start_percentage, end_percentage)
+			VALUES ('reader', 'document-00', 'kindle-id', ?, ?, ?, ?)`,
+			row.startTime, row.duration, row.start, row.end,
+		)
+		require.NoError(t, err)
+	}
+
+	health, err := api.getActivityHealth(t.Context())
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, health.Total)
+	assert.EqualValues(t, 3, health.Duplicates)
+	assert.EqualValues(t, 1, health.Invalid)
+	require.Len(t, health.Groups, 1)
+	assert.EqualValues(t, 4, health.Groups[0].Count)
+	assert.Equal(t, "kindle", health.Groups[0].DeviceName)
+
+	t.Run("merge duplicates", func(t *testing.T) {
+		removed, err := api.mergeDuplicateActivity(t.Context())
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, removed)
+
+		var duration int64
+		var start, end float64
+		err = api.db.DB.QueryRow("SELECT duration, start_percentage, end_percentage FROM activity").Scan(&duration, &start, &end)
+		require.NoError(t, err)
+		assert.EqualValues(t, 86400, duration, "should keep longest duration")
+		assert.Equal(t, 0.05, start)
+		assert.Equal(t, 0.4, end)
+	})
+
+	t.Run("delete invalid", func(t *testing.T) {
+		removed, err := api.deleteInvalidActivity(t.Context())
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, removed)
+
+		health, err := api.getActivityHealth(t.Context())
+		require.NoError(t, err)
+		assert.Zero(t, health.Total)
+	})
+}
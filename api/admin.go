@@ -0,0 +1,130 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+// MissingDocument is a document whose file no longer exists on disk
+type MissingDocument struct {
+	ID       string
+	Title    string
+	Author   string
+	FilePath string
+}
+
+// CreateUser creates a user with the default role.
+func (api *API) CreateUser(ctx context.Context, user, password string, isAdmin bool) error {
+	return api.createUser(ctx, user, &password, &isAdmin, nil)
+}
+
+// DeleteUser deletes the user after saving a DB backup. The last admin can't
+// be deleted.
+func (api *API) DeleteUser(ctx context.Context, user string) error {
+	if _, err := api.db.Queries.GetUser(ctx, user); errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %s does not exist", user)
+	} else if err != nil {
+		return fmt.Errorf("GetUser DB Error: %w", err)
+	}
+	return api.deleteUser(ctx, user)
+}
+
+// SetPassword updates the password of the user, which also rotates
+// the auth hash.
+func (api *API) SetPassword(ctx context.Context, user, password string) error {
+	return api.updateUser(ctx, user, &password, nil, nil)
+}
+
+// SetAdmin promotes or demotes the user. The last admin can't be demoted.
+func (api *API) SetAdmin(ctx context.Context, user string, isAdmin bool) error {
+	return api.updateUser(ctx, user, nil, &isAdmin, nil)
+}
+
+// ImportDirectory runs an import job of all documents within the directory in
+// the foreground. When copyFiles is set the files are copied into the data
+// path, otherwise they're referenced in place.
+func (api *API) ImportDirectory(ctx context.Context, directory string, copyFiles bool) ([]ImportResult, error) {
+	iType := importDirect
+	if copyFiles {
+		iType = importCopy
+	}
+
+	importJob, err := api.createImportJob(ctx, directory, iType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run In Foreground - Claimed Before A Job Runner Can
+	job, err := api.db.Queries.StartJob(ctx, *importJob.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("StartJob DB Error: %w", err)
+	}
+
+	jobErr := api.runImportJob(ctx, importJob.ID)
+	result := database.CompleteJobParams{Status: string(JobSucceeded), ID: job.ID}
+	if jobErr != nil {
+		result.Status = string(JobFailed)
+		result.Error = ptr.Of(jobErr.Error())
+	}
+	if err := api.db.Queries.CompleteJob(ctx, result); err != nil {
+		return nil, fmt.Errorf("CompleteJob DB Error: %w", err)
+	} else if jobErr != nil {
+		return nil, jobErr
+	}
+
+	return api.getImportJobResults(ctx, importJob.ID)
+}
+
+// Backup writes a backup archive of the DB, covers and documents to w.
+func (api *API) Backup(ctx context.Context, w io.Writer) error {
+	return api.createBackup(ctx, w, []string{"covers", "documents"})
+}
+
+// Restore replaces all data with the contents of the backup archive, after
+// saving a backup of the current data to the backups directory.
+func (api *API) Restore(ctx context.Context, zipPath string) error {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	return api.restoreBackup(ctx, &zipReader.Reader)
+}
+
+// ScanMissingDocuments returns the documents whose file doesn't exist on disk.
+func (api *API) ScanMissingDocuments(ctx context.Context) ([]MissingDocument, error) {
+	documentFiles, err := api.db.Queries.GetDocumentFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentFiles DB Error: %w", err)
+	}
+
+	var missingDocuments []MissingDocument
+	for _, document := range documentFiles {
+		filePath := api.documentFilePath(document.Basepath, *document.Filepath)
+		if _, err := os.Stat(filePath); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		missingDocument := MissingDocument{ID: document.ID, FilePath: filePath}
+		if document.Title != nil {
+			missingDocument.Title = *document.Title
+		}
+		if document.Author != nil {
+			missingDocument.Author = *document.Author
+		}
+		missingDocuments = append(missingDocuments, missingDocument)
+	}
+
+	return missingDocuments, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestAdminUsers(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	ctx := t.Context()
+	require.NoError(t, os.MkdirAll(filepath.Join(api.cfg.ConfigPath, "backups"), 0755))
+
+	require.NoError(t, api.CreateUser(ctx, "admin", "pass", true))
+	assert.ErrorContains(t, api.CreateUser(ctx, "admin", "pass", false), "already exists")
+
+	user, err := api.db.Queries.GetUser(ctx, "admin")
+	require.NoError(t, err)
+	assert.True(t, user.Admin)
+
+	// Last Admin
+	assert.ErrorContains(t, api.SetAdmin(ctx, "admin", false), "last admin")
+	require.NoError(t, api.SetAdmin(ctx, "reader", true))
+	require.NoError(t, api.SetAdmin(ctx, "admin", false))
+
+	// Password & Auth Hash Rotation
+	require.NoError(t, api.SetPassword(ctx, "admin", "new-pass"))
+	updatedUser, err := api.db.Queries.GetUser(ctx, "admin")
+	require.NoError(t, err)
+	assert.NotEqual(t, user.Pass, updatedUser.Pass)
+	assert.NotEqual(t, user.AuthHash, updatedUser.AuthHash)
+	assert.ErrorContains(t, api.SetPassword(ctx, "admin", ""), "empty")
+
+	// Delete
+	assert.ErrorContains(t, api.DeleteUser(ctx, "unknown"), "does not exist")
+}
+
+func TestAdminScanMissingDocuments(t *testing.T) {
+	api, _ := newTestAPI(t, 2)
+	ctx := t.Context()
+
+	// Present Document
+	basePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "present.epub"), []byte("epub"), 0644))
+	_, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:       "document-00",
+		Filepath: ptr.Of("present.epub"),
+		Basepath: &basePath,
+	})
+	require.NoError(t, err)
+
+	missingDocuments, err := api.ScanMissingDocuments(ctx)
+	require.NoError(t, err)
+	require.Len(t, missingDocuments, 1)
+	assert.Equal(t, "document-01", missingDocuments[0].ID)
+	assert.Equal(t, "Title 01", missingDocuments[0].Title)
+	assert.Equal(t, filepath.Join(api.cfg.DataPath, "documents", "document-01.epub"), missingDocuments[0].FilePath)
+}
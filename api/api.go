@@ -9,38 +9,48 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/multitemplate"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"reichard.io/antholume/config"
 	"reichard.io/antholume/database"
-	"reichard.io/antholume/utils"
+	"reichard.io/antholume/metrics"
 )
 
 type API struct {
-	db            *database.DBManager
-	cfg           *config.Config
-	assets        fs.FS
-	httpServer    *http.Server
-	templates     map[string]*template.Template
-	userAuthCache map[string]string
+	db         *database.DBManager
+	cfg        *config.Config
+	assets     fs.FS
+	httpServer *http.Server
+	templates  map[string]*template.Template
+
+	cookieCodecs *cookieCodecs
+	authCache    *authCache
+
+	jobQueue chan struct{}
+
+	watchMu      sync.Mutex
+	watchedFiles map[string]*watchedFile
 }
 
 var htmlPolicy = bluemonday.StrictPolicy()
 
 func NewApi(db *database.DBManager, c *config.Config, assets fs.FS) *API {
 	api := &API{
-		db:            db,
-		cfg:           c,
-		assets:        assets,
-		templates:     make(map[string]*template.Template),
-		userAuthCache: make(map[string]string),
+		db:        db,
+		cfg:       c,
+		assets:    assets,
+		templates: make(map[string]*template.Template),
+		authCache: newAuthCache(),
+
+		jobQueue:     make(chan struct{}, 1),
+		watchedFiles: make(map[string]*watchedFile),
 	}
 
 	// Create router
@@ -52,8 +62,9 @@ func NewApi(db *database.DBManager, c *config.Config, assets fs.FS) *API {
 		Addr:    (":" + c.ListenPort),
 	}
 
-	// Add global logging middleware
+	// Add global logging & metrics middleware
 	router.Use(loggingMiddleware)
+	router.Use(metricsMiddleware)
 
 	// Add global template loader middleware (develop)
 	if c.Version == "develop" {
@@ -65,30 +76,13 @@ func NewApi(db *database.DBManager, c *config.Config, assets fs.FS) *API {
 	assetsDir, _ := fs.Sub(assets, "assets")
 	router.StaticFS("/assets", http.FS(assetsDir))
 
-	// Generate auth token
-	var newToken []byte
-	var err error
-	if c.CookieAuthKey != "" {
-		log.Info("utilizing environment cookie auth key")
-		newToken = []byte(c.CookieAuthKey)
-	} else {
-		log.Info("generating cookie auth key")
-		newToken, err = utils.GenerateToken(64)
-		if err != nil {
-			log.Panic("unable to generate cookie auth key")
-		}
-	}
-
-	// Set enc token
-	store := cookie.NewStore(newToken)
-	if c.CookieEncKey != "" {
-		if len(c.CookieEncKey) == 16 || len(c.CookieEncKey) == 32 {
-			log.Info("utilizing environment cookie encryption key")
-			store = cookie.NewStore(newToken, []byte(c.CookieEncKey))
-		} else {
-			log.Panic("invalid cookie encryption key (must be 16 or 32 bytes)")
-		}
+	// Load cookie keys
+	keyPairs, err := getCookieKeyPairs(c)
+	if err != nil {
+		log.Panic("unable to load cookie keys: ", err)
 	}
+	api.cookieCodecs = newCookieCodecs(keyPairs)
+	store := newCookieStore(api.cookieCodecs)
 
 	// Configure cookie session store
 	store.Options(sessions.Options{
@@ -106,6 +100,16 @@ func NewApi(db *database.DBManager, c *config.Config, assets fs.FS) *API {
 	apiGroup := router.Group("/api")
 	api.registerKOAPIRoutes(apiGroup)
 	api.registerOPDSRoutes(apiGroup)
+	api.registerV1Routes(apiGroup)
+
+	// Register health routes
+	router.GET("/healthz", api.getHealth)
+	router.GET("/readyz", api.getReadiness)
+
+	// Register metrics route
+	if c.MetricsEnabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler(db.TotalsCollector())))
+	}
 
 	return api
 }
@@ -151,16 +155,29 @@ func (api *API) registerWebAppRoutes(router *gin.Engine) {
 	router.GET("/documents", api.authWebAppMiddleware, api.appGetDocuments)
 	router.GET("/documents/:document", api.authWebAppMiddleware, api.appGetDocument)
 	router.GET("/documents/:document/cover", api.authWebAppMiddleware, api.createGetCoverHandler(appErrorPage))
-	router.GET("/documents/:document/file", api.authWebAppMiddleware, api.createDownloadDocumentHandler(appErrorPage))
+	router.GET("/documents/:document/file", api.authWebAppMiddleware, api.authPermissionMiddleware(permDownload, appErrorPage), api.createDownloadDocumentHandler(appErrorPage))
+	router.GET("/documents/:document/progress", api.authWebAppMiddleware, api.appGetDocumentProgressHistory)
 	router.GET("/login", api.appGetLogin)
 	router.GET("/logout", api.authWebAppMiddleware, api.appAuthLogout)
 	router.GET("/register", api.appGetRegister)
 	router.GET("/settings", api.authWebAppMiddleware, api.appGetSettings)
+	router.GET("/admin/activity", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminActivity)
+	router.POST("/admin/activity", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminActivity)
+	router.GET("/admin/jobs", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminJobs)
+	router.POST("/admin/jobs", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminJobs)
+	router.GET("/admin/invites", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminInvites)
+	router.POST("/admin/invites", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminInvites)
 	router.GET("/admin/logs", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminLogs)
 	router.GET("/admin/import", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminImport)
 	router.POST("/admin/import", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appPerformAdminImport)
+	router.GET("/admin/import/jobs/:job", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminImportJob)
+	router.POST("/admin/import/jobs/:job", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminImportJob)
+	router.GET("/admin/roles", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminRoles)
+	router.POST("/admin/roles", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminRoles)
 	router.GET("/admin/users", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminUsers)
 	router.POST("/admin/users", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminUsers)
+	router.GET("/admin/webhooks", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdminWebhooks)
+	router.POST("/admin/webhooks", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appUpdateAdminWebhooks)
 	router.GET("/admin", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appGetAdmin)
 	router.POST("/admin", api.authWebAppMiddleware, api.authAdminWebAppMiddleware, api.appPerformAdminAction)
 	router.POST("/login", api.appAuthLogin)
@@ -172,19 +189,33 @@ func (api *API) registerWebAppRoutes(router *gin.Engine) {
 		router.POST("/documents/:document/delete", api.authWebAppMiddleware, api.appDemoModeError)
 		router.POST("/documents/:document/edit", api.authWebAppMiddleware, api.appDemoModeError)
 		router.POST("/documents/:document/identify", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/documents/:document/progress", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/documents/:document/shares", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/documents/:document/visibility", api.authWebAppMiddleware, api.appDemoModeError)
 		router.POST("/settings", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/settings/api-tokens", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/settings/devices", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/settings/sessions", api.authWebAppMiddleware, api.appDemoModeError)
+		router.POST("/settings/webhooks", api.authWebAppMiddleware, api.appDemoModeError)
 	} else {
-		router.POST("/documents", api.authWebAppMiddleware, api.appUploadNewDocument)
-		router.POST("/documents/:document/delete", api.authWebAppMiddleware, api.appDeleteDocument)
-		router.POST("/documents/:document/edit", api.authWebAppMiddleware, api.appEditDocument)
-		router.POST("/documents/:document/identify", api.authWebAppMiddleware, api.appIdentifyDocument)
+		router.POST("/documents", api.authWebAppMiddleware, api.authPermissionMiddleware(permUpload, appErrorPage), api.appUploadNewDocument)
+		router.POST("/documents/:document/delete", api.authWebAppMiddleware, api.authPermissionMiddleware(permDelete, appErrorPage), api.appDeleteDocument)
+		router.POST("/documents/:document/edit", api.authWebAppMiddleware, api.authPermissionMiddleware(permEdit, appErrorPage), api.appEditDocument)
+		router.POST("/documents/:document/identify", api.authWebAppMiddleware, api.authPermissionMiddleware(permEdit, appErrorPage), api.appIdentifyDocument)
+		router.POST("/documents/:document/progress", api.authWebAppMiddleware, api.authPermissionMiddleware(permSync, appErrorPage), api.appRestoreDocumentProgress)
+		router.POST("/documents/:document/shares", api.authWebAppMiddleware, api.appUpdateDocumentShares)
+		router.POST("/documents/:document/visibility", api.authWebAppMiddleware, api.appUpdateDocumentVisibility)
 		router.POST("/settings", api.authWebAppMiddleware, api.appEditSettings)
+		router.POST("/settings/api-tokens", api.authWebAppMiddleware, api.appRevokeAPIToken)
+		router.POST("/settings/devices", api.authWebAppMiddleware, api.appEditDevice)
+		router.POST("/settings/sessions", api.authWebAppMiddleware, api.appRevokeSession)
+		router.POST("/settings/webhooks", api.authWebAppMiddleware, api.appEditWebhooks)
 	}
 
 	// Search enabled configuration
 	if api.cfg.SearchEnabled {
-		router.GET("/search", api.authWebAppMiddleware, api.appGetSearch)
-		router.POST("/search", api.authWebAppMiddleware, api.appSaveNewDocument)
+		router.GET("/search", api.authWebAppMiddleware, api.authPermissionMiddleware(permSearch, appErrorPage), api.appGetSearch)
+		router.POST("/search", api.authWebAppMiddleware, api.authPermissionMiddleware(permSearch, appErrorPage), api.authPermissionMiddleware(permUpload, appErrorPage), api.appSaveNewDocument)
 	}
 }
 
@@ -192,23 +223,25 @@ func (api *API) registerKOAPIRoutes(apiGroup *gin.RouterGroup) {
 	koGroup := apiGroup.Group("/ko")
 
 	// KO sync routes (webapp uses - progress & activity)
-	koGroup.GET("/documents/:document/file", api.authKOMiddleware, api.createDownloadDocumentHandler(apiErrorPage))
-	koGroup.GET("/syncs/progress/:document", api.authKOMiddleware, api.koGetProgress)
-	koGroup.GET("/users/auth", api.authKOMiddleware, api.koAuthorizeUser)
-	koGroup.POST("/activity", api.authKOMiddleware, api.koAddActivities)
-	koGroup.POST("/syncs/activity", api.authKOMiddleware, api.koCheckActivitySync)
-	koGroup.POST("/users/create", api.koAuthRegister)
-	koGroup.PUT("/syncs/progress", api.authKOMiddleware, api.koSetProgress)
+	koGroup.GET("/documents/:document/file", koOperationMiddleware("download_document"), api.authKOMiddleware, api.authPermissionMiddleware(permDownload, apiErrorPage), api.createDownloadDocumentHandler(apiErrorPage))
+	koGroup.GET("/syncs/progress/:document", koOperationMiddleware("get_progress"), api.authKOMiddleware, api.koGetProgress)
+	koGroup.GET("/syncs/progress/:document/devices", koOperationMiddleware("get_devices_progress"), api.authKOMiddleware, api.koGetDevicesProgress)
+	koGroup.GET("/healthcheck", api.koHealthCheck)
+	koGroup.GET("/users/auth", koOperationMiddleware("authorize"), api.authKOMiddleware, api.koAuthorizeUser)
+	koGroup.POST("/activity", koOperationMiddleware("add_activity"), api.authKOMiddleware, api.authPermissionMiddleware(permSync, apiErrorPage), api.koAddActivities)
+	koGroup.POST("/syncs/activity", koOperationMiddleware("check_activity"), api.authKOMiddleware, api.authPermissionMiddleware(permSync, apiErrorPage), api.koCheckActivitySync)
+	koGroup.POST("/users/create", koOperationMiddleware("create_user"), api.koAuthRegister)
+	koGroup.PUT("/syncs/progress", koOperationMiddleware("update_progress"), api.authKOMiddleware, api.authPermissionMiddleware(permSync, apiErrorPage), api.koSetProgress)
 
 	// Demo mode enabled configuration
 	if api.cfg.DemoMode {
-		koGroup.POST("/documents", api.authKOMiddleware, api.koDemoModeJSONError)
-		koGroup.POST("/syncs/documents", api.authKOMiddleware, api.koDemoModeJSONError)
-		koGroup.PUT("/documents/:document/file", api.authKOMiddleware, api.koDemoModeJSONError)
+		koGroup.POST("/documents", koOperationMiddleware("add_documents"), api.authKOMiddleware, api.koDemoModeJSONError)
+		koGroup.POST("/syncs/documents", koOperationMiddleware("check_documents"), api.authKOMiddleware, api.koDemoModeJSONError)
+		koGroup.PUT("/documents/:document/file", koOperationMiddleware("upload_document"), api.authKOMiddleware, api.koDemoModeJSONError)
 	} else {
-		koGroup.POST("/documents", api.authKOMiddleware, api.koAddDocuments)
-		koGroup.POST("/syncs/documents", api.authKOMiddleware, api.koCheckDocumentsSync)
-		koGroup.PUT("/documents/:document/file", api.authKOMiddleware, api.koUploadExistingDocument)
+		koGroup.POST("/documents", koOperationMiddleware("add_documents"), api.authKOMiddleware, api.authPermissionMiddleware(permUpload, apiErrorPage), api.koAddDocuments)
+		koGroup.POST("/syncs/documents", koOperationMiddleware("check_documents"), api.authKOMiddleware, api.authPermissionMiddleware(permSync, apiErrorPage), api.koCheckDocumentsSync)
+		koGroup.PUT("/documents/:document/file", koOperationMiddleware("upload_document"), api.authKOMiddleware, api.authPermissionMiddleware(permUpload, apiErrorPage), api.koUploadExistingDocument)
 	}
 }
 
@@ -220,8 +253,17 @@ func (api *API) registerOPDSRoutes(apiGroup *gin.RouterGroup) {
 	opdsGroup.GET("/", api.authOPDSMiddleware, api.opdsEntry)
 	opdsGroup.GET("/search.xml", api.authOPDSMiddleware, api.opdsSearchDescription)
 	opdsGroup.GET("/documents", api.authOPDSMiddleware, api.opdsDocuments)
+	opdsGroup.GET("/v2", api.authOPDSMiddleware, api.opds2Entry)
+	opdsGroup.GET("/authors", api.authOPDSMiddleware, api.opdsGroups("author", "Authors"))
+	opdsGroup.GET("/series", api.authOPDSMiddleware, api.opdsGroups("series", "Series"))
+	opdsGroup.GET("/languages", api.authOPDSMiddleware, api.opdsGroups("lang", "Languages"))
+	opdsGroup.GET("/v2/documents", api.authOPDSMiddleware, api.opds2Documents)
+	opdsGroup.GET("/v2/authors", api.authOPDSMiddleware, api.opds2Groups("author", "Authors"))
+	opdsGroup.GET("/v2/series", api.authOPDSMiddleware, api.opds2Groups("series", "Series"))
+	opdsGroup.GET("/v2/languages", api.authOPDSMiddleware, api.opds2Groups("lang", "Languages"))
 	opdsGroup.GET("/documents/:document/cover", api.authOPDSMiddleware, api.createGetCoverHandler(apiErrorPage))
-	opdsGroup.GET("/documents/:document/file", api.authOPDSMiddleware, api.createDownloadDocumentHandler(apiErrorPage))
+	opdsGroup.GET("/documents/:document/pages/:page", api.authOPDSMiddleware, api.authPermissionMiddleware(permDownload, apiErrorPage), api.opdsDocumentPage)
+	opdsGroup.GET("/documents/:document/file", api.authOPDSMiddleware, api.authPermissionMiddleware(permDownload, apiErrorPage), api.createDownloadDocumentHandler(apiErrorPage))
 }
 
 func (api *API) generateTemplates() *multitemplate.Renderer {
@@ -235,8 +277,10 @@ func (api *API) generateTemplates() *multitemplate.Renderer {
 		"getSVGGraphData": getSVGGraphData,
 		"getTimeZones":    getTimeZones,
 		"hasPrefix":       strings.HasPrefix,
+		"hasSuffix":       strings.HasSuffix,
 		"niceNumbers":     niceNumbers,
 		"niceSeconds":     niceSeconds,
+		"niceUserAgent":   niceUserAgent,
 	}
 
 	// Load Base
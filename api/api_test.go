@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+// newTestAPI returns an API backed by an in-memory database with the user
+// "reader" (password "pass") and the given number of documents, served by a
+// test server.
+func newTestAPI(t *testing.T, documentCount int) (*API, *httptest.Server) {
+	cfg := &config.Config{DBType: "memory", ConfigPath: t.TempDir(), DataPath: t.TempDir()}
+	db := database.NewMgr(cfg)
+	api := NewApi(db, cfg, os.DirFS(".."))
+
+	ctx := context.Background()
+	require.NoError(t, api.createUser(ctx, "reader", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+	for i := range documentCount {
+		lang := "en"
+		if i%2 == 1 {
+			lang = "de"
+		}
+		_, err := db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+			ID:          fmt.Sprintf("document-%02d", i),
+			Title:       ptr.Of(fmt.Sprintf("Title %02d", i)),
+			Author:      ptr.Of("Author"),
+			Lang:        ptr.Of(lang),
+			Description: ptr.Of("Description"),
+			Isbn13:      ptr.Of("9780000000000"),
+			Filepath:    ptr.Of(fmt.Sprintf("document-%02d.epub", i)),
+		})
+		require.NoError(t, err)
+	}
+
+	server := httptest.NewServer(api.httpServer.Handler)
+	t.Cleanup(server.Close)
+	return api, server
+}
@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"context"
 	"crypto/md5"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +15,6 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
-	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"reichard.io/antholume/database"
 	"reichard.io/antholume/metadata"
+	"reichard.io/antholume/pkg/ptr"
 	"reichard.io/antholume/utils"
 )
 
@@ -61,18 +62,52 @@ type requestAdminImport struct {
 	Type      importType `form:"type"`
 }
 
+type requestAdminImportJob struct {
+	JobID     int64         `uri:"job" binding:"required"`
+	Operation operationType `form:"operation"`
+}
+
 type operationType string
 
 const (
 	opUpdate operationType = "UPDATE"
 	opCreate operationType = "CREATE"
 	opDelete operationType = "DELETE"
+	opRevoke operationType = "REVOKE"
+	opMerge  operationType = "MERGE"
+	opCancel operationType = "CANCEL"
+	opResume operationType = "RESUME"
+	opRetry  operationType = "RETRY"
 )
 
 type requestAdminUpdateUser struct {
 	User      string        `form:"user"`
 	Password  *string       `form:"password"`
 	IsAdmin   *bool         `form:"is_admin"`
+	Role      *string       `form:"role"`
+	Operation operationType `form:"operation"`
+}
+
+type requestAdminUpdateRole struct {
+	Role        string        `form:"role"`
+	Permissions []permission  `form:"permissions"`
+	Operation   operationType `form:"operation"`
+}
+
+type requestAdminUpdateInvite struct {
+	Code        string        `form:"code"`
+	Role        string        `form:"role"`
+	MaxUses     int64         `form:"max_uses"`
+	ExpiresDays int           `form:"expires_days"`
+	Operation   operationType `form:"operation"`
+}
+
+type requestAdminActivity struct {
+	Operation operationType `form:"operation"`
+}
+
+type requestAdminJob struct {
+	JobID     int64         `form:"job_id" binding:"required"`
 	Operation operationType `form:"operation"`
 }
 
@@ -80,24 +115,31 @@ type requestAdminLogs struct {
 	Filter string `form:"filter"`
 }
 
-type importStatus string
+type ImportStatus string
 
 const (
-	importFailed  importStatus = "FAILED"
-	importSuccess importStatus = "SUCCESS"
-	importExists  importStatus = "EXISTS"
+	importFailed  ImportStatus = "FAILED"
+	importSuccess ImportStatus = "SUCCESS"
+	importExists  ImportStatus = "EXISTS"
 )
 
-type importResult struct {
+var (
+	errImportDataPath     = errors.New("directory is the same as data path")
+	errRestoreMissingDB   = errors.New("invalid restore zip - missing db")
+	errRestoreInvalidFile = errors.New("invalid restore zip - invalid file(s)")
+)
+
+// ImportResult is the outcome of importing a single file
+type ImportResult struct {
 	ID     string
 	Name   string
 	Path   string
-	Status importStatus
+	Status ImportStatus
 	Error  error
 }
 
 func (api *API) appPerformAdminAction(c *gin.Context) {
-	templateVars, _ := api.getBaseTemplateVars("admin", c)
+	templateVars, auth := api.getBaseTemplateVars("admin", c)
 
 	var rAdminAction requestAdminAction
 	if err := c.ShouldBind(&rAdminAction); err != nil {
@@ -112,12 +154,14 @@ func (api *API) appPerformAdminAction(c *gin.Context) {
 		// 1. Documents xref most recent metadata table?
 		// 2. Select all / deselect?
 	case adminCacheTables:
-		go func() {
-			err := api.db.CacheTempTables(c)
-			if err != nil {
-				log.Error("Unable to cache temp tables: ", err)
-			}
-		}()
+		jobID, err := api.EnqueueJob(c, CacheTablesJob{}, &auth.UserName)
+		if err != nil {
+			log.Error("Unable to enqueue cache tables job: ", err)
+			appErrorPage(c, http.StatusInternalServerError, "Unable to enqueue cache tables job")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("./admin/jobs#job-%d", jobID))
+		return
 	case adminRestore:
 		api.processRestoreFile(rAdminAction, c)
 		return
@@ -269,7 +313,15 @@ func (api *API) appGetAdminUsers(c *gin.Context) {
 		return
 	}
 
+	roles, err := api.db.Queries.GetRoles(c)
+	if err != nil {
+		log.Error("GetRoles DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetRoles DB Error: %v", err))
+		return
+	}
+
 	templateVars["Data"] = users
+	templateVars["Roles"] = roles
 
 	c.HTML(http.StatusOK, "page/admin-users", templateVars)
 }
@@ -293,11 +345,13 @@ func (api *API) appUpdateAdminUsers(c *gin.Context) {
 	var err error
 	switch rUpdate.Operation {
 	case opCreate:
-		err = api.createUser(c, rUpdate.User, rUpdate.Password, rUpdate.IsAdmin)
+		err = api.createUser(c, rUpdate.User, rUpdate.Password, rUpdate.IsAdmin, rUpdate.Role)
 	case opUpdate:
-		err = api.updateUser(c, rUpdate.User, rUpdate.Password, rUpdate.IsAdmin)
+		err = api.updateUser(c, rUpdate.User, rUpdate.Password, rUpdate.IsAdmin, rUpdate.Role)
 	case opDelete:
 		err = api.deleteUser(c, rUpdate.User)
+	case opRevoke:
+		err = api.revokeUserSessions(c, rUpdate.User)
 	default:
 		appErrorPage(c, http.StatusNotFound, "Unknown user operation")
 		return
@@ -315,13 +369,85 @@ func (api *API) appUpdateAdminUsers(c *gin.Context) {
 		return
 	}
 
+	roles, err := api.db.Queries.GetRoles(c)
+	if err != nil {
+		log.Error("GetRoles DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetRoles DB Error: %v", err))
+		return
+	}
+
 	templateVars["Data"] = users
+	templateVars["Roles"] = roles
 
 	c.HTML(http.StatusOK, "page/admin-users", templateVars)
 }
 
+func (api *API) appGetAdminRoles(c *gin.Context) {
+	templateVars, _ := api.getBaseTemplateVars("admin-roles", c)
+
+	roles, err := api.db.Queries.GetRoles(c)
+	if err != nil {
+		log.Error("GetRoles DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetRoles DB Error: %v", err))
+		return
+	}
+
+	templateVars["Data"] = roles
+	templateVars["Permissions"] = allPermissions
+
+	c.HTML(http.StatusOK, "page/admin-roles", templateVars)
+}
+
+func (api *API) appUpdateAdminRoles(c *gin.Context) {
+	templateVars, _ := api.getBaseTemplateVars("admin-roles", c)
+
+	var rUpdate requestAdminUpdateRole
+	if err := c.ShouldBind(&rUpdate); err != nil {
+		log.Error("Invalid Form Bind: ", err)
+		appErrorPage(c, http.StatusNotFound, "Invalid role parameters")
+		return
+	}
+
+	// Ensure Role Name
+	rUpdate.Role = strings.ToLower(strings.TrimSpace(rUpdate.Role))
+	if rUpdate.Role == "" {
+		appErrorPage(c, http.StatusBadRequest, "Role cannot be empty")
+		return
+	}
+
+	var err error
+	switch rUpdate.Operation {
+	case opCreate:
+		err = api.createRole(c, rUpdate.Role, rUpdate.Permissions)
+	case opUpdate:
+		err = api.updateRole(c, rUpdate.Role, rUpdate.Permissions)
+	case opDelete:
+		err = api.deleteRole(c, rUpdate.Role)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown role operation")
+		return
+	}
+
+	if err != nil {
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to create or update role: %v", err))
+		return
+	}
+
+	roles, err := api.db.Queries.GetRoles(c)
+	if err != nil {
+		log.Error("GetRoles DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetRoles DB Error: %v", err))
+		return
+	}
+
+	templateVars["Data"] = roles
+	templateVars["Permissions"] = allPermissions
+
+	c.HTML(http.StatusOK, "page/admin-roles", templateVars)
+}
+
 func (api *API) appGetAdminImport(c *gin.Context) {
-	templateVars, _ := api.getBaseTemplateVars("admin-import", c)
+	templateVars, auth := api.getBaseTemplateVars("admin-import", c)
 
 	var rImportFolder requestAdminImport
 	if err := c.ShouldBindQuery(&rImportFolder); err != nil {
@@ -364,14 +490,44 @@ func (api *API) appGetAdminImport(c *gin.Context) {
 		allDirectories = append(allDirectories, e.Name())
 	}
 
+	user, err := api.db.Queries.GetUser(c, auth.UserName)
+	if err != nil {
+		log.Error("GetUser DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUser DB Error: %v", err))
+		return
+	}
+
+	importLog, err := api.db.Queries.GetImportLog(c, database.GetImportLogParams{
+		Timezone: *user.Timezone,
+		Limit:    importLogSize,
+	})
+	if err != nil {
+		log.Error("GetImportLog DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetImportLog DB Error: %v", err))
+		return
+	}
+
+	importJobs, err := api.db.Queries.GetImportJobs(c, database.GetImportJobsParams{
+		Timezone: *user.Timezone,
+		Limit:    importJobsSize,
+	})
+	if err != nil {
+		log.Error("GetImportJobs DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetImportJobs DB Error: %v", err))
+		return
+	}
+
 	templateVars["CurrentPath"] = filepath.Clean(rImportFolder.Directory)
 	templateVars["Data"] = allDirectories
+	templateVars["WatchDirectories"] = api.cfg.WatchDirectories
+	templateVars["ImportLog"] = importLog
+	templateVars["ImportJobs"] = importJobs
 
 	c.HTML(http.StatusOK, "page/admin-import", templateVars)
 }
 
 func (api *API) appPerformAdminImport(c *gin.Context) {
-	templateVars, _ := api.getBaseTemplateVars("admin-import", c)
+	_, auth := api.getBaseTemplateVars("admin-import", c)
 
 	var rAdminImport requestAdminImport
 	if err := c.ShouldBind(&rAdminImport); err != nil {
@@ -380,157 +536,264 @@ func (api *API) appPerformAdminImport(c *gin.Context) {
 		return
 	}
 
-	// Get import directory
-	importDirectory := filepath.Clean(rAdminImport.Directory)
+	importJob, err := api.createImportJob(c, rAdminImport.Directory, rAdminImport.Type, &auth.UserName)
+	if errors.Is(err, errImportDataPath) {
+		appErrorPage(c, http.StatusBadRequest, "Directory is the same as data path")
+		return
+	} else if err != nil {
+		log.Error("Import Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Import Failed: %v", err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("./import/jobs/%d", importJob.ID))
+}
 
-	// Get data directory
-	absoluteDataPath, _ := filepath.Abs(filepath.Join(api.cfg.DataPath, "documents"))
+func (api *API) appGetAdminImportJob(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-import", c)
 
-	// Validate different path
-	if absoluteDataPath == importDirectory {
-		appErrorPage(c, http.StatusBadRequest, "Directory is the same as data path")
+	var rImportJob requestAdminImportJob
+	if err := c.ShouldBindUri(&rImportJob); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid import job")
 		return
 	}
 
-	// Do Transaction
-	tx, err := api.db.DB.Begin()
+	user, err := api.db.Queries.GetUser(c, auth.UserName)
 	if err != nil {
-		log.Error("Transaction Begin DB Error:", err)
-		apiErrorPage(c, http.StatusBadRequest, "Unknown error")
+		log.Error("GetUser DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUser DB Error: %v", err))
 		return
 	}
 
-	// Defer & Start Transaction
-	defer func() {
-		if err := tx.Rollback(); err != nil {
-			log.Error("DB Rollback Error:", err)
-		}
-	}()
-	qtx := api.db.Queries.WithTx(tx)
+	getImportJob := func() (database.GetImportJobRow, error) {
+		return api.db.Queries.GetImportJob(c, database.GetImportJobParams{
+			Timezone: *user.Timezone,
+			ID:       rImportJob.JobID,
+		})
+	}
 
-	// Track imports
-	importResults := make([]importResult, 0)
+	job, err := getImportJob()
+	if errors.Is(err, sql.ErrNoRows) {
+		appErrorPage(c, http.StatusNotFound, "Import job not found")
+		return
+	} else if err != nil {
+		log.Error("GetImportJob DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetImportJob DB Error: %v", err))
+		return
+	}
+
+	importResults, err := api.getImportJobResults(c, job.ID)
+	if err != nil {
+		log.Error("Import Results Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Import Results Error: %v", err))
+		return
+	}
+
+	templateVars["Job"] = job
+	templateVars["Data"] = importResults
+	c.HTML(http.StatusOK, "page/admin-import-job", templateVars)
+
+	if !importJobActive(job.Status) {
+		return
+	}
 
-	// Walk Directory & Import
-	err = filepath.WalkDir(importDirectory, func(importPath string, f fs.DirEntry, err error) error {
+	// Create Streamer
+	stream := api.newStreamer(c, `
+	  <div class="absolute top-0 left-0 w-full h-full z-50">
+	    <div class="fixed top-0 left-0 bg-black opacity-50 w-screen h-screen"></div>
+	    <div id="stream-main" class="relative max-h-[95%] -translate-x-2/4 top-1/2 left-1/2 w-5/6">`)
+	defer stream.close(`</div></div>`)
+
+	// Stream Progress
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		job, err = getImportJob()
 		if err != nil {
-			return err
+			log.Error("GetImportJob DB Error: ", err)
+			stream.send("component/download-progress", gin.H{
+				"Message":    "Unable to get import progress",
+				"Error":      true,
+				"ButtonText": "Close",
+				"ButtonHref": "/admin/import",
+			})
+			return
 		}
 
-		if f.IsDir() {
-			return nil
+		templateVars := gin.H{
+			"Message":    fmt.Sprintf("Importing %d of %d files...", job.ProcessedFiles, ptr.Deref(job.TotalFiles)),
+			"Progress":   importJobProgress(job),
+			"ButtonText": "Continue in Background",
+			"ButtonHref": "/admin/import",
 		}
 
-		// Get relative path
-		basePath := importDirectory
-		relFilePath, err := filepath.Rel(importDirectory, importPath)
-		if err != nil {
-			log.Warnf("path error: %v", err)
-			return nil
+		switch JobStatus(job.Status) {
+		case JobQueued, JobRunning:
+			templateVars["CancelAction"] = fmt.Sprintf("./%d", job.ID)
+			if job.TotalFiles == nil {
+				templateVars["Message"] = "Finding files..."
+			}
+		case JobSucceeded:
+			templateVars["Message"] = fmt.Sprintf("Imported %d files (%d failed)", job.ProcessedFiles, job.FailedFiles)
+			templateVars["Progress"] = 100
+		default:
+			templateVars["Message"] = fmt.Sprintf("Import %s", strings.ToLower(job.Status))
+			templateVars["Error"] = true
 		}
 
-		// Track imports
-		iResult := importResult{
-			Path:   relFilePath,
-			Status: importFailed,
+		if !importJobActive(job.Status) {
+			templateVars["ButtonText"] = "View Results"
+			templateVars["ButtonHref"] = fmt.Sprintf("./%d", job.ID)
+			stream.send("component/download-progress", templateVars)
+			return
 		}
-		defer func() {
-			importResults = append(importResults, iResult)
-		}()
+		stream.send("component/download-progress", templateVars)
 
-		// Get metadata
-		fileMeta, err := metadata.GetMetadata(importPath)
-		if err != nil {
-			log.Errorf("metadata error: %v", err)
-			iResult.Error = err
-			return nil
+		select {
+		case <-ticker.C:
+		case <-c.Request.Context().Done():
+			return
 		}
-		iResult.ID = *fileMeta.PartialMD5
-		iResult.Name = fmt.Sprintf("%s - %s", *fileMeta.Author, *fileMeta.Title)
+	}
+}
 
-		// Check already exists
-		_, err = qtx.GetDocument(c, *fileMeta.PartialMD5)
-		if err == nil {
-			log.Warnf("document already exists: %s", *fileMeta.PartialMD5)
-			iResult.Status = importExists
-			return nil
-		}
+func (api *API) appUpdateAdminImportJob(c *gin.Context) {
+	var rImportJob requestAdminImportJob
+	if err := c.ShouldBindUri(&rImportJob); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid import job")
+		return
+	}
+	if err := c.ShouldBind(&rImportJob); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
 
-		// Import Copy
-		if rAdminImport.Type == importCopy {
-			// Derive & Sanitize File Name
-			relFilePath = deriveBaseFileName(fileMeta)
-			safePath := filepath.Join(api.cfg.DataPath, "documents", relFilePath)
+	var err error
+	switch rImportJob.Operation {
+	case opCancel:
+		err = api.cancelImportJob(c, rImportJob.JobID)
+	case opResume:
+		err = api.resumeImportJob(c, rImportJob.JobID)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown operation")
+		return
+	}
 
-			// Open Source File
-			srcFile, err := os.Open(importPath)
-			if err != nil {
-				log.Errorf("unable to open current file: %v", err)
-				iResult.Error = err
-				return nil
-			}
-			defer srcFile.Close()
+	if errors.Is(err, errImportJobNotFound) {
+		appErrorPage(c, http.StatusNotFound, "Import job not found")
+		return
+	} else if errors.Is(err, errImportJobNotRunning) || errors.Is(err, errImportJobNotStopped) {
+		appErrorPage(c, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		log.Error("Import Job Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Import Job Error: %v", err))
+		return
+	}
 
-			// Open Destination File
-			destFile, err := os.Create(safePath)
-			if err != nil {
-				log.Errorf("unable to open destination file: %v", err)
-				iResult.Error = err
-				return nil
-			}
-			defer destFile.Close()
+	c.Redirect(http.StatusFound, fmt.Sprintf("./%d", rImportJob.JobID))
+}
 
-			// Copy File
-			if _, err = io.Copy(destFile, srcFile); err != nil {
-				log.Errorf("unable to save file: %v", err)
-				iResult.Error = err
-				return nil
-			}
+// importFile imports a single document within the import directory, deduped
+// by its partial MD5.
+func (api *API) importFile(ctx context.Context, importDirectory, importPath string, iType importType) ImportResult {
+	// Get relative path
+	basePath := importDirectory
+	relFilePath, err := filepath.Rel(importDirectory, importPath)
+	if err != nil {
+		log.Warnf("path error: %v", err)
+		return ImportResult{Path: importPath, Status: importFailed, Error: err}
+	}
 
-			// Update Base & Path
-			basePath = filepath.Join(api.cfg.DataPath, "documents")
-			iResult.Path = relFilePath
-		}
-
-		// Upsert document
-		if _, err = qtx.UpsertDocument(c, database.UpsertDocumentParams{
-			ID:          *fileMeta.PartialMD5,
-			Title:       fileMeta.Title,
-			Author:      fileMeta.Author,
-			Description: fileMeta.Description,
-			Md5:         fileMeta.MD5,
-			Words:       fileMeta.WordCount,
-			Filepath:    &relFilePath,
-			Basepath:    &basePath,
-		}); err != nil {
-			log.Errorf("UpsertDocument DB Error: %v", err)
-			iResult.Error = err
-			return nil
-		}
+	// Track import
+	iResult := ImportResult{
+		Path:   relFilePath,
+		Status: importFailed,
+	}
 
-		iResult.Status = importSuccess
-		return nil
-	})
+	// Check already exists (prior to the full MD5 & word count)
+	partialMD5, err := utils.CalculatePartialMD5(importPath)
 	if err != nil {
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Import Failed: %v", err))
-		return
+		log.Errorf("partial MD5 error: %v", err)
+		iResult.Error = err
+		return iResult
+	}
+	if existingDocument, err := api.db.Queries.GetDocument(ctx, *partialMD5); err == nil {
+		log.Warnf("document already exists: %s", *partialMD5)
+		iResult.ID = *partialMD5
+		iResult.Name = fmt.Sprintf("%s - %s", ptr.Deref(existingDocument.Author), ptr.Deref(existingDocument.Title))
+		iResult.Status = importExists
+		return iResult
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		log.Error("Transaction Commit DB Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Import DB Error: %v", err))
-		return
+	// Get metadata
+	fileMeta, err := metadata.GetMetadata(importPath)
+	if err != nil {
+		log.Errorf("metadata error: %v", err)
+		iResult.Error = err
+		return iResult
 	}
+	iResult.ID = *fileMeta.PartialMD5
+	iResult.Name = fmt.Sprintf("%s - %s", ptr.Deref(fileMeta.Author), ptr.Deref(fileMeta.Title))
 
-	// Sort import results
-	sort.Slice(importResults, func(i int, j int) bool {
-		return importStatusPriority(importResults[i].Status) <
-			importStatusPriority(importResults[j].Status)
-	})
+	// Import Copy
+	if iType == importCopy {
+		// Derive & Sanitize File Name
+		relFilePath = deriveBaseFileName(fileMeta)
+		safePath := filepath.Join(api.cfg.DataPath, "documents", relFilePath)
 
-	templateVars["Data"] = importResults
-	c.HTML(http.StatusOK, "page/admin-import-results", templateVars)
+		// Open Source File
+		srcFile, err := os.Open(importPath)
+		if err != nil {
+			log.Errorf("unable to open current file: %v", err)
+			iResult.Error = err
+			return iResult
+		}
+		defer srcFile.Close()
+
+		// Open Destination File
+		destFile, err := os.Create(safePath)
+		if err != nil {
+			log.Errorf("unable to open destination file: %v", err)
+			iResult.Error = err
+			return iResult
+		}
+		defer destFile.Close()
+
+		// Copy File
+		if _, err = io.Copy(destFile, srcFile); err != nil {
+			log.Errorf("unable to save file: %v", err)
+			iResult.Error = err
+			return iResult
+		}
+
+		// Update Base & Path
+		basePath = filepath.Join(api.cfg.DataPath, "documents")
+		iResult.Path = relFilePath
+	}
+
+	// Upsert document
+	if _, err = api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:          *fileMeta.PartialMD5,
+		Title:       fileMeta.Title,
+		Author:      fileMeta.Author,
+		Description: fileMeta.Description,
+		Md5:         fileMeta.MD5,
+		Words:       fileMeta.WordCount,
+		Pages:       fileMeta.PageCount,
+		Filepath:    &relFilePath,
+		Basepath:    &basePath,
+	}); err != nil {
+		log.Errorf("UpsertDocument DB Error: %v", err)
+		iResult.Error = err
+		return iResult
+	}
+
+	iResult.Status = importSuccess
+	return iResult
 }
 
 func (api *API) processRestoreFile(rAdminAction requestAdminAction, c *gin.Context) {
@@ -591,6 +854,29 @@ func (api *API) processRestoreFile(rAdminAction requestAdminAction, c *gin.Conte
 		return
 	}
 
+	// Restore Backup
+	err = api.restoreBackup(c, zipReader)
+	if errors.Is(err, errRestoreMissingDB) {
+		log.Error("Invalid ZIP File - Missing DB")
+		appErrorPage(c, http.StatusInternalServerError, "Invalid Restore ZIP - Missing DB")
+		return
+	} else if errors.Is(err, errRestoreInvalidFile) {
+		log.Error("Invalid ZIP File - Invalid File(s)")
+		appErrorPage(c, http.StatusInternalServerError, "Invalid Restore ZIP - Invalid File(s)")
+		return
+	} else if err != nil {
+		log.Error("Restore Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to restore data: %v", err))
+		return
+	}
+
+	// Redirect to login page
+	c.Redirect(http.StatusFound, "/login")
+}
+
+// restoreBackup validates the backup archive, saves a backup of the current
+// data, and then replaces the data with the archive contents.
+func (api *API) restoreBackup(ctx context.Context, zipReader *zip.Reader) error {
 	// Validate ZIP Contents
 	hasDBFile := false
 	hasUnknownFile := false
@@ -607,63 +893,52 @@ func (api *API) processRestoreFile(rAdminAction requestAdminAction, c *gin.Conte
 
 	// Invalid ZIP
 	if !hasDBFile {
-		log.Error("Invalid ZIP File - Missing DB")
-		appErrorPage(c, http.StatusInternalServerError, "Invalid Restore ZIP - Missing DB")
-		return
+		return errRestoreMissingDB
 	} else if hasUnknownFile {
-		log.Error("Invalid ZIP File - Invalid File(s)")
-		appErrorPage(c, http.StatusInternalServerError, "Invalid Restore ZIP - Invalid File(s)")
-		return
+		return errRestoreInvalidFile
 	}
 
 	// Create Backup File
 	backupFilePath := filepath.Join(api.cfg.ConfigPath, fmt.Sprintf("backups/AnthoLumeBackup_%s.zip", time.Now().Format("20060102150405")))
 	backupFile, err := os.Create(backupFilePath)
 	if err != nil {
-		log.Error("Unable to create backup file: ", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to create backup file")
-		return
+		return errors.Wrap(err, "Unable to create backup file")
 	}
 	defer backupFile.Close()
 
 	// Save Backup File
 	w := bufio.NewWriter(backupFile)
-	err = api.createBackup(c, w, []string{"covers", "documents"})
+	err = api.createBackup(ctx, w, []string{"covers", "documents"})
+	if err == nil {
+		err = w.Flush()
+	}
 	if err != nil {
-		log.Error("Unable to save backup file: ", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to save backup file")
-		return
+		return errors.Wrap(err, "Unable to save backup file")
 	}
 
 	// Remove Data
 	err = api.removeData()
 	if err != nil {
-		log.Error("Unable to delete data: ", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to delete data")
-		return
+		return errors.Wrap(err, "Unable to delete data")
 	}
 
 	// Restore Data
 	err = api.restoreData(zipReader)
 	if err != nil {
-		appErrorPage(c, http.StatusInternalServerError, "Unable to restore data")
 		log.Panic("Unable to restore data: ", err)
 	}
 
 	// Reinit DB
-	if err := api.db.Reload(c); err != nil {
-		appErrorPage(c, http.StatusInternalServerError, "Unable to reload DB")
+	if err := api.db.Reload(ctx); err != nil {
 		log.Panicf("Unable to reload DB: %v", err)
 	}
 
 	// Rotate Auth Hashes
-	if err := api.rotateAllAuthHashes(c); err != nil {
-		appErrorPage(c, http.StatusInternalServerError, "Unable to rotate hashes")
+	if err := api.rotateAllAuthHashes(ctx); err != nil {
 		log.Panicf("Unable to rotate auth hashes: %v", err)
 	}
 
-	// Redirect to login page
-	c.Redirect(http.StatusFound, "/login")
+	return nil
 }
 
 func (api *API) restoreData(zipReader *zip.Reader) error {
@@ -810,7 +1085,7 @@ func (api *API) isLastAdmin(ctx context.Context, userID string) (bool, error) {
 	return !hasAdmin, nil
 }
 
-func (api *API) createUser(ctx context.Context, user string, rawPassword *string, isAdmin *bool) error {
+func (api *API) createUser(ctx context.Context, user string, rawPassword *string, isAdmin *bool, role *string) error {
 	// Validate Necessary Parameters
 	if rawPassword == nil || *rawPassword == "" {
 		return fmt.Errorf("password can't be empty")
@@ -818,7 +1093,8 @@ func (api *API) createUser(ctx context.Context, user string, rawPassword *string
 
 	// Base Params
 	createParams := database.CreateUserParams{
-		ID: user,
+		ID:   user,
+		Role: roleUser,
 	}
 
 	// Handle Admin (Explicit or False)
@@ -828,6 +1104,14 @@ func (api *API) createUser(ctx context.Context, user string, rawPassword *string
 		createParams.Admin = false
 	}
 
+	// Handle Role (Explicit or Default)
+	if role != nil && *role != "" {
+		if err := api.validateRole(ctx, *role); err != nil {
+			return err
+		}
+		createParams.Role = *role
+	}
+
 	// Parse Password
 	password := fmt.Sprintf("%x", md5.Sum([]byte(*rawPassword)))
 	hashedPassword, err := argon2.CreateHash(password, argon2.DefaultParams)
@@ -856,9 +1140,9 @@ func (api *API) createUser(ctx context.Context, user string, rawPassword *string
 	return nil
 }
 
-func (api *API) updateUser(ctx context.Context, user string, rawPassword *string, isAdmin *bool) error {
+func (api *API) updateUser(ctx context.Context, user string, rawPassword *string, isAdmin *bool, role *string) error {
 	// Validate Necessary Parameters
-	if rawPassword == nil && isAdmin == nil {
+	if rawPassword == nil && isAdmin == nil && role == nil {
 		return fmt.Errorf("nothing to update")
 	}
 
@@ -867,6 +1151,14 @@ func (api *API) updateUser(ctx context.Context, user string, rawPassword *string
 		UserID: user,
 	}
 
+	// Handle Role
+	if role != nil {
+		if err := api.validateRole(ctx, *role); err != nil {
+			return err
+		}
+		updateParams.Role = role
+	}
+
 	// Handle Admin (Update or Existing)
 	if isAdmin != nil {
 		updateParams.Admin = *isAdmin
@@ -913,6 +1205,9 @@ func (api *API) updateUser(ctx context.Context, user string, rawPassword *string
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("UpdateUser DB Error: %v", err))
 	}
+	if rawPassword != nil {
+		api.authCache.forget(user)
+	}
 
 	return nil
 }
@@ -936,6 +1231,9 @@ func (api *API) deleteUser(ctx context.Context, user string) error {
 	// Save Backup File (DB Only)
 	w := bufio.NewWriter(backupFile)
 	err = api.createBackup(ctx, w, []string{})
+	if err == nil {
+		err = w.Flush()
+	}
 	if err != nil {
 		return err
 	}
@@ -948,3 +1246,377 @@ func (api *API) deleteUser(ctx context.Context, user string) error {
 
 	return nil
 }
+
+func (api *API) revokeUserSessions(ctx context.Context, user string) error {
+	// Delete Server Sessions
+	if _, err := api.db.Queries.DeleteUserSessions(ctx, user); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("DeleteUserSessions DB Error: %v", err))
+	}
+
+	// Delete API Tokens
+	if _, err := api.db.Queries.DeleteUserAPITokens(ctx, user); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("DeleteUserAPITokens DB Error: %v", err))
+	}
+
+	return nil
+}
+
+func (api *API) validateRole(ctx context.Context, role string) error {
+	if _, err := api.db.Queries.GetRole(ctx, role); err == sql.ErrNoRows {
+		return fmt.Errorf("role %s does not exist", role)
+	} else if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("GetRole DB Error: %v", err))
+	}
+	return nil
+}
+
+func (api *API) appGetAdminInvites(c *gin.Context) {
+	templateVars, _ := api.getBaseTemplateVars("admin-invites", c)
+	if err := api.setAdminInvitesTemplateVars(c, templateVars); err != nil {
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-invites", templateVars)
+}
+
+func (api *API) appUpdateAdminInvites(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-invites", c)
+
+	var rUpdate requestAdminUpdateInvite
+	if err := c.ShouldBind(&rUpdate); err != nil {
+		log.Error("Invalid Form Bind: ", err)
+		appErrorPage(c, http.StatusNotFound, "Invalid invite parameters")
+		return
+	}
+
+	var err error
+	switch rUpdate.Operation {
+	case opCreate:
+		err = api.createInvite(c, auth.UserName, rUpdate.Role, rUpdate.MaxUses, rUpdate.ExpiresDays)
+	case opDelete:
+		err = api.deleteInvite(c, rUpdate.Code)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown invite operation")
+		return
+	}
+
+	if err != nil {
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to create or delete invite: %v", err))
+		return
+	}
+
+	if err := api.setAdminInvitesTemplateVars(c, templateVars); err != nil {
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-invites", templateVars)
+}
+
+func (api *API) appGetAdminJobs(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-jobs", c)
+	if err := api.setAdminJobsTemplateVars(c, auth.UserName, templateVars); err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-jobs", templateVars)
+}
+
+func (api *API) appUpdateAdminJobs(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-jobs", c)
+
+	var rUpdate requestAdminJob
+	if err := c.ShouldBind(&rUpdate); err != nil {
+		log.Error("Invalid Form Bind: ", err)
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	var count int64
+	var err error
+	switch rUpdate.Operation {
+	case opCancel:
+		count, err = api.db.Queries.CancelJob(c, rUpdate.JobID)
+	case opRetry:
+		count, err = api.db.Queries.RequeueJob(c, rUpdate.JobID)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown job operation")
+		return
+	}
+	if count > 0 {
+		api.signalJobQueue()
+	}
+
+	if err != nil {
+		log.Error("Job Update DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to update job: %v", err))
+		return
+	} else if count == 0 {
+		templateVars["JobErrorMessage"] = fmt.Sprintf("Job %d can't be updated in its current state", rUpdate.JobID)
+	}
+
+	if err := api.setAdminJobsTemplateVars(c, auth.UserName, templateVars); err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-jobs", templateVars)
+}
+
+func (api *API) appGetAdminWebhooks(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-webhooks", c)
+	if err := api.setWebhookTemplateVars(c, nil, auth.UserName, templateVars); err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-webhooks", templateVars)
+}
+
+func (api *API) appUpdateAdminWebhooks(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("admin-webhooks", c)
+
+	var rWebhookEdit requestWebhookEdit
+	if err := c.ShouldBind(&rWebhookEdit); err != nil {
+		log.Error("Invalid Form Bind: ", err)
+		appErrorPage(c, http.StatusNotFound, "Invalid webhook parameters")
+		return
+	}
+
+	secret, err := api.editWebhook(c, nil, rWebhookEdit)
+	if message, ok := webhookEditMessage(err); ok {
+		templateVars["WebhookErrorMessage"] = message
+	} else if errors.Is(err, sql.ErrNoRows) || errors.Is(err, errInvalidWebhookOperation) {
+		appErrorPage(c, http.StatusNotFound, "Invalid webhook")
+		return
+	} else if err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to edit webhook: %v", err))
+		return
+	}
+	templateVars["NewWebhookSecret"] = secret
+
+	if err := api.setWebhookTemplateVars(c, nil, auth.UserName, templateVars); err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, "page/admin-webhooks", templateVars)
+}
+
+func (api *API) appGetAdminActivity(c *gin.Context) {
+	templateVars, _ := api.getBaseTemplateVars("admin-activity", c)
+
+	health, err := api.getActivityHealth(c)
+	if err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, "Unable to get activity health")
+		return
+	}
+	templateVars["Data"] = health
+
+	c.HTML(http.StatusOK, "page/admin-activity", templateVars)
+}
+
+func (api *API) appUpdateAdminActivity(c *gin.Context) {
+	templateVars, _ := api.getBaseTemplateVars("admin-activity", c)
+
+	var rUpdate requestAdminActivity
+	if err := c.ShouldBind(&rUpdate); err != nil {
+		log.Error("Invalid Form Bind: ", err)
+		appErrorPage(c, http.StatusNotFound, "Invalid activity parameters")
+		return
+	}
+
+	var removed int64
+	var err error
+	switch rUpdate.Operation {
+	case opMerge:
+		removed, err = api.mergeDuplicateActivity(c)
+	case opDelete:
+		removed, err = api.deleteInvalidActivity(c)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown activity operation")
+		return
+	}
+
+	if err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to clean activity: %v", err))
+		return
+	}
+
+	health, err := api.getActivityHealth(c)
+	if err != nil {
+		log.Error(err)
+		appErrorPage(c, http.StatusInternalServerError, "Unable to get activity health")
+		return
+	}
+	templateVars["Data"] = health
+	templateVars["Removed"] = removed
+
+	c.HTML(http.StatusOK, "page/admin-activity", templateVars)
+}
+
+func (api *API) setAdminInvitesTemplateVars(ctx context.Context, templateVars gin.H) error {
+	invites, err := api.db.Queries.GetInvites(ctx)
+	if err != nil {
+		log.Error("GetInvites DB Error: ", err)
+		return fmt.Errorf("GetInvites DB Error: %v", err)
+	}
+
+	inviteUses, err := api.db.Queries.GetInviteUses(ctx, 50)
+	if err != nil {
+		log.Error("GetInviteUses DB Error: ", err)
+		return fmt.Errorf("GetInviteUses DB Error: %v", err)
+	}
+
+	roles, err := api.db.Queries.GetRoles(ctx)
+	if err != nil {
+		log.Error("GetRoles DB Error: ", err)
+		return fmt.Errorf("GetRoles DB Error: %v", err)
+	}
+
+	templateVars["Data"] = invites
+	templateVars["InviteUses"] = inviteUses
+	templateVars["Roles"] = roles
+	return nil
+}
+
+func (api *API) createInvite(ctx context.Context, createdBy, role string, maxUses int64, expiresDays int) error {
+	// Validate Role
+	if role == "" {
+		role = roleUser
+	}
+	if _, err := api.db.Queries.GetRole(ctx, role); err == sql.ErrNoRows {
+		return fmt.Errorf("role %s does not exist", role)
+	} else if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("GetRole DB Error: %v", err))
+	}
+
+	// Validate Limits
+	if maxUses == 0 {
+		maxUses = 1
+	} else if maxUses < 0 {
+		return fmt.Errorf("max uses must be positive")
+	}
+	if expiresDays == 0 {
+		expiresDays = 7
+	} else if expiresDays < 0 || expiresDays > 365 {
+		return fmt.Errorf("expiry must be between 1 and 365 days")
+	}
+
+	// Generate Code
+	rawCode, err := utils.GenerateToken(16)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate invite code")
+	}
+
+	if _, err := api.db.Queries.CreateInvite(ctx, database.CreateInviteParams{
+		Code:      fmt.Sprintf("%x", rawCode),
+		Role:      role,
+		MaxUses:   maxUses,
+		ExpiresAt: time.Now().UTC().Add(time.Duration(expiresDays) * 24 * time.Hour).Format(time.RFC3339),
+		CreatedBy: createdBy,
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("CreateInvite DB Error: %v", err))
+	}
+
+	return nil
+}
+
+func (api *API) deleteInvite(ctx context.Context, code string) error {
+	if changed, err := api.db.Queries.DeleteInvite(ctx, code); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("DeleteInvite DB Error: %v", err))
+	} else if changed == 0 {
+		return fmt.Errorf("invite %s does not exist", code)
+	}
+	return nil
+}
+
+func (api *API) createRole(ctx context.Context, role string, permissions []permission) error {
+	// Check Existing
+	if _, err := api.db.Queries.GetRole(ctx, role); err == nil {
+		return fmt.Errorf("role %s already exists", role)
+	} else if err != sql.ErrNoRows {
+		return errors.Wrap(err, fmt.Sprintf("GetRole DB Error: %v", err))
+	}
+
+	return api.updateRole(ctx, role, permissions)
+}
+
+func (api *API) updateRole(ctx context.Context, role string, permissions []permission) error {
+	// Validate Permissions
+	for _, p := range permissions {
+		if !slices.Contains(allPermissions, p) {
+			return fmt.Errorf("unknown permission %s", p)
+		}
+	}
+
+	// Upsert Role
+	if _, err := api.db.Queries.UpsertRole(ctx, database.UpsertRoleParams{
+		Name:        role,
+		CanUpload:   slices.Contains(permissions, permUpload),
+		CanDelete:   slices.Contains(permissions, permDelete),
+		CanEdit:     slices.Contains(permissions, permEdit),
+		CanSearch:   slices.Contains(permissions, permSearch),
+		CanDownload: slices.Contains(permissions, permDownload),
+		CanSync:     slices.Contains(permissions, permSync),
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("UpsertRole DB Error: %v", err))
+	}
+
+	return nil
+}
+
+func (api *API) deleteRole(ctx context.Context, role string) error {
+	// Disallow Built-In Roles
+	if role == roleUser || role == roleGuest {
+		return fmt.Errorf("unable to delete built-in role %s", role)
+	}
+
+	// Do Transaction
+	tx, err := api.db.DB.Begin()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Transaction Begin DB Error: %v", err))
+	}
+
+	// Defer & Start Transaction
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Error("DB Rollback Error:", err)
+		}
+	}()
+	qtx := api.db.Queries.WithTx(tx)
+
+	// Demote Users -> Guest
+	if _, err := qtx.UpdateRoleUsers(ctx, database.UpdateRoleUsersParams{
+		Role:        roleGuest,
+		CurrentRole: role,
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("UpdateRoleUsers DB Error: %v", err))
+	}
+
+	// Remove Role Shares
+	if _, err := qtx.DeleteShares(ctx, database.DeleteSharesParams{
+		ShareType: string(shareRole),
+		ShareWith: role,
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("DeleteShares DB Error: %v", err))
+	}
+
+	// Delete Role
+	if changed, err := qtx.DeleteRole(ctx, role); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("DeleteRole DB Error: %v", err))
+	} else if changed == 0 {
+		return fmt.Errorf("role %s does not exist", role)
+	}
+
+	return tx.Commit()
+}
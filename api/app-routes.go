@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/md5"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -68,9 +69,49 @@ type requestDocumentIdentify struct {
 }
 
 type requestSettingsEdit struct {
-	Password    *string `form:"password"`
-	NewPassword *string `form:"new_password"`
-	Timezone    *string `form:"timezone"`
+	Password         *string `form:"password"`
+	NewPassword      *string `form:"new_password"`
+	Timezone         *string `form:"timezone"`
+	ProgressStrategy *string `form:"progress_strategy"`
+	APITokenName     *string `form:"api_token_name"`
+}
+
+type requestSessionRevoke struct {
+	Session int64 `form:"session" binding:"required"`
+}
+
+type requestAPITokenRevoke struct {
+	Token int64 `form:"token" binding:"required"`
+}
+
+type requestWebhookEdit struct {
+	Operation operationType `form:"operation" binding:"required"`
+	Webhook   int64         `form:"webhook"`
+	URL       string        `form:"url"`
+	Events    []string      `form:"events"`
+	Enabled   bool          `form:"enabled"`
+}
+
+type requestDeviceEdit struct {
+	Device    string        `form:"device" binding:"required"`
+	Operation operationType `form:"operation" binding:"required"`
+	Name      *string       `form:"name"`
+	Sync      *bool         `form:"sync"`
+	Target    string        `form:"target"`
+}
+
+type requestDocumentVisibility struct {
+	Visibility documentVisibility `form:"visibility" binding:"required"`
+}
+
+type requestProgressRestore struct {
+	History int64 `form:"history" binding:"required"`
+}
+
+type requestDocumentShare struct {
+	ShareType shareType     `form:"share_type" binding:"required"`
+	ShareWith string        `form:"share_with" binding:"required"`
+	Operation operationType `form:"operation" binding:"required"`
 }
 
 type requestDocumentAdd struct {
@@ -124,7 +165,10 @@ func (api *API) appGetDocuments(c *gin.Context) {
 		return
 	}
 
-	length, err := api.db.Queries.GetDocumentsSize(c, query)
+	length, err := api.db.Queries.GetDocumentsSize(c, database.GetDocumentsSizeParams{
+		UserID: auth.UserName,
+		Query:  query,
+	})
 	if err != nil {
 		log.Error("GetDocumentsSize DB Error: ", err)
 		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentsSize DB Error: %v", err))
@@ -170,7 +214,35 @@ func (api *API) appGetDocument(c *gin.Context) {
 		return
 	}
 
+	access, err := api.getDocumentAccess(c, auth, rDocID.DocumentID)
+	if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return
+	}
+
+	// Sharing Options
+	if access.CanManage {
+		shares, err := api.db.Queries.GetDocumentShares(c, rDocID.DocumentID)
+		if err != nil {
+			log.Error("GetDocumentShares DB Error: ", err)
+			appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentShares DB Error: %v", err))
+			return
+		}
+
+		roles, err := api.db.Queries.GetRoles(c)
+		if err != nil {
+			log.Error("GetRoles DB Error: ", err)
+			appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetRoles DB Error: %v", err))
+			return
+		}
+
+		templateVars["Shares"] = shares
+		templateVars["Roles"] = roles
+	}
+
 	templateVars["Data"] = document
+	templateVars["Access"] = access
 	templateVars["TotalTimeLeftSeconds"] = int64((100.0 - document.Percentage) * float64(document.SecondsPerPercent))
 
 	c.HTML(http.StatusOK, "page/document", templateVars)
@@ -204,6 +276,42 @@ func (api *API) appGetProgress(c *gin.Context) {
 	c.HTML(http.StatusOK, "page/progress", templateVars)
 }
 
+func (api *API) appGetDocumentProgressHistory(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("documents", c)
+	qParams := bindQueryParams(c, 50)
+
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid document")
+		return
+	}
+
+	document, err := api.db.GetDocument(c, rDocID.DocumentID, auth.UserName)
+	if err != nil {
+		log.Error("GetDocument DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocument DB Error: %v", err))
+		return
+	}
+
+	history, err := api.db.Queries.GetDocumentProgressHistory(c, database.GetDocumentProgressHistoryParams{
+		UserID:     auth.UserName,
+		DocumentID: rDocID.DocumentID,
+		Offset:     (*qParams.Page - 1) * *qParams.Limit,
+		Limit:      *qParams.Limit,
+	})
+	if err != nil {
+		log.Error("GetDocumentProgressHistory DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentProgressHistory DB Error: %v", err))
+		return
+	}
+
+	templateVars["Document"] = document
+	templateVars["Data"] = history
+
+	c.HTML(http.StatusOK, "page/document-progress", templateVars)
+}
+
 func (api *API) appGetActivity(c *gin.Context) {
 	templateVars, auth := api.getBaseTemplateVars("activity", c)
 	qParams := bindQueryParams(c, 15)
@@ -283,26 +391,52 @@ func (api *API) appGetHome(c *gin.Context) {
 func (api *API) appGetSettings(c *gin.Context) {
 	templateVars, auth := api.getBaseTemplateVars("settings", c)
 
-	user, err := api.db.Queries.GetUser(c, auth.UserName)
+	settingsData, err := api.getSettingsData(c, auth.UserName)
 	if err != nil {
-		log.Error("GetUser DB Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUser DB Error: %v", err))
+		log.Error("Get Settings Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	devices, err := api.db.Queries.GetDevices(c, auth.UserName)
+	templateVars["Data"] = settingsData
+
+	c.HTML(http.StatusOK, "page/settings", templateVars)
+}
+
+// getSettingsData returns the settings page data for the user.
+func (api *API) getSettingsData(ctx context.Context, userID string) (gin.H, error) {
+	user, err := api.db.Queries.GetUser(ctx, userID)
 	if err != nil {
-		log.Error("GetDevices DB Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDevices DB Error: %v", err))
-		return
+		return nil, fmt.Errorf("GetUser DB Error: %w", err)
 	}
 
-	templateVars["Data"] = gin.H{
-		"Timezone": *user.Timezone,
-		"Devices":  devices,
+	devices, err := api.db.Queries.GetDevices(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetDevices DB Error: %w", err)
 	}
 
-	c.HTML(http.StatusOK, "page/settings", templateVars)
+	userSessions, err := api.db.Queries.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSessions DB Error: %w", err)
+	}
+
+	apiTokens, err := api.db.Queries.GetAPITokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetAPITokens DB Error: %w", err)
+	}
+
+	settingsData := gin.H{
+		"Timezone":         *user.Timezone,
+		"ProgressStrategy": user.ProgressStrategy,
+		"Devices":          devices,
+		"Sessions":         userSessions,
+		"APITokens":        apiTokens,
+	}
+	if err := api.setWebhookTemplateVars(ctx, &userID, userID, settingsData); err != nil {
+		return nil, err
+	}
+
+	return settingsData, nil
 }
 
 // Tabs:
@@ -344,7 +478,8 @@ func (api *API) appGetLogin(c *gin.Context) {
 }
 
 func (api *API) appGetRegister(c *gin.Context) {
-	if !api.cfg.RegistrationEnabled {
+	inviteCode := strings.TrimSpace(c.Query("invite"))
+	if !api.cfg.RegistrationEnabled && inviteCode == "" {
 		c.Redirect(http.StatusFound, "/login")
 		return
 	}
@@ -352,6 +487,21 @@ func (api *API) appGetRegister(c *gin.Context) {
 	templateVars, _ := api.getBaseTemplateVars("login", c)
 	templateVars["RegistrationEnabled"] = api.cfg.RegistrationEnabled
 	templateVars["Register"] = true
+
+	// Validate Invite
+	if inviteCode != "" {
+		if _, err := api.db.Queries.GetInvite(c, inviteCode); err != nil {
+			if err != sql.ErrNoRows {
+				log.Error("GetInvite DB Error: ", err)
+			}
+			templateVars["Register"] = false
+			templateVars["Error"] = "Invalid or Expired Invite"
+			c.HTML(http.StatusNotFound, "page/login", templateVars)
+			return
+		}
+		templateVars["Invite"] = inviteCode
+	}
+
 	c.HTML(http.StatusOK, "page/login", templateVars)
 }
 
@@ -385,12 +535,22 @@ func (api *API) appGetDocumentProgress(c *gin.Context) {
 		return
 	}
 
+	// Translate KOReader XPointer
+	var cfi string
+	if document.Filepath != nil && strings.HasPrefix(progress.Progress, "/body/DocFragment") {
+		filePath := api.documentFilePath(document.Basepath, *document.Filepath)
+		if cfi, err = metadata.GetCFIFromXPointer(filePath, progress.Progress); err != nil {
+			log.Warn("Unable to translate progress to CFI: ", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"id":         document.ID,
 		"title":      document.Title,
 		"author":     document.Author,
 		"words":      document.Words,
 		"progress":   progress.Progress,
+		"cfi":        cfi,
 		"percentage": document.Percentage,
 	})
 }
@@ -413,6 +573,11 @@ func (api *API) appGetDevices(c *gin.Context) {
 }
 
 func (api *API) appUploadNewDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
 	var rDocUpload requestDocumentUpload
 	if err := c.ShouldBind(&rDocUpload); err != nil {
 		log.Error("Invalid Form Bind")
@@ -425,77 +590,17 @@ func (api *API) appUploadNewDocument(c *gin.Context) {
 		return
 	}
 
-	// Create Temp File
-	tempFile, err := os.CreateTemp("", "book")
-	if err != nil {
-		log.Warn("Temp File Create Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to create temp file")
-		return
-	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	// Save Temp File
-	err = c.SaveUploadedFile(rDocUpload.DocumentFile, tempFile.Name())
-	if err != nil {
-		log.Error("File Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to save file")
-		return
-	}
-
-	// Get Metadata
-	metadataInfo, err := metadata.GetMetadata(tempFile.Name())
-	if err != nil {
-		log.Errorf("unable to acquire metadata: %v", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to acquire metadata")
-		return
-	}
-
-	// Check Already Exists
-	_, err = api.db.Queries.GetDocument(c, *metadataInfo.PartialMD5)
-	if err == nil {
-		log.Warnf("document already exists: %s", *metadataInfo.PartialMD5)
-		c.Redirect(http.StatusFound, fmt.Sprintf("./documents/%s", *metadataInfo.PartialMD5))
-	}
-
-	// Derive & Sanitize File Name
-	fileName := deriveBaseFileName(metadataInfo)
-	basePath := filepath.Join(api.cfg.DataPath, "documents")
-	safePath := filepath.Join(basePath, fileName)
-
-	// Open Destination File
-	destFile, err := os.Create(safePath)
-	if err != nil {
-		log.Errorf("unable to open destination file: %v", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to open destination file")
-		return
-	}
-	defer destFile.Close()
-
-	// Copy File
-	if _, err = io.Copy(destFile, tempFile); err != nil {
-		log.Errorf("unable to save file: %v", err)
-		appErrorPage(c, http.StatusInternalServerError, "Unable to save file")
+	documentID, err := api.storeUploadedDocument(c, rDocUpload.DocumentFile, auth)
+	if errors.Is(err, errDocumentExists) {
+		appErrorPage(c, http.StatusConflict, "Document already exists")
 		return
-	}
-
-	// Upsert Document
-	if _, err = api.db.Queries.UpsertDocument(c, database.UpsertDocumentParams{
-		ID:          *metadataInfo.PartialMD5,
-		Title:       metadataInfo.Title,
-		Author:      metadataInfo.Author,
-		Description: metadataInfo.Description,
-		Md5:         metadataInfo.MD5,
-		Words:       metadataInfo.WordCount,
-		Filepath:    &fileName,
-		Basepath:    &basePath,
-	}); err != nil {
-		log.Errorf("UpsertDocument DB Error: %v", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpsertDocument DB Error: %v", err))
+	} else if err != nil {
+		log.Error("Store Document Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to save document: %v", err))
 		return
 	}
 
-	c.Redirect(http.StatusFound, fmt.Sprintf("./documents/%s", *metadataInfo.PartialMD5))
+	c.Redirect(http.StatusFound, fmt.Sprintf("./documents/%s", documentID))
 }
 
 func (api *API) appEditDocument(c *gin.Context) {
@@ -506,6 +611,11 @@ func (api *API) appEditDocument(c *gin.Context) {
 		return
 	}
 
+	// Validate Access
+	if !api.documentEditable(c, rDocID.DocumentID) {
+		return
+	}
+
 	var rDocEdit requestDocumentEdit
 	if err := c.ShouldBind(&rDocEdit); err != nil {
 		log.Error("Invalid Form Bind")
@@ -596,12 +706,32 @@ func (api *API) appEditDocument(c *gin.Context) {
 }
 
 func (api *API) appDeleteDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
 	var rDocID requestDocumentID
 	if err := c.ShouldBindUri(&rDocID); err != nil {
 		log.Error("Invalid URI Bind")
 		appErrorPage(c, http.StatusNotFound, "Invalid document")
 		return
 	}
+
+	// Validate Access
+	access, err := api.getDocumentAccess(c, auth, rDocID.DocumentID)
+	if err == sql.ErrNoRows || (err == nil && !access.CanRead) {
+		appErrorPage(c, http.StatusNotFound, "Invalid document")
+		return
+	} else if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return
+	} else if !access.CanDelete {
+		appErrorPage(c, http.StatusUnauthorized, "Only the document owner can delete this document")
+		return
+	}
+
 	changed, err := api.db.Queries.DeleteDocument(c, rDocID.DocumentID)
 	if err != nil {
 		log.Error("DeleteDocument DB Error")
@@ -617,6 +747,126 @@ func (api *API) appDeleteDocument(c *gin.Context) {
 	c.Redirect(http.StatusFound, "../")
 }
 
+func (api *API) appUpdateDocumentVisibility(c *gin.Context) {
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid document")
+		return
+	}
+
+	var rVisibility requestDocumentVisibility
+	if err := c.ShouldBind(&rVisibility); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	if !rVisibility.Visibility.valid() {
+		appErrorPage(c, http.StatusBadRequest, fmt.Sprintf("Unknown visibility: %s", rVisibility.Visibility))
+		return
+	}
+
+	if !api.documentManageable(c, rDocID.DocumentID) {
+		return
+	}
+
+	if _, err := api.db.Queries.UpdateDocumentVisibility(c, database.UpdateDocumentVisibilityParams{
+		Visibility: string(rVisibility.Visibility),
+		DocumentID: rDocID.DocumentID,
+	}); err != nil {
+		log.Error("UpdateDocumentVisibility DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpdateDocumentVisibility DB Error: %v", err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "./")
+}
+
+func (api *API) appRestoreDocumentProgress(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid document")
+		return
+	}
+
+	var rRestore requestProgressRestore
+	if err := c.ShouldBind(&rRestore); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	if _, err := api.restoreProgress(c, auth.UserName, rDocID.DocumentID, rRestore.History); errors.Is(err, sql.ErrNoRows) {
+		appErrorPage(c, http.StatusNotFound, "Unknown progress history")
+		return
+	} else if err != nil {
+		log.Error("Restore Progress Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Restore Progress Error: %v", err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "./progress")
+}
+
+func (api *API) appUpdateDocumentShares(c *gin.Context) {
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		log.Error("Invalid URI Bind")
+		appErrorPage(c, http.StatusNotFound, "Invalid document")
+		return
+	}
+
+	var rShare requestDocumentShare
+	if err := c.ShouldBind(&rShare); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	if !api.documentManageable(c, rDocID.DocumentID) {
+		return
+	}
+
+	switch rShare.Operation {
+	case opCreate:
+		if err := api.validateShareTarget(c, rShare.ShareType, rShare.ShareWith); err != nil {
+			appErrorPage(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, err := api.db.Queries.UpsertDocumentShare(c, database.UpsertDocumentShareParams{
+			DocumentID: rDocID.DocumentID,
+			ShareType:  string(rShare.ShareType),
+			ShareWith:  rShare.ShareWith,
+		}); err != nil {
+			log.Error("UpsertDocumentShare DB Error: ", err)
+			appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpsertDocumentShare DB Error: %v", err))
+			return
+		}
+	case opDelete:
+		if _, err := api.db.Queries.DeleteDocumentShare(c, database.DeleteDocumentShareParams{
+			DocumentID: rDocID.DocumentID,
+			ShareType:  string(rShare.ShareType),
+			ShareWith:  rShare.ShareWith,
+		}); err != nil {
+			log.Error("DeleteDocumentShare DB Error: ", err)
+			appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("DeleteDocumentShare DB Error: %v", err))
+			return
+		}
+	default:
+		appErrorPage(c, http.StatusBadRequest, fmt.Sprintf("Unsupported operation: %s", rShare.Operation))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "./")
+}
+
 func (api *API) appIdentifyDocument(c *gin.Context) {
 	var rDocID requestDocumentID
 	if err := c.ShouldBindUri(&rDocID); err != nil {
@@ -625,6 +875,11 @@ func (api *API) appIdentifyDocument(c *gin.Context) {
 		return
 	}
 
+	// Validate Access
+	if !api.documentEditable(c, rDocID.DocumentID) {
+		return
+	}
+
 	var rDocIdentify requestDocumentIdentify
 	if err := c.ShouldBind(&rDocIdentify); err != nil {
 		log.Error("Invalid Form Bind")
@@ -697,6 +952,11 @@ func (api *API) appIdentifyDocument(c *gin.Context) {
 }
 
 func (api *API) appSaveNewDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
 	var rDocAdd requestDocumentAdd
 	if err := c.ShouldBind(&rDocAdd); err != nil {
 		log.Error("Invalid Form Bind")
@@ -811,20 +1071,28 @@ func (api *API) appSaveNewDocument(c *gin.Context) {
 	sendDownloadMessage("Saving to database...", gin.H{"Progress": 99})
 
 	// Upsert Document
+	_, err = api.db.Queries.GetDocument(c, *metadata.PartialMD5)
+	exists := err == nil
 	if _, err = api.db.Queries.UpsertDocument(c, database.UpsertDocumentParams{
 		ID:       *metadata.PartialMD5,
 		Title:    &docTitle,
 		Author:   &docAuthor,
 		Md5:      metadata.MD5,
 		Words:    metadata.WordCount,
+		Pages:    metadata.PageCount,
 		Filepath: &fileName,
 		Basepath: &basePath,
+		OwnerID:  &auth.UserName,
 	}); err != nil {
 		log.Error("UpsertDocument DB Error: ", err)
 		sendDownloadMessage("Unable to save to database", gin.H{"Error": true})
 		return
 	}
 
+	if !exists {
+		api.emitDocumentEvent(c, auth.UserName, webhookDocumentAdded, *metadata.PartialMD5, nil)
+	}
+
 	// Send Message
 	sendDownloadMessage("Download Success", gin.H{
 		"Progress":   100,
@@ -842,7 +1110,7 @@ func (api *API) appEditSettings(c *gin.Context) {
 	}
 
 	// Validate Something Exists
-	if rUserSettings.Password == nil && rUserSettings.NewPassword == nil && rUserSettings.Timezone == nil {
+	if rUserSettings.Password == nil && rUserSettings.NewPassword == nil && rUserSettings.Timezone == nil && rUserSettings.ProgressStrategy == nil && rUserSettings.APITokenName == nil {
 		log.Error("Missing Form Values")
 		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
 		return
@@ -879,6 +1147,28 @@ func (api *API) appEditSettings(c *gin.Context) {
 		newUserSettings.Timezone = rUserSettings.Timezone
 	}
 
+	// Set Progress Strategy
+	if rUserSettings.ProgressStrategy != nil {
+		if !progressStrategy(*rUserSettings.ProgressStrategy).valid() {
+			templateVars["ProgressStrategyErrorMessage"] = "Invalid Progress Strategy"
+		} else {
+			templateVars["ProgressStrategyMessage"] = "Progress Strategy Updated"
+			newUserSettings.ProgressStrategy = rUserSettings.ProgressStrategy
+		}
+	}
+
+	// Create API Token (Only Shown Once)
+	if rUserSettings.APITokenName != nil {
+		if name := strings.TrimSpace(*rUserSettings.APITokenName); name == "" {
+			templateVars["APITokenErrorMessage"] = "Token Name Required"
+		} else if token, err := api.createAPIToken(c, auth.UserName, name); err != nil {
+			log.Error("Create API Token Error: ", err)
+			templateVars["APITokenErrorMessage"] = "Unable to create token"
+		} else {
+			templateVars["NewAPIToken"] = token
+		}
+	}
+
 	// Update User
 	_, err := api.db.Queries.UpdateUser(c, newUserSettings)
 	if err != nil {
@@ -886,27 +1176,175 @@ func (api *API) appEditSettings(c *gin.Context) {
 		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpdateUser DB Error: %v", err))
 		return
 	}
+	if newUserSettings.Password != nil {
+		api.authCache.forget(auth.UserName)
+	}
 
-	// Get User
-	user, err := api.db.Queries.GetUser(c, auth.UserName)
+	// Get Settings
+	settingsData, err := api.getSettingsData(c, auth.UserName)
 	if err != nil {
-		log.Error("GetUser DB Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUser DB Error: %v", err))
+		log.Error("Get Settings Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	templateVars["Data"] = settingsData
+
+	c.HTML(http.StatusOK, "page/settings", templateVars)
+}
+
+func (api *API) appEditDevice(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDeviceEdit requestDeviceEdit
+	if err := c.ShouldBind(&rDeviceEdit); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	// Validate Device
+	device, err := api.db.Queries.GetDevice(c, rDeviceEdit.Device)
+	if err != nil && err != sql.ErrNoRows {
+		log.Error("GetDevice DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDevice DB Error: %v", err))
+		return
+	} else if err == sql.ErrNoRows || device.UserID != auth.UserName {
+		appErrorPage(c, http.StatusNotFound, "Invalid device")
+		return
+	}
+
+	switch rDeviceEdit.Operation {
+	case opUpdate:
+		if rDeviceEdit.Name != nil {
+			if name := strings.TrimSpace(*rDeviceEdit.Name); name != "" {
+				rDeviceEdit.Name = &name
+			} else {
+				rDeviceEdit.Name = nil
+			}
+		}
+		_, err = api.db.Queries.UpdateDevice(c, database.UpdateDeviceParams{
+			ID:         device.ID,
+			UserID:     auth.UserName,
+			DeviceName: rDeviceEdit.Name,
+			Sync:       rDeviceEdit.Sync,
+		})
+	case opDelete:
+		if _, err = api.db.Queries.DeleteDevice(c, database.DeleteDeviceParams{
+			ID:     device.ID,
+			UserID: auth.UserName,
+		}); err == nil {
+			err = api.CacheTempTables(c)
+		}
+	case opMerge:
+		err = api.mergeDevice(c, auth.UserName, device.ID, rDeviceEdit.Target)
+	default:
+		appErrorPage(c, http.StatusNotFound, "Unknown device operation")
 		return
 	}
 
-	// Get Devices
-	devices, err := api.db.Queries.GetDevices(c, auth.UserName)
 	if err != nil {
-		log.Error("GetDevices DB Error: ", err)
-		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDevices DB Error: %v", err))
+		log.Error("Edit Device Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to edit device: %v", err))
 		return
 	}
 
-	templateVars["Data"] = gin.H{
-		"Timezone": *user.Timezone,
-		"Devices":  devices,
+	c.Redirect(http.StatusFound, "/settings")
+}
+
+func (api *API) appRevokeSession(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rSessionRevoke requestSessionRevoke
+	if err := c.ShouldBind(&rSessionRevoke); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	changed, err := api.db.Queries.DeleteSession(c, database.DeleteSessionParams{
+		ID:     rSessionRevoke.Session,
+		UserID: auth.UserName,
+	})
+	if err != nil {
+		log.Error("DeleteSession DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("DeleteSession DB Error: %v", err))
+		return
+	}
+	if changed == 0 {
+		appErrorPage(c, http.StatusNotFound, "Invalid session")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/settings")
+}
+
+func (api *API) appRevokeAPIToken(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rAPITokenRevoke requestAPITokenRevoke
+	if err := c.ShouldBind(&rAPITokenRevoke); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	changed, err := api.db.Queries.DeleteAPIToken(c, database.DeleteAPITokenParams{
+		ID:     rAPITokenRevoke.Token,
+		UserID: auth.UserName,
+	})
+	if err != nil {
+		log.Error("DeleteAPIToken DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("DeleteAPIToken DB Error: %v", err))
+		return
+	}
+	if changed == 0 {
+		appErrorPage(c, http.StatusNotFound, "Invalid token")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/settings")
+}
+
+func (api *API) appEditWebhooks(c *gin.Context) {
+	templateVars, auth := api.getBaseTemplateVars("settings", c)
+
+	var rWebhookEdit requestWebhookEdit
+	if err := c.ShouldBind(&rWebhookEdit); err != nil {
+		log.Error("Invalid Form Bind")
+		appErrorPage(c, http.StatusBadRequest, "Invalid or missing form values")
+		return
+	}
+
+	secret, err := api.editWebhook(c, &auth.UserName, rWebhookEdit)
+	if message, ok := webhookEditMessage(err); ok {
+		templateVars["WebhookErrorMessage"] = message
+	} else if errors.Is(err, sql.ErrNoRows) || errors.Is(err, errInvalidWebhookOperation) {
+		appErrorPage(c, http.StatusNotFound, "Invalid webhook")
+		return
+	} else if err != nil {
+		log.Error("Edit Webhook Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to edit webhook: %v", err))
+		return
+	}
+	templateVars["NewWebhookSecret"] = secret
+
+	settingsData, err := api.getSettingsData(c, auth.UserName)
+	if err != nil {
+		log.Error("Get Settings Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, err.Error())
+		return
 	}
+	templateVars["Data"] = settingsData
 
 	c.HTML(http.StatusOK, "page/settings", templateVars)
 }
@@ -1008,7 +1446,7 @@ func appErrorPage(c *gin.Context, errorCode int, errorMessage string) {
 		errorHuman = "Something's missing."
 	case http.StatusBadRequest:
 		errorHuman = "We didn't expect that."
-	case http.StatusUnauthorized:
+	case http.StatusUnauthorized, http.StatusForbidden:
 		errorHuman = "You're not allowed to do that."
 	}
 
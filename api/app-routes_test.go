@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestDocumentEditOwnership(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	ctx := t.Context()
+
+	require.NoError(t, api.createUser(ctx, "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+	for id, owner := range map[string]string{"owned-document": "reader", "other-document": "other"} {
+		_, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+			ID:      id,
+			Title:   ptr.Of("Owned Title"),
+			OwnerID: ptr.Of(owner),
+		})
+		require.NoError(t, err)
+	}
+	_, err := api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+		DocumentID: "other-document",
+		Visibility: string(visibilityPublic),
+	})
+	require.NoError(t, err)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	for _, tc := range []struct {
+		documentID string
+		status     int
+		title      string
+	}{
+		{"other-document", http.StatusUnauthorized, "Owned Title"},
+		{"owned-document", http.StatusOK, "Edited"},
+		{"document-00", http.StatusOK, "Edited"},
+	} {
+		for _, action := range []string{"edit", "identify"} {
+			resp, err := client.PostForm(server.URL+"/documents/"+tc.documentID+"/"+action, url.Values{"title": {"Edited"}})
+			require.NoError(t, err)
+			resp.Body.Close()
+			if tc.status != http.StatusOK {
+				assert.Equal(t, tc.status, resp.StatusCode, "%s %s", action, tc.documentID)
+			}
+		}
+
+		document, err := api.db.Queries.GetDocument(ctx, tc.documentID)
+		require.NoError(t, err)
+		assert.Equal(t, tc.title, *document.Title)
+	}
+}
+
+func TestSessionTokenHashed(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Decode Session Cookie
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	var token string
+	for _, cookie := range jar.Cookies(serverURL) {
+		if cookie.Name != "token" {
+			continue
+		}
+		values := make(map[any]any)
+		require.NoError(t, api.cookieCodecs.Decode(cookie.Name, cookie.Value, &values))
+		token, _ = values["token"].(string)
+	}
+	require.NotEmpty(t, token)
+
+	var tokenHash string
+	require.NoError(t, api.db.DB.QueryRow("SELECT token_hash FROM sessions WHERE user_id = 'reader'").Scan(&tokenHash))
+	assert.Equal(t, hashToken(token), tokenHash, "should only store token hash")
+
+	// Authenticated
+	resp, err = client.Get(server.URL + "/settings")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/settings", resp.Request.URL.Path, "should not redirect to login")
+
+	// Logout Deletes Session
+	resp, err = client.Get(server.URL + "/logout")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	var remaining int
+	require.NoError(t, api.db.DB.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&remaining))
+	assert.Zero(t, remaining)
+}
+
+func TestUnownedDocumentAccess(t *testing.T) {
+	api, _ := newTestAPI(t, 1)
+
+	for _, tc := range []struct {
+		permissions rolePermissions
+		canEdit     bool
+		canDelete   bool
+	}{
+		{rolePermissions{}, false, false},
+		{rolePermissions{permEdit: true}, true, false},
+		{rolePermissions{permDelete: true}, false, true},
+	} {
+		auth := authData{UserName: "reader", Permissions: tc.permissions}
+		access, err := api.getDocumentAccess(t.Context(), auth, "document-00")
+		require.NoError(t, err)
+		assert.True(t, access.CanRead)
+		assert.False(t, access.CanManage, "only admins manage unowned documents")
+		assert.Equal(t, tc.canEdit, access.CanEdit, "%v", tc.permissions)
+		assert.Equal(t, tc.canDelete, access.CanDelete, "%v", tc.permissions)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// Verified credentials are remembered so KOSync header auth, which is sent
+// with every request, doesn't rerun argon2 each time.
+const (
+	authCacheSize = 1024
+	authCacheTTL  = time.Hour
+)
+
+// authCacheKey identifies verified credentials. It includes the user's stored
+// password & auth hashes, so changing either invalidates the entry - even when
+// changed by another process (e.g. the admin CLI).
+type authCacheKey struct {
+	userID       string
+	keyHash      string
+	passwordHash string
+	authHash     string
+}
+
+type authCache struct {
+	mu      sync.Mutex
+	entries map[authCacheKey]time.Time
+}
+
+func newAuthCache() *authCache {
+	return &authCache{entries: make(map[authCacheKey]time.Time)}
+}
+
+func (c *authCache) contains(key authCacheKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt, ok := c.entries[key]
+	if ok && time.Now().After(expiresAt) {
+		delete(c.entries, key)
+		return false
+	}
+	return ok
+}
+
+func (c *authCache) add(key authCacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Evict expired entries, then arbitrary entries, when full
+	if len(c.entries) >= authCacheSize {
+		now := time.Now()
+		for entryKey, expiresAt := range c.entries {
+			if now.After(expiresAt) {
+				delete(c.entries, entryKey)
+			}
+		}
+		for entryKey := range c.entries {
+			if len(c.entries) < authCacheSize {
+				break
+			}
+			delete(c.entries, entryKey)
+		}
+	}
+
+	c.entries[key] = time.Now().Add(authCacheTTL)
+}
+
+// forget removes the user's entries
+func (c *authCache) forget(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.userID == userID {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"crypto/md5"
+	"fmt"
+	"testing"
+
+	argon2 "github.com/alexedwards/argon2id"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestAuthorizeCredentialsCache(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	ctx := t.Context()
+	password := fmt.Sprintf("%x", md5.Sum([]byte("pass")))
+
+	assert.Nil(t, api.authorizeCredentials(ctx, "reader", "invalid"))
+	assert.Empty(t, api.authCache.entries, "should not cache invalid credentials")
+
+	require.NotNil(t, api.authorizeCredentials(ctx, "reader", password))
+	assert.Len(t, api.authCache.entries, 1)
+	require.NotNil(t, api.authorizeCredentials(ctx, "reader", password), "should authorize cached credentials")
+	assert.Len(t, api.authCache.entries, 1)
+
+	// Password Changed Elsewhere (e.g. CLI)
+	hashedPassword, err := argon2.CreateHash(fmt.Sprintf("%x", md5.Sum([]byte("other"))), argon2.DefaultParams)
+	require.NoError(t, err)
+	user, err := api.db.Queries.GetUser(ctx, "reader")
+	require.NoError(t, err)
+	_, err = api.db.Queries.UpdateUser(ctx, database.UpdateUserParams{UserID: "reader", Password: &hashedPassword, Admin: user.Admin})
+	require.NoError(t, err)
+	assert.Nil(t, api.authorizeCredentials(ctx, "reader", password), "should not authorize previous password")
+
+	// Password Changed
+	require.NoError(t, api.createUser(ctx, "admin", ptr.Of("pass"), ptr.Of(true), nil))
+	require.NoError(t, api.updateUser(ctx, "reader", ptr.Of("pass"), nil, nil))
+	assert.Empty(t, api.authCache.entries, "should forget user credentials")
+	assert.NotNil(t, api.authorizeCredentials(ctx, "reader", password))
+}
+
+func TestAuthCacheBounded(t *testing.T) {
+	cache := newAuthCache()
+	for i := range authCacheSize + 10 {
+		cache.add(authCacheKey{userID: fmt.Sprintf("user-%d", i)})
+	}
+	assert.Len(t, cache.entries, authCacheSize)
+	assert.True(t, cache.contains(authCacheKey{userID: fmt.Sprintf("user-%d", authCacheSize+9)}), "should keep newest entry")
+}
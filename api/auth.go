@@ -3,6 +3,9 @@ package api
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,11 +21,28 @@ import (
 
 // Authorization Data
 type authData struct {
-	UserName string
-	IsAdmin  bool
-	AuthHash string
+	UserName    string
+	IsAdmin     bool
+	AuthHash    string
+	SessionID   int64
+	Role        string
+	Permissions rolePermissions
 }
 
+var (
+	errInvalidInvite = errors.New("invalid or expired invite")
+	errUserExists    = errors.New("user already exists")
+)
+
+// Server Side Session Lifetimes
+const (
+	sessionMaxAge          = 7 * 24 * time.Hour
+	sessionRefreshInterval = 5 * time.Minute
+)
+
+// API tokens are shown once on creation, only their hash is stored.
+const apiTokenPrefix = "alt_"
+
 // KOSync API Auth Headers
 type authKOHeader struct {
 	AuthUser string `header:"x-auth-user"`
@@ -35,17 +55,26 @@ func (api *API) authorizeCredentials(ctx context.Context, username string, passw
 		return
 	}
 
-	if match, err := argon2.ComparePasswordAndHash(password, *user.Pass); err != nil || !match {
-		return
+	// Verify Password (Cached)
+	cacheKey := authCacheKey{
+		userID:       user.ID,
+		keyHash:      hashToken(password),
+		passwordHash: *user.Pass,
+		authHash:     *user.AuthHash,
+	}
+	if !api.authCache.contains(cacheKey) {
+		if match, err := argon2.ComparePasswordAndHash(password, *user.Pass); err != nil || !match {
+			return
+		}
+		api.authCache.add(cacheKey)
 	}
-
-	// Update auth cache
-	api.userAuthCache[user.ID] = *user.AuthHash
 
 	return &authData{
-		UserName: user.ID,
-		IsAdmin:  user.Admin,
-		AuthHash: *user.AuthHash,
+		UserName:    user.ID,
+		IsAdmin:     user.Admin,
+		AuthHash:    *user.AuthHash,
+		Role:        user.Role,
+		Permissions: api.getRolePermissions(ctx, user.Role),
 	}
 }
 
@@ -64,22 +93,19 @@ func (api *API) authKOMiddleware(c *gin.Context) {
 
 	var rHeader authKOHeader
 	if err := c.ShouldBindHeader(&rHeader); err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Incorrect Headers"})
+		koErrorPage(c, http.StatusUnauthorized, koErrorUnauthorized, "Unauthorized")
 		return
 	}
 	if rHeader.AuthUser == "" || rHeader.AuthKey == "" {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization Headers"})
+		koErrorPage(c, http.StatusUnauthorized, koErrorUnauthorized, "Unauthorized")
 		return
 	}
 
+	// Header auth is validated per request and doesn't create a server side
+	// session, as KOReader doesn't persist cookies.
 	authData := api.authorizeCredentials(c, rHeader.AuthUser, rHeader.AuthKey)
 	if authData == nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	if err := api.setSession(session, *authData); err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		koErrorPage(c, http.StatusUnauthorized, koErrorUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -112,6 +138,34 @@ func (api *API) authOPDSMiddleware(c *gin.Context) {
 	c.Next()
 }
 
+func (api *API) authAPIMiddleware(c *gin.Context) {
+	c.Header("Cache-Control", "private")
+
+	// Check Bearer Token
+	if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		auth, ok := api.getAPITokenAuth(c, strings.TrimSpace(token))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="antholume", error="invalid_token"`)
+			apiErrorPage(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		c.Set("Authorization", auth)
+		c.Next()
+		return
+	}
+
+	// Token Missing -> Check Session (Allows the web app to use the API)
+	if auth, ok := api.getSession(c, sessions.Default(c)); ok {
+		c.Set("Authorization", auth)
+		c.Next()
+		return
+	}
+
+	c.Header("WWW-Authenticate", `Bearer realm="antholume"`)
+	apiErrorPage(c, http.StatusUnauthorized, "Unauthorized")
+}
+
 func (api *API) authWebAppMiddleware(c *gin.Context) {
 	session := sessions.Default(c)
 
@@ -163,7 +217,7 @@ func (api *API) appAuthLogin(c *gin.Context) {
 
 	// Set Session
 	session := sessions.Default(c)
-	if err := api.setSession(session, *authData); err != nil {
+	if err := api.setSession(c, session, *authData); err != nil {
 		templateVars["Error"] = "Invalid Credentials"
 		c.HTML(http.StatusUnauthorized, "page/login", templateVars)
 		return
@@ -174,13 +228,15 @@ func (api *API) appAuthLogin(c *gin.Context) {
 }
 
 func (api *API) appAuthRegister(c *gin.Context) {
-	if !api.cfg.RegistrationEnabled {
+	inviteCode := strings.TrimSpace(c.PostForm("invite"))
+	if !api.cfg.RegistrationEnabled && inviteCode == "" {
 		appErrorPage(c, http.StatusUnauthorized, "Nice try. Registration is disabled.")
 		return
 	}
 
 	templateVars, _ := api.getBaseTemplateVars("login", c)
 	templateVars["Register"] = true
+	templateVars["Invite"] = inviteCode
 
 	username := strings.TrimSpace(c.PostForm("username"))
 	rawPassword := strings.TrimSpace(c.PostForm("password"))
@@ -192,60 +248,14 @@ func (api *API) appAuthRegister(c *gin.Context) {
 	}
 	password := fmt.Sprintf("%x", md5.Sum([]byte(rawPassword)))
 
-	hashedPassword, err := argon2.CreateHash(password, argon2.DefaultParams)
-	if err != nil {
-		templateVars["Error"] = "Registration Disabled or User Already Exists"
+	// Create User
+	user, err := api.registerUser(c, username, password, inviteCode)
+	if err == errInvalidInvite {
+		templateVars["Error"] = "Invalid or Expired Invite"
 		c.HTML(http.StatusBadRequest, "page/login", templateVars)
 		return
-	}
-
-	// Generate auth hash
-	rawAuthHash, err := utils.GenerateToken(64)
-	if err != nil {
-		log.Error("Failed to generate user token: ", err)
-		templateVars["Error"] = "Failed to Create User"
-		c.HTML(http.StatusBadRequest, "page/login", templateVars)
-		return
-	}
-
-	// Get current users
-	currentUsers, err := api.db.Queries.GetUsers(c)
-	if err != nil {
-		log.Error("Failed to check all users: ", err)
-		templateVars["Error"] = "Failed to Create User"
-		c.HTML(http.StatusBadRequest, "page/login", templateVars)
-		return
-	}
-
-	// Determine if we should be admin
-	isAdmin := false
-	if len(currentUsers) == 0 {
-		isAdmin = true
-	}
-
-	// Create user in DB
-	authHash := fmt.Sprintf("%x", rawAuthHash)
-	if rows, err := api.db.Queries.CreateUser(c, database.CreateUserParams{
-		ID:       username,
-		Pass:     &hashedPassword,
-		AuthHash: &authHash,
-		Admin:    isAdmin,
-	}); err != nil {
-		log.Error("CreateUser DB Error:", err)
-		templateVars["Error"] = "Registration Disabled or User Already Exists"
-		c.HTML(http.StatusBadRequest, "page/login", templateVars)
-		return
-	} else if rows == 0 {
-		log.Warn("User Already Exists:", username)
-		templateVars["Error"] = "Registration Disabled or User Already Exists"
-		c.HTML(http.StatusBadRequest, "page/login", templateVars)
-		return
-	}
-
-	// Get user
-	user, err := api.db.Queries.GetUser(c, username)
-	if err != nil {
-		log.Error("GetUser DB Error:", err)
+	} else if err != nil {
+		log.Error("Register User Error: ", err)
 		templateVars["Error"] = "Registration Disabled or User Already Exists"
 		c.HTML(http.StatusBadRequest, "page/login", templateVars)
 		return
@@ -253,12 +263,14 @@ func (api *API) appAuthRegister(c *gin.Context) {
 
 	// Set session
 	auth := authData{
-		UserName: user.ID,
-		IsAdmin:  user.Admin,
-		AuthHash: *user.AuthHash,
+		UserName:    user.ID,
+		IsAdmin:     user.Admin,
+		AuthHash:    *user.AuthHash,
+		Role:        user.Role,
+		Permissions: api.getRolePermissions(c, user.Role),
 	}
 	session := sessions.Default(c)
-	if err := api.setSession(session, auth); err != nil {
+	if err := api.setSession(c, session, auth); err != nil {
 		appErrorPage(c, http.StatusUnauthorized, "Unauthorized.")
 		return
 	}
@@ -269,6 +281,14 @@ func (api *API) appAuthRegister(c *gin.Context) {
 
 func (api *API) appAuthLogout(c *gin.Context) {
 	session := sessions.Default(c)
+
+	// Delete Server Session
+	if token, ok := session.Get("token").(string); ok && token != "" {
+		if _, err := api.db.Queries.DeleteSessionByToken(c, hashToken(token)); err != nil {
+			log.Error("DeleteSessionByToken DB Error: ", err)
+		}
+	}
+
 	session.Clear()
 	if err := session.Save(); err != nil {
 		log.Error("unable to save session")
@@ -278,46 +298,71 @@ func (api *API) appAuthLogout(c *gin.Context) {
 }
 
 func (api *API) koAuthRegister(c *gin.Context) {
-	if !api.cfg.RegistrationEnabled {
-		c.AbortWithStatus(http.StatusConflict)
-		return
-	}
-
 	var rUser requestUser
 	if err := c.ShouldBindJSON(&rUser); err != nil {
 		log.Error("Invalid JSON Bind")
-		apiErrorPage(c, http.StatusBadRequest, "Invalid User Data")
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
+		return
+	}
+
+	// Invite may be provided in the body or as a query parameter for clients
+	// (e.g. KOReader) that only allow configuring the server URL.
+	inviteCode := strings.TrimSpace(rUser.Invite)
+	if inviteCode == "" {
+		inviteCode = strings.TrimSpace(c.Query("invite"))
+	}
+
+	if !api.cfg.RegistrationEnabled && inviteCode == "" {
+		koErrorPage(c, http.StatusPaymentRequired, koErrorRegistrationDisabled, "User registration is disabled.")
 		return
 	}
 
 	if rUser.Username == "" || rUser.Password == "" {
 		log.Error("Invalid User - Empty Username or Password")
-		apiErrorPage(c, http.StatusBadRequest, "Invalid User Data")
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
+		return
+	}
+
+	// Create User
+	if _, err := api.registerUser(c, rUser.Username, rUser.Password, inviteCode); err == errInvalidInvite {
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidInvite, "Invalid or expired invite.")
+		return
+	} else if err == errUserExists {
+		log.Error("User Already Exists:", rUser.Username)
+		koErrorPage(c, http.StatusPaymentRequired, koErrorUserExists, "Username is already registered.")
+		return
+	} else if err != nil {
+		log.Error("Register User Error: ", err)
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
 		return
 	}
 
+	c.JSON(http.StatusCreated, gin.H{
+		"username": rUser.Username,
+	})
+}
+
+// registerUser creates a self-registered user from an MD5 hashed password.
+// When an invite code is provided it's consumed within the same transaction,
+// its role assigned, and its usage audited.
+func (api *API) registerUser(c *gin.Context, username, password, inviteCode string) (*database.User, error) {
 	// Generate password hash
-	hashedPassword, err := argon2.CreateHash(rUser.Password, argon2.DefaultParams)
+	hashedPassword, err := argon2.CreateHash(password, argon2.DefaultParams)
 	if err != nil {
-		log.Error("Argon2 Hash Failure:", err)
-		apiErrorPage(c, http.StatusBadRequest, "Unknown Error")
-		return
+		return nil, fmt.Errorf("argon2 hash failure: %w", err)
 	}
 
 	// Generate auth hash
 	rawAuthHash, err := utils.GenerateToken(64)
 	if err != nil {
-		log.Error("Failed to generate user token: ", err)
-		apiErrorPage(c, http.StatusBadRequest, "Unknown Error")
-		return
+		return nil, fmt.Errorf("failed to generate user token: %w", err)
 	}
+	authHash := fmt.Sprintf("%x", rawAuthHash)
 
 	// Get current users
 	currentUsers, err := api.db.Queries.GetUsers(c)
 	if err != nil {
-		log.Error("Failed to check all users: ", err)
-		apiErrorPage(c, http.StatusBadRequest, "Failed to Create User")
-		return
+		return nil, fmt.Errorf("failed to check all users: %w", err)
 	}
 
 	// Determine if we should be admin
@@ -326,57 +371,111 @@ func (api *API) koAuthRegister(c *gin.Context) {
 		isAdmin = true
 	}
 
-	// Create user
-	authHash := fmt.Sprintf("%x", rawAuthHash)
-	if rows, err := api.db.Queries.CreateUser(c, database.CreateUserParams{
-		ID:       rUser.Username,
+	// Do Transaction
+	tx, err := api.db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("transaction begin DB error: %w", err)
+	}
+
+	// Defer & Start Transaction
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Error("DB Rollback Error:", err)
+		}
+	}()
+	qtx := api.db.Queries.WithTx(tx)
+
+	// Consume Invite
+	role := roleUser
+	var invite database.Invite
+	if inviteCode != "" {
+		invite, err = qtx.UseInvite(c, inviteCode)
+		if err == sql.ErrNoRows {
+			log.Warnf("invalid or expired invite used by %s (%s)", username, c.ClientIP())
+			return nil, errInvalidInvite
+		} else if err != nil {
+			return nil, fmt.Errorf("UseInvite DB error: %w", err)
+		}
+		role = invite.Role
+	}
+
+	// Create user in DB
+	if rows, err := qtx.CreateUser(c, database.CreateUserParams{
+		ID:       username,
 		Pass:     &hashedPassword,
 		AuthHash: &authHash,
 		Admin:    isAdmin,
+		Role:     role,
 	}); err != nil {
-		log.Error("CreateUser DB Error:", err)
-		apiErrorPage(c, http.StatusBadRequest, "Invalid User Data")
-		return
+		return nil, fmt.Errorf("CreateUser DB error: %w", err)
 	} else if rows == 0 {
-		log.Error("User Already Exists:", rUser.Username)
-		apiErrorPage(c, http.StatusBadRequest, "User Already Exists")
-		return
+		return nil, errUserExists
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"username": rUser.Username,
-	})
+	// Audit Invite
+	if inviteCode != "" {
+		if err := qtx.AddInviteUse(c, database.AddInviteUseParams{
+			InviteCode: inviteCode,
+			UserID:     username,
+			UserAgent:  c.Request.UserAgent(),
+			Ip:         c.ClientIP(),
+		}); err != nil {
+			return nil, fmt.Errorf("AddInviteUse DB error: %w", err)
+		}
+		log.Infof("user %s registered with invite created by %s", username, invite.CreatedBy)
+	}
+
+	// Get user
+	user, err := qtx.GetUser(c, username)
+	if err != nil {
+		return nil, fmt.Errorf("GetUser DB error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit DB error: %w", err)
+	}
+
+	return &user, nil
 }
 
-func (api *API) getSession(ctx context.Context, session sessions.Session) (auth authData, ok bool) {
-	// Get Session
-	authorizedUser := session.Get("authorizedUser")
-	isAdmin := session.Get("isAdmin")
-	expiresAt := session.Get("expiresAt")
-	authHash := session.Get("authHash")
-	if authorizedUser == nil || isAdmin == nil || expiresAt == nil || authHash == nil {
+func (api *API) getSession(c *gin.Context, session sessions.Session) (auth authData, ok bool) {
+	// Get Session Token
+	token, _ := session.Get("token").(string)
+	if token == "" {
 		return
 	}
 
-	// Create Auth Object
-	auth = authData{
-		UserName: authorizedUser.(string),
-		IsAdmin:  isAdmin.(bool),
-		AuthHash: authHash.(string),
+	// Get Server Session
+	dbSession, err := api.db.Queries.GetSession(c, hashToken(token))
+	if err != nil {
+		return
 	}
 
-	// Validate Auth Hash
-	correctAuthHash, err := api.getUserAuthHash(ctx, auth.UserName)
-	if err != nil || correctAuthHash != auth.AuthHash {
+	// Validate Auth Hash (Rotated on Restore)
+	if dbSession.UserAuthHash == nil || *dbSession.UserAuthHash != dbSession.AuthHash {
 		return
 	}
 
-	// Refresh
-	if expiresAt.(int64)-time.Now().Unix() < 60*60*24 {
-		log.Info("Refreshing Session")
-		if err := api.setSession(session, auth); err != nil {
-			log.Error("unable to get session")
-			return
+	// Create Auth Object
+	auth = authData{
+		UserName:    dbSession.UserID,
+		IsAdmin:     dbSession.Admin,
+		AuthHash:    dbSession.AuthHash,
+		SessionID:   dbSession.ID,
+		Role:        dbSession.Role,
+		Permissions: api.getRolePermissions(c, dbSession.Role),
+	}
+
+	// Refresh Last Seen & Expiration
+	lastSeen, err := time.Parse(time.RFC3339, dbSession.LastSeen)
+	if err != nil || time.Since(lastSeen) > sessionRefreshInterval {
+		now := time.Now().UTC()
+		if err := api.db.Queries.UpdateSession(c, database.UpdateSessionParams{
+			ID:        dbSession.ID,
+			LastSeen:  now.Format(time.RFC3339),
+			ExpiresAt: now.Add(sessionMaxAge).Format(time.RFC3339),
+		}); err != nil {
+			log.Error("UpdateSession DB Error: ", err)
 		}
 	}
 
@@ -384,33 +483,105 @@ func (api *API) getSession(ctx context.Context, session sessions.Session) (auth
 	return auth, true
 }
 
-func (api *API) setSession(session sessions.Session, auth authData) error {
+func (api *API) setSession(c *gin.Context, session sessions.Session, auth authData) error {
+	// Delete Previous Server Session
+	if token, ok := session.Get("token").(string); ok && token != "" {
+		if _, err := api.db.Queries.DeleteSessionByToken(c, hashToken(token)); err != nil {
+			log.Error("DeleteSessionByToken DB Error: ", err)
+		}
+	}
+
+	// Generate Session Token
+	rawToken, err := utils.GenerateToken(64)
+	if err != nil {
+		return err
+	}
+	token := fmt.Sprintf("%x", rawToken)
+
+	// Create Server Session
+	if _, err := api.db.Queries.CreateSession(c, database.CreateSessionParams{
+		UserID:    auth.UserName,
+		TokenHash: hashToken(token),
+		AuthHash:  auth.AuthHash,
+		UserAgent: c.Request.UserAgent(),
+		Ip:        c.ClientIP(),
+		ExpiresAt: time.Now().UTC().Add(sessionMaxAge).Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+
 	// Set Session Cookie
-	session.Set("authorizedUser", auth.UserName)
-	session.Set("isAdmin", auth.IsAdmin)
-	session.Set("expiresAt", time.Now().Unix()+(60*60*24*7))
-	session.Set("authHash", auth.AuthHash)
+	session.Clear()
+	session.Set("token", token)
 
 	return session.Save()
 }
 
-func (api *API) getUserAuthHash(ctx context.Context, username string) (string, error) {
-	// Return Cache
-	if api.userAuthCache[username] != "" {
-		return api.userAuthCache[username], nil
+func (api *API) getAPITokenAuth(c *gin.Context, token string) (auth authData, ok bool) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return
 	}
 
-	// Get DB
-	user, err := api.db.Queries.GetUser(ctx, username)
+	// Get Token
+	dbToken, err := api.db.Queries.GetAPIToken(c, hashToken(token))
+	if err != nil {
+		return
+	}
+
+	// Create Auth Object
+	auth = authData{
+		UserName:    dbToken.UserID,
+		IsAdmin:     dbToken.Admin,
+		Role:        dbToken.Role,
+		Permissions: api.getRolePermissions(c, dbToken.Role),
+	}
+	if dbToken.UserAuthHash != nil {
+		auth.AuthHash = *dbToken.UserAuthHash
+	}
+
+	// Refresh Last Used
+	var lastUsed time.Time
+	if dbToken.LastUsed != nil {
+		lastUsed, _ = time.Parse(time.RFC3339, *dbToken.LastUsed)
+	}
+	if time.Since(lastUsed) > sessionRefreshInterval {
+		now := time.Now().UTC().Format(time.RFC3339)
+		if err := api.db.Queries.UpdateAPITokenLastUsed(c, database.UpdateAPITokenLastUsedParams{
+			ID:       dbToken.ID,
+			LastUsed: &now,
+		}); err != nil {
+			log.Error("UpdateAPITokenLastUsed DB Error: ", err)
+		}
+	}
+
+	// Authorized
+	return auth, true
+}
+
+// createAPIToken generates a new API token for the user, returning the raw
+// token which isn't recoverable afterwards.
+func (api *API) createAPIToken(ctx context.Context, userID string, name string) (string, error) {
+	rawToken, err := utils.GenerateToken(32)
 	if err != nil {
-		log.Error("GetUser DB Error:", err)
 		return "", err
 	}
+	token := fmt.Sprintf("%s%x", apiTokenPrefix, rawToken)
 
-	// Update Cache
-	api.userAuthCache[username] = *user.AuthHash
+	if _, err := api.db.Queries.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(token),
+	}); err != nil {
+		return "", fmt.Errorf("CreateAPIToken DB Error: %w", err)
+	}
 
-	return api.userAuthCache[username], nil
+	return token, nil
+}
+
+// hashToken returns the SHA256 hash of a session or API token, which is all
+// that's stored server side.
+func hashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
 }
 
 func (api *API) rotateAllAuthHashes(ctx context.Context) error {
@@ -435,7 +606,6 @@ func (api *API) rotateAllAuthHashes(ctx context.Context) error {
 	}
 
 	// Update Users
-	newAuthHashCache := make(map[string]string, 0)
 	for _, user := range users {
 		// Generate Auth Hash
 		rawAuthHash, err := utils.GenerateToken(64)
@@ -452,9 +622,6 @@ func (api *API) rotateAllAuthHashes(ctx context.Context) error {
 		}); err != nil {
 			return err
 		}
-
-		// Save New Hash Cache
-		newAuthHashCache[user.ID] = fmt.Sprintf("%x", rawAuthHash)
 	}
 
 	// Commit Transaction
@@ -463,10 +630,5 @@ func (api *API) rotateAllAuthHashes(ctx context.Context) error {
 		return err
 	}
 
-	// Transaction Succeeded -> Update Cache
-	for user, hash := range newAuthHashCache {
-		api.userAuthCache[user] = hash
-	}
-
 	return nil
 }
@@ -21,6 +21,11 @@ func (api *API) createDownloadDocumentHandler(errorFunc func(*gin.Context, int,
 			return
 		}
 
+		// Validate Access
+		if !api.documentReadable(c, rDoc.DocumentID, errorFunc) {
+			return
+		}
+
 		// Get Document
 		document, err := api.db.Queries.GetDocument(c, rDoc.DocumentID)
 		if err != nil {
@@ -35,14 +40,8 @@ func (api *API) createDownloadDocumentHandler(errorFunc func(*gin.Context, int,
 			return
 		}
 
-		// Derive Basepath
-		basepath := filepath.Join(api.cfg.DataPath, "documents")
-		if document.Basepath != nil && *document.Basepath != "" {
-			basepath = *document.Basepath
-		}
-
 		// Derive Storage Location
-		filePath := filepath.Join(basepath, *document.Filepath)
+		filePath := api.documentFilePath(document.Basepath, *document.Filepath)
 
 		// Validate File Exists
 		_, err = os.Stat(filePath)
@@ -67,6 +66,11 @@ func (api *API) createGetCoverHandler(errorFunc func(*gin.Context, int, string))
 			return
 		}
 
+		// Validate Access
+		if !api.documentReadable(c, rDoc.DocumentID, errorFunc) {
+			return
+		}
+
 		// Validate Document Exists in DB
 		document, err := api.db.Queries.GetDocument(c, rDoc.DocumentID)
 		if err != nil {
@@ -149,3 +153,12 @@ func (api *API) createGetCoverHandler(errorFunc func(*gin.Context, int, string))
 		c.File(coverFilePath)
 	}
 }
+
+// documentFilePath returns the storage location of a document file, which
+// lives in the data directory unless imported in place (basepath).
+func (api *API) documentFilePath(basepath *string, fileName string) string {
+	if basepath != nil && *basepath != "" {
+		return filepath.Join(*basepath, fileName)
+	}
+	return filepath.Join(api.cfg.DataPath, "documents", fileName)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/utils"
+)
+
+const cookieKeysFilename = "cookie_keys.json"
+
+type cookieKey struct {
+	AuthKey   string     `json:"auth_key"`
+	EncKey    string     `json:"enc_key"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+type cookieKeyFile struct {
+	Keys []cookieKey `json:"keys"`
+}
+
+// getCookieKeyPairs returns the key pairs used by the cookie store. The first
+// pair signs new cookies while the rest only validate existing ones.
+func getCookieKeyPairs(c *config.Config) ([][]byte, error) {
+	// Environment keys take precedence
+	if c.CookieAuthKey != "" {
+		log.Info("utilizing environment cookie auth key")
+		keyPair := [][]byte{[]byte(c.CookieAuthKey)}
+		if c.CookieEncKey != "" {
+			if len(c.CookieEncKey) != 16 && len(c.CookieEncKey) != 32 {
+				return nil, errors.New("invalid cookie encryption key (must be 16 or 32 bytes)")
+			}
+			log.Info("utilizing environment cookie encryption key")
+			keyPair = append(keyPair, []byte(c.CookieEncKey))
+		}
+		return keyPair, nil
+	}
+
+	keyPairs, _, err := rotateCookieKeys(c)
+	return keyPairs, err
+}
+
+// rotateCookieKeys loads, rotates & persists the generated cookie keys. It
+// returns the active key pairs and whether they changed.
+func rotateCookieKeys(c *config.Config) ([][]byte, bool, error) {
+	keyPath := filepath.Join(c.ConfigPath, cookieKeysFilename)
+	keyFile, err := loadCookieKeys(keyPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Rotate & prune keys
+	rotation := time.Duration(c.CookieKeyRotation) * 24 * time.Hour
+	grace := time.Duration(c.CookieKeyGrace) * 24 * time.Hour
+	changed, err := keyFile.rotate(time.Now(), rotation, grace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Persist keys
+	if changed {
+		log.Info("persisting cookie keys")
+		if err := saveCookieKeys(keyPath, keyFile); err != nil {
+			log.Warn("unable to persist cookie keys, sessions will not survive restart: ", err)
+		}
+	}
+
+	keyPairs, err := keyFile.keyPairs()
+	if err != nil {
+		return nil, false, err
+	}
+	return keyPairs, changed, nil
+}
+
+// RotateCookieKeys rotates the generated cookie keys and swaps the session
+// store codecs when they change. Environment keys are never rotated.
+func (api *API) RotateCookieKeys(ctx context.Context) error {
+	if api.cfg.CookieAuthKey != "" {
+		return nil
+	}
+
+	keyPairs, changed, err := rotateCookieKeys(api.cfg)
+	if err != nil {
+		return err
+	} else if changed {
+		api.cookieCodecs.setKeyPairs(keyPairs)
+	}
+	return nil
+}
+
+// cookieCodecs is the single codec of the session store. It delegates to the
+// codecs of the active key pairs, which are swapped atomically on rotation.
+type cookieCodecs struct {
+	codecs atomic.Pointer[[]securecookie.Codec]
+}
+
+func newCookieCodecs(keyPairs [][]byte) *cookieCodecs {
+	c := &cookieCodecs{}
+	c.setKeyPairs(keyPairs)
+	return c
+}
+
+func (c *cookieCodecs) setKeyPairs(keyPairs [][]byte) {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	c.codecs.Store(&codecs)
+}
+
+func (c *cookieCodecs) Encode(name string, value any) (string, error) {
+	return securecookie.EncodeMulti(name, value, *c.codecs.Load()...)
+}
+
+func (c *cookieCodecs) Decode(name, value string, dst any) error {
+	return securecookie.DecodeMulti(name, value, dst, *c.codecs.Load()...)
+}
+
+// cookieStore is a cookie session store backed by rotatable codecs
+type cookieStore struct {
+	*gsessions.CookieStore
+}
+
+func newCookieStore(codecs *cookieCodecs) *cookieStore {
+	return &cookieStore{&gsessions.CookieStore{
+		Codecs:  []securecookie.Codec{codecs},
+		Options: &gsessions.Options{Path: "/"},
+	}}
+}
+
+func (s *cookieStore) Options(options sessions.Options) {
+	s.CookieStore.Options = options.ToGorillaOptions()
+}
+
+// rotate generates a new primary key when none exists or the current one is
+// older than the rotation period, and drops retired keys past their grace
+// period. A rotation period of zero disables rotation.
+func (f *cookieKeyFile) rotate(now time.Time, rotation, grace time.Duration) (bool, error) {
+	changed := false
+
+	// Generate primary key
+	if len(f.Keys) == 0 || (rotation > 0 && now.Sub(f.Keys[0].CreatedAt) >= rotation) {
+		if len(f.Keys) == 0 {
+			log.Info("generating cookie keys")
+		} else {
+			log.Info("rotating cookie keys")
+			f.Keys[0].RetiredAt = &now
+		}
+
+		newKey, err := generateCookieKey(now)
+		if err != nil {
+			return false, err
+		}
+
+		f.Keys = append([]cookieKey{*newKey}, f.Keys...)
+		changed = true
+	}
+
+	// Prune expired keys
+	activeKeys := f.Keys[:1]
+	for _, key := range f.Keys[1:] {
+		if key.RetiredAt != nil && now.Sub(*key.RetiredAt) >= grace {
+			changed = true
+			continue
+		}
+		activeKeys = append(activeKeys, key)
+	}
+	f.Keys = activeKeys
+
+	return changed, nil
+}
+
+func (f *cookieKeyFile) keyPairs() ([][]byte, error) {
+	var keyPairs [][]byte
+	for _, key := range f.Keys {
+		authKey, err := hex.DecodeString(key.AuthKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cookie auth key")
+		}
+
+		encKey, err := hex.DecodeString(key.EncKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cookie encryption key")
+		}
+
+		keyPairs = append(keyPairs, authKey, encKey)
+	}
+	return keyPairs, nil
+}
+
+func generateCookieKey(now time.Time) (*cookieKey, error) {
+	authKey, err := utils.GenerateToken(64)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate cookie auth key")
+	}
+
+	encKey, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate cookie encryption key")
+	}
+
+	return &cookieKey{
+		AuthKey:   hex.EncodeToString(authKey),
+		EncKey:    hex.EncodeToString(encKey),
+		CreatedAt: now,
+	}, nil
+}
+
+func loadCookieKeys(keyPath string) (*cookieKeyFile, error) {
+	keyFile := &cookieKeyFile{}
+
+	rawData, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		return keyFile, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "unable to read cookie keys")
+	}
+
+	if err := json.Unmarshal(rawData, keyFile); err != nil {
+		return nil, errors.Wrap(err, "unable to parse cookie keys")
+	}
+
+	return keyFile, nil
+}
+
+func saveCookieKeys(keyPath string, keyFile *cookieKeyFile) error {
+	rawData, err := json.MarshalIndent(keyFile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write atomically & only readable by owner
+	tmpPath := keyPath + ".tmp"
+	if err := os.WriteFile(tmpPath, rawData, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, keyPath)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/config"
+)
+
+func TestGetCookieKeyPairsPersisted(t *testing.T) {
+	cfg := &config.Config{ConfigPath: t.TempDir(), CookieKeyRotation: 90, CookieKeyGrace: 7}
+
+	firstPairs, err := getCookieKeyPairs(cfg)
+	require.NoError(t, err)
+	assert.Len(t, firstPairs, 2, "should have single auth & enc key pair")
+	assert.Len(t, firstPairs[1], 32, "should have 32 byte encryption key")
+
+	secondPairs, err := getCookieKeyPairs(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, firstPairs, secondPairs, "should reuse persisted keys")
+
+	info, err := os.Stat(filepath.Join(cfg.ConfigPath, cookieKeysFilename))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "should only be readable by owner")
+}
+
+func TestGetCookieKeyPairsEnvironment(t *testing.T) {
+	cfg := &config.Config{ConfigPath: t.TempDir(), CookieAuthKey: "authkey", CookieEncKey: "0123456789abcdef"}
+
+	keyPairs, err := getCookieKeyPairs(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("authkey"), []byte("0123456789abcdef")}, keyPairs)
+
+	cfg.CookieEncKey = "short"
+	_, err = getCookieKeyPairs(cfg)
+	assert.Error(t, err, "should reject invalid encryption key length")
+}
+
+func TestCookieKeyRotation(t *testing.T) {
+	now := time.Now()
+	rotation := 90 * 24 * time.Hour
+	grace := 7 * 24 * time.Hour
+
+	keyFile := &cookieKeyFile{}
+	changed, err := keyFile.rotate(now.Add(-100*24*time.Hour), rotation, grace)
+	require.NoError(t, err)
+	assert.True(t, changed, "should generate initial key")
+	originalKey := keyFile.Keys[0].AuthKey
+
+	// Rotate Expired Primary
+	changed, err = keyFile.rotate(now, rotation, grace)
+	require.NoError(t, err)
+	assert.True(t, changed, "should rotate expired key")
+	assert.Len(t, keyFile.Keys, 2, "should retain previous key within grace period")
+	assert.Equal(t, originalKey, keyFile.Keys[1].AuthKey, "should demote previous key")
+	assert.NotNil(t, keyFile.Keys[1].RetiredAt, "should mark previous key retired")
+
+	// Within Grace
+	changed, err = keyFile.rotate(now.Add(6*24*time.Hour), rotation, grace)
+	require.NoError(t, err)
+	assert.False(t, changed, "should not change within grace period")
+	assert.Len(t, keyFile.Keys, 2)
+
+	// After Grace
+	changed, err = keyFile.rotate(now.Add(8*24*time.Hour), rotation, grace)
+	require.NoError(t, err)
+	assert.True(t, changed, "should prune key after grace period")
+	assert.Len(t, keyFile.Keys, 1)
+	assert.NotEqual(t, originalKey, keyFile.Keys[0].AuthKey)
+
+	// Rotation Disabled
+	changed, err = keyFile.rotate(now.Add(1000*24*time.Hour), 0, grace)
+	require.NoError(t, err)
+	assert.False(t, changed, "should not rotate when disabled")
+}
+
+func TestRotateCookieKeys(t *testing.T) {
+	cfg := &config.Config{ConfigPath: t.TempDir(), CookieKeyRotation: 90, CookieKeyGrace: 7}
+	keyPairs, err := getCookieKeyPairs(cfg)
+	require.NoError(t, err)
+	api := &API{cfg: cfg, cookieCodecs: newCookieCodecs(keyPairs)}
+
+	// Not Due
+	require.NoError(t, api.RotateCookieKeys(t.Context()))
+	oldCookie, err := api.cookieCodecs.Encode("token", "value")
+	require.NoError(t, err)
+
+	// Expire Primary
+	keyPath := filepath.Join(cfg.ConfigPath, cookieKeysFilename)
+	keyFile, err := loadCookieKeys(keyPath)
+	require.NoError(t, err)
+	keyFile.Keys[0].CreatedAt = time.Now().Add(-100 * 24 * time.Hour)
+	require.NoError(t, saveCookieKeys(keyPath, keyFile))
+
+	require.NoError(t, api.RotateCookieKeys(t.Context()))
+	newCookie, err := api.cookieCodecs.Encode("token", "value")
+	require.NoError(t, err)
+
+	var value string
+	require.NoError(t, api.cookieCodecs.Decode("token", oldCookie, &value), "should accept retired key within grace period")
+	assert.Equal(t, "value", value)
+	require.NoError(t, api.cookieCodecs.Decode("token", newCookie, &value))
+	assert.Error(t, securecookie.DecodeMulti("token", newCookie, &value, securecookie.CodecsFromPairs(keyPairs...)...), "should sign with rotated key")
+}
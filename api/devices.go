@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"reichard.io/antholume/database"
+)
+
+// mergeDevice moves the source devices activity & progress history to the
+// target device (e.g. when replacing a reader) and removes the source device.
+// The newest progress of either device is kept for each document.
+func (api *API) mergeDevice(ctx context.Context, userID, sourceID, targetID string) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge device %s into itself", sourceID)
+	}
+
+	// Validate Ownership
+	for _, deviceID := range []string{sourceID, targetID} {
+		if device, err := api.db.Queries.GetDevice(ctx, deviceID); err != nil {
+			return fmt.Errorf("GetDevice DB Error: %w", err)
+		} else if device.UserID != userID {
+			return fmt.Errorf("device %s does not exist", deviceID)
+		}
+	}
+
+	tx, err := api.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Transaction Begin DB Error: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := api.db.Queries.WithTx(tx)
+
+	// Both Devices May Have Uploaded The Same Activity - Merged Into The Target
+	if _, err := qtx.MergeDeviceActivity(ctx, database.MergeDeviceActivityParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		return fmt.Errorf("MergeDeviceActivity DB Error: %w", err)
+	}
+	if _, err := qtx.MoveDeviceActivity(ctx, database.MoveDeviceActivityParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		return fmt.Errorf("MoveDeviceActivity DB Error: %w", err)
+	}
+	if _, err := qtx.DeleteSupersededDeviceProgress(ctx, database.DeleteSupersededDeviceProgressParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		return fmt.Errorf("DeleteSupersededDeviceProgress DB Error: %w", err)
+	}
+	if _, err := qtx.MoveDeviceProgress(ctx, database.MoveDeviceProgressParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		return fmt.Errorf("MoveDeviceProgress DB Error: %w", err)
+	}
+	if _, err := qtx.MoveDeviceProgressHistory(ctx, database.MoveDeviceProgressHistoryParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		return fmt.Errorf("MoveDeviceProgressHistory DB Error: %w", err)
+	}
+
+	// Remaining (Older) Progress & Merged Activity Deleted With Device
+	if _, err := qtx.DeleteDevice(ctx, database.DeleteDeviceParams{ID: sourceID, UserID: userID}); err != nil {
+		return fmt.Errorf("DeleteDevice DB Error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Transaction Commit DB Error: %w", err)
+	}
+
+	return api.CacheTempTables(ctx)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestDeviceManagement(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	now := time.Now().Unix()
+
+	// Kindle Reads, Then Kobo Replaces It
+	for _, position := range []gin.H{
+		koPosition("kindle", 0.50, now-300),
+		koPosition("kobo", 0.60, now-100),
+	} {
+		code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+		require.Equal(t, http.StatusOK, code)
+	}
+	for device, duration := range map[string]int64{"kindle": 45, "kobo": 30} {
+		code, _ := koRequest(t, server, http.MethodPost, "/api/ko/activity", gin.H{
+			"device":    device,
+			"device_id": device + "-id",
+			"activity":  []gin.H{koActivity(now-600, duration, 1, 10)},
+		})
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	editDevice := func(values url.Values) int {
+		resp, err := client.PostForm(server.URL+"/settings/devices", values)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("rename & disable sync", func(t *testing.T) {
+		code := editDevice(url.Values{"device": {"kindle-id"}, "operation": {"UPDATE"}, "name": {"Old Kindle"}, "sync": {"false"}})
+		require.Equal(t, http.StatusOK, code)
+
+		device, err := api.db.Queries.GetDevice(t.Context(), "kindle-id")
+		require.NoError(t, err)
+		assert.Equal(t, "Old Kindle", device.DeviceName)
+		assert.False(t, device.Sync)
+
+		resp, err := client.Get(server.URL + "/settings")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Refused
+		code, body := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.70, now))
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.EqualValues(t, koErrorDeviceDisabled, body["code"])
+		code, body = koRequest(t, server, http.MethodPost, "/api/ko/activity", gin.H{
+			"device":    "kindle",
+			"device_id": "kindle-id",
+			"activity":  []gin.H{koActivity(now-60, 30, 2, 10)},
+		})
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.EqualValues(t, koErrorDeviceDisabled, body["code"])
+
+		// Name Kept On Sync
+		device, err = api.db.Queries.GetDevice(t.Context(), "kindle-id")
+		require.NoError(t, err)
+		assert.Equal(t, "Old Kindle", device.DeviceName)
+	})
+
+	t.Run("other users device", func(t *testing.T) {
+		require.NoError(t, api.createUser(t.Context(), "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+		_, err := api.db.Queries.UpsertDevice(t.Context(), database.UpsertDeviceParams{
+			ID:         "other-id",
+			UserID:     "other",
+			DeviceName: "other",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, editDevice(url.Values{"device": {"other-id"}, "operation": {"DELETE"}}))
+		assert.Equal(t, http.StatusNotFound, editDevice(url.Values{"device": {"unknown"}, "operation": {"DELETE"}}))
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		code := editDevice(url.Values{"device": {"kindle-id"}, "operation": {"MERGE"}, "target": {"kobo-id"}})
+		require.Equal(t, http.StatusOK, code)
+
+		_, err := api.db.Queries.GetDevice(t.Context(), "kindle-id")
+		assert.Error(t, err, "source device should be removed")
+
+		history, err := api.db.Queries.GetDocumentProgressHistory(t.Context(), database.GetDocumentProgressHistoryParams{
+			UserID:     "reader",
+			DocumentID: "document-00",
+			Limit:      50,
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		for _, entry := range history {
+			assert.Equal(t, "kobo-id", entry.DeviceID)
+		}
+
+		progress, err := api.db.Queries.GetDocumentProgress(t.Context(), database.GetDocumentProgressParams{
+			UserID:     "reader",
+			DocumentID: "document-00",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0.60, progress.Percentage, "newest progress should be kept")
+
+		var activity, duration int
+		require.NoError(t, api.db.DB.QueryRow("SELECT COUNT(*), MAX(duration) FROM activity WHERE device_id = 'kobo-id'").Scan(&activity, &duration))
+		assert.Equal(t, 1, activity, "duplicate activity should be merged")
+		assert.Equal(t, 45, duration, "longest duration should be kept")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		code := editDevice(url.Values{"device": {"kobo-id"}, "operation": {"DELETE"}})
+		require.Equal(t, http.StatusOK, code)
+
+		var remaining int
+		require.NoError(t, api.db.DB.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM activity)
+				+ (SELECT COUNT(*) FROM document_progress)
+				+ (SELECT COUNT(*) FROM document_progress_history)
+		`).Scan(&remaining))
+		assert.Zero(t, remaining)
+	})
+}
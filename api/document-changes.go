@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"reichard.io/antholume/database"
+)
+
+// Maximum changelog entries returned per delta document sync.
+const documentChangesLimit = 500
+
+// documentChanges are the changes a device needs to apply to catch up to
+// Cursor. More indicates additional changes remain after Cursor.
+type documentChanges struct {
+	Give    []database.Document
+	Delete  []string
+	Cursor  int64
+	More    bool
+	Expired bool
+}
+
+// getDocumentChanges returns the users document changes after the provided
+// cursor. Documents that were deleted or are no longer visible to the user are
+// deleted, provided the user could previously see them, while visible
+// documents with a file are given. Cursors ahead of the
+// changelog (e.g. after restoring a backup) restart from the beginning.
+func (api *API) getDocumentChanges(ctx context.Context, userID string, cursor int64) (*documentChanges, error) {
+	latest, err := api.db.Queries.GetDocumentChangesCursor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentChangesCursor DB Error: %w", err)
+	}
+
+	changes := &documentChanges{
+		Give:   []database.Document{},
+		Delete: []string{},
+		Cursor: cursor,
+	}
+	if cursor > latest || cursor < 0 {
+		changes.Cursor = 0
+		changes.Expired = true
+	}
+
+	rows, err := api.db.Queries.GetDocumentChanges(ctx, database.GetDocumentChangesParams{
+		UserID: userID,
+		Cursor: changes.Cursor,
+		Limit:  documentChangesLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentChanges DB Error: %w", err)
+	}
+
+	for _, row := range rows {
+		changes.Cursor = row.Seq
+		if row.Document.Deleted || !row.Visible {
+			// Never Disclose Documents The User Couldn't See
+			if !row.Visible && !row.PreviouslyVisible {
+				continue
+			}
+			changes.Delete = append(changes.Delete, row.Document.ID)
+		} else if row.Document.Filepath != nil {
+			changes.Give = append(changes.Give, row.Document)
+		}
+	}
+	changes.More = len(rows) == documentChangesLimit && changes.Cursor < latest
+
+	return changes, nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/metadata"
+)
+
+// errDocumentExists is returned when an upload matches a document the uploader
+// can't see.
+var errDocumentExists = errors.New("document already exists")
+
+// storeUploadedDocument saves an uploaded document to the data path and
+// upserts it as owned by the uploader, returning the document ID. An upload
+// matching an existing document the uploader can't manage leaves it as is.
+func (api *API) storeUploadedDocument(ctx context.Context, file *multipart.FileHeader, auth authData) (string, error) {
+	// Open Upload
+	uploadedFile, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("unable to open file: %w", err)
+	}
+	defer uploadedFile.Close()
+
+	// Create Temp File
+	tempFile, err := os.CreateTemp("", "book")
+	if err != nil {
+		return "", fmt.Errorf("unable to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	// Save Temp File
+	if _, err := io.Copy(tempFile, uploadedFile); err != nil {
+		return "", fmt.Errorf("unable to save file: %w", err)
+	}
+
+	// Get Metadata
+	metadataInfo, err := metadata.GetMetadata(tempFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("unable to acquire metadata: %w", err)
+	}
+
+	// Check Already Exists
+	access, err := api.getDocumentAccess(ctx, auth, *metadataInfo.PartialMD5)
+	exists := err == nil
+	if exists {
+		log.Warnf("document already exists: %s", *metadataInfo.PartialMD5)
+		if !access.CanManage && !access.CanRead {
+			return "", errDocumentExists
+		} else if !access.CanManage {
+			return *metadataInfo.PartialMD5, nil
+		}
+	} else if err != sql.ErrNoRows {
+		return "", fmt.Errorf("GetDocumentAccess DB Error: %w", err)
+	}
+
+	// Derive & Sanitize File Name
+	fileName := deriveBaseFileName(metadataInfo)
+	basePath := filepath.Join(api.cfg.DataPath, "documents")
+	safePath := filepath.Join(basePath, fileName)
+
+	// Open Destination File
+	destFile, err := os.Create(safePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to open destination file: %w", err)
+	}
+	defer destFile.Close()
+
+	// Copy File
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("unable to read temp file: %w", err)
+	}
+	if _, err = io.Copy(destFile, tempFile); err != nil {
+		return "", fmt.Errorf("unable to save file: %w", err)
+	}
+
+	// Upsert Document
+	if _, err = api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:          *metadataInfo.PartialMD5,
+		Title:       metadataInfo.Title,
+		Author:      metadataInfo.Author,
+		Description: metadataInfo.Description,
+		Md5:         metadataInfo.MD5,
+		Words:       metadataInfo.WordCount,
+		Pages:       metadataInfo.PageCount,
+		Filepath:    &fileName,
+		Basepath:    &basePath,
+		OwnerID:     &auth.UserName,
+	}); err != nil {
+		return "", fmt.Errorf("UpsertDocument DB Error: %w", err)
+	}
+
+	if !exists {
+		api.emitDocumentEvent(ctx, auth.UserName, webhookDocumentAdded, *metadataInfo.PartialMD5, nil)
+	}
+
+	return *metadataInfo.PartialMD5, nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type healthStatus string
+
+const (
+	healthOK          healthStatus = "ok"
+	healthFailing     healthStatus = "failing"
+	healthUnavailable healthStatus = "unavailable"
+	healthReloading   healthStatus = "reloading"
+)
+
+const readinessTimeout = 5 * time.Second
+
+var errDiskSpaceUnsupported = errors.New("free disk space unsupported on this platform")
+
+type healthCheck struct {
+	Status healthStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Detail gin.H        `json:"detail,omitempty"`
+}
+
+type healthResponse struct {
+	Status  healthStatus           `json:"status"`
+	Version string                 `json:"version"`
+	Checks  map[string]healthCheck `json:"checks,omitempty"`
+}
+
+// getHealth reports the process is alive without touching any dependency.
+func (api *API) getHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{
+		Status:  healthOK,
+		Version: api.cfg.Version,
+	})
+}
+
+// getReadiness reports whether the instance can serve traffic. While the DB
+// is reloading (e.g. after a restore) the DB checks are skipped and the
+// instance is reported as reloading rather than failing.
+func (api *API) getReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c, readinessTimeout)
+	defer cancel()
+
+	checks := map[string]healthCheck{}
+	if api.db.Reloading() {
+		checks["database"] = healthCheck{Status: healthReloading}
+		checks["migrations"] = healthCheck{Status: healthReloading}
+	} else {
+		checks["database"] = newHealthCheck(api.db.Ping(ctx), nil)
+		checks["migrations"] = api.checkMigrations(ctx)
+	}
+	checks["config_path"] = newHealthCheck(checkWritable(api.cfg.ConfigPath), gin.H{"path": api.cfg.ConfigPath})
+	checks["data_path"] = newHealthCheck(checkWritable(api.cfg.DataPath), gin.H{"path": api.cfg.DataPath})
+	checks["disk_space"] = api.checkDiskSpace()
+
+	// Derive Overall Status
+	status, statusCode := healthOK, http.StatusOK
+	for name, check := range checks {
+		switch check.Status {
+		case healthFailing:
+			log.Warnf("readiness check %s failing: %s", name, check.Error)
+			status, statusCode = healthUnavailable, http.StatusServiceUnavailable
+		case healthReloading:
+			if status == healthOK {
+				status, statusCode = healthReloading, http.StatusServiceUnavailable
+			}
+		}
+	}
+
+	c.JSON(statusCode, healthResponse{
+		Status:  status,
+		Version: api.cfg.Version,
+		Checks:  checks,
+	})
+}
+
+func (api *API) checkMigrations(ctx context.Context) healthCheck {
+	current, expected, err := api.db.MigrationVersion(ctx)
+	if err != nil {
+		return newHealthCheck(err, nil)
+	}
+
+	detail := gin.H{"current": current, "expected": expected}
+	if current != expected {
+		return newHealthCheck(fmt.Errorf("migration version %d, expected %d", current, expected), detail)
+	}
+	return newHealthCheck(nil, detail)
+}
+
+func (api *API) checkDiskSpace() healthCheck {
+	freeBytes, err := freeDiskSpace(api.cfg.DataPath)
+	if errors.Is(err, errDiskSpaceUnsupported) {
+		return newHealthCheck(nil, gin.H{"supported": false})
+	} else if err != nil {
+		return newHealthCheck(err, nil)
+	}
+
+	thresholdBytes := uint64(max(api.cfg.MinFreeDiskMB, 0)) * 1024 * 1024
+	detail := gin.H{"free_bytes": freeBytes, "threshold_bytes": thresholdBytes}
+	if freeBytes < thresholdBytes {
+		return newHealthCheck(fmt.Errorf("free disk space below %d MB", api.cfg.MinFreeDiskMB), detail)
+	}
+	return newHealthCheck(nil, detail)
+}
+
+func newHealthCheck(err error, detail gin.H) healthCheck {
+	if err != nil {
+		return healthCheck{Status: healthFailing, Error: err.Error(), Detail: detail}
+	}
+	return healthCheck{Status: healthOK, Detail: detail}
+}
+
+// checkWritable verifies a file can be created within the directory.
+func checkWritable(dir string) error {
+	probeFile, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	probeFile.Close()
+	return os.Remove(probeFile.Name())
+}
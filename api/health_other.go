@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package api
+
+// freeDiskSpace isn't supported on this platform, so the disk space check is
+// skipped.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getHealthResponse(t *testing.T, url string) (int, healthResponse) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var health healthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	return resp.StatusCode, health
+}
+
+func TestHealth(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+
+	t.Run("healthz", func(t *testing.T) {
+		code, health := getHealthResponse(t, server.URL+"/healthz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, healthOK, health.Status)
+		assert.Empty(t, health.Checks)
+	})
+
+	t.Run("readyz", func(t *testing.T) {
+		code, health := getHealthResponse(t, server.URL+"/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, healthOK, health.Status)
+		for _, name := range []string{"database", "migrations", "config_path", "data_path", "disk_space"} {
+			assert.Equal(t, healthOK, health.Checks[name].Status, name)
+		}
+		assert.Equal(t, health.Checks["migrations"].Detail["expected"], health.Checks["migrations"].Detail["current"])
+	})
+
+	t.Run("readyz low disk space", func(t *testing.T) {
+		api.cfg.MinFreeDiskMB = 1 << 40
+		t.Cleanup(func() { api.cfg.MinFreeDiskMB = 0 })
+
+		code, health := getHealthResponse(t, server.URL+"/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, healthUnavailable, health.Status)
+		assert.Equal(t, healthFailing, health.Checks["disk_space"].Status)
+		assert.Equal(t, healthOK, health.Checks["database"].Status)
+	})
+
+	t.Run("readyz unwritable data path", func(t *testing.T) {
+		dataPath := api.cfg.DataPath
+		api.cfg.DataPath = dataPath + "/missing"
+		t.Cleanup(func() { api.cfg.DataPath = dataPath })
+
+		code, health := getHealthResponse(t, server.URL+"/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, healthFailing, health.Checks["data_path"].Status)
+		assert.NotEmpty(t, health.Checks["data_path"].Error)
+	})
+}
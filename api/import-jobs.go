@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+const importJobsSize = 10
+
+var (
+	errImportJobNotFound   = errors.New("import job not found")
+	errImportJobNotRunning = errors.New("import job is not queued or running")
+	errImportJobNotStopped = errors.New("import job is not cancelled or failed")
+)
+
+// createImportJob validates the directory, creates the import job and
+// enqueues its ImportJob, which is run by the job runner.
+func (api *API) createImportJob(ctx context.Context, directory string, iType importType, userID *string) (*database.ImportJob, error) {
+	// Get import directory
+	importDirectory := filepath.Clean(directory)
+
+	// Get data directory
+	absoluteDataPath, _ := filepath.Abs(filepath.Join(api.cfg.DataPath, "documents"))
+
+	// Validate different path
+	if absoluteDataPath == importDirectory {
+		return nil, errImportDataPath
+	}
+
+	// Validate directory
+	if dirInfo, err := os.Stat(importDirectory); err != nil {
+		return nil, err
+	} else if !dirInfo.IsDir() {
+		return nil, errors.Errorf("%s is not a directory", importDirectory)
+	}
+
+	tx, err := api.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transaction Begin DB Error")
+	}
+	defer tx.Rollback()
+	qtx := api.db.Queries.WithTx(tx)
+
+	importJob, err := qtx.CreateImportJob(ctx, database.CreateImportJobParams{
+		Directory:  importDirectory,
+		ImportType: string(iType),
+		CreatedBy:  userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateImportJob DB Error")
+	}
+
+	// Enqueue Job
+	jobID, err := enqueueJob(ctx, qtx, ImportJob{ID: importJob.ID}, userID)
+	if err != nil {
+		return nil, err
+	}
+	importJob.JobID = &jobID
+	if err := qtx.LinkImportJob(ctx, database.LinkImportJobParams{
+		JobID: importJob.JobID,
+		ID:    importJob.ID,
+	}); err != nil {
+		return nil, errors.Wrap(err, "LinkImportJob DB Error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "Transaction Commit DB Error")
+	}
+
+	api.signalJobQueue()
+	return &importJob, nil
+}
+
+// RunImportJob runs the import job, resuming after the already processed
+// files.
+func (api *API) RunImportJob(ctx context.Context, job ImportJob) error {
+	return api.runImportJob(ctx, job.ID)
+}
+
+// runImportJob imports all files within the job directory that don't already
+// have a result, committing each result as it's processed.
+func (api *API) runImportJob(ctx context.Context, jobID int64) error {
+	job, err := api.db.Queries.GetImportJob(ctx, database.GetImportJobParams{Timezone: "UTC", ID: jobID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errImportJobNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "GetImportJob DB Error")
+	}
+
+	// Find Files
+	var importPaths []string
+	err = filepath.WalkDir(job.Directory, func(importPath string, f fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if !f.IsDir() {
+			importPaths = append(importPaths, importPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := api.db.Queries.UpdateImportJobTotal(ctx, database.UpdateImportJobTotalParams{
+		TotalFiles: ptr.Of(int64(len(importPaths))),
+		ID:         jobID,
+	}); err != nil {
+		return errors.Wrap(err, "UpdateImportJobTotal DB Error")
+	}
+
+	// Get Processed Files (Resume)
+	processedResults, err := api.db.Queries.GetImportJobResults(ctx, jobID)
+	if err != nil {
+		return errors.Wrap(err, "GetImportJobResults DB Error")
+	}
+	processedPaths := make(map[string]bool)
+	for _, result := range processedResults {
+		processedPaths[result.Path] = true
+	}
+
+	// Import Files
+	for _, importPath := range importPaths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		relFilePath, err := filepath.Rel(job.Directory, importPath)
+		if err != nil || processedPaths[relFilePath] {
+			continue
+		}
+
+		iResult := api.importFile(ctx, job.Directory, importPath, importType(job.ImportType))
+		if err := ctx.Err(); err != nil {
+			return err
+		} else if err := api.addImportJobResult(ctx, jobID, relFilePath, iResult); err != nil {
+			return err
+		}
+
+		if iResult.Status == importSuccess {
+			api.emitDocumentEvent(ctx, ptr.Deref(job.CreatedBy), webhookDocumentAdded, iResult.ID, nil)
+		}
+	}
+
+	return nil
+}
+
+// addImportJobResult records the import result of the file
+func (api *API) addImportJobResult(ctx context.Context, jobID int64, path string, iResult ImportResult) error {
+	result := database.AddImportJobResultParams{
+		JobID:  jobID,
+		Path:   path,
+		Status: string(iResult.Status),
+	}
+	if iResult.ID != "" {
+		result.DocumentID = &iResult.ID
+	}
+	if iResult.Name != "" {
+		result.Name = &iResult.Name
+	}
+	if iResult.Error != nil {
+		result.Error = ptr.Of(iResult.Error.Error())
+	}
+
+	if err := api.db.Queries.AddImportJobResult(ctx, result); err != nil {
+		return errors.Wrap(err, "AddImportJobResult DB Error")
+	}
+	return nil
+}
+
+// cancelImportJob cancels the queued or running import job. Processed files
+// are kept, and the job may be resumed later.
+func (api *API) cancelImportJob(ctx context.Context, importJobID int64) error {
+	job, err := api.getImportJobBackgroundID(ctx, importJobID)
+	if err != nil {
+		return err
+	}
+
+	count, err := api.db.Queries.CancelJob(ctx, job)
+	if err != nil {
+		return errors.Wrap(err, "CancelJob DB Error")
+	} else if count == 0 {
+		return errImportJobNotRunning
+	}
+
+	api.signalJobQueue()
+	return nil
+}
+
+// resumeImportJob requeues the cancelled or failed import job, skipping the
+// files that were already processed.
+func (api *API) resumeImportJob(ctx context.Context, importJobID int64) error {
+	job, err := api.getImportJobBackgroundID(ctx, importJobID)
+	if err != nil {
+		return err
+	}
+
+	count, err := api.db.Queries.RequeueJob(ctx, job)
+	if err != nil {
+		return errors.Wrap(err, "RequeueJob DB Error")
+	} else if count == 0 {
+		return errImportJobNotStopped
+	}
+
+	api.signalJobQueue()
+	return nil
+}
+
+// getImportJobBackgroundID returns the ID of the background job running the
+// import job.
+func (api *API) getImportJobBackgroundID(ctx context.Context, importJobID int64) (int64, error) {
+	job, err := api.db.Queries.GetImportJob(ctx, database.GetImportJobParams{Timezone: "UTC", ID: importJobID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errImportJobNotFound
+	} else if err != nil {
+		return 0, errors.Wrap(err, "GetImportJob DB Error")
+	}
+	return ptr.Deref(job.JobID), nil
+}
+
+// getImportJobResults returns the import job results, sorted with the failures
+// first.
+func (api *API) getImportJobResults(ctx context.Context, jobID int64) ([]ImportResult, error) {
+	jobResults, err := api.db.Queries.GetImportJobResults(ctx, jobID)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetImportJobResults DB Error")
+	}
+
+	importResults := make([]ImportResult, 0, len(jobResults))
+	for _, result := range jobResults {
+		iResult := ImportResult{
+			ID:     ptr.Deref(result.DocumentID),
+			Name:   ptr.Deref(result.Name),
+			Path:   result.Path,
+			Status: ImportStatus(result.Status),
+		}
+		if result.Error != nil {
+			iResult.Error = errors.New(*result.Error)
+		}
+		importResults = append(importResults, iResult)
+	}
+
+	sort.SliceStable(importResults, func(i int, j int) bool {
+		return importStatusPriority(importResults[i].Status) <
+			importStatusPriority(importResults[j].Status)
+	})
+
+	return importResults, nil
+}
+
+// importJobActive returns whether the import job is queued or running
+func importJobActive(status string) bool {
+	return JobStatus(status) == JobQueued || JobStatus(status) == JobRunning
+}
+
+// importJobProgress returns the import job progress percentage
+func importJobProgress(job database.GetImportJobRow) int {
+	totalFiles := ptr.Deref(job.TotalFiles)
+	if totalFiles == 0 {
+		return 0
+	}
+	return int(job.ProcessedFiles * 100 / totalFiles)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestImportJobs(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	ctx := t.Context()
+	require.NoError(t, os.MkdirAll(filepath.Join(api.cfg.DataPath, "documents"), 0755))
+
+	importDirectory := t.TempDir()
+	writeTestCBZ(t, filepath.Join(importDirectory, "Imported Comic.cbz"))
+	require.NoError(t, os.WriteFile(filepath.Join(importDirectory, "invalid.epub"), bytes.Repeat([]byte("not an epub"), 512), 0644))
+
+	getImportJob := func(jobID int64) database.GetImportJobRow {
+		job, err := api.db.Queries.GetImportJob(ctx, database.GetImportJobParams{Timezone: "UTC", ID: jobID})
+		require.NoError(t, err)
+		return job
+	}
+
+	// Data Directory
+	_, err := api.createImportJob(ctx, filepath.Join(api.cfg.DataPath, "documents"), importDirect, nil)
+	assert.ErrorIs(t, err, errImportDataPath)
+
+	// Queued Background Job
+	importJob, err := api.createImportJob(ctx, importDirectory, importDirect, ptr.Of("reader"))
+	require.NoError(t, err)
+	job, err := api.db.Queries.GetJob(ctx, *importJob.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, ImportJob{}.JobType(), job.Type)
+	assert.JSONEq(t, fmt.Sprintf(`{"id":%d}`, importJob.ID), job.Payload)
+	assert.Equal(t, string(JobQueued), getImportJob(importJob.ID).Status)
+
+	// Resume - Processed Files Skipped
+	require.NoError(t, api.addImportJobResult(ctx, importJob.ID, "invalid.epub", ImportResult{Status: importFailed, Error: errors.New("previous failure")}))
+	require.NoError(t, api.RunImportJob(ctx, ImportJob{ID: importJob.ID}))
+
+	jobRow := getImportJob(importJob.ID)
+	assert.Equal(t, int64(2), ptr.Deref(jobRow.TotalFiles))
+	assert.Equal(t, int64(2), jobRow.ProcessedFiles)
+	assert.Equal(t, int64(1), jobRow.FailedFiles)
+
+	importResults, err := api.getImportJobResults(ctx, importJob.ID)
+	require.NoError(t, err)
+	require.Len(t, importResults, 2)
+	assert.Equal(t, "invalid.epub", importResults[0].Path)
+	assert.EqualError(t, importResults[0].Error, "previous failure")
+	assert.Equal(t, "Imported Comic.cbz", importResults[1].Path)
+	assert.Equal(t, importSuccess, importResults[1].Status)
+
+	document, err := api.db.Queries.GetDocument(ctx, importResults[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, importDirectory, *document.Basepath)
+
+	// Cancel & Resume
+	assert.ErrorIs(t, api.resumeImportJob(ctx, importJob.ID), errImportJobNotStopped)
+	require.NoError(t, api.cancelImportJob(ctx, importJob.ID))
+	assert.Equal(t, string(JobCancelled), getImportJob(importJob.ID).Status)
+	assert.ErrorIs(t, api.cancelImportJob(ctx, importJob.ID), errImportJobNotRunning)
+	require.NoError(t, api.resumeImportJob(ctx, importJob.ID))
+	assert.Equal(t, string(JobQueued), getImportJob(importJob.ID).Status)
+	assert.ErrorIs(t, api.cancelImportJob(ctx, 999), errImportJobNotFound)
+
+	// Foreground - Existing Documents
+	importResults, err = api.ImportDirectory(ctx, importDirectory, true)
+	require.NoError(t, err)
+	require.Len(t, importResults, 2)
+	assert.Equal(t, importFailed, importResults[0].Status)
+	assert.Equal(t, importExists, importResults[1].Status)
+	assert.Equal(t, document.ID, importResults[1].ID)
+
+	importJobs, err := api.db.Queries.GetImportJobs(ctx, database.GetImportJobsParams{Timezone: "UTC", Limit: importJobsSize})
+	require.NoError(t, err)
+	require.Len(t, importJobs, 2)
+	assert.Equal(t, string(JobSucceeded), importJobs[0].Status)
+
+	// Deleted With Background Job
+	_, err = api.db.DB.ExecContext(ctx, "DELETE FROM jobs WHERE id = ?", *importJob.JobID)
+	require.NoError(t, err)
+	_, err = api.db.Queries.GetImportJob(ctx, database.GetImportJobParams{Timezone: "UTC", ID: importJob.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	importResults, err = api.getImportJobResults(ctx, importJob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, importResults)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+)
+
+const jobsSize = 50
+
+// JobStatus is the status of a persisted background job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Job is the typed payload of a persisted background job. Jobs are run by the
+// server job runner, which registers a handler per job type.
+type Job interface {
+	JobType() string
+}
+
+// CacheTablesJob refreshes the statistic caches
+type CacheTablesJob struct{}
+
+func (CacheTablesJob) JobType() string { return "cache_tables" }
+
+// CleanupJob deletes the expired sessions, import log entries and jobs
+type CleanupJob struct{}
+
+func (CleanupJob) JobType() string { return "cleanup" }
+
+// ImportJob imports the files of an import job directory
+type ImportJob struct {
+	ID int64 `json:"id"`
+}
+
+func (ImportJob) JobType() string { return "import" }
+
+// DeliverWebhooksJob attempts the due webhook deliveries
+type DeliverWebhooksJob struct{}
+
+func (DeliverWebhooksJob) JobType() string { return "deliver_webhooks" }
+
+// ScanWatchDirectoriesJob imports the new or changed watch directory files
+type ScanWatchDirectoriesJob struct{}
+
+func (ScanWatchDirectoriesJob) JobType() string { return "scan_watch_directories" }
+
+// RotateCookieKeysJob rotates the generated cookie keys
+type RotateCookieKeysJob struct{}
+
+func (RotateCookieKeysJob) JobType() string { return "rotate_cookie_keys" }
+
+// EnqueueJob persists the job and signals the job runner
+func (api *API) EnqueueJob(ctx context.Context, job Job, userID *string) (int64, error) {
+	jobID, err := enqueueJob(ctx, api.db.Queries, job, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	api.signalJobQueue()
+	return jobID, nil
+}
+
+// enqueueJob persists the job with the provided queries, allowing jobs to be
+// enqueued within a transaction. The job runner must be signalled afterwards.
+func enqueueJob(ctx context.Context, q *database.Queries, job Job, userID *string) (int64, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return 0, err
+	}
+
+	dbJob, err := q.CreateJob(ctx, database.CreateJobParams{
+		Type:      job.JobType(),
+		Payload:   string(payload),
+		CreatedBy: userID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("CreateJob DB Error: %w", err)
+	}
+
+	return dbJob.ID, nil
+}
+
+// enqueueUniqueJob persists the job unless a job of the type is already
+// queued, and signals the job runner.
+func (api *API) enqueueUniqueJob(ctx context.Context, job Job) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	if _, err := api.db.Queries.CreateUniqueJob(ctx, database.CreateUniqueJobParams{
+		Type:    job.JobType(),
+		Payload: string(payload),
+	}); err != nil {
+		return fmt.Errorf("CreateUniqueJob DB Error: %w", err)
+	}
+
+	api.signalJobQueue()
+	return nil
+}
+
+// signalJobQueue wakes the job runner without blocking
+func (api *API) signalJobQueue() {
+	select {
+	case api.jobQueue <- struct{}{}:
+	default:
+	}
+}
+
+// JobQueue signals that new jobs are queued.
+func (api *API) JobQueue() <-chan struct{} {
+	return api.jobQueue
+}
+
+// DeleteExpiredData deletes the expired sessions, import log entries and
+// finished jobs.
+func (api *API) DeleteExpiredData(ctx context.Context) error {
+	sessionCount, err := api.db.Queries.DeleteExpiredSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteExpiredSessions DB Error: %w", err)
+	}
+
+	importLogCount, err := api.db.Queries.DeleteExpiredImportLog(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteExpiredImportLog DB Error: %w", err)
+	}
+
+	jobCount, err := api.db.Queries.DeleteExpiredJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteExpiredJobs DB Error: %w", err)
+	}
+
+	log.Debugf("deleted %d sessions, %d import log entries and %d jobs", sessionCount, importLogCount, jobCount)
+	return nil
+}
+
+// setAdminJobsTemplateVars sets the recent jobs, shown in the viewers timezone
+func (api *API) setAdminJobsTemplateVars(ctx context.Context, viewerID string, templateVars gin.H) error {
+	viewer, err := api.db.Queries.GetUser(ctx, viewerID)
+	if err != nil {
+		return fmt.Errorf("GetUser DB Error: %w", err)
+	}
+
+	jobs, err := api.db.Queries.GetJobs(ctx, database.GetJobsParams{
+		Timezone: *viewer.Timezone,
+		Limit:    jobsSize,
+	})
+	if err != nil {
+		return fmt.Errorf("GetJobs DB Error: %w", err)
+	}
+
+	templateVars["Data"] = jobs
+	return nil
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestEnqueueJob(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	ctx := t.Context()
+
+	jobID, err := api.EnqueueJob(ctx, CacheTablesJob{}, ptr.Of("reader"))
+	require.NoError(t, err)
+
+	// Job Runner Signalled
+	select {
+	case <-api.JobQueue():
+	default:
+		assert.Fail(t, "job queue not signalled")
+	}
+
+	job, err := api.db.Queries.GetJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, "cache_tables", job.Type)
+	assert.Equal(t, "queued", job.Status)
+	assert.Equal(t, "{}", job.Payload)
+	assert.Equal(t, "reader", *job.CreatedBy)
+
+	// Admin Jobs
+	templateVars := gin.H{}
+	require.NoError(t, api.setAdminJobsTemplateVars(ctx, "reader", templateVars))
+	jobs := templateVars["Data"].([]database.GetJobsRow)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobID, jobs[0].ID)
+
+	// Queued Jobs Retained
+	require.NoError(t, api.DeleteExpiredData(ctx))
+	_, err = api.db.Queries.GetJob(ctx, jobID)
+	assert.NoError(t, err)
+}
@@ -4,12 +4,14 @@ import (
 	"crypto/md5"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -42,22 +44,27 @@ type requestDocument struct {
 }
 
 type requestPosition struct {
-	DocumentID string  `json:"document"`
-	Percentage float64 `json:"percentage"`
-	Progress   string  `json:"progress"`
-	Device     string  `json:"device"`
-	DeviceID   string  `json:"device_id"`
+	DocumentID string   `json:"document"`
+	Percentage *float64 `json:"percentage"`
+	Progress   string   `json:"progress"`
+	Device     string   `json:"device"`
+	DeviceID   string   `json:"device_id"`
+	Timestamp  *int64   `json:"timestamp"`
 }
 
 type requestUser struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Invite   string `json:"invite"`
 }
 
 type requestCheckDocumentSync struct {
 	DeviceID string   `json:"device_id"`
 	Device   string   `json:"device"`
 	Have     []string `json:"have"`
+
+	// Delta Sync - Have Only Contains Documents New To The Device
+	Cursor *int64 `json:"cursor"`
 }
 
 type responseCheckDocumentSync struct {
@@ -65,8 +72,23 @@ type responseCheckDocumentSync struct {
 	WantMetadata []string            `json:"want_metadata"`
 	Give         []database.Document `json:"give"`
 	Delete       []string            `json:"deleted"`
+	Cursor       int64               `json:"cursor"`
+	More         bool                `json:"more"`
+	Reset        bool                `json:"reset"`
 }
 
+// koreader-sync-server error codes, extended with our own (2006+)
+const (
+	koErrorUnauthorized         = 2001
+	koErrorUserExists           = 2002
+	koErrorInvalidRequest       = 2003
+	koErrorDocumentMissing      = 2004
+	koErrorRegistrationDisabled = 2005
+	koErrorProgressConflict     = 2006
+	koErrorInvalidInvite        = 2007
+	koErrorDeviceDisabled       = 2008
+)
+
 type requestDocumentID struct {
 	DocumentID string `uri:"document" binding:"required"`
 }
@@ -77,6 +99,12 @@ func (api *API) koAuthorizeUser(c *gin.Context) {
 	})
 }
 
+func (api *API) koHealthCheck(c *gin.Context) {
+	koJSON(c, http.StatusOK, gin.H{
+		"state": "OK",
+	})
+}
+
 func (api *API) koSetProgress(c *gin.Context) {
 	var auth authData
 	if data, _ := c.Get("Authorization"); data != nil {
@@ -86,18 +114,42 @@ func (api *API) koSetProgress(c *gin.Context) {
 	var rPosition requestPosition
 	if err := c.ShouldBindJSON(&rPosition); err != nil {
 		log.Error("Invalid JSON Bind")
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Progress Data")
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
+		return
+	}
+
+	// Validate Position
+	if rPosition.DocumentID == "" {
+		koErrorPage(c, http.StatusForbidden, koErrorDocumentMissing, "Field 'document' not provided.")
+		return
+	} else if rPosition.Percentage == nil || rPosition.Progress == "" || rPosition.Device == "" {
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
 		return
 	}
 
+	// Devices without a timestamp (e.g. upstream KOReader) - or from the
+	// future - are recorded at the servers time.
+	now := time.Now().UTC()
+	timestamp := now
+	if rPosition.Timestamp != nil && *rPosition.Timestamp > 0 {
+		if deviceTime := time.Unix(*rPosition.Timestamp, 0).UTC(); deviceTime.Before(now) {
+			timestamp = deviceTime
+		}
+	}
+
 	// Upsert Device
-	if _, err := api.db.Queries.UpsertDevice(c, database.UpsertDeviceParams{
+	if device, err := api.db.Queries.UpsertDevice(c, database.UpsertDeviceParams{
 		ID:         rPosition.DeviceID,
 		UserID:     auth.UserName,
 		DeviceName: rPosition.Device,
-		LastSynced: time.Now().UTC().Format(time.RFC3339),
+		LastSynced: now.Format(time.RFC3339),
 	}); err != nil {
 		log.Error("UpsertDevice DB Error:", err)
+		koErrorPage(c, http.StatusInternalServerError, koErrorInvalidRequest, "Invalid device")
+		return
+	} else if !device.Sync {
+		koErrorPage(c, http.StatusForbidden, koErrorDeviceDisabled, "Device sync disabled.")
+		return
 	}
 
 	// Upsert Document
@@ -107,23 +159,47 @@ func (api *API) koSetProgress(c *gin.Context) {
 		log.Error("UpsertDocument DB Error:", err)
 	}
 
+	// Translate CFI - KOReader Only Understands XPointers
+	if strings.HasPrefix(rPosition.Progress, "epubcfi(") {
+		if access, err := api.getDocumentAccess(c, auth, rPosition.DocumentID); err != nil || !access.CanRead {
+			log.Warn("Unable to translate CFI of inaccessible document: ", rPosition.DocumentID)
+		} else if document, err := api.db.Queries.GetDocument(c, rPosition.DocumentID); err == nil && document.Filepath != nil {
+			filePath := api.documentFilePath(document.Basepath, *document.Filepath)
+			if xpointer, err := metadata.GetXPointerFromCFI(filePath, rPosition.Progress); err != nil {
+				log.Warn("Unable to translate CFI to XPointer: ", err)
+			} else {
+				rPosition.Progress = xpointer
+			}
+		}
+	}
+
 	// Create or Replace Progress
-	progress, err := api.db.Queries.UpdateProgress(c, database.UpdateProgressParams{
-		Percentage: rPosition.Percentage,
-		DocumentID: rPosition.DocumentID,
-		DeviceID:   rPosition.DeviceID,
-		UserID:     auth.UserName,
-		Progress:   rPosition.Progress,
-	})
-	if err != nil {
-		log.Error("UpdateProgress DB Error:", err)
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+	progress, err := api.updateProgress(c, auth.UserName, rPosition.DeviceID, rPosition.DocumentID, *rPosition.Percentage, rPosition.Progress, timestamp)
+	var conflictErr *progressConflictError
+	if errors.As(err, &conflictErr) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"code":    koErrorProgressConflict,
+			"message": "Progress is older than the current progress.",
+			"current": koProgress(conflictErr.Current),
+		})
+		return
+	} else if err != nil {
+		log.Error("Update Progress Error:", err)
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
 		return
 	}
 
+	api.emitWebhookEvent(c, auth.UserName, webhookDeviceSynced, gin.H{
+		"device_id":   rPosition.DeviceID,
+		"device_name": rPosition.Device,
+		"sync_type":   "progress",
+		"document_id": progress.DocumentID,
+	})
+
+	progressTime, _ := time.Parse(time.RFC3339, progress.CreatedAt)
 	koJSON(c, http.StatusOK, gin.H{
 		"document":  progress.DocumentID,
-		"timestamp": progress.CreatedAt,
+		"timestamp": progressTime.Unix(),
 	})
 }
 
@@ -136,7 +212,7 @@ func (api *API) koGetProgress(c *gin.Context) {
 	var rDocID requestDocumentID
 	if err := c.ShouldBindUri(&rDocID); err != nil {
 		log.Error("Invalid URI Bind")
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		koErrorPage(c, http.StatusForbidden, koErrorDocumentMissing, "Field 'document' not provided.")
 		return
 	}
 
@@ -151,16 +227,56 @@ func (api *API) koGetProgress(c *gin.Context) {
 		return
 	} else if err != nil {
 		log.Error("GetDocumentProgress DB Error:", err)
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Document")
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
+		return
+	}
+
+	koJSON(c, http.StatusOK, koProgress(progress))
+}
+
+func (api *API) koGetDevicesProgress(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		log.Error("Invalid URI Bind")
+		koErrorPage(c, http.StatusForbidden, koErrorDocumentMissing, "Field 'document' not provided.")
+		return
+	}
+
+	current, err := api.db.Queries.GetDocumentProgress(c, database.GetDocumentProgressParams{
+		DocumentID: rDocID.DocumentID,
+		UserID:     auth.UserName,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Error("GetDocumentProgress DB Error:", err)
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
+		return
+	}
+
+	devicesProgress, err := api.db.Queries.GetDocumentDevicesProgress(c, database.GetDocumentDevicesProgressParams{
+		DocumentID: rDocID.DocumentID,
+		UserID:     auth.UserName,
+	})
+	if err != nil {
+		log.Error("GetDocumentDevicesProgress DB Error:", err)
+		koErrorPage(c, http.StatusForbidden, koErrorInvalidRequest, "Invalid request")
 		return
 	}
 
+	devices := make([]gin.H, 0, len(devicesProgress))
+	for _, item := range devicesProgress {
+		deviceProgress := koProgress(database.GetDocumentProgressRow(item))
+		deviceProgress["current"] = item.DeviceID == current.DeviceID
+		devices = append(devices, deviceProgress)
+	}
+
 	koJSON(c, http.StatusOK, gin.H{
-		"document":   progress.DocumentID,
-		"percentage": progress.Percentage,
-		"progress":   progress.Progress,
-		"device":     progress.DeviceName,
-		"device_id":  progress.DeviceID,
+		"document": rDocID.DocumentID,
+		"devices":  devices,
 	})
 }
 
@@ -177,6 +293,17 @@ func (api *API) koAddActivities(c *gin.Context) {
 		return
 	}
 
+	// Validate Activity
+	now := time.Now()
+	var validActivity []activityItem
+	for _, item := range rActivity.Activity {
+		if err := item.validate(now); err != nil {
+			log.Warnf("Rejecting activity of %s for %s: %v", rActivity.DeviceID, item.DocumentID, err)
+			continue
+		}
+		validActivity = append(validActivity, item)
+	}
+
 	// Do Transaction
 	tx, err := api.db.DB.Begin()
 	if err != nil {
@@ -187,7 +314,7 @@ func (api *API) koAddActivities(c *gin.Context) {
 
 	// Derive Unique Documents
 	allDocumentsMap := make(map[string]bool)
-	for _, item := range rActivity.Activity {
+	for _, item := range validActivity {
 		allDocumentsMap[item.DocumentID] = true
 	}
 	allDocuments := getKeys(allDocumentsMap)
@@ -212,31 +339,41 @@ func (api *API) koAddActivities(c *gin.Context) {
 	}
 
 	// Upsert Device
-	if _, err = qtx.UpsertDevice(c, database.UpsertDeviceParams{
+	device, err := qtx.UpsertDevice(c, database.UpsertDeviceParams{
 		ID:         rActivity.DeviceID,
 		UserID:     auth.UserName,
 		DeviceName: rActivity.Device,
 		LastSynced: time.Now().UTC().Format(time.RFC3339),
-	}); err != nil {
+	})
+	if err != nil {
 		log.Error("UpsertDevice DB Error:", err)
 		apiErrorPage(c, http.StatusBadRequest, "Invalid Device")
 		return
+	} else if !device.Sync {
+		koErrorPage(c, http.StatusForbidden, koErrorDeviceDisabled, "Device sync disabled.")
+		return
 	}
 
-	// Add All Activity
-	for _, item := range rActivity.Activity {
+	// Add All Activity - Replays Are Skipped
+	var added, duplicates int
+	for _, item := range validActivity {
+		startPercentage, endPercentage := item.percentages()
 		if _, err := qtx.AddActivity(c, database.AddActivityParams{
 			UserID:          auth.UserName,
 			DocumentID:      item.DocumentID,
 			DeviceID:        rActivity.DeviceID,
-			StartTime:       time.Unix(int64(item.StartTime), 0).UTC().Format(time.RFC3339),
-			Duration:        int64(item.Duration),
-			StartPercentage: float64(item.Page) / float64(item.Pages),
-			EndPercentage:   float64(item.Page+1) / float64(item.Pages),
-		}); err != nil {
+			StartTime:       time.Unix(item.StartTime, 0).UTC().Format(time.RFC3339),
+			Duration:        item.Duration,
+			StartPercentage: startPercentage,
+			EndPercentage:   endPercentage,
+		}); err == sql.ErrNoRows {
+			duplicates++
+		} else if err != nil {
 			log.Error("AddActivity DB Error:", err)
 			apiErrorPage(c, http.StatusBadRequest, "Invalid Activity")
 			return
+		} else {
+			added++
 		}
 	}
 
@@ -247,8 +384,17 @@ func (api *API) koAddActivities(c *gin.Context) {
 		return
 	}
 
+	api.emitWebhookEvent(c, auth.UserName, webhookDeviceSynced, gin.H{
+		"device_id":   rActivity.DeviceID,
+		"device_name": rActivity.Device,
+		"sync_type":   "activity",
+		"added":       added,
+	})
+
 	koJSON(c, http.StatusOK, gin.H{
-		"added": len(rActivity.Activity),
+		"added":      added,
+		"duplicates": duplicates,
+		"rejected":   len(rActivity.Activity) - len(validActivity),
 	})
 }
 
@@ -304,6 +450,11 @@ func (api *API) koCheckActivitySync(c *gin.Context) {
 }
 
 func (api *API) koAddDocuments(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
 	var rNewDocs requestDocument
 	if err := c.ShouldBindJSON(&rNewDocs); err != nil {
 		log.Error("Invalid JSON Bind")
@@ -328,8 +479,25 @@ func (api *API) koAddDocuments(c *gin.Context) {
 	qtx := api.db.Queries.WithTx(tx)
 
 	// Upsert Documents
+	var addedDocuments []string
+	var changedCount int
 	for _, doc := range rNewDocs.Documents {
-		_, err := qtx.UpsertDocument(c, database.UpsertDocumentParams{
+		// Existing Documents - Only Owners & Admins Update Metadata
+		ownerID := &auth.UserName
+		existingDoc, err := qtx.GetDocument(c, doc.ID)
+		if err == sql.ErrNoRows {
+			addedDocuments = append(addedDocuments, doc.ID)
+		} else if err != nil {
+			log.Error("GetDocument DB Error:", err)
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Document")
+			return
+		} else if !auth.IsAdmin && (existingDoc.OwnerID == nil || *existingDoc.OwnerID != auth.UserName) {
+			continue
+		} else {
+			ownerID = nil
+		}
+
+		_, err = qtx.UpsertDocument(c, database.UpsertDocumentParams{
 			ID:          doc.ID,
 			Title:       api.sanitizeInput(doc.Title),
 			Author:      api.sanitizeInput(doc.Author),
@@ -337,12 +505,14 @@ func (api *API) koAddDocuments(c *gin.Context) {
 			SeriesIndex: doc.SeriesIndex,
 			Lang:        api.sanitizeInput(doc.Lang),
 			Description: api.sanitizeInput(doc.Description),
+			OwnerID:     ownerID,
 		})
 		if err != nil {
 			log.Error("UpsertDocument DB Error:", err)
 			apiErrorPage(c, http.StatusBadRequest, "Invalid Document")
 			return
 		}
+		changedCount++
 	}
 
 	// Commit Transaction
@@ -352,8 +522,13 @@ func (api *API) koAddDocuments(c *gin.Context) {
 		return
 	}
 
+	// Notify Webhooks
+	for _, documentID := range addedDocuments {
+		api.emitDocumentEvent(c, auth.UserName, webhookDocumentAdded, documentID, nil)
+	}
+
 	koJSON(c, http.StatusOK, gin.H{
-		"changed": len(rNewDocs.Documents),
+		"changed": changedCount,
 	})
 }
 
@@ -383,20 +558,65 @@ func (api *API) koCheckDocumentsSync(c *gin.Context) {
 		return
 	}
 
-	// Get Missing Documents
-	missingDocs, err := api.db.Queries.GetMissingDocuments(c, rCheckDocs.Have)
-	if err != nil {
-		log.Error("GetMissingDocuments DB Error", err)
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
-		return
+	rCheckDocSync := responseCheckDocumentSync{
+		Delete:       []string{},
+		WantFiles:    []string{},
+		WantMetadata: []string{},
+		Give:         []database.Document{},
 	}
 
-	// Get Deleted Documents
-	deletedDocIDs, err := api.db.Queries.GetDeletedDocuments(c, rCheckDocs.Have)
-	if err != nil {
-		log.Error("GetDeletedDocuments DB Error", err)
-		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
-		return
+	if rCheckDocs.Cursor != nil {
+		// Get Changes Since Cursor
+		changes, err := api.getDocumentChanges(c, auth.UserName, *rCheckDocs.Cursor)
+		if err != nil {
+			log.Error(err)
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+			return
+		}
+
+		rCheckDocSync.Give = changes.Give
+		rCheckDocSync.Delete = changes.Delete
+		rCheckDocSync.Cursor = changes.Cursor
+		rCheckDocSync.More = changes.More
+		rCheckDocSync.Reset = changes.Expired
+	} else {
+		// Get Cursor - Prior To Diffing, So Concurrent Changes Are Included Next Sync
+		rCheckDocSync.Cursor, err = api.db.Queries.GetDocumentChangesCursor(c)
+		if err != nil {
+			log.Error("GetDocumentChangesCursor DB Error", err)
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+			return
+		}
+
+		// Get Missing Documents
+		missingDocs, err := api.db.Queries.GetMissingDocuments(c, database.GetMissingDocumentsParams{
+			UserID:      auth.UserName,
+			DocumentIds: rCheckDocs.Have,
+		})
+		if err != nil {
+			log.Error("GetMissingDocuments DB Error", err)
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+			return
+		}
+
+		// Get Deleted Documents - Deleted Or Hidden, But Previously Visible
+		deletedDocIDs, err := api.db.Queries.GetDeletedDocuments(c, database.GetDeletedDocumentsParams{
+			UserID:      auth.UserName,
+			DocumentIds: rCheckDocs.Have,
+		})
+		if err != nil {
+			log.Error("GetDeletedDocuments DB Error", err)
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+			return
+		}
+
+		// Ensure Empty Array
+		if missingDocs != nil {
+			rCheckDocSync.Give = missingDocs
+		}
+		if deletedDocIDs != nil {
+			rCheckDocSync.Delete = deletedDocIDs
+		}
 	}
 
 	// Get Wanted Documents
@@ -407,7 +627,10 @@ func (api *API) koCheckDocumentsSync(c *gin.Context) {
 		return
 	}
 
-	wantedDocs, err := api.db.Queries.GetWantedDocuments(c, string(jsonHaves))
+	wantedDocs, err := api.db.Queries.GetWantedDocuments(c, database.GetWantedDocumentsParams{
+		DocumentIds: string(jsonHaves),
+		UserID:      auth.UserName,
+	})
 	if err != nil {
 		log.Error("GetWantedDocuments DB Error", err)
 		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
@@ -426,13 +649,6 @@ func (api *API) koCheckDocumentsSync(c *gin.Context) {
 		}
 	}
 
-	rCheckDocSync := responseCheckDocumentSync{
-		Delete:       []string{},
-		WantFiles:    []string{},
-		WantMetadata: []string{},
-		Give:         []database.Document{},
-	}
-
 	// Ensure Empty Array
 	if wantedMetadataDocIDs != nil {
 		rCheckDocSync.WantMetadata = wantedMetadataDocIDs
@@ -440,12 +656,6 @@ func (api *API) koCheckDocumentsSync(c *gin.Context) {
 	if wantedFilesDocIDs != nil {
 		rCheckDocSync.WantFiles = wantedFilesDocIDs
 	}
-	if missingDocs != nil {
-		rCheckDocSync.Give = missingDocs
-	}
-	if deletedDocIDs != nil {
-		rCheckDocSync.Delete = deletedDocIDs
-	}
 
 	koJSON(c, http.StatusOK, rCheckDocSync)
 }
@@ -466,6 +676,11 @@ func (api *API) koUploadExistingDocument(c *gin.Context) {
 		return
 	}
 
+	// Validate Access
+	if !api.documentReadable(c, rDoc.DocumentID, apiErrorPage) {
+		return
+	}
+
 	// Validate Document Exists in DB
 	document, err := api.db.Queries.GetDocument(c, rDoc.DocumentID)
 	if err != nil {
@@ -526,6 +741,7 @@ func (api *API) koUploadExistingDocument(c *gin.Context) {
 		ID:       document.ID,
 		Md5:      metadataInfo.MD5,
 		Words:    metadataInfo.WordCount,
+		Pages:    metadataInfo.PageCount,
 		Filepath: &fileName,
 		Basepath: &basePath,
 	}); err != nil {
@@ -547,6 +763,11 @@ func apiErrorPage(c *gin.Context, errorCode int, errorMessage string) {
 	c.AbortWithStatusJSON(errorCode, gin.H{"error": errorMessage})
 }
 
+// koErrorPage aborts with a koreader-sync-server compatible error body.
+func koErrorPage(c *gin.Context, statusCode int, errorCode int, errorMessage string) {
+	c.AbortWithStatusJSON(statusCode, gin.H{"code": errorCode, "message": errorMessage})
+}
+
 func (api *API) sanitizeInput(val any) *string {
 	switch v := val.(type) {
 	case *string:
@@ -590,6 +811,19 @@ func getFileMD5(filePath string) (*string, error) {
 	return &fileHash, nil
 }
 
+// koProgress converts progress to a KOSync progress response.
+func koProgress(progress database.GetDocumentProgressRow) gin.H {
+	progressTime, _ := time.Parse(time.RFC3339, progress.CreatedAt)
+	return gin.H{
+		"document":   progress.DocumentID,
+		"percentage": progress.Percentage,
+		"progress":   progress.Progress,
+		"device":     progress.DeviceName,
+		"device_id":  progress.DeviceID,
+		"timestamp":  progressTime.Unix(),
+	}
+}
+
 // koJSON forces koJSON Content-Type to only return `application/json`. This is addressing
 // the following issue: https://github.com/koreader/koreader/issues/13629
 func koJSON(c *gin.Context, code int, obj any) {
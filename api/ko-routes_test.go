@@ -0,0 +1,431 @@
+package api
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+// koRequest performs a KOSync request authenticated as "reader", returning the
+// status code and the decoded JSON body.
+func koRequest(t *testing.T, server *httptest.Server, method, path string, body any) (int, map[string]any) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, server.URL+path, &reqBody)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/vnd.koreader.v1+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Auth-User", "reader")
+	req.Header.Set("X-Auth-Key", fmt.Sprintf("%x", md5.Sum([]byte("pass"))))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var respBody map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&respBody))
+	return resp.StatusCode, respBody
+}
+
+func koPosition(device string, percentage float64, timestamp int64) gin.H {
+	position := gin.H{
+		"document":   "document-00",
+		"percentage": percentage,
+		"progress":   fmt.Sprintf("/body/DocFragment[%d]", int(percentage*100)),
+		"device":     device,
+		"device_id":  device + "-id",
+	}
+	if timestamp != 0 {
+		position["timestamp"] = timestamp
+	}
+	return position
+}
+
+// TestKOSyncConformance follows the koreader-sync-server API (spec/api.yaml)
+func TestKOSyncConformance(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	api.cfg.RegistrationEnabled = true
+
+	t.Run("healthcheck", func(t *testing.T) {
+		code, body := koRequest(t, server, http.MethodGet, "/api/ko/healthcheck", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "OK", body["state"])
+	})
+
+	t.Run("create user", func(t *testing.T) {
+		code, body := koRequest(t, server, http.MethodPost, "/api/ko/users/create", gin.H{
+			"username": "new-reader",
+			"password": fmt.Sprintf("%x", md5.Sum([]byte("pass"))),
+		})
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "new-reader", body["username"])
+
+		code, body = koRequest(t, server, http.MethodPost, "/api/ko/users/create", gin.H{
+			"username": "new-reader",
+			"password": "pass",
+		})
+		assert.Equal(t, http.StatusPaymentRequired, code)
+		assert.EqualValues(t, koErrorUserExists, body["code"])
+
+		code, body = koRequest(t, server, http.MethodPost, "/api/ko/users/create", gin.H{"username": "other"})
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.EqualValues(t, koErrorInvalidRequest, body["code"])
+
+		api.cfg.RegistrationEnabled = false
+		defer func() { api.cfg.RegistrationEnabled = true }()
+		code, body = koRequest(t, server, http.MethodPost, "/api/ko/users/create", gin.H{
+			"username": "other",
+			"password": "pass",
+		})
+		assert.Equal(t, http.StatusPaymentRequired, code)
+		assert.EqualValues(t, koErrorRegistrationDisabled, body["code"])
+	})
+
+	t.Run("authorize user", func(t *testing.T) {
+		code, body := koRequest(t, server, http.MethodGet, "/api/ko/users/auth", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "OK", body["authorized"])
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/ko/users/auth", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Auth-User", "reader")
+		req.Header.Set("X-Auth-Key", "invalid")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var errBody map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errBody))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.EqualValues(t, koErrorUnauthorized, errBody["code"])
+	})
+
+	t.Run("update progress", func(t *testing.T) {
+		code, body := koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, body)
+
+		code, body = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.25, 0))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "document-00", body["document"])
+		assert.InDelta(t, time.Now().Unix(), body["timestamp"], 5)
+
+		code, body = koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "document-00", body["document"])
+		assert.Equal(t, 0.25, body["percentage"])
+		assert.Equal(t, "/body/DocFragment[25]", body["progress"])
+		assert.Equal(t, "kindle", body["device"])
+		assert.Equal(t, "kindle-id", body["device_id"])
+		assert.Contains(t, body, "timestamp")
+	})
+
+	t.Run("invalid progress", func(t *testing.T) {
+		position := koPosition("kindle", 0.25, 0)
+		delete(position, "document")
+		code, body := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.EqualValues(t, koErrorDocumentMissing, body["code"])
+
+		position = koPosition("kindle", 0.25, 0)
+		delete(position, "percentage")
+		code, body = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.EqualValues(t, koErrorInvalidRequest, body["code"])
+	})
+}
+
+func TestKOSyncProgressConflicts(t *testing.T) {
+	now := time.Now().Unix()
+
+	t.Run("newest wins", func(t *testing.T) {
+		_, server := newTestAPI(t, 1)
+
+		code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.50, now-60))
+		require.Equal(t, http.StatusOK, code)
+
+		// Stale Device - Rejected
+		code, body := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kobo", 0.75, now-120))
+		assert.Equal(t, http.StatusConflict, code)
+		assert.EqualValues(t, koErrorProgressConflict, body["code"])
+		assert.Equal(t, "kindle", body["current"].(map[string]any)["device"])
+
+		// Newer Device - Wins, Even Going Backwards
+		code, body = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kobo", 0.10, now))
+		assert.Equal(t, http.StatusOK, code)
+		assert.EqualValues(t, now, body["timestamp"])
+
+		_, body = koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+		assert.Equal(t, "kobo", body["device"])
+		assert.Equal(t, 0.10, body["percentage"])
+	})
+
+	t.Run("furthest wins", func(t *testing.T) {
+		api, server := newTestAPI(t, 1)
+		_, err := api.db.Queries.UpdateUser(t.Context(), database.UpdateUserParams{
+			UserID:           "reader",
+			ProgressStrategy: ptr.Of(string(progressFurthest)),
+		})
+		require.NoError(t, err)
+
+		code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.50, now-120))
+		require.Equal(t, http.StatusOK, code)
+
+		// Behind - Rejected
+		code, body := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kobo", 0.25, now))
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, 0.50, body["current"].(map[string]any)["percentage"])
+
+		// Further - Wins, Even When Older
+		code, _ = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kobo", 0.75, now-180))
+		assert.Equal(t, http.StatusOK, code)
+
+		_, body = koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+		assert.Equal(t, "kobo", body["device"])
+		assert.Equal(t, 0.75, body["percentage"])
+	})
+
+	t.Run("concurrent furthest", func(t *testing.T) {
+		api, server := newTestAPI(t, 1)
+		_, err := api.db.Queries.UpdateUser(t.Context(), database.UpdateUserParams{
+			UserID:           "reader",
+			ProgressStrategy: ptr.Of(string(progressFurthest)),
+		})
+		require.NoError(t, err)
+
+		// Check & Update Are Atomic - Highest Always Wins
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition(fmt.Sprintf("device-%d", i), float64(i+1)/10, now))
+			}()
+		}
+		wg.Wait()
+
+		_, body := koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+		assert.Equal(t, 1.0, body["percentage"])
+	})
+
+	t.Run("future timestamp", func(t *testing.T) {
+		_, server := newTestAPI(t, 1)
+
+		code, body := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.50, now+3600))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Less(t, body["timestamp"], float64(now+60))
+	})
+}
+
+func TestKOSyncDevicesProgress(t *testing.T) {
+	_, server := newTestAPI(t, 1)
+	now := time.Now().Unix()
+
+	for i, device := range []string{"kindle", "kobo"} {
+		code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition(device, 0.25*float64(i+1), now-int64(60*(2-i))))
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	code, body := koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00/devices", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "document-00", body["document"])
+
+	devices := body["devices"].([]any)
+	require.Len(t, devices, 2)
+	current := map[string]bool{}
+	for _, device := range devices {
+		device := device.(map[string]any)
+		current[device["device"].(string)] = device["current"].(bool)
+	}
+	assert.Equal(t, map[string]bool{"kindle": false, "kobo": true}, current)
+}
+
+func TestKOSyncDocumentChanges(t *testing.T) {
+	api, server := newTestAPI(t, 3)
+	ctx := t.Context()
+
+	documentIDs := func(body map[string]any, key string) []string {
+		var ids []string
+		for _, item := range body[key].([]any) {
+			if doc, ok := item.(map[string]any); ok {
+				ids = append(ids, doc["id"].(string))
+			} else {
+				ids = append(ids, item.(string))
+			}
+		}
+		return ids
+	}
+	syncDocuments := func(cursor any, have []string) map[string]any {
+		request := gin.H{"device": "kindle", "device_id": "kindle-id", "have": have}
+		if cursor != nil {
+			request["cursor"] = cursor
+		}
+		code, body := koRequest(t, server, http.MethodPost, "/api/ko/syncs/documents", request)
+		require.Equal(t, http.StatusOK, code)
+		return body
+	}
+
+	// Full Sync - Provides Cursor
+	body := syncDocuments(nil, []string{"document-00"})
+	assert.ElementsMatch(t, []string{"document-01", "document-02"}, documentIDs(body, "give"))
+	cursor := body["cursor"]
+	assert.NotZero(t, cursor)
+
+	// No Changes
+	body = syncDocuments(cursor, []string{})
+	assert.Empty(t, body["give"])
+	assert.Empty(t, body["deleted"])
+	assert.Equal(t, cursor, body["cursor"])
+
+	// Unchanged Upserts (e.g. Activity Sync) Aren't Changes
+	_, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{ID: "document-00"})
+	require.NoError(t, err)
+	body = syncDocuments(cursor, []string{})
+	assert.Empty(t, body["give"])
+
+	// Update, Delete & Hide
+	_, err = api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{ID: "document-00", Title: ptr.Of("Updated")})
+	require.NoError(t, err)
+	_, err = api.db.Queries.DeleteDocument(ctx, "document-01")
+	require.NoError(t, err)
+	_, err = api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+		DocumentID: "document-02",
+		Visibility: "private",
+	})
+	require.NoError(t, err)
+
+	body = syncDocuments(cursor, []string{"new-document"})
+	assert.Equal(t, []string{"document-00"}, documentIDs(body, "give"))
+	assert.ElementsMatch(t, []string{"document-01", "document-02"}, documentIDs(body, "deleted"))
+	assert.Equal(t, []string{"new-document"}, documentIDs(body, "want_metadata"))
+	assert.Greater(t, body["cursor"], cursor)
+	assert.Equal(t, false, body["reset"])
+
+	// Unknown Cursor - Restarts
+	body = syncDocuments(cursor.(float64)+1000, []string{})
+	assert.Equal(t, true, body["reset"])
+	assert.Equal(t, []string{"document-00"}, documentIDs(body, "give"))
+}
+
+func TestKOSyncDocumentChangesAccess(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+	ctx := t.Context()
+	require.NoError(t, api.createUser(ctx, "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+
+	_, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:       "other-document",
+		Title:    ptr.Of("Other Title"),
+		Filepath: ptr.Of("other-document.epub"),
+		OwnerID:  ptr.Of("other"),
+	})
+	require.NoError(t, err)
+	_, err = api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+		DocumentID: "other-document",
+		Visibility: "private",
+	})
+	require.NoError(t, err)
+
+	syncDocuments := func(cursor any, have ...string) map[string]any {
+		request := gin.H{"device": "kindle", "device_id": "kindle-id", "have": append([]string{}, have...)}
+		if cursor != nil {
+			request["cursor"] = cursor
+		}
+		code, body := koRequest(t, server, http.MethodPost, "/api/ko/syncs/documents", request)
+		require.Equal(t, http.StatusOK, code)
+		return body
+	}
+	cursor := syncDocuments(nil)["cursor"]
+
+	// Role Change - Doesn't Affect Document Access
+	_, err = api.db.Queries.UpdateUser(ctx, database.UpdateUserParams{UserID: "other", Role: ptr.Of(roleGuest)})
+	require.NoError(t, err)
+	body := syncDocuments(cursor)
+	assert.Equal(t, cursor, body["cursor"], "should not resequence unaffected documents")
+
+	// Private Changes - Never Visible
+	_, err = api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{ID: "other-document", Title: ptr.Of("Updated")})
+	require.NoError(t, err)
+	_, err = api.db.Queries.DeleteDocument(ctx, "other-document")
+	require.NoError(t, err)
+	body = syncDocuments(cursor)
+	assert.Greater(t, body["cursor"], cursor)
+	assert.Empty(t, body["give"])
+	assert.Empty(t, body["deleted"], "should not disclose private documents")
+	body = syncDocuments(nil, "other-document")
+	assert.Empty(t, body["deleted"], "should not disclose private documents on full sync")
+
+	// Admin Demotion - Previously Visible
+	_, err = api.db.Queries.UpdateUser(ctx, database.UpdateUserParams{UserID: "reader", Admin: true})
+	require.NoError(t, err)
+	cursor = syncDocuments(nil)["cursor"]
+	_, err = api.db.Queries.UpdateUser(ctx, database.UpdateUserParams{UserID: "reader", Admin: false})
+	require.NoError(t, err)
+	body = syncDocuments(cursor)
+	assert.Equal(t, []any{"other-document"}, body["deleted"])
+
+	// Hidden - Full Sync
+	_, err = api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:       "hidden-document",
+		Filepath: ptr.Of("hidden-document.epub"),
+		OwnerID:  ptr.Of("other"),
+	})
+	require.NoError(t, err)
+	for _, visibility := range []documentVisibility{visibilityPublic, visibilityPrivate} {
+		_, err = api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+			DocumentID: "hidden-document",
+			Visibility: string(visibility),
+		})
+		require.NoError(t, err)
+	}
+	body = syncDocuments(nil, "hidden-document")
+	assert.Equal(t, []any{"hidden-document"}, body["deleted"], "should remove documents no longer visible")
+}
+
+func TestKOSyncAddDocumentsOwnership(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	ctx := t.Context()
+	require.NoError(t, api.createUser(ctx, "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+
+	_, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:      "other-document",
+		Title:   ptr.Of("Other Title"),
+		OwnerID: ptr.Of("other"),
+	})
+	require.NoError(t, err)
+
+	code, body := koRequest(t, server, http.MethodPost, "/api/ko/documents", gin.H{"documents": []gin.H{
+		{"id": "other-document", "title": "Overwritten"},
+		{"id": "document-00", "title": "Overwritten"},
+		{"id": "new-document", "title": "New Title"},
+	}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), body["changed"])
+
+	// Non-Owned Documents Unchanged
+	for documentID, title := range map[string]string{"other-document": "Other Title", "document-00": "Title 00"} {
+		document, err := api.db.Queries.GetDocument(ctx, documentID)
+		require.NoError(t, err)
+		assert.Equal(t, title, *document.Title)
+	}
+
+	// New Documents Owned & Private
+	document, err := api.db.Queries.GetDocument(ctx, "new-document")
+	require.NoError(t, err)
+	assert.Equal(t, "reader", *document.OwnerID)
+	assert.Equal(t, "private", document.Visibility)
+}
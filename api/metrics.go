@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"reichard.io/antholume/metrics"
+)
+
+// metricsMiddleware records request counts & latencies by route pattern (not
+// path, to bound label cardinality).
+func metricsMiddleware(c *gin.Context) {
+	startTime := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(c.Writer.Status())
+
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(startTime).Seconds())
+}
+
+// koOperationMiddleware records the result of a KOSync operation. It precedes
+// the auth middleware so that rejected requests are counted.
+func koOperationMiddleware(operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		var result string
+		switch status := c.Writer.Status(); {
+		case status < http.StatusBadRequest:
+			result = metrics.ResultSuccess
+		case status == http.StatusUnauthorized:
+			result = "unauthorized"
+		case status == http.StatusConflict:
+			result = "conflict"
+		default:
+			result = metrics.ResultError
+		}
+
+		metrics.KOSyncOperations.WithLabelValues(operation, result).Inc()
+	}
+}
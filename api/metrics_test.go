@@ -0,0 +1,60 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestMetrics(t *testing.T) {
+	cfg := &config.Config{DBType: "memory", ConfigPath: t.TempDir(), DataPath: t.TempDir(), MetricsEnabled: true}
+	db := database.NewMgr(cfg)
+	api := NewApi(db, cfg, os.DirFS(".."))
+	require.NoError(t, api.createUser(t.Context(), "reader", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+	_, err := db.Queries.UpsertDocument(t.Context(), database.UpsertDocumentParams{ID: "document-00"})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(api.httpServer.Handler)
+	t.Cleanup(server.Close)
+
+	// Generate Traffic
+	code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.5, time.Now().Unix()))
+	require.Equal(t, http.StatusOK, code)
+	code, _ = koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/unknown", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.NoError(t, api.CacheTempTables(t.Context()))
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	page := string(body)
+
+	assert.Contains(t, page, `antholume_http_requests_total{method="PUT",route="/api/ko/syncs/progress",status="200"}`)
+	assert.Contains(t, page, `antholume_http_request_duration_seconds_bucket{method="PUT",route="/api/ko/syncs/progress",status="200"`)
+	assert.Contains(t, page, `antholume_kosync_operations_total{operation="update_progress",result="success"}`)
+	assert.Contains(t, page, `antholume_db_query_duration_seconds_count{query="UpsertDevice"}`)
+	assert.Contains(t, page, "antholume_cache_temp_tables_duration_seconds_count")
+	assert.Contains(t, page, "antholume_documents 1\n")
+	assert.Contains(t, page, "antholume_users 1\n")
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	_, server := newTestAPI(t, 0)
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
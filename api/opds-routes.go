@@ -1,15 +1,31 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"reichard.io/antholume/database"
+	"reichard.io/antholume/metadata"
 	"reichard.io/antholume/opds"
+	"reichard.io/antholume/opds/opds2"
 	"reichard.io/antholume/pkg/ptr"
 )
 
@@ -25,9 +41,95 @@ var mimeMapping map[string]string = map[string]string{
 	"html": "text/html",
 	"doc":  "application/msword",
 	"lit":  "application/x-ms-reader",
+	"cbz":  "application/vnd.comicbook+zip",
+}
+
+// opdsPSEDeviceName is the device that page streaming progress is recorded as
+const opdsPSEDeviceName = "OPDS-PSE"
+
+type requestDocumentPage struct {
+	DocumentID string `uri:"document" binding:"required"`
+	Page       int    `uri:"page" binding:"min=0"`
+	Width      int    `form:"width" binding:"min=0"`
+}
+
+const (
+	opdsAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	opdsNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsFacetRel        = "http://opds-spec.org/facet"
+)
+
+// opdsDocumentFilter are the document feed filters shared by the OPDS 1 & 2 catalogs
+type opdsDocumentFilter struct {
+	Search *string `form:"search"`
+	Author *string `form:"author"`
+	Series *string `form:"series"`
+	Lang   *string `form:"lang"`
+	Status *string `form:"status"`
+	Sort   *string `form:"sort"`
+}
+
+// opdsReadingStateScheme is the category scheme of the users reading state
+const opdsReadingStateScheme = "urn:antholume:reading-state"
+
+// opdsNavigationItem is a browsable feed listed on the catalog root
+type opdsNavigationItem struct {
+	Title       string
+	Path        string
+	Acquisition bool
+}
+
+var opdsNavigationItems = []opdsNavigationItem{
+	{Title: "All Documents", Path: "/documents", Acquisition: true},
+	{Title: "Recently Added", Path: "/documents?sort=added", Acquisition: true},
+	{Title: "Currently Reading", Path: "/documents?status=reading", Acquisition: true},
+	{Title: "Finished", Path: "/documents?status=finished", Acquisition: true},
+	{Title: "Unread", Path: "/documents?status=unread", Acquisition: true},
+	{Title: "Authors", Path: "/authors"},
+	{Title: "Series", Path: "/series"},
+	{Title: "Languages", Path: "/languages"},
+}
+
+// opdsSortFacet is a selectable sort order of a document feed
+type opdsSortFacet struct {
+	Sort  string
+	Title string
+}
+
+// opdsSortFacets are the available sort orders, the first being the default
+var opdsSortFacets = []opdsSortFacet{
+	{Sort: "read", Title: "Recently Read"},
+	{Sort: "added", Title: "Recently Added"},
+	{Sort: "title", Title: "Title"},
+	{Sort: "author", Title: "Author"},
 }
 
 func (api *API) opdsEntry(c *gin.Context) {
+	// Navigation Entries
+	var entries []opds.Entry
+	for _, item := range opdsNavigationItems {
+		linkType := opdsNavigationType
+		if item.Acquisition {
+			linkType = opdsAcquisitionType
+		}
+
+		entries = append(entries, opds.Entry{
+			Title: item.Title,
+			ID:    "/api/opds" + item.Path,
+			Content: &opds.Content{
+				Content:     "AnthoLume - " + item.Title,
+				ContentType: "text",
+			},
+			Links: []opds.Link{
+				{
+					Rel:      "subsection",
+					Href:     "/api/opds" + item.Path,
+					TypeLink: linkType,
+				},
+			},
+		})
+	}
+
 	// Build & Return XML
 	mainFeed := &opds.Feed{
 		Title:   "AnthoLume OPDS Server",
@@ -44,26 +146,17 @@ func (api *API) opdsEntry(c *gin.Context) {
 			{
 				Title:    "Search AnthoLume",
 				Rel:      "search",
-				TypeLink: "application/atom+xml;profile=opds-catalog;kind=acquisition",
+				TypeLink: opdsAcquisitionType,
 				Href:     "/api/opds/documents?search={searchTerms}",
 			},
-		},
-
-		Entries: []opds.Entry{
 			{
-				Title: "AnthoLume - All Documents",
-				Content: &opds.Content{
-					Content:     "AnthoLume - All Documents",
-					ContentType: "text",
-				},
-				Links: []opds.Link{
-					{
-						Href:     "/api/opds/documents",
-						TypeLink: "application/atom+xml;type=feed;profile=opds-catalog",
-					},
-				},
+				Title:    "AnthoLume OPDS 2.0 Catalog",
+				Rel:      "alternate",
+				TypeLink: opds2.MediaTypeFeed,
+				Href:     "/api/opds/v2",
 			},
 		},
+		Entries: entries,
 	}
 
 	c.XML(http.StatusOK, mainFeed)
@@ -77,93 +170,435 @@ func (api *API) opdsDocuments(c *gin.Context) {
 
 	// Potential URL Parameters (Default Pagination - 100)
 	qParams := bindQueryParams(c, 100)
-
-	// Possible Query
-	var query *string
-	if qParams.Search != nil && *qParams.Search != "" {
-		search := "%" + *qParams.Search + "%"
-		query = &search
+	filter, err := bindOPDSDocumentFilter(c)
+	if err != nil {
+		log.Error("Invalid OPDS Filter:", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
 	}
 
 	// Get Documents
-	documents, err := api.db.Queries.GetDocumentsWithStats(c, database.GetDocumentsWithStatsParams{
-		UserID:  auth.UserName,
-		Query:   query,
-		Deleted: ptr.Of(false),
-		Offset:  (*qParams.Page - 1) * *qParams.Limit,
-		Limit:   *qParams.Limit,
-	})
+	documents, err := api.db.Queries.GetDocumentsWithStats(c, filter.documentsParams(auth.UserName, *qParams.Page, *qParams.Limit))
 	if err != nil {
 		log.Error("GetDocumentsWithStats DB Error:", err)
 		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
+	// Get Total
+	total, err := api.db.Queries.GetDocumentsSize(c, filter.sizeParams(auth.UserName))
+	if err != nil {
+		log.Error("GetDocumentsSize DB Error:", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	// Get Page Streaming Progress
+	var streamIDs []string
+	for _, doc := range documents {
+		if strings.HasSuffix(*doc.Filepath, ".cbz") {
+			streamIDs = append(streamIDs, doc.ID)
+		}
+	}
+	streamProgress := make(map[string]database.GetDocumentsProgressRow)
+	if len(streamIDs) > 0 {
+		progress, err := api.db.Queries.GetDocumentsProgress(c, database.GetDocumentsProgressParams{
+			UserID:      auth.UserName,
+			DocumentIds: streamIDs,
+		})
+		if err != nil {
+			log.Error("GetDocumentsProgress DB Error:", err)
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		for _, item := range progress {
+			streamProgress[item.DocumentID] = item
+		}
+	}
+
 	// Build OPDS Entries
 	var allEntries []opds.Entry
 	for _, doc := range documents {
-		// Require File
-		if doc.Filepath != nil {
-			splitFilepath := strings.Split(*doc.Filepath, ".")
-			fileType := splitFilepath[len(splitFilepath)-1]
-
-			title := "N/A"
-			if doc.Title != nil {
-				title = *doc.Title
-			}
+		splitFilepath := strings.Split(*doc.Filepath, ".")
+		fileType := splitFilepath[len(splitFilepath)-1]
 
-			author := "N/A"
-			if doc.Author != nil {
-				author = *doc.Author
-			}
+		title := "N/A"
+		if doc.Title != nil {
+			title = *doc.Title
+		}
 
-			description := "N/A"
-			if doc.Description != nil {
-				description = *doc.Description
-			}
+		author := "N/A"
+		if doc.Author != nil {
+			author = *doc.Author
+		}
 
-			item := opds.Entry{
-				Title: title,
-				Author: []opds.Author{
-					{
-						Name: author,
-					},
+		description := "N/A"
+		if doc.Description != nil {
+			description = *doc.Description
+		}
+
+		readingState := opdsReadingState(doc.Percentage)
+		item := opds.Entry{
+			Title: title,
+			Author: []opds.Author{
+				{
+					Name: author,
+				},
+			},
+			Content: &opds.Content{
+				Content:     fmt.Sprintf("%s\n\n%s", opdsProgressSummary(doc), description),
+				ContentType: "text",
+			},
+			Category: []opds.Category{
+				{
+					Scheme: opdsReadingStateScheme,
+					Term:   readingState,
+					Label:  readingState,
+				},
+			},
+			Links: []opds.Link{
+				{
+					Rel:      "http://opds-spec.org/acquisition",
+					Href:     fmt.Sprintf("/api/opds/documents/%s/file", doc.ID),
+					TypeLink: mimeMapping[fileType],
 				},
+				{
+					Rel:      "http://opds-spec.org/image",
+					Href:     fmt.Sprintf("/api/opds/documents/%s/cover", doc.ID),
+					TypeLink: "image/jpeg",
+				},
+			},
+		}
+
+		// Page Streaming
+		if fileType == "cbz" {
+			progress, hasProgress := streamProgress[doc.ID]
+			if link := api.opdsPageStreamLink(c, doc, progress, hasProgress); link != nil {
+				item.Links = append(item.Links, *link)
+			}
+		}
+
+		allEntries = append(allEntries, item)
+	}
+
+	// Pagination & Sort Facets
+	links := opdsPaginationLinks(filter.values(), *qParams.Page, *qParams.Limit, total)
+	for _, facet := range opdsSortFacets {
+		links = append(links, opds.Link{
+			Rel:         opdsFacetRel,
+			Href:        "/api/opds/documents?" + filter.withSort(facet.Sort).values().Encode(),
+			TypeLink:    opdsAcquisitionType,
+			Title:       facet.Title,
+			FacetGroup:  "Sort",
+			ActiveFacet: filter.sort() == facet.Sort,
+		})
+	}
+
+	// Build & Return XML
+	searchFeed := &opds.Feed{
+		Title:        filter.title(),
+		Updated:      time.Now().UTC(),
+		Links:        links,
+		Entries:      allEntries,
+		PSENamespace: opds.PSENamespace,
+		TotalResults: int(total),
+		ItemsPerPage: int(*qParams.Limit),
+		StartIndex:   int((*qParams.Page-1)**qParams.Limit) + 1,
+	}
+
+	c.XML(http.StatusOK, searchFeed)
+}
+
+// opdsGroups returns a navigation feed of the distinct values of a document
+// field (author, series or lang) linking to their filtered acquisition feeds
+func (api *API) opdsGroups(field, title string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var auth authData
+		if data, _ := c.Get("Authorization"); data != nil {
+			auth = data.(authData)
+		}
+
+		// Potential URL Parameters (Default Pagination - 100)
+		qParams := bindQueryParams(c, 100)
+
+		// Get Groups
+		groups, err := api.db.Queries.GetDocumentGroups(c, database.GetDocumentGroupsParams{
+			Field:  field,
+			UserID: auth.UserName,
+			Offset: (*qParams.Page - 1) * *qParams.Limit,
+			Limit:  *qParams.Limit,
+		})
+		if err != nil {
+			log.Error("GetDocumentGroups DB Error:", err)
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		// Build OPDS Entries
+		var entries []opds.Entry
+		for _, group := range groups {
+			href := "/api/opds/documents?" + url.Values{field: {group.Value}}.Encode()
+			entries = append(entries, opds.Entry{
+				Title: group.Value,
+				ID:    href,
 				Content: &opds.Content{
-					Content:     description,
+					Content:     fmt.Sprintf("%d Documents", group.Count),
 					ContentType: "text",
 				},
 				Links: []opds.Link{
 					{
-						Rel:      "http://opds-spec.org/acquisition",
-						Href:     fmt.Sprintf("/api/opds/documents/%s/file", doc.ID),
-						TypeLink: mimeMapping[fileType],
-					},
-					{
-						Rel:      "http://opds-spec.org/image",
-						Href:     fmt.Sprintf("/api/opds/documents/%s/cover", doc.ID),
-						TypeLink: "image/jpeg",
+						Rel:      "subsection",
+						Href:     href,
+						TypeLink: opdsAcquisitionType,
+						Count:    int(group.Count),
 					},
 				},
-			}
+			})
+		}
 
-			allEntries = append(allEntries, item)
+		// Next Page
+		var links []opds.Link
+		if int64(len(groups)) == *qParams.Limit {
+			links = append(links, opds.Link{
+				Rel:      "next",
+				Href:     fmt.Sprintf("%s?page=%d&limit=%d", c.Request.URL.Path, *qParams.Page+1, *qParams.Limit),
+				TypeLink: opdsNavigationType,
+			})
 		}
+
+		// Build & Return XML
+		c.XML(http.StatusOK, &opds.Feed{
+			Title:   title,
+			Updated: time.Now().UTC(),
+			Links:   links,
+			Entries: entries,
+		})
 	}
+}
 
-	feedTitle := "All Documents"
-	if query != nil {
-		feedTitle = "Search Results"
+// opdsReadingState returns the users reading state (Unread, Reading or
+// Finished) of a document given its percentage, where finished is 100.
+func opdsReadingState(percentage float64) string {
+	switch {
+	case percentage <= 0:
+		return "Unread"
+	case percentage >= 100:
+		return "Finished"
+	default:
+		return "Reading"
 	}
+}
 
-	// Build & Return XML
-	searchFeed := &opds.Feed{
-		Title:   feedTitle,
-		Updated: time.Now().UTC(),
-		Entries: allEntries,
+// opdsProgressSummary returns a human readable reading state, progress and
+// last read date of the document.
+func opdsProgressSummary(doc database.GetDocumentsWithStatsRow) string {
+	readingState := opdsReadingState(doc.Percentage)
+	if readingState == "Unread" {
+		return readingState
 	}
+	return fmt.Sprintf("%s - %.0f%% - Last Read: %v", readingState, doc.Percentage, doc.LastRead)
+}
 
-	c.XML(http.StatusOK, searchFeed)
+// opdsPageStreamLink returns the OPDS-PSE stream link of an image based
+// document, including the users last read page when they have progress.
+func (api *API) opdsPageStreamLink(ctx context.Context, doc database.GetDocumentsWithStatsRow, progress database.GetDocumentsProgressRow, hasProgress bool) *opds.Link {
+	pageCount, err := api.documentPageCount(ctx, doc.ID, doc.Pages, api.documentFilePath(doc.Basepath, *doc.Filepath))
+	if err != nil || pageCount == 0 {
+		log.Warnf("Unable to count pages of %s: %v", doc.ID, err)
+		return nil
+	}
+
+	link := &opds.Link{
+		Rel:      opds.PSERelStream,
+		Href:     fmt.Sprintf("/api/opds/documents/%s/pages/{pageNumber}?width={maxWidth}", doc.ID),
+		TypeLink: "image/jpeg",
+		PSECount: pageCount,
+	}
+
+	// Last Read - Progress is the 1-indexed page (KOReader compatible)
+	if !hasProgress {
+		return link
+	}
+	if page, err := strconv.Atoi(progress.Progress); err == nil && page > 0 {
+		link.PSELastRead = ptr.Of(min(page-1, pageCount-1))
+		if lastReadDate, err := time.Parse(time.RFC3339, progress.CreatedAt); err == nil {
+			link.PSELastReadDate = &lastReadDate
+		}
+	}
+
+	return link
+}
+
+// documentPageCount returns the stored page count of an image based document.
+// Documents imported before page counts were stored are counted once, and the
+// count is stored.
+func (api *API) documentPageCount(ctx context.Context, documentID string, pages *int64, filePath string) (int, error) {
+	if pages != nil {
+		return int(*pages), nil
+	}
+
+	pageCount, err := metadata.GetPageCount(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := api.db.Queries.UpsertDocument(ctx, database.UpsertDocumentParams{
+		ID:    documentID,
+		Pages: ptr.Of(int64(pageCount)),
+	}); err != nil {
+		log.Error("UpsertDocument DB Error:", err)
+	}
+
+	return pageCount, nil
+}
+
+// opdsDocumentPage serves a single (optionally downscaled) page of an image
+// based document, recording it as the users last read page.
+func (api *API) opdsDocumentPage(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rPage requestDocumentPage
+	if err := c.ShouldBindUri(&rPage); err != nil {
+		log.Error("Invalid URI Bind")
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		return
+	} else if err := c.ShouldBindQuery(&rPage); err != nil {
+		log.Error("Invalid Query Bind")
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+
+	// Validate Access
+	if !api.documentReadable(c, rPage.DocumentID, apiErrorPage) {
+		return
+	}
+
+	// Get Document
+	document, err := api.db.Queries.GetDocument(c, rPage.DocumentID)
+	if err != nil {
+		log.Error("GetDocument DB Error:", err)
+		apiErrorPage(c, http.StatusBadRequest, "Unknown Document")
+		return
+	} else if document.Filepath == nil {
+		log.Error("Document Doesn't Have File:", rPage.DocumentID)
+		apiErrorPage(c, http.StatusBadRequest, "Document Doesn't Exist")
+		return
+	}
+
+	// Require Image Based Document
+	filePath := api.documentFilePath(document.Basepath, *document.Filepath)
+	docType, err := metadata.GetDocumentType(filePath)
+	if err != nil || *docType != metadata.TYPE_CBZ {
+		apiErrorPage(c, http.StatusBadRequest, "Document Not Image Based")
+		return
+	}
+
+	pageCount, err := api.documentPageCount(c, document.ID, document.Pages, filePath)
+	if err != nil {
+		log.Error("Page Count Error:", err)
+		apiErrorPage(c, http.StatusInternalServerError, "Unable to read document")
+		return
+	}
+
+	pageData, mimeType, err := metadata.GetPage(filePath, rPage.Page)
+	if err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Page")
+		return
+	}
+
+	// Scale Page
+	if rPage.Width > 0 {
+		if pageData, mimeType, err = scalePage(pageData, mimeType, rPage.Width); err != nil {
+			log.Error("Page Scale Error:", err)
+			apiErrorPage(c, http.StatusInternalServerError, "Unable to scale page")
+			return
+		}
+	}
+
+	// Record Progress
+	api.setPageProgress(c, auth.UserName, document.ID, rPage.Page, pageCount)
+
+	c.Data(http.StatusOK, mimeType, pageData)
+}
+
+// setPageProgress records the page as the last read page of the document
+// against the users page streaming device. Only pages past the current page
+// are recorded, as readers also request previous pages (e.g. thumbnails).
+func (api *API) setPageProgress(ctx context.Context, userID, documentID string, page, pageCount int) {
+	if progress, err := api.db.Queries.GetDocumentProgress(ctx, database.GetDocumentProgressParams{
+		UserID:     userID,
+		DocumentID: documentID,
+	}); err == nil {
+		if lastPage, err := strconv.Atoi(progress.Progress); err == nil && page+1 <= lastPage {
+			return
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Error("GetDocumentProgress DB Error:", err)
+		return
+	}
+
+	deviceID := fmt.Sprintf("%x", md5.Sum([]byte(userID+":"+opdsPSEDeviceName)))
+	if _, err := api.db.Queries.UpsertDevice(ctx, database.UpsertDeviceParams{
+		ID:         deviceID,
+		UserID:     userID,
+		DeviceName: opdsPSEDeviceName,
+		LastSynced: time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		log.Error("UpsertDevice DB Error:", err)
+		return
+	}
+
+	percentage := float64(page+1) / float64(pageCount)
+	if _, err := api.updateProgress(ctx, userID, deviceID, documentID, percentage, fmt.Sprint(page+1), time.Now()); err != nil {
+		var conflictErr *progressConflictError
+		if !errors.As(err, &conflictErr) {
+			log.Error("Update Progress Error:", err)
+		}
+	}
+}
+
+// scalePage downscales the page image to the provided width as a JPEG. Pages
+// already narrower than the width are returned as is.
+func scalePage(data []byte, mimeType string, width int) ([]byte, string, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() <= width {
+		return data, mimeType, nil
+	}
+
+	// Box Sampling
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		sy0 := bounds.Min.Y + y*bounds.Dy()/height
+		sy1 := max(sy0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := range width {
+			sx0 := bounds.Min.X + x*bounds.Dx()/width
+			sx1 := max(sx0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
 }
 
 func (api *API) opdsSearchDescription(c *gin.Context) {
@@ -174,3 +609,433 @@ func (api *API) opdsSearchDescription(c *gin.Context) {
 		   </OpenSearchDescription>`
 	c.Data(http.StatusOK, "application/xml", []byte(rawXML))
 }
+
+func (api *API) opds2Entry(c *gin.Context) {
+	// Navigation Links
+	var navigation []opds2.Link
+	for _, item := range opdsNavigationItems {
+		navigation = append(navigation, opds2.Link{
+			Title:    item.Title,
+			Rel:      opds2.RelSubsection,
+			Href:     "/api/opds/v2" + item.Path,
+			TypeLink: opds2.MediaTypeFeed,
+		})
+	}
+
+	now := time.Now().UTC()
+	opds2JSON(c, &opds2.Feed{
+		Metadata: opds2.Metadata{
+			Title:    "AnthoLume OPDS Server",
+			Modified: &now,
+		},
+		Links: []opds2.Link{
+			{Rel: opds2.RelSelf, Href: "/api/opds/v2", TypeLink: opds2.MediaTypeFeed},
+			{Rel: opds2.RelStart, Href: "/api/opds/v2", TypeLink: opds2.MediaTypeFeed},
+			opds2SearchLink(),
+		},
+		Navigation: navigation,
+	})
+}
+
+func (api *API) opds2Documents(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	// Potential URL Parameters (Default Pagination - 100)
+	qParams := bindQueryParams(c, 100)
+	filter, err := bindOPDSDocumentFilter(c)
+	if err != nil {
+		log.Error("Invalid OPDS Filter:", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	// Get Documents
+	documents, err := api.db.Queries.GetDocumentsWithStats(c, filter.documentsParams(auth.UserName, *qParams.Page, *qParams.Limit))
+	if err != nil {
+		log.Error("GetDocumentsWithStats DB Error:", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	// Get Total
+	total, err := api.db.Queries.GetDocumentsSize(c, filter.sizeParams(auth.UserName))
+	if err != nil {
+		log.Error("GetDocumentsSize DB Error:", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	// Build Publications
+	publications := []opds2.Publication{}
+	for _, doc := range documents {
+		publications = append(publications, newOPDS2Publication(doc))
+	}
+
+	// Sort Facets
+	sortFacet := opds2.Facet{Metadata: opds2.Metadata{Title: "Sort"}}
+	for _, facet := range opdsSortFacets {
+		sortFacet.Links = append(sortFacet.Links, opds2.Link{
+			Title:    facet.Title,
+			Href:     "/api/opds/v2/documents?" + filter.withSort(facet.Sort).values().Encode(),
+			TypeLink: opds2.MediaTypeFeed,
+		})
+		if filter.sort() == facet.Sort {
+			sortFacet.Links[len(sortFacet.Links)-1].Rel = opds2.RelSelf
+		}
+	}
+
+	now := time.Now().UTC()
+	opds2JSON(c, &opds2.Feed{
+		Metadata: opds2.Metadata{
+			Title:         filter.title(),
+			Modified:      &now,
+			NumberOfItems: total,
+			ItemsPerPage:  *qParams.Limit,
+			CurrentPage:   *qParams.Page,
+		},
+		Links:        opds2PaginationLinks(filter.values(), *qParams.Page, *qParams.Limit, total),
+		Publications: publications,
+		Facets:       []opds2.Facet{sortFacet},
+	})
+}
+
+// opds2Groups is the OPDS 2 equivalent of opdsGroups
+func (api *API) opds2Groups(field, title string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var auth authData
+		if data, _ := c.Get("Authorization"); data != nil {
+			auth = data.(authData)
+		}
+
+		// Potential URL Parameters (Default Pagination - 100)
+		qParams := bindQueryParams(c, 100)
+
+		// Get Groups
+		groups, err := api.db.Queries.GetDocumentGroups(c, database.GetDocumentGroupsParams{
+			Field:  field,
+			UserID: auth.UserName,
+			Offset: (*qParams.Page - 1) * *qParams.Limit,
+			Limit:  *qParams.Limit,
+		})
+		if err != nil {
+			log.Error("GetDocumentGroups DB Error:", err)
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		// Build Navigation
+		var navigation []opds2.Link
+		for _, group := range groups {
+			navigation = append(navigation, opds2.Link{
+				Title:      group.Value,
+				Rel:        opds2.RelSubsection,
+				Href:       "/api/opds/v2/documents?" + url.Values{field: {group.Value}}.Encode(),
+				TypeLink:   opds2.MediaTypeFeed,
+				Properties: &opds2.LinkProperties{NumberOfItems: group.Count},
+			})
+		}
+
+		pageLink := func(rel string, page int64) opds2.Link {
+			return opds2.Link{
+				Rel:      rel,
+				Href:     fmt.Sprintf("%s?page=%d&limit=%d", c.Request.URL.Path, page, *qParams.Limit),
+				TypeLink: opds2.MediaTypeFeed,
+			}
+		}
+
+		links := []opds2.Link{
+			pageLink(opds2.RelSelf, *qParams.Page),
+			{Rel: opds2.RelStart, Href: "/api/opds/v2", TypeLink: opds2.MediaTypeFeed},
+		}
+		if *qParams.Page > 1 {
+			links = append(links, pageLink(opds2.RelPrevious, *qParams.Page-1))
+		}
+		if int64(len(groups)) == *qParams.Limit {
+			links = append(links, pageLink(opds2.RelNext, *qParams.Page+1))
+		}
+
+		now := time.Now().UTC()
+		feed := &opds2.Feed{
+			Metadata: opds2.Metadata{
+				Title:        title,
+				Modified:     &now,
+				ItemsPerPage: *qParams.Limit,
+				CurrentPage:  *qParams.Page,
+			},
+			Links:      links,
+			Navigation: navigation,
+		}
+		if len(navigation) == 0 {
+			// Navigation Requires Items - Empty Collection Instead
+			feed.Publications = []opds2.Publication{}
+		}
+		opds2JSON(c, feed)
+	}
+}
+
+func newOPDS2Publication(doc database.GetDocumentsWithStatsRow) opds2.Publication {
+	splitFilepath := strings.Split(*doc.Filepath, ".")
+	fileType := splitFilepath[len(splitFilepath)-1]
+
+	title := "N/A"
+	if doc.Title != nil {
+		title = *doc.Title
+	}
+
+	identifier := fmt.Sprintf("urn:antholume:%s", doc.ID)
+	if doc.Isbn13 != nil && *doc.Isbn13 != "" {
+		identifier = fmt.Sprintf("urn:isbn:%s", *doc.Isbn13)
+	} else if doc.Isbn10 != nil && *doc.Isbn10 != "" {
+		identifier = fmt.Sprintf("urn:isbn:%s", *doc.Isbn10)
+	}
+
+	publication := opds2.Publication{
+		Metadata: opds2.PublicationMetadata{
+			RDFType:    opds2.TypeBook,
+			Identifier: identifier,
+			Title:      title,
+			Subject: []opds2.Subject{
+				{
+					Name:   opdsReadingState(doc.Percentage),
+					Scheme: opdsReadingStateScheme,
+					Code:   opdsReadingState(doc.Percentage),
+				},
+			},
+		},
+		Links: []opds2.Link{
+			{
+				Rel:      opds2.RelAcquisition,
+				Href:     fmt.Sprintf("/api/opds/documents/%s/file", doc.ID),
+				TypeLink: mimeMapping[fileType],
+			},
+		},
+		Images: []opds2.Link{
+			{
+				Href:     fmt.Sprintf("/api/opds/documents/%s/cover", doc.ID),
+				TypeLink: "image/jpeg",
+			},
+		},
+	}
+
+	if doc.Author != nil {
+		publication.Metadata.Author = []opds2.Contributor{{Name: *doc.Author}}
+	}
+	if doc.Description != nil {
+		publication.Metadata.Description = *doc.Description
+	}
+
+	return publication
+}
+
+func opds2SearchLink() opds2.Link {
+	return opds2.Link{
+		Title:     "Search AnthoLume",
+		Rel:       opds2.RelSearch,
+		Href:      "/api/opds/v2/documents{?search}",
+		TypeLink:  opds2.MediaTypeFeed,
+		Templated: true,
+	}
+}
+
+func opdsPaginationLinks(params url.Values, page, limit, total int64) []opds.Link {
+	pageLink := func(rel string, page int64) opds.Link {
+		params.Set("page", fmt.Sprint(page))
+		params.Set("limit", fmt.Sprint(limit))
+		return opds.Link{
+			Rel:      rel,
+			Href:     "/api/opds/documents?" + params.Encode(),
+			TypeLink: opdsAcquisitionType,
+		}
+	}
+
+	lastPage := opdsLastPage(limit, total)
+	links := []opds.Link{
+		pageLink("self", page),
+		{Rel: "start", Href: "/api/opds", TypeLink: opdsNavigationType},
+		pageLink("first", 1),
+		pageLink("last", lastPage),
+	}
+	if page > 1 {
+		links = append(links, pageLink("previous", min(page-1, lastPage)))
+	}
+	if page < lastPage {
+		links = append(links, pageLink("next", page+1))
+	}
+
+	return links
+}
+
+func opds2PaginationLinks(params url.Values, page, limit, total int64) []opds2.Link {
+	pageLink := func(rel string, page int64) opds2.Link {
+		params.Set("page", fmt.Sprint(page))
+		params.Set("limit", fmt.Sprint(limit))
+		return opds2.Link{
+			Rel:      rel,
+			Href:     "/api/opds/v2/documents?" + params.Encode(),
+			TypeLink: opds2.MediaTypeFeed,
+		}
+	}
+
+	lastPage := opdsLastPage(limit, total)
+	links := []opds2.Link{
+		pageLink(opds2.RelSelf, page),
+		{Rel: opds2.RelStart, Href: "/api/opds/v2", TypeLink: opds2.MediaTypeFeed},
+		opds2SearchLink(),
+		pageLink(opds2.RelFirst, 1),
+		pageLink(opds2.RelLast, lastPage),
+	}
+	if page > 1 {
+		links = append(links, pageLink(opds2.RelPrevious, min(page-1, lastPage)))
+	}
+	if page < lastPage {
+		links = append(links, pageLink(opds2.RelNext, page+1))
+	}
+
+	return links
+}
+
+// opdsLastPage returns the last page number, which is always at least one
+func opdsLastPage(limit, total int64) int64 {
+	if limit <= 0 || total <= 0 {
+		return 1
+	}
+	return (total + limit - 1) / limit
+}
+
+func opds2JSON(c *gin.Context, feed *opds2.Feed) {
+	data, err := json.Marshal(feed)
+	if err != nil {
+		log.Error("OPDS 2 JSON Marshal Error:", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, opds2.MediaTypeFeed, data)
+}
+
+func bindOPDSDocumentFilter(c *gin.Context) (opdsDocumentFilter, error) {
+	var filter opdsDocumentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		return filter, err
+	}
+
+	// Normalize Empty
+	for _, value := range []**string{&filter.Search, &filter.Author, &filter.Series, &filter.Lang, &filter.Status, &filter.Sort} {
+		if *value != nil && strings.TrimSpace(**value) == "" {
+			*value = nil
+		}
+	}
+
+	if filter.Status != nil && !slices.Contains([]string{"reading", "finished", "unread"}, *filter.Status) {
+		return filter, fmt.Errorf("invalid status: %s", *filter.Status)
+	}
+	if filter.Sort != nil && !slices.ContainsFunc(opdsSortFacets, func(f opdsSortFacet) bool { return f.Sort == *filter.Sort }) {
+		return filter, fmt.Errorf("invalid sort: %s", *filter.Sort)
+	}
+
+	return filter, nil
+}
+
+// sort returns the active sort order, defaulting to recently read
+func (f opdsDocumentFilter) sort() string {
+	if f.Sort == nil {
+		return opdsSortFacets[0].Sort
+	}
+	return *f.Sort
+}
+
+func (f opdsDocumentFilter) withSort(sort string) opdsDocumentFilter {
+	f.Sort = &sort
+	if sort == opdsSortFacets[0].Sort {
+		f.Sort = nil
+	}
+	return f
+}
+
+// values returns the filter as URL query values, excluding pagination
+func (f opdsDocumentFilter) values() url.Values {
+	params := url.Values{}
+	for key, value := range map[string]*string{
+		"search": f.Search,
+		"author": f.Author,
+		"series": f.Series,
+		"lang":   f.Lang,
+		"status": f.Status,
+		"sort":   f.Sort,
+	} {
+		if value != nil {
+			params.Set(key, *value)
+		}
+	}
+	return params
+}
+
+func (f opdsDocumentFilter) title() string {
+	switch {
+	case f.Search != nil:
+		return "Search Results"
+	case f.Author != nil:
+		return *f.Author
+	case f.Series != nil:
+		return *f.Series
+	case f.Lang != nil:
+		return fmt.Sprintf("Language: %s", *f.Lang)
+	case f.Status != nil && *f.Status == "reading":
+		return "Currently Reading"
+	case f.Status != nil && *f.Status == "finished":
+		return "Finished"
+	case f.Status != nil && *f.Status == "unread":
+		return "Unread"
+	case f.Sort != nil && *f.Sort == "added":
+		return "Recently Added"
+	default:
+		return "All Documents"
+	}
+}
+
+func (f opdsDocumentFilter) query() *string {
+	if f.Search == nil {
+		return nil
+	}
+	return ptr.Of("%" + *f.Search + "%")
+}
+
+func (f opdsDocumentFilter) documentsParams(userID string, page, limit int64) database.GetDocumentsWithStatsParams {
+	params := database.GetDocumentsWithStatsParams{
+		UserID:  userID,
+		Query:   f.query(),
+		Author:  f.Author,
+		Series:  f.Series,
+		Lang:    f.Lang,
+		Deleted: ptr.Of(false),
+		HasFile: ptr.Of(true),
+		Offset:  (page - 1) * limit,
+		Limit:   limit,
+	}
+	if f.Status != nil {
+		params.Status = *f.Status
+	}
+	if f.Sort != nil {
+		params.Sort = *f.Sort
+	}
+	return params
+}
+
+func (f opdsDocumentFilter) sizeParams(userID string) database.GetDocumentsSizeParams {
+	params := database.GetDocumentsSizeParams{
+		UserID:  userID,
+		Author:  f.Author,
+		Series:  f.Series,
+		Lang:    f.Lang,
+		HasFile: ptr.Of(true),
+	}
+	if query := f.query(); query != nil {
+		params.Query = *query
+	}
+	if f.Status != nil {
+		params.Status = *f.Status
+	}
+	return params
+}
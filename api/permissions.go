@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+)
+
+type permission string
+
+const (
+	permUpload   permission = "upload"
+	permDelete   permission = "delete"
+	permEdit     permission = "edit"
+	permSearch   permission = "search"
+	permDownload permission = "download"
+	permSync     permission = "sync"
+)
+
+var allPermissions = []permission{permUpload, permDelete, permEdit, permSearch, permDownload, permSync}
+
+// Built-in roles can be edited but not deleted. Users of deleted roles fall
+// back to the guest role.
+const (
+	roleUser  = "user"
+	roleGuest = "guest"
+)
+
+type documentVisibility string
+
+const (
+	visibilityPrivate documentVisibility = "private"
+	visibilityShared  documentVisibility = "shared"
+	visibilityPublic  documentVisibility = "public"
+)
+
+func (v documentVisibility) valid() bool {
+	return v == visibilityPrivate || v == visibilityShared || v == visibilityPublic
+}
+
+type shareType string
+
+const (
+	shareUser shareType = "user"
+	shareRole shareType = "role"
+)
+
+type rolePermissions map[permission]bool
+
+func newRolePermissions(role database.Role) rolePermissions {
+	return rolePermissions{
+		permUpload:   role.CanUpload,
+		permDelete:   role.CanDelete,
+		permEdit:     role.CanEdit,
+		permSearch:   role.CanSearch,
+		permDownload: role.CanDownload,
+		permSync:     role.CanSync,
+	}
+}
+
+// HasPermission is exported for use in templates. Admins have every
+// permission regardless of role.
+func (auth authData) HasPermission(p permission) bool {
+	return auth.IsAdmin || auth.Permissions[p]
+}
+
+func (api *API) getRolePermissions(ctx context.Context, roleName string) rolePermissions {
+	role, err := api.db.Queries.GetRole(ctx, roleName)
+	if err == sql.ErrNoRows {
+		log.Warnf("unknown role %q, no permissions granted", roleName)
+		return rolePermissions{}
+	} else if err != nil {
+		log.Error("GetRole DB Error: ", err)
+		return rolePermissions{}
+	}
+	return newRolePermissions(role)
+}
+
+// authPermissionMiddleware requires the authenticated user to have the
+// permission. Missing permissions are forbidden (403) rather than unauthorized
+// (401), as the credentials are valid.
+func (api *API) authPermissionMiddleware(p permission, errorFunc func(*gin.Context, int, string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, _ := c.Get("Authorization")
+		if data == nil {
+			errorFunc(c, http.StatusUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		if !data.(authData).HasPermission(p) {
+			errorFunc(c, http.StatusForbidden, fmt.Sprintf("Permission Required: %s", p))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// documentAccess describes what a user may do with a single document. Owners
+// and admins manage visibility & shares. Documents without an owner predate
+// per-user libraries (or were imported), and remain editable & deletable by
+// anyone who can see them with the edit & delete permissions.
+type documentAccess struct {
+	CanRead   bool
+	CanManage bool
+	CanEdit   bool
+	CanDelete bool
+}
+
+func (api *API) getDocumentAccess(ctx context.Context, auth authData, documentID string) (documentAccess, error) {
+	access, err := api.db.Queries.GetDocumentAccess(ctx, database.GetDocumentAccessParams{
+		UserID:     auth.UserName,
+		DocumentID: documentID,
+	})
+	if err != nil {
+		return documentAccess{}, err
+	}
+
+	isOwner := access.OwnerID != nil && *access.OwnerID == auth.UserName
+	isUnowned := access.OwnerID == nil && access.HasAccess
+	return documentAccess{
+		CanRead:   access.HasAccess,
+		CanManage: auth.IsAdmin || isOwner,
+		CanEdit:   auth.IsAdmin || isOwner || (isUnowned && auth.HasPermission(permEdit)),
+		CanDelete: auth.IsAdmin || isOwner || (isUnowned && auth.HasPermission(permDelete)),
+	}, nil
+}
+
+func (api *API) validateShareTarget(ctx context.Context, sType shareType, shareWith string) error {
+	var err error
+	switch sType {
+	case shareUser:
+		_, err = api.db.Queries.GetUser(ctx, shareWith)
+	case shareRole:
+		_, err = api.db.Queries.GetRole(ctx, shareWith)
+	default:
+		return fmt.Errorf("unknown share type: %s", sType)
+	}
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("unknown %s: %s", sType, shareWith)
+	} else if err != nil {
+		log.Errorf("Get %s DB Error: %v", sType, err)
+		return fmt.Errorf("unable to validate %s", sType)
+	}
+	return nil
+}
+
+// documentReadable writes a not found error when the document doesn't exist or
+// isn't visible to the current user, so hidden documents aren't disclosed.
+func (api *API) documentReadable(c *gin.Context, documentID string, errorFunc func(*gin.Context, int, string)) bool {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	access, err := api.getDocumentAccess(c, auth, documentID)
+	if err == sql.ErrNoRows || (err == nil && !access.CanRead) {
+		errorFunc(c, http.StatusNotFound, "Unknown Document")
+		return false
+	} else if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		errorFunc(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return false
+	}
+	return true
+}
+
+// documentManageable writes an error unless the current user owns the document
+// or is an admin.
+func (api *API) documentManageable(c *gin.Context, documentID string) bool {
+	return api.documentAllowed(c, documentID, func(access documentAccess) bool {
+		return access.CanManage
+	}, "Only the document owner can manage this document")
+}
+
+// documentEditable writes an error unless the current user may edit the
+// document's metadata.
+func (api *API) documentEditable(c *gin.Context, documentID string) bool {
+	return api.documentAllowed(c, documentID, func(access documentAccess) bool {
+		return access.CanEdit
+	}, "Only the document owner can edit this document")
+}
+
+func (api *API) documentAllowed(c *gin.Context, documentID string, allowed func(documentAccess) bool, message string) bool {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	access, err := api.getDocumentAccess(c, auth, documentID)
+	if err == sql.ErrNoRows || (err == nil && !access.CanRead) {
+		appErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return false
+	} else if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		appErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return false
+	} else if !allowed(access) {
+		appErrorPage(c, http.StatusUnauthorized, message)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+)
+
+// progressStrategy determines which device's progress is the current progress
+// of a document when devices disagree.
+type progressStrategy string
+
+const (
+	progressNewest   progressStrategy = "newest"
+	progressFurthest progressStrategy = "furthest"
+)
+
+func (s progressStrategy) valid() bool {
+	return s == progressNewest || s == progressFurthest
+}
+
+// progressConflictError is returned when an update loses against the current
+// progress under the users strategy (i.e. an older timestamp with "newest", or
+// a lower percentage with "furthest").
+type progressConflictError struct {
+	Current database.GetDocumentProgressRow
+}
+
+func (e *progressConflictError) Error() string {
+	return fmt.Sprintf("progress conflicts with current progress of device %s", e.Current.DeviceID)
+}
+
+// updateProgress records a device's document progress at the provided time.
+// Conflicting updates aren't recorded and return a *progressConflictError
+// containing the current progress.
+func (api *API) updateProgress(ctx context.Context, userID, deviceID, documentID string, percentage float64, progress string, timestamp time.Time) (*database.DocumentProgress, error) {
+	user, err := api.db.Queries.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUser DB Error: %w", err)
+	}
+
+	// Conflict Detection & Update Are Atomic
+	tx, err := api.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Transaction Begin DB Error: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := api.db.Queries.WithTx(tx)
+
+	// Detect Conflict
+	var previous float64
+	current, err := qtx.GetDocumentProgress(ctx, database.GetDocumentProgressParams{
+		UserID:     userID,
+		DocumentID: documentID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("GetDocumentProgress DB Error: %w", err)
+	} else if err == nil {
+		previous = current.Percentage
+		currentTime, _ := time.Parse(time.RFC3339, current.CreatedAt)
+
+		switch progressStrategy(user.ProgressStrategy) {
+		case progressFurthest:
+			if percentage < current.Percentage {
+				log.Warnf("Rejecting progress of %s for %s: %.4f behind %.4f", deviceID, documentID, percentage, current.Percentage)
+				return nil, &progressConflictError{Current: current}
+			}
+		default:
+			if timestamp.Before(currentTime) {
+				log.Warnf("Rejecting progress of %s for %s: %s older than %s", deviceID, documentID, timestamp, currentTime)
+				return nil, &progressConflictError{Current: current}
+			}
+		}
+	}
+
+	// Create or Replace Progress
+	newProgress, err := qtx.UpdateProgress(ctx, database.UpdateProgressParams{
+		UserID:     userID,
+		DocumentID: documentID,
+		DeviceID:   deviceID,
+		Percentage: percentage,
+		Progress:   progress,
+		CreatedAt:  timestamp.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProgress DB Error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Transaction Commit DB Error: %w", err)
+	}
+
+	api.emitProgressEvents(ctx, previous, &newProgress)
+	return &newProgress, nil
+}
+
+// restoreProgress makes a previous position from the documents progress history
+// the current progress of the device that recorded it. Restores are explicit, so
+// they aren't subject to conflict detection.
+func (api *API) restoreProgress(ctx context.Context, userID, documentID string, historyID int64) (*database.DocumentProgress, error) {
+	entry, err := api.db.Queries.GetProgressHistoryEntry(ctx, database.GetProgressHistoryEntryParams{
+		ID:     historyID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetProgressHistoryEntry DB Error: %w", err)
+	} else if entry.DocumentID != documentID {
+		return nil, fmt.Errorf("GetProgressHistoryEntry DB Error: %w", sql.ErrNoRows)
+	}
+
+	var previous float64
+	current, err := api.db.Queries.GetDocumentProgress(ctx, database.GetDocumentProgressParams{
+		UserID:     userID,
+		DocumentID: documentID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("GetDocumentProgress DB Error: %w", err)
+	} else if err == nil {
+		previous = current.Percentage
+	}
+
+	progress, err := api.db.Queries.UpdateProgress(ctx, database.UpdateProgressParams{
+		UserID:     userID,
+		DocumentID: entry.DocumentID,
+		DeviceID:   entry.DeviceID,
+		Percentage: entry.Percentage,
+		Progress:   entry.Progress,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProgress DB Error: %w", err)
+	}
+
+	api.emitProgressEvents(ctx, previous, &progress)
+	return &progress, nil
+}
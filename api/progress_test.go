@@ -0,0 +1,147 @@
+package api
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func TestRestoreProgress(t *testing.T) {
+	api, server := newTestAPI(t, 2)
+	now := time.Now().Unix()
+
+	// Kindle Jumps Forward, Then Kobo Catches Up
+	for i, position := range []gin.H{
+		koPosition("kindle", 0.20, now-300),
+		koPosition("kindle", 0.90, now-200),
+		koPosition("kobo", 0.25, now-100),
+	} {
+		code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+		require.Equal(t, http.StatusOK, code, "position %d", i)
+	}
+
+	history, err := api.db.Queries.GetDocumentProgressHistory(t.Context(), database.GetDocumentProgressHistoryParams{
+		UserID:     "reader",
+		DocumentID: "document-00",
+		Limit:      50,
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "kindle", history[1].DeviceName)
+	assert.Equal(t, 90.0, history[1].Percentage)
+
+	// Restore Kindles Original Position
+	progress, err := api.restoreProgress(t.Context(), "reader", "document-00", history[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "kindle-id", progress.DeviceID)
+	assert.Equal(t, "/body/DocFragment[20]", progress.Progress)
+
+	_, body := koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+	assert.Equal(t, "kindle", body["device"])
+	assert.Equal(t, 0.20, body["percentage"])
+
+	// Restores Are Recorded
+	history, err = api.db.Queries.GetDocumentProgressHistory(t.Context(), database.GetDocumentProgressHistoryParams{
+		UserID:     "reader",
+		DocumentID: "document-00",
+		Limit:      50,
+	})
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+
+	// Entry Of Another Document
+	_, err = api.restoreProgress(t.Context(), "reader", "document-01", history[0].ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestProgressPositionTranslation(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	api.cfg.CookieSecure = false
+
+	// Create EPUB - Chapter Two Paragraphs
+	documentsPath := filepath.Join(api.cfg.DataPath, "documents")
+	require.NoError(t, os.MkdirAll(documentsPath, 0755))
+	f, err := os.Create(filepath.Join(documentsPath, "document-00.epub"))
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`,
+		"content.opf": `<package><metadata/><manifest>
+			<item id="one" href="one.xhtml" media-type="application/xhtml+xml"/>
+			<item id="two" href="two.xhtml" media-type="application/xhtml+xml"/>
+		</manifest><spine><itemref idref="one"/><itemref idref="two"/></spine></package>`,
+		"one.xhtml": `<html><head/><body><p>One</p></body></html>`,
+		"two.xhtml": `<html><head/><body><p>Two</p><p>Three</p></body></html>`,
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	// CFI Stored As XPointer
+	position := koPosition("browser", 0.75, 0)
+	position["progress"] = "epubcfi(/6/4[two]!/4/4/1:2)"
+	code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+	require.Equal(t, http.StatusOK, code)
+
+	_, body := koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+	assert.Equal(t, "/body/DocFragment[2]/body/p[2]", body["progress"])
+
+	// XPointer Translated For Web Reader
+	position = koPosition("kindle", 0.5, 0)
+	position["progress"] = "/body/DocFragment[2]/body/p/text().0"
+	code, _ = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+	require.Equal(t, http.StatusOK, code)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Get(server.URL + "/reader/progress/document-00")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var progress map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&progress))
+	assert.Equal(t, "/body/DocFragment[2]/body/p/text().0", progress["progress"])
+	assert.Equal(t, "epubcfi(/6/4[two]!/4/2)", progress["cfi"])
+
+	// Inaccessible Document - CFI Not Translated
+	require.NoError(t, api.createUser(t.Context(), "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+	_, err = api.db.Queries.UpsertDocument(t.Context(), database.UpsertDocumentParams{ID: "document-00", OwnerID: ptr.Of("other")})
+	require.NoError(t, err)
+	_, err = api.db.Queries.UpdateDocumentVisibility(t.Context(), database.UpdateDocumentVisibilityParams{
+		Visibility: string(visibilityPrivate),
+		DocumentID: "document-00",
+	})
+	require.NoError(t, err)
+
+	position = koPosition("browser", 0.80, 0)
+	position["progress"] = "epubcfi(/6/4[two]!/4/4/1:2)"
+	code, _ = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", position)
+	require.Equal(t, http.StatusOK, code)
+
+	_, body = koRequest(t, server, http.MethodGet, "/api/ko/syncs/progress/document-00", nil)
+	assert.Equal(t, "epubcfi(/6/4[two]!/4/4/1:2)", body["progress"])
+}
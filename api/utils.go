@@ -11,6 +11,7 @@ import (
 	"reichard.io/antholume/database"
 	"reichard.io/antholume/graph"
 	"reichard.io/antholume/metadata"
+	"reichard.io/antholume/pkg/ptr"
 )
 
 // getTimeZones returns a string slice of IANA timezones.
@@ -105,6 +106,50 @@ func niceNumbers(input int64) string {
 	}
 }
 
+// niceUserAgent takes in a user agent and returns a short readable browser &
+// platform description. For example "Firefox on Linux".
+func niceUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown"
+	}
+
+	// Ordered - More Specific First
+	browsers := [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	platforms := [][2]string{
+		{"Kindle", "Kindle"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"CrOS", "ChromeOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	browser := "Unknown Browser"
+	for _, item := range browsers {
+		if strings.Contains(userAgent, item[0]) {
+			browser = item[1]
+			break
+		}
+	}
+
+	for _, item := range platforms {
+		if strings.Contains(userAgent, item[0]) {
+			return fmt.Sprintf("%s on %s", browser, item[1])
+		}
+	}
+
+	return browser
+}
+
 // getSVGGraphData builds SVGGraphData from the provided stats, width and height.
 // It is used exclusively in templates to generate the daily read stats graph.
 func getSVGGraphData(inputData []database.GetDailyReadStatsRow, svgWidth int, svgHeight int) graph.SVGGraphData {
@@ -159,12 +204,12 @@ func slice(elements ...any) []any {
 func deriveBaseFileName(metadataInfo *metadata.MetadataInfo) string {
 	// Derive New FileName
 	var newFileName string
-	if *metadataInfo.Author != "" {
+	if ptr.Deref(metadataInfo.Author) != "" {
 		newFileName = newFileName + *metadataInfo.Author
 	} else {
 		newFileName = newFileName + "Unknown"
 	}
-	if *metadataInfo.Title != "" {
+	if ptr.Deref(metadataInfo.Title) != "" {
 		newFileName = newFileName + " - " + *metadataInfo.Title
 	} else {
 		newFileName = newFileName + " - Unknown"
@@ -176,7 +221,7 @@ func deriveBaseFileName(metadataInfo *metadata.MetadataInfo) string {
 }
 
 // importStatusPriority returns the order priority for import status in the UI.
-func importStatusPriority(status importStatus) int {
+func importStatusPriority(status ImportStatus) int {
 	switch status {
 	case importFailed:
 		return 1
@@ -33,3 +33,15 @@ func TestNiceNumbers(t *testing.T) {
 	assert.Equal(t, wantThousandsTwo, niceThousandsTwo, "should be nice thousands")
 	assert.Equal(t, wantZero, niceZero, "should be nice zero")
 }
+
+func TestNiceUserAgent(t *testing.T) {
+	firefoxLinux := "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0"
+	chromeAndroid := "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Mobile Safari/537.36"
+	safariIOS := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.6 Mobile/15E148 Safari/604.1"
+
+	assert.Equal(t, "Firefox on Linux", niceUserAgent(firefoxLinux), "should be firefox on linux")
+	assert.Equal(t, "Chrome on Android", niceUserAgent(chromeAndroid), "should be chrome on android")
+	assert.Equal(t, "Safari on iOS", niceUserAgent(safariIOS), "should be safari on ios")
+	assert.Equal(t, "curl", niceUserAgent("curl/8.9.1"), "should be curl")
+	assert.Equal(t, "Unknown", niceUserAgent(""), "should be unknown")
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+// openAPIGenerator builds an OpenAPI 3.0 spec from the v1 route definitions,
+// collecting referenced structs as component schemas.
+type openAPIGenerator struct {
+	schemas gin.H
+}
+
+func newOpenAPISpec(version string, routes []v1Route) gin.H {
+	g := &openAPIGenerator{schemas: gin.H{}}
+	errorResponse := func(description string) gin.H {
+		return gin.H{
+			"description": description,
+			"content": gin.H{
+				"application/json": gin.H{"schema": g.schema(reflect.TypeOf(v1Error{}))},
+			},
+		}
+	}
+
+	paths := gin.H{}
+	for _, route := range routes {
+		// Path Parameters (:document -> {document})
+		var parameters []gin.H
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if name, found := strings.CutPrefix(segment, ":"); found {
+				segments[i] = "{" + name + "}"
+				parameters = append(parameters, gin.H{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   gin.H{"type": "string"},
+				})
+			}
+		}
+		path := strings.Join(segments, "/")
+
+		// Query Parameters
+		if route.Query != nil {
+			for _, field := range reflect.VisibleFields(reflect.TypeOf(route.Query)) {
+				name := field.Tag.Get("form")
+				if name == "" || name == "-" {
+					continue
+				}
+				// Optional, Not Nullable
+				schema := g.schema(field.Type)
+				delete(schema, "nullable")
+
+				parameter := gin.H{
+					"name":   name,
+					"in":     "query",
+					"schema": schema,
+				}
+				if doc := field.Tag.Get("doc"); doc != "" {
+					parameter["description"] = doc
+				}
+				parameters = append(parameters, parameter)
+			}
+		}
+
+		operation := gin.H{
+			"operationId": route.OperationID,
+			"summary":     route.Summary,
+			"tags":        []string{route.Tag},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.Permission != "" {
+			operation["description"] = "Requires the `" + string(route.Permission) + "` permission."
+		}
+
+		// Request Body
+		if route.Body != nil {
+			operation["requestBody"] = gin.H{
+				"required": true,
+				"content": gin.H{
+					"application/json": gin.H{"schema": g.schema(reflect.TypeOf(route.Body))},
+				},
+			}
+		} else if route.Form != nil {
+			operation["requestBody"] = gin.H{
+				"required": true,
+				"content": gin.H{
+					"multipart/form-data": gin.H{"schema": g.objectSchema(reflect.TypeOf(route.Form), "form")},
+				},
+			}
+		}
+
+		// Responses
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := gin.H{"description": http.StatusText(status)}
+		if route.Response != nil {
+			response["content"] = gin.H{
+				"application/json": gin.H{"schema": g.schema(reflect.TypeOf(route.Response))},
+			}
+		}
+		responses := gin.H{
+			strconv.Itoa(status):                  response,
+			strconv.Itoa(http.StatusUnauthorized): errorResponse(http.StatusText(http.StatusUnauthorized)),
+			"default":                             errorResponse("Error"),
+		}
+		if route.Permission != "" {
+			responses[strconv.Itoa(http.StatusForbidden)] = errorResponse(http.StatusText(http.StatusForbidden))
+		}
+		operation["responses"] = responses
+
+		if _, ok := paths[path]; !ok {
+			paths[path] = gin.H{}
+		}
+		paths[path].(gin.H)[strings.ToLower(route.Method)] = operation
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "AnthoLume API",
+			"version": version,
+		},
+		"servers":  []gin.H{{"url": "/api/v1"}},
+		"security": []gin.H{{"bearerAuth": []string{}}},
+		"paths":    paths,
+		"components": gin.H{
+			"schemas": g.schemas,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+			},
+		},
+	}
+}
+
+// schema returns the schema of a type, referencing structs as components.
+func (g *openAPIGenerator) schema(t reflect.Type) gin.H {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema gin.H
+	switch {
+	case t == fileHeaderType:
+		schema = gin.H{"type": "string", "format": "binary"}
+	case t.Kind() == reflect.String:
+		schema = gin.H{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		schema = gin.H{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = gin.H{"type": "integer", "format": "int64"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = gin.H{"type": "number", "format": "double"}
+	case t.Kind() == reflect.Slice:
+		schema = gin.H{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "v1")
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = gin.H{} // Recursion Guard
+			g.schemas[name] = g.objectSchema(t, "json")
+		}
+		return gin.H{"$ref": "#/components/schemas/" + name}
+	default:
+		schema = gin.H{}
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+// objectSchema returns an inline object schema using the given field tag
+// (json or form) for property names. Non pointer fields are required.
+func (g *openAPIGenerator) objectSchema(t reflect.Type, tagName string) gin.H {
+	properties := gin.H{}
+	required := []string{}
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		property := g.schema(field.Type)
+		if tagName == "form" {
+			delete(property, "nullable")
+		}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			if _, isRef := property["$ref"]; isRef {
+				property = gin.H{"allOf": []gin.H{property}}
+			}
+			property["description"] = doc
+		}
+		properties[name] = property
+
+		if field.Type.Kind() != reflect.Pointer || strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
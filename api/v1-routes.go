@@ -0,0 +1,872 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+// Page sizes for v1 list endpoints.
+const (
+	v1DefaultLimit int64 = 25
+	v1MaxLimit     int64 = 100
+)
+
+// v1Route describes a single /api/v1 endpoint. Routes are registered and
+// documented (OpenAPI) from the same definition, so the request & response
+// types here must match what the handler binds and returns.
+type v1Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tag         string
+	Permission  permission
+	Query       any
+	Body        any
+	Form        any
+	Status      int
+	Response    any
+	Handler     gin.HandlerFunc
+}
+
+type v1Error struct {
+	Error string `json:"error"`
+}
+
+type v1DocumentsQuery struct {
+	Page   *int64  `form:"page" doc:"Page number, starting at 1"`
+	Limit  *int64  `form:"limit" doc:"Page size (default 25, max 100)"`
+	Search *string `form:"search" doc:"Title or author search"`
+}
+
+type v1ListQuery struct {
+	Page     *int64  `form:"page" doc:"Page number, starting at 1"`
+	Limit    *int64  `form:"limit" doc:"Page size (default 25, max 100)"`
+	Document *string `form:"document" doc:"Only include this document"`
+}
+
+type v1DocumentUpload struct {
+	DocumentFile *multipart.FileHeader `form:"document_file" binding:"required" doc:"EPUB document"`
+}
+
+type v1DocumentEdit struct {
+	Title       *string `json:"title"`
+	Author      *string `json:"author"`
+	Description *string `json:"description"`
+	ISBN10      *string `json:"isbn10"`
+	ISBN13      *string `json:"isbn13"`
+}
+
+type v1DeviceEdit struct {
+	Name *string `json:"name"`
+	Sync *bool   `json:"sync" doc:"Whether the device may sync progress & activity"`
+}
+
+type v1SettingsEdit struct {
+	Timezone         *string `json:"timezone"`
+	ProgressStrategy *string `json:"progress_strategy" doc:"newest or furthest"`
+}
+
+type v1Document struct {
+	ID               string  `json:"id"`
+	Title            *string `json:"title"`
+	Author           *string `json:"author"`
+	Description      *string `json:"description"`
+	ISBN10           *string `json:"isbn10"`
+	ISBN13           *string `json:"isbn13"`
+	Series           *string `json:"series"`
+	SeriesIndex      *int64  `json:"series_index"`
+	Language         *string `json:"lang"`
+	Words            *int64  `json:"words"`
+	OwnerID          *string `json:"owner_id"`
+	Visibility       string  `json:"visibility"`
+	Percentage       float64 `json:"percentage" doc:"Current progress (0 - 100)"`
+	ReadPercentage   float64 `json:"read_percentage" doc:"Portion of the document covered by activity (0 - 100)"`
+	WPM              int64   `json:"wpm"`
+	TotalTimeSeconds int64   `json:"total_time_seconds"`
+	TimeLeftSeconds  int64   `json:"time_left_seconds"`
+	LastRead         *string `json:"last_read"`
+}
+
+type v1Documents struct {
+	Documents []v1Document `json:"documents"`
+	Page      int64        `json:"page"`
+	Limit     int64        `json:"limit"`
+	Total     int64        `json:"total"`
+}
+
+type v1Progress struct {
+	DocumentID string  `json:"document_id"`
+	Title      *string `json:"title"`
+	Author     *string `json:"author"`
+	DeviceName string  `json:"device_name"`
+	Percentage float64 `json:"percentage" doc:"Progress (0 - 100)"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type v1ProgressList struct {
+	Progress []v1Progress `json:"progress"`
+	Page     int64        `json:"page"`
+	Limit    int64        `json:"limit"`
+}
+
+type v1Activity struct {
+	DocumentID      string  `json:"document_id"`
+	DeviceID        string  `json:"device_id"`
+	Title           *string `json:"title"`
+	Author          *string `json:"author"`
+	StartTime       string  `json:"start_time"`
+	Duration        int64   `json:"duration" doc:"Seconds"`
+	StartPercentage float64 `json:"start_percentage"`
+	EndPercentage   float64 `json:"end_percentage"`
+	ReadPercentage  float64 `json:"read_percentage"`
+}
+
+type v1ActivityList struct {
+	Activity []v1Activity `json:"activity"`
+	Page     int64        `json:"page"`
+	Limit    int64        `json:"limit"`
+}
+
+type v1ReadingStatistics struct {
+	WordsRead int64   `json:"words_read"`
+	Seconds   int64   `json:"seconds"`
+	WPM       float64 `json:"wpm"`
+}
+
+type v1DailyStatistics struct {
+	Date        string `json:"date"`
+	MinutesRead int64  `json:"minutes_read"`
+}
+
+type v1Statistics struct {
+	Documents int64               `json:"documents"`
+	Activity  int64               `json:"activity"`
+	Progress  int64               `json:"progress"`
+	Devices   int64               `json:"devices"`
+	Total     v1ReadingStatistics `json:"total"`
+	Yearly    v1ReadingStatistics `json:"yearly"`
+	Monthly   v1ReadingStatistics `json:"monthly"`
+	Weekly    v1ReadingStatistics `json:"weekly"`
+	Daily     []v1DailyStatistics `json:"daily" doc:"Minutes read per day over the last 30 days"`
+}
+
+type v1Streak struct {
+	Window                 string `json:"window" doc:"DAY or WEEK"`
+	CurrentStreak          int64  `json:"current_streak"`
+	CurrentStreakStartDate string `json:"current_streak_start_date"`
+	CurrentStreakEndDate   string `json:"current_streak_end_date"`
+	MaxStreak              int64  `json:"max_streak"`
+	MaxStreakStartDate     string `json:"max_streak_start_date"`
+	MaxStreakEndDate       string `json:"max_streak_end_date"`
+}
+
+type v1Streaks struct {
+	Streaks []v1Streak `json:"streaks"`
+}
+
+type v1Device struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Sync       bool    `json:"sync"`
+	LastSynced *string `json:"last_synced"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type v1Devices struct {
+	Devices []v1Device `json:"devices"`
+}
+
+type v1Settings struct {
+	Username         string   `json:"username"`
+	Admin            bool     `json:"admin"`
+	Role             string   `json:"role"`
+	Permissions      []string `json:"permissions"`
+	Timezone         string   `json:"timezone"`
+	ProgressStrategy string   `json:"progress_strategy"`
+}
+
+type requestDeviceID struct {
+	DeviceID string `uri:"device" binding:"required"`
+}
+
+func (api *API) v1Routes() []v1Route {
+	return []v1Route{
+		{Method: http.MethodGet, Path: "/documents", OperationID: "listDocuments", Summary: "List & search documents", Tag: "Documents", Query: v1DocumentsQuery{}, Response: v1Documents{}, Handler: api.v1GetDocuments},
+		{Method: http.MethodPost, Path: "/documents", OperationID: "uploadDocument", Summary: "Upload a document", Tag: "Documents", Permission: permUpload, Form: v1DocumentUpload{}, Status: http.StatusCreated, Response: v1Document{}, Handler: api.v1UploadDocument},
+		{Method: http.MethodGet, Path: "/documents/:document", OperationID: "getDocument", Summary: "Get a document", Tag: "Documents", Response: v1Document{}, Handler: api.v1GetDocument},
+		{Method: http.MethodPatch, Path: "/documents/:document", OperationID: "editDocument", Summary: "Edit document metadata", Tag: "Documents", Permission: permEdit, Body: v1DocumentEdit{}, Response: v1Document{}, Handler: api.v1EditDocument},
+		{Method: http.MethodDelete, Path: "/documents/:document", OperationID: "deleteDocument", Summary: "Delete a document", Tag: "Documents", Permission: permDelete, Status: http.StatusNoContent, Handler: api.v1DeleteDocument},
+		{Method: http.MethodGet, Path: "/progress", OperationID: "listProgress", Summary: "List progress", Tag: "Reading", Query: v1ListQuery{}, Response: v1ProgressList{}, Handler: api.v1GetProgress},
+		{Method: http.MethodGet, Path: "/activity", OperationID: "listActivity", Summary: "List activity", Tag: "Reading", Query: v1ListQuery{}, Response: v1ActivityList{}, Handler: api.v1GetActivity},
+		{Method: http.MethodGet, Path: "/statistics", OperationID: "getStatistics", Summary: "Get reading statistics", Tag: "Reading", Response: v1Statistics{}, Handler: api.v1GetStatistics},
+		{Method: http.MethodGet, Path: "/streaks", OperationID: "getStreaks", Summary: "Get reading streaks", Tag: "Reading", Response: v1Streaks{}, Handler: api.v1GetStreaks},
+		{Method: http.MethodGet, Path: "/devices", OperationID: "listDevices", Summary: "List devices", Tag: "Devices", Response: v1Devices{}, Handler: api.v1GetDevices},
+		{Method: http.MethodPatch, Path: "/devices/:device", OperationID: "editDevice", Summary: "Rename a device or toggle its sync", Tag: "Devices", Body: v1DeviceEdit{}, Response: v1Device{}, Handler: api.v1EditDevice},
+		{Method: http.MethodDelete, Path: "/devices/:device", OperationID: "deleteDevice", Summary: "Delete a device with its activity & progress", Tag: "Devices", Status: http.StatusNoContent, Handler: api.v1DeleteDevice},
+		{Method: http.MethodGet, Path: "/settings", OperationID: "getSettings", Summary: "Get user settings", Tag: "Settings", Response: v1Settings{}, Handler: api.v1GetSettings},
+		{Method: http.MethodPatch, Path: "/settings", OperationID: "editSettings", Summary: "Edit user settings", Tag: "Settings", Body: v1SettingsEdit{}, Response: v1Settings{}, Handler: api.v1EditSettings},
+	}
+}
+
+func (api *API) registerV1Routes(apiGroup *gin.RouterGroup) {
+	v1Group := apiGroup.Group("/v1")
+
+	routes := api.v1Routes()
+	spec := newOpenAPISpec(api.cfg.Version, routes)
+	v1Group.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+
+	for _, route := range routes {
+		handlers := []gin.HandlerFunc{api.authAPIMiddleware}
+		if route.Permission != "" {
+			handlers = append(handlers, api.authPermissionMiddleware(route.Permission, apiErrorPage))
+		}
+		if api.cfg.DemoMode && route.Method != http.MethodGet {
+			handlers = append(handlers, api.koDemoModeJSONError)
+		} else {
+			handlers = append(handlers, route.Handler)
+		}
+		v1Group.Handle(route.Method, route.Path, handlers...)
+	}
+}
+
+func (api *API) v1GetDocuments(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rQuery v1DocumentsQuery
+	if err := c.ShouldBindQuery(&rQuery); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	page, limit := v1Page(rQuery.Page, rQuery.Limit)
+
+	var query *string
+	if rQuery.Search != nil && *rQuery.Search != "" {
+		search := "%" + *rQuery.Search + "%"
+		query = &search
+	}
+
+	documents, err := api.db.Queries.GetDocumentsWithStats(c, database.GetDocumentsWithStatsParams{
+		UserID:  auth.UserName,
+		Query:   query,
+		Deleted: ptr.Of(false),
+		Offset:  (page - 1) * limit,
+		Limit:   limit,
+	})
+	if err != nil {
+		log.Error("GetDocumentsWithStats DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentsWithStats DB Error: %v", err))
+		return
+	}
+
+	total, err := api.db.Queries.GetDocumentsSize(c, database.GetDocumentsSizeParams{
+		UserID: auth.UserName,
+		Query:  query,
+	})
+	if err != nil {
+		log.Error("GetDocumentsSize DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentsSize DB Error: %v", err))
+		return
+	}
+
+	if err = api.getDocumentsWordCount(c, documents); err != nil {
+		log.Error("Unable to Get Word Counts: ", err)
+	}
+
+	response := v1Documents{
+		Documents: make([]v1Document, 0, len(documents)),
+		Page:      page,
+		Limit:     limit,
+		Total:     total,
+	}
+	for _, document := range documents {
+		response.Documents = append(response.Documents, newV1Document(document))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1GetDocument(c *gin.Context) {
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	}
+
+	api.v1DocumentResponse(c, http.StatusOK, rDocID.DocumentID)
+}
+
+func (api *API) v1UploadDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDocUpload v1DocumentUpload
+	if err := c.ShouldBind(&rDocUpload); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Missing document_file")
+		return
+	}
+
+	documentID, err := api.storeUploadedDocument(c, rDocUpload.DocumentFile, auth)
+	if errors.Is(err, errDocumentExists) {
+		apiErrorPage(c, http.StatusConflict, "Document already exists")
+		return
+	} else if err != nil {
+		log.Error("Store Document Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("Unable to save document: %v", err))
+		return
+	}
+
+	api.v1DocumentResponse(c, http.StatusCreated, documentID)
+}
+
+func (api *API) v1EditDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	}
+
+	// Validate Access
+	access, err := api.getDocumentAccess(c, auth, rDocID.DocumentID)
+	if err == sql.ErrNoRows || (err == nil && !access.CanRead) {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	} else if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return
+	} else if !access.CanEdit {
+		apiErrorPage(c, http.StatusForbidden, "Only the document owner can edit this document")
+		return
+	}
+
+	var rDocEdit v1DocumentEdit
+	if err := c.ShouldBindJSON(&rDocEdit); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+
+	if _, err := api.db.Queries.UpsertDocument(c, database.UpsertDocumentParams{
+		ID:          rDocID.DocumentID,
+		Title:       api.sanitizeInput(rDocEdit.Title),
+		Author:      api.sanitizeInput(rDocEdit.Author),
+		Description: api.sanitizeInput(rDocEdit.Description),
+		Isbn10:      api.sanitizeInput(rDocEdit.ISBN10),
+		Isbn13:      api.sanitizeInput(rDocEdit.ISBN13),
+	}); err != nil {
+		log.Error("UpsertDocument DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpsertDocument DB Error: %v", err))
+		return
+	}
+
+	api.v1DocumentResponse(c, http.StatusOK, rDocID.DocumentID)
+}
+
+func (api *API) v1DeleteDocument(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDocID requestDocumentID
+	if err := c.ShouldBindUri(&rDocID); err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	}
+
+	// Validate Access
+	access, err := api.getDocumentAccess(c, auth, rDocID.DocumentID)
+	if err == sql.ErrNoRows || (err == nil && !access.CanRead) {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	} else if err != nil {
+		log.Error("GetDocumentAccess DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocumentAccess DB Error: %v", err))
+		return
+	} else if !access.CanDelete {
+		apiErrorPage(c, http.StatusForbidden, "Only the document owner can delete this document")
+		return
+	}
+
+	changed, err := api.db.Queries.DeleteDocument(c, rDocID.DocumentID)
+	if err != nil {
+		log.Error("DeleteDocument DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("DeleteDocument DB Error: %v", err))
+		return
+	}
+	if changed == 0 {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Document")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (api *API) v1GetProgress(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rQuery v1ListQuery
+	if err := c.ShouldBindQuery(&rQuery); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	page, limit := v1Page(rQuery.Page, rQuery.Limit)
+
+	progressFilter := database.GetProgressParams{
+		UserID: auth.UserName,
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if rQuery.Document != nil {
+		progressFilter.DocFilter = true
+		progressFilter.DocumentID = *rQuery.Document
+	}
+
+	progress, err := api.db.Queries.GetProgress(c, progressFilter)
+	if err != nil {
+		log.Error("GetProgress DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetProgress DB Error: %v", err))
+		return
+	}
+
+	response := v1ProgressList{
+		Progress: make([]v1Progress, 0, len(progress)),
+		Page:     page,
+		Limit:    limit,
+	}
+	for _, item := range progress {
+		response.Progress = append(response.Progress, v1Progress{
+			DocumentID: item.DocumentID,
+			Title:      item.Title,
+			Author:     item.Author,
+			DeviceName: item.DeviceName,
+			Percentage: item.Percentage,
+			CreatedAt:  fmt.Sprint(item.CreatedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1GetActivity(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rQuery v1ListQuery
+	if err := c.ShouldBindQuery(&rQuery); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	page, limit := v1Page(rQuery.Page, rQuery.Limit)
+
+	activityFilter := database.GetActivityParams{
+		UserID: auth.UserName,
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if rQuery.Document != nil {
+		activityFilter.DocFilter = true
+		activityFilter.DocumentID = *rQuery.Document
+	}
+
+	activity, err := api.db.Queries.GetActivity(c, activityFilter)
+	if err != nil {
+		log.Error("GetActivity DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetActivity DB Error: %v", err))
+		return
+	}
+
+	response := v1ActivityList{
+		Activity: make([]v1Activity, 0, len(activity)),
+		Page:     page,
+		Limit:    limit,
+	}
+	for _, item := range activity {
+		response.Activity = append(response.Activity, v1Activity{
+			DocumentID:      item.DocumentID,
+			DeviceID:        item.DeviceID,
+			Title:           item.Title,
+			Author:          item.Author,
+			StartTime:       fmt.Sprint(item.StartTime),
+			Duration:        item.Duration,
+			StartPercentage: item.StartPercentage,
+			EndPercentage:   item.EndPercentage,
+			ReadPercentage:  item.ReadPercentage,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1GetStatistics(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	databaseInfo, err := api.db.Queries.GetDatabaseInfo(c, auth.UserName)
+	if err != nil {
+		log.Error("GetDatabaseInfo DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDatabaseInfo DB Error: %v", err))
+		return
+	}
+
+	dailyStats, err := api.db.Queries.GetDailyReadStats(c, auth.UserName)
+	if err != nil {
+		log.Error("GetDailyReadStats DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDailyReadStats DB Error: %v", err))
+		return
+	}
+
+	userStatistics, err := api.db.Queries.GetUserStatistics(c)
+	if err != nil {
+		log.Error("GetUserStatistics DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUserStatistics DB Error: %v", err))
+		return
+	}
+
+	response := v1Statistics{
+		Documents: databaseInfo.DocumentsSize,
+		Activity:  databaseInfo.ActivitySize,
+		Progress:  databaseInfo.ProgressSize,
+		Devices:   databaseInfo.DevicesSize,
+		Daily:     make([]v1DailyStatistics, 0, len(dailyStats)),
+	}
+	for _, item := range dailyStats {
+		response.Daily = append(response.Daily, v1DailyStatistics{
+			Date:        item.Date,
+			MinutesRead: item.MinutesRead,
+		})
+	}
+
+	// Only Users With Words Read Are Listed
+	if i := slices.IndexFunc(userStatistics, func(s database.GetUserStatisticsRow) bool {
+		return s.UserID == auth.UserName
+	}); i >= 0 {
+		stats := userStatistics[i]
+		response.Total = v1ReadingStatistics{WordsRead: stats.TotalWordsRead, Seconds: stats.TotalSeconds, WPM: stats.TotalWpm}
+		response.Yearly = v1ReadingStatistics{WordsRead: stats.YearlyWordsRead, Seconds: stats.YearlySeconds, WPM: stats.YearlyWpm}
+		response.Monthly = v1ReadingStatistics{WordsRead: stats.MonthlyWordsRead, Seconds: stats.MonthlySeconds, WPM: stats.MonthlyWpm}
+		response.Weekly = v1ReadingStatistics{WordsRead: stats.WeeklyWordsRead, Seconds: stats.WeeklySeconds, WPM: stats.WeeklyWpm}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1GetStreaks(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	streaks, err := api.db.Queries.GetUserStreaks(c, auth.UserName)
+	if err != nil {
+		log.Error("GetUserStreaks DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUserStreaks DB Error: %v", err))
+		return
+	}
+
+	response := v1Streaks{Streaks: make([]v1Streak, 0, len(streaks))}
+	for _, streak := range streaks {
+		response.Streaks = append(response.Streaks, v1Streak{
+			Window:                 streak.Window,
+			CurrentStreak:          streak.CurrentStreak,
+			CurrentStreakStartDate: streak.CurrentStreakStartDate,
+			CurrentStreakEndDate:   streak.CurrentStreakEndDate,
+			MaxStreak:              streak.MaxStreak,
+			MaxStreakStartDate:     streak.MaxStreakStartDate,
+			MaxStreakEndDate:       streak.MaxStreakEndDate,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1GetDevices(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	devices, err := api.db.Queries.GetDevices(c, auth.UserName)
+	if err != nil {
+		log.Error("GetDevices DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDevices DB Error: %v", err))
+		return
+	}
+
+	response := v1Devices{Devices: make([]v1Device, 0, len(devices))}
+	for _, device := range devices {
+		response.Devices = append(response.Devices, newV1Device(device))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (api *API) v1EditDevice(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDeviceID requestDeviceID
+	if err := c.ShouldBindUri(&rDeviceID); err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Device")
+		return
+	}
+
+	var rDeviceEdit v1DeviceEdit
+	if err := c.ShouldBindJSON(&rDeviceEdit); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+
+	if rDeviceEdit.Name != nil {
+		if name := strings.TrimSpace(*rDeviceEdit.Name); name != "" {
+			rDeviceEdit.Name = &name
+		} else {
+			rDeviceEdit.Name = nil
+		}
+	}
+
+	_, err := api.db.Queries.UpdateDevice(c, database.UpdateDeviceParams{
+		ID:         rDeviceID.DeviceID,
+		UserID:     auth.UserName,
+		DeviceName: rDeviceEdit.Name,
+		Sync:       rDeviceEdit.Sync,
+	})
+	if err == sql.ErrNoRows {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Device")
+		return
+	} else if err != nil {
+		log.Error("UpdateDevice DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpdateDevice DB Error: %v", err))
+		return
+	}
+
+	devices, err := api.db.Queries.GetDevices(c, auth.UserName)
+	if err != nil {
+		log.Error("GetDevices DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDevices DB Error: %v", err))
+		return
+	}
+
+	i := slices.IndexFunc(devices, func(d database.GetDevicesRow) bool {
+		return d.ID == rDeviceID.DeviceID
+	})
+	if i < 0 {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Device")
+		return
+	}
+
+	c.JSON(http.StatusOK, newV1Device(devices[i]))
+}
+
+func (api *API) v1DeleteDevice(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rDeviceID requestDeviceID
+	if err := c.ShouldBindUri(&rDeviceID); err != nil {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Device")
+		return
+	}
+
+	changed, err := api.db.Queries.DeleteDevice(c, database.DeleteDeviceParams{
+		ID:     rDeviceID.DeviceID,
+		UserID: auth.UserName,
+	})
+	if err != nil {
+		log.Error("DeleteDevice DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("DeleteDevice DB Error: %v", err))
+		return
+	}
+	if changed == 0 {
+		apiErrorPage(c, http.StatusNotFound, "Unknown Device")
+		return
+	}
+
+	if err := api.CacheTempTables(c); err != nil {
+		log.Error("CacheTempTables DB Error: ", err)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (api *API) v1GetSettings(c *gin.Context) {
+	api.v1SettingsResponse(c)
+}
+
+func (api *API) v1EditSettings(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	var rSettingsEdit v1SettingsEdit
+	if err := c.ShouldBindJSON(&rSettingsEdit); err != nil {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+
+	// Validate Settings
+	if rSettingsEdit.Timezone != nil {
+		if _, err := time.LoadLocation(*rSettingsEdit.Timezone); err != nil || *rSettingsEdit.Timezone == "" {
+			apiErrorPage(c, http.StatusBadRequest, "Invalid Timezone")
+			return
+		}
+	}
+	if rSettingsEdit.ProgressStrategy != nil && !progressStrategy(*rSettingsEdit.ProgressStrategy).valid() {
+		apiErrorPage(c, http.StatusBadRequest, "Invalid Progress Strategy")
+		return
+	}
+
+	if _, err := api.db.Queries.UpdateUser(c, database.UpdateUserParams{
+		UserID:           auth.UserName,
+		Admin:            auth.IsAdmin,
+		Timezone:         rSettingsEdit.Timezone,
+		ProgressStrategy: rSettingsEdit.ProgressStrategy,
+	}); err != nil {
+		log.Error("UpdateUser DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("UpdateUser DB Error: %v", err))
+		return
+	}
+
+	api.v1SettingsResponse(c)
+}
+
+func (api *API) v1DocumentResponse(c *gin.Context, status int, documentID string) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	// Validate Access
+	if !api.documentReadable(c, documentID, apiErrorPage) {
+		return
+	}
+
+	document, err := api.db.GetDocument(c, documentID, auth.UserName)
+	if err != nil {
+		log.Error("GetDocument DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetDocument DB Error: %v", err))
+		return
+	}
+
+	c.JSON(status, newV1Document(*document))
+}
+
+func (api *API) v1SettingsResponse(c *gin.Context) {
+	var auth authData
+	if data, _ := c.Get("Authorization"); data != nil {
+		auth = data.(authData)
+	}
+
+	user, err := api.db.Queries.GetUser(c, auth.UserName)
+	if err != nil {
+		log.Error("GetUser DB Error: ", err)
+		apiErrorPage(c, http.StatusInternalServerError, fmt.Sprintf("GetUser DB Error: %v", err))
+		return
+	}
+
+	permissions := make([]string, 0, len(allPermissions))
+	for _, p := range allPermissions {
+		if auth.HasPermission(p) {
+			permissions = append(permissions, string(p))
+		}
+	}
+
+	c.JSON(http.StatusOK, v1Settings{
+		Username:         user.ID,
+		Admin:            user.Admin,
+		Role:             user.Role,
+		Permissions:      permissions,
+		Timezone:         *user.Timezone,
+		ProgressStrategy: user.ProgressStrategy,
+	})
+}
+
+// v1Page normalizes pagination query parameters.
+func v1Page(page, limit *int64) (int64, int64) {
+	rPage, rLimit := int64(1), v1DefaultLimit
+	if page != nil && *page > 1 {
+		rPage = *page
+	}
+	if limit != nil && *limit > 0 {
+		rLimit = min(*limit, v1MaxLimit)
+	}
+	return rPage, rLimit
+}
+
+func newV1Document(document database.GetDocumentsWithStatsRow) v1Document {
+	var lastRead *string
+	if document.LastRead != nil {
+		lastRead = ptr.Of(fmt.Sprint(document.LastRead))
+	}
+
+	return v1Document{
+		ID:               document.ID,
+		Title:            document.Title,
+		Author:           document.Author,
+		Description:      document.Description,
+		ISBN10:           document.Isbn10,
+		ISBN13:           document.Isbn13,
+		Series:           document.Series,
+		SeriesIndex:      document.SeriesIndex,
+		Language:         document.Lang,
+		Words:            document.Words,
+		OwnerID:          document.OwnerID,
+		Visibility:       document.Visibility,
+		Percentage:       document.Percentage,
+		ReadPercentage:   document.ReadPercentage,
+		WPM:              document.Wpm,
+		TotalTimeSeconds: document.TotalTimeSeconds,
+		TimeLeftSeconds:  int64((100.0 - document.Percentage) * float64(document.SecondsPerPercent)),
+		LastRead:         lastRead,
+	}
+}
+
+func newV1Device(device database.GetDevicesRow) v1Device {
+	var lastSynced *string
+	if device.LastSynced != nil {
+		lastSynced = ptr.Of(fmt.Sprint(device.LastSynced))
+	}
+
+	return v1Device{
+		ID:         device.ID,
+		Name:       device.DeviceName,
+		Sync:       device.Sync,
+		LastSynced: lastSynced,
+		CreatedAt:  fmt.Sprint(device.CreatedAt),
+	}
+}
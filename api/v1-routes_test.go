@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+func v1Request(t *testing.T, server *httptest.Server, token, method, path string, body any) (int, map[string]any) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, server.URL+"/api/v1"+path, &reqBody)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var respBody map[string]any
+	if resp.StatusCode != http.StatusNoContent {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&respBody))
+	}
+	return resp.StatusCode, respBody
+}
+
+func v1Upload(t *testing.T, server *httptest.Server, token string) (int, v1Document) {
+	var reqBody bytes.Buffer
+	writer := multipart.NewWriter(&reqBody)
+	part, err := writer.CreateFormFile("document_file", "alice.epub")
+	require.NoError(t, err)
+	epub, err := os.ReadFile("../_test_files/alice.epub")
+	require.NoError(t, err)
+	_, err = part.Write(epub)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/documents", &reqBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var document v1Document
+	if resp.StatusCode == http.StatusCreated {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&document))
+	}
+	return resp.StatusCode, document
+}
+
+func TestV1Auth(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+
+	code, _ := v1Request(t, server, "", http.MethodGet, "/documents", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = v1Request(t, server, apiTokenPrefix+"invalid", http.MethodGet, "/documents", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	token, err := api.createAPIToken(t.Context(), "reader", "Home Assistant")
+	require.NoError(t, err)
+	code, body := v1Request(t, server, token, http.MethodGet, "/settings", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "reader", body["username"])
+
+	tokens, err := api.db.Queries.GetAPITokens(t.Context(), "reader")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.NotNil(t, tokens[0].LastUsed)
+
+	t.Run("missing permission", func(t *testing.T) {
+		require.NoError(t, api.createUser(t.Context(), "guest", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleGuest)))
+		guestToken, err := api.createAPIToken(t.Context(), "guest", "test")
+		require.NoError(t, err)
+
+		code, body := v1Request(t, server, guestToken, http.MethodDelete, "/documents/document-00", nil)
+		assert.Equal(t, http.StatusForbidden, code, "should be forbidden with valid credentials")
+		assert.Equal(t, "Permission Required: delete", body["error"])
+	})
+
+	t.Run("settings page", func(t *testing.T) {
+		jar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+		client := &http.Client{Jar: jar}
+		resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		// Create - Shown Once
+		resp, err = client.PostForm(server.URL+"/settings", url.Values{"api_token_name": {"Mobile"}})
+		require.NoError(t, err)
+		page, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		newToken := regexp.MustCompile(apiTokenPrefix + `[0-9a-f]{64}`).FindString(string(page))
+		require.NotEmpty(t, newToken)
+
+		code, _ := v1Request(t, server, newToken, http.MethodGet, "/settings", nil)
+		assert.Equal(t, http.StatusOK, code)
+
+		// Revoke
+		tokens, err := api.db.Queries.GetAPITokens(t.Context(), "reader")
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		resp, err = client.PostForm(server.URL+"/settings/api-tokens", url.Values{"token": {"999"}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		for _, token := range tokens {
+			resp, err = client.PostForm(server.URL+"/settings/api-tokens", url.Values{"token": {fmt.Sprint(token.ID)}})
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		code, _ = v1Request(t, server, newToken, http.MethodGet, "/settings", nil)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
+
+func TestV1Routes(t *testing.T) {
+	api, server := newTestAPI(t, 3)
+	now := time.Now().Unix()
+
+	token, err := api.createAPIToken(t.Context(), "reader", "test")
+	require.NoError(t, err)
+
+	code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.25, now))
+	require.Equal(t, http.StatusOK, code)
+	code, _ = koRequest(t, server, http.MethodPost, "/api/ko/activity", gin.H{
+		"device":    "kindle",
+		"device_id": "kindle-id",
+		"activity":  []gin.H{koActivity(now-60, 30, 1, 10)},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.NoError(t, api.db.CacheTempTables(t.Context()))
+
+	t.Run("documents", func(t *testing.T) {
+		code, body := v1Request(t, server, token, http.MethodGet, "/documents?limit=2", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.EqualValues(t, 3, body["total"])
+		assert.Len(t, body["documents"], 2)
+
+		code, body = v1Request(t, server, token, http.MethodGet, "/documents?search=Title%2002", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.EqualValues(t, 1, body["total"])
+
+		code, body = v1Request(t, server, token, http.MethodGet, "/documents/document-00", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Title 00", body["title"])
+		assert.EqualValues(t, 25, body["percentage"])
+
+		// Edit - Owners Only
+		require.NoError(t, api.createUser(t.Context(), "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+		_, err := api.db.Queries.UpsertDocument(t.Context(), database.UpsertDocumentParams{ID: "document-02", OwnerID: ptr.Of("other")})
+		require.NoError(t, err)
+		code, _ = v1Request(t, server, token, http.MethodPatch, "/documents/document-02", gin.H{"title": "New Title"})
+		require.Equal(t, http.StatusForbidden, code)
+
+		// Edit - Unowned
+		code, body = v1Request(t, server, token, http.MethodPatch, "/documents/document-00", gin.H{"title": "New Title"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "New Title", body["title"])
+		assert.Equal(t, "Author", body["author"])
+
+		code, _ = v1Request(t, server, token, http.MethodDelete, "/documents/document-01", nil)
+		assert.Equal(t, http.StatusNoContent, code)
+		code, _ = v1Request(t, server, token, http.MethodGet, "/documents/unknown", nil)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(api.cfg.DataPath, "documents"), 0755))
+
+		code, document := v1Upload(t, server, token)
+		require.Equal(t, http.StatusCreated, code)
+		require.NotNil(t, document.OwnerID)
+		assert.Equal(t, "reader", *document.OwnerID)
+	})
+
+	t.Run("reading", func(t *testing.T) {
+		code, body := v1Request(t, server, token, http.MethodGet, "/progress?document=document-00", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, body["progress"], 1)
+
+		code, body = v1Request(t, server, token, http.MethodGet, "/activity", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, body["activity"], 1)
+
+		code, body = v1Request(t, server, token, http.MethodGet, "/statistics", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.EqualValues(t, 1, body["devices"])
+
+		code, _ = v1Request(t, server, token, http.MethodGet, "/streaks", nil)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("devices", func(t *testing.T) {
+		code, body := v1Request(t, server, token, http.MethodPatch, "/devices/kindle-id", gin.H{"name": "Kindle", "sync": false})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Kindle", body["name"])
+		assert.Equal(t, false, body["sync"])
+
+		code, _ = v1Request(t, server, token, http.MethodPatch, "/devices/unknown", gin.H{"name": "Unknown"})
+		assert.Equal(t, http.StatusNotFound, code)
+
+		code, _ = v1Request(t, server, token, http.MethodDelete, "/devices/kindle-id", nil)
+		assert.Equal(t, http.StatusNoContent, code)
+
+		code, body = v1Request(t, server, token, http.MethodGet, "/devices", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, body["devices"])
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		code, _ := v1Request(t, server, token, http.MethodPatch, "/settings", gin.H{"progress_strategy": "invalid"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = v1Request(t, server, token, http.MethodPatch, "/settings", gin.H{"timezone": "Invalid/Zone"})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, body := v1Request(t, server, token, http.MethodPatch, "/settings", gin.H{"timezone": "Europe/Berlin", "progress_strategy": "furthest"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Europe/Berlin", body["timezone"])
+		assert.Equal(t, "furthest", body["progress_strategy"])
+		assert.Contains(t, body["permissions"], "upload")
+	})
+}
+
+func TestV1UploadExistingDocument(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+	ctx := t.Context()
+	require.NoError(t, os.MkdirAll(filepath.Join(api.cfg.DataPath, "documents"), 0755))
+
+	require.NoError(t, api.createUser(ctx, "other", ptr.Of("pass"), ptr.Of(false), ptr.Of(roleUser)))
+	readerToken, err := api.createAPIToken(ctx, "reader", "test")
+	require.NoError(t, err)
+	otherToken, err := api.createAPIToken(ctx, "other", "test")
+	require.NoError(t, err)
+
+	// Private Reader Document
+	code, document := v1Upload(t, server, readerToken)
+	require.Equal(t, http.StatusCreated, code)
+	_, err = api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+		DocumentID: document.ID,
+		Visibility: string(visibilityPrivate),
+	})
+	require.NoError(t, err)
+	code, _ = v1Request(t, server, readerToken, http.MethodPatch, "/documents/"+document.ID, gin.H{"title": "Reader Title"})
+	require.Equal(t, http.StatusOK, code)
+
+	dbDocument, err := api.db.Queries.GetDocument(ctx, document.ID)
+	require.NoError(t, err)
+	filePath := filepath.Join(*dbDocument.Basepath, *dbDocument.Filepath)
+	require.NoError(t, os.WriteFile(filePath, []byte("reader file"), 0644))
+
+	assertUnchanged := func() {
+		dbDocument, err := api.db.Queries.GetDocument(ctx, document.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Reader Title", *dbDocument.Title, "should keep metadata")
+		assert.Equal(t, "reader", *dbDocument.OwnerID)
+		fileData, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "reader file", string(fileData), "should keep file")
+	}
+
+	// Hidden - Rejected
+	code, _ = v1Upload(t, server, otherToken)
+	assert.Equal(t, http.StatusConflict, code)
+	assertUnchanged()
+
+	// Visible - Existing Document
+	_, err = api.db.Queries.UpdateDocumentVisibility(ctx, database.UpdateDocumentVisibilityParams{
+		DocumentID: document.ID,
+		Visibility: string(visibilityPublic),
+	})
+	require.NoError(t, err)
+	code, existing := v1Upload(t, server, otherToken)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, document.ID, existing.ID)
+	assertUnchanged()
+}
+
+func TestV1OpenAPI(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+
+	code, spec := v1Request(t, server, "", http.MethodGet, "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	// Every Route Documented
+	paths := spec["paths"].(map[string]any)
+	for _, route := range api.v1Routes() {
+		path := regexp.MustCompile(`:(\w+)`).ReplaceAllString(route.Path, "{$1}")
+		require.Contains(t, paths, path)
+		assert.Contains(t, paths[path], strings.ToLower(route.Method))
+	}
+
+	// Referenced Schemas Exist
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	document := schemas["Document"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "nullable": true}, document["title"])
+	assert.Contains(t, schemas, "Documents")
+	assert.Contains(t, schemas, "Error")
+
+	upload := paths["/documents"].(map[string]any)["post"].(map[string]any)
+	assert.Contains(t, upload["requestBody"], "content")
+	assert.Equal(t, "Requires the `upload` permission.", upload["description"])
+	assert.Contains(t, upload["responses"], "401")
+	assert.Contains(t, upload["responses"], "403")
+	settings := paths["/settings"].(map[string]any)["get"].(map[string]any)
+	assert.Contains(t, settings["responses"], "401")
+	assert.NotContains(t, settings["responses"], "403", "should only be forbidden with a permission")
+}
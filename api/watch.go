@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/metadata"
+)
+
+const importLogSize = 50
+
+// watchedFile tracks a file within a watch directory. A file is imported once
+// its size & modification time have settled for the debounce duration, and
+// again only if it changes afterwards.
+type watchedFile struct {
+	size      int64
+	modTime   time.Time
+	settledAt time.Time
+	processed bool
+}
+
+// ScanWatchDirectories imports new or changed documents within the configured
+// watch directories. Results other than already existing documents are
+// recorded in the import log.
+func (api *API) ScanWatchDirectories(ctx context.Context) error {
+	api.watchMu.Lock()
+	defer api.watchMu.Unlock()
+
+	iType := importCopy
+	if api.cfg.WatchImportType == "direct" {
+		iType = importDirect
+	}
+	debounce := time.Duration(api.cfg.WatchDebounce) * time.Second
+
+	now := time.Now()
+	seenFiles := make(map[string]bool)
+
+	// Get data directory
+	absoluteDataPath, _ := filepath.Abs(filepath.Join(api.cfg.DataPath, "documents"))
+
+	var scanErrors []string
+	for _, watchDirectory := range api.cfg.WatchDirectories {
+		watchDirectory = filepath.Clean(watchDirectory)
+		if absoluteWatchPath, _ := filepath.Abs(watchDirectory); absoluteWatchPath == absoluteDataPath {
+			scanErrors = append(scanErrors, fmt.Sprintf("%s: %v", watchDirectory, errImportDataPath))
+			continue
+		}
+
+		err := filepath.WalkDir(watchDirectory, func(filePath string, f fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// Skip Hidden Files & Directories (e.g. Syncthing Temporary Files)
+			if strings.HasPrefix(f.Name(), ".") && filePath != watchDirectory {
+				if f.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			} else if f.IsDir() || !metadata.IsSupported(filePath) {
+				return nil
+			}
+
+			fileInfo, err := f.Info()
+			if err != nil {
+				return nil
+			}
+			seenFiles[filePath] = true
+
+			// Track Changes
+			file, ok := api.watchedFiles[filePath]
+			if !ok || file.size != fileInfo.Size() || !file.modTime.Equal(fileInfo.ModTime()) {
+				api.watchedFiles[filePath] = &watchedFile{
+					size:      fileInfo.Size(),
+					modTime:   fileInfo.ModTime(),
+					settledAt: now,
+				}
+				return nil
+			} else if file.processed || now.Sub(file.settledAt) < debounce {
+				return nil
+			}
+
+			file.processed = true
+			api.importWatchedFile(ctx, watchDirectory, filePath, iType)
+			return nil
+		})
+		if err != nil {
+			scanErrors = append(scanErrors, err.Error())
+		}
+	}
+
+	// Forget Removed Files
+	for filePath := range api.watchedFiles {
+		if !seenFiles[filePath] {
+			delete(api.watchedFiles, filePath)
+		}
+	}
+
+	if len(scanErrors) > 0 {
+		return errors.New(strings.Join(scanErrors, "; "))
+	}
+	return nil
+}
+
+// importWatchedFile imports the file and records the result in the import log
+func (api *API) importWatchedFile(ctx context.Context, watchDirectory, filePath string, iType importType) {
+	iResult := api.importFile(ctx, watchDirectory, filePath, iType)
+	if iResult.Status == importExists {
+		return
+	}
+
+	if iResult.Status == importSuccess {
+		log.Infof("imported watched file %s", filePath)
+		api.emitDocumentEvent(ctx, "", webhookDocumentAdded, iResult.ID, nil)
+	}
+
+	importLog := database.AddImportLogParams{
+		Directory: watchDirectory,
+		Path:      iResult.Path,
+		Status:    string(iResult.Status),
+	}
+	if iResult.ID != "" {
+		importLog.DocumentID = &iResult.ID
+	}
+	if iResult.Error != nil {
+		importError := iResult.Error.Error()
+		importLog.Error = &importError
+	}
+	if err := api.db.Queries.AddImportLog(ctx, importLog); err != nil {
+		log.Error("AddImportLog DB Error: ", err)
+	}
+}
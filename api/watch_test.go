@@ -0,0 +1,98 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+)
+
+func writeTestCBZ(t *testing.T, cbzPath string) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+
+	f, err := os.Create(cbzPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Partial MD5 Requires > 1KB
+	zw := zip.NewWriter(f)
+	for _, page := range []string{"page1.png", "page2.png"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: page, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = w.Write(buf.Bytes())
+		require.NoError(t, err)
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "padding.txt", Method: zip.Store})
+	require.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("padding"), 512))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+}
+
+func TestScanWatchDirectories(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	ctx := t.Context()
+	require.NoError(t, os.MkdirAll(filepath.Join(api.cfg.DataPath, "documents"), 0755))
+
+	watchDirectory := t.TempDir()
+	api.cfg.WatchDirectories = []string{watchDirectory}
+	api.cfg.WatchImportType = "copy"
+
+	writeTestCBZ(t, filepath.Join(watchDirectory, "Watched Comic.cbz"))
+	writeTestCBZ(t, filepath.Join(watchDirectory, ".Hidden Comic.cbz"))
+	require.NoError(t, os.WriteFile(filepath.Join(watchDirectory, "invalid.epub"), bytes.Repeat([]byte("not an epub"), 512), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(watchDirectory, "notes.txt"), []byte("notes"), 0644))
+
+	getImportLog := func() []database.GetImportLogRow {
+		importLog, err := api.db.Queries.GetImportLog(ctx, database.GetImportLogParams{Timezone: "UTC", Limit: importLogSize})
+		require.NoError(t, err)
+		return importLog
+	}
+
+	// First Scan - Debounce
+	require.NoError(t, api.ScanWatchDirectories(ctx))
+	assert.Empty(t, getImportLog())
+
+	// Second Scan - Import
+	require.NoError(t, api.ScanWatchDirectories(ctx))
+	importLog := getImportLog()
+	require.Len(t, importLog, 2)
+
+	byPath := map[string]database.GetImportLogRow{}
+	for _, entry := range importLog {
+		assert.Equal(t, watchDirectory, entry.Directory)
+		byPath[entry.Path] = entry
+	}
+
+	failed := byPath["invalid.epub"]
+	assert.Equal(t, string(importFailed), failed.Status)
+	assert.NotNil(t, failed.Error)
+
+	var imported database.GetImportLogRow
+	for _, entry := range importLog {
+		if entry.Status == string(importSuccess) {
+			imported = entry
+		}
+	}
+	require.NotNil(t, imported.DocumentID)
+	document, err := api.db.Queries.GetDocument(ctx, *imported.DocumentID)
+	require.NoError(t, err)
+	assert.Equal(t, "Watched Comic", *document.Title)
+	assert.FileExists(t, filepath.Join(api.cfg.DataPath, "documents", imported.Path))
+
+	// Third Scan - Unchanged
+	require.NoError(t, api.ScanWatchDirectories(ctx))
+	assert.Len(t, getImportLog(), 2)
+
+	// Data Directory
+	api.cfg.WatchDirectories = []string{filepath.Join(api.cfg.DataPath, "documents")}
+	assert.ErrorContains(t, api.ScanWatchDirectories(ctx), errImportDataPath.Error())
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/utils"
+)
+
+// webhookEvent is a reading event external systems can subscribe to.
+type webhookEvent string
+
+const (
+	webhookDocumentFinished webhookEvent = "document.finished"
+	webhookDocumentAdded    webhookEvent = "document.added"
+	webhookStreakExtended   webhookEvent = "streak.extended"
+	webhookStreakBroken     webhookEvent = "streak.broken"
+	webhookDeviceSynced     webhookEvent = "device.synced"
+)
+
+var webhookEvents = []webhookEvent{
+	webhookDocumentFinished,
+	webhookDocumentAdded,
+	webhookStreakExtended,
+	webhookStreakBroken,
+	webhookDeviceSynced,
+}
+
+const (
+	webhookPending = "pending"
+	webhookSuccess = "success"
+	webhookFailed  = "failed"
+)
+
+const (
+	webhookSecretPrefix    = "whsec_"
+	webhookMaxAttempts     = 6
+	webhookRetryDelay      = 30 * time.Second
+	webhookBatchSize       = 50
+	webhookLogSize         = 25
+	finishedPercentage     = 0.97
+	webhookSignatureHeader = "X-AnthoLume-Signature"
+	webhookTimestampHeader = "X-AnthoLume-Timestamp"
+)
+
+var (
+	errInvalidWebhookURL       = errors.New("webhook url must be an absolute http(s) url")
+	errPrivateWebhookURL       = errors.New("webhook url must not target a private address")
+	errInvalidWebhookEvents    = errors.New("webhook requires at least one valid event")
+	errInvalidWebhookOperation = errors.New("unknown webhook operation")
+)
+
+// webhookClient delivers admin webhooks, which may target internal services.
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookUserClient delivers user webhooks, refusing private addresses at dial
+// time so hostnames resolving (or redirecting) to internal services are caught.
+// Proxies are bypassed, as the proxy address would be checked instead.
+var webhookUserClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: webhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// webhookPayload is the JSON body POSTed to webhook subscribers.
+type webhookPayload struct {
+	Event     webhookEvent `json:"event"`
+	UserID    string       `json:"user_id"`
+	Timestamp string       `json:"timestamp"`
+	Data      any          `json:"data"`
+}
+
+// createWebhook subscribes the URL to the events, returning the generated
+// signing secret. A nil user creates an admin webhook receiving the events of
+// all users.
+func (api *API) createWebhook(ctx context.Context, userID *string, rawURL string, events []string) (string, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return "", errInvalidWebhookURL
+	}
+
+	// Only Admins May Target Internal Services - Hostnames Are Checked On Delivery
+	if userID != nil && !webhookHostAllowed(parsedURL.Hostname()) {
+		return "", errPrivateWebhookURL
+	}
+
+	var validEvents []string
+	for _, event := range events {
+		if slices.Contains(webhookEvents, webhookEvent(event)) && !slices.Contains(validEvents, event) {
+			validEvents = append(validEvents, event)
+		}
+	}
+	if len(validEvents) == 0 {
+		return "", errInvalidWebhookEvents
+	}
+
+	rawSecret, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	secret := fmt.Sprintf("%s%x", webhookSecretPrefix, rawSecret)
+
+	if _, err := api.db.Queries.CreateWebhook(ctx, database.CreateWebhookParams{
+		UserID: userID,
+		Url:    parsedURL.String(),
+		Secret: secret,
+		Events: strings.Join(validEvents, ","),
+	}); err != nil {
+		return "", fmt.Errorf("CreateWebhook DB Error: %w", err)
+	}
+
+	return secret, nil
+}
+
+// editWebhook applies a webhook form operation within the scope (nil for admin
+// webhooks), returning the signing secret of created webhooks. Operations on
+// webhooks outside of the scope return sql.ErrNoRows.
+func (api *API) editWebhook(ctx context.Context, scope *string, rWebhookEdit requestWebhookEdit) (string, error) {
+	var changed int64
+	var err error
+	switch rWebhookEdit.Operation {
+	case opCreate:
+		return api.createWebhook(ctx, scope, rWebhookEdit.URL, rWebhookEdit.Events)
+	case opUpdate:
+		changed, err = api.db.Queries.UpdateWebhook(ctx, database.UpdateWebhookParams{
+			Enabled: rWebhookEdit.Enabled,
+			ID:      rWebhookEdit.Webhook,
+			UserID:  scope,
+		})
+		if err != nil {
+			return "", fmt.Errorf("UpdateWebhook DB Error: %w", err)
+		}
+	case opDelete:
+		changed, err = api.db.Queries.DeleteWebhook(ctx, database.DeleteWebhookParams{
+			ID:     rWebhookEdit.Webhook,
+			UserID: scope,
+		})
+		if err != nil {
+			return "", fmt.Errorf("DeleteWebhook DB Error: %w", err)
+		}
+	default:
+		return "", errInvalidWebhookOperation
+	}
+
+	if changed == 0 {
+		return "", sql.ErrNoRows
+	}
+	return "", nil
+}
+
+// setWebhookTemplateVars sets the webhooks and recent deliveries of the scope
+// (nil for admin webhooks), shown in the viewers timezone.
+func (api *API) setWebhookTemplateVars(ctx context.Context, scope *string, viewerID string, templateVars gin.H) error {
+	viewer, err := api.db.Queries.GetUser(ctx, viewerID)
+	if err != nil {
+		return fmt.Errorf("GetUser DB Error: %w", err)
+	}
+
+	webhooks, err := api.db.Queries.GetWebhooks(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("GetWebhooks DB Error: %w", err)
+	}
+
+	deliveries, err := api.db.Queries.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{
+		Timezone: *viewer.Timezone,
+		UserID:   scope,
+		Limit:    webhookLogSize,
+	})
+	if err != nil {
+		return fmt.Errorf("GetWebhookDeliveries DB Error: %w", err)
+	}
+
+	templateVars["Webhooks"] = webhooks
+	templateVars["WebhookDeliveries"] = deliveries
+	templateVars["WebhookEvents"] = webhookEvents
+	return nil
+}
+
+// webhookEditMessage returns the form message of user correctable errors.
+func webhookEditMessage(err error) (string, bool) {
+	switch err {
+	case errInvalidWebhookURL:
+		return "Invalid Webhook URL", true
+	case errPrivateWebhookURL:
+		return "Webhook URL Must Be Public", true
+	case errInvalidWebhookEvents:
+		return "Select At Least One Event", true
+	default:
+		return "", false
+	}
+}
+
+// emitWebhookEvent queues a delivery of the event to every enabled webhook
+// subscribed to it - the users own as well as admin webhooks. Events are a
+// side effect, so failures are logged rather than returned.
+func (api *API) emitWebhookEvent(ctx context.Context, userID string, event webhookEvent, data any) {
+	payload, err := json.Marshal(webhookPayload{
+		Event:     event,
+		UserID:    userID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	})
+	if err != nil {
+		log.Error("Webhook Payload Error: ", err)
+		return
+	}
+
+	count, err := api.db.Queries.CreateWebhookDeliveries(ctx, database.CreateWebhookDeliveriesParams{
+		Event:   string(event),
+		Payload: string(payload),
+		UserID:  &userID,
+	})
+	if err != nil {
+		log.Error("CreateWebhookDeliveries DB Error: ", err)
+		return
+	}
+
+	// Enqueue Delivery
+	if count > 0 {
+		if err := api.enqueueUniqueJob(ctx, DeliverWebhooksJob{}); err != nil {
+			log.Error("Enqueue Webhook Delivery Error: ", err)
+		}
+	}
+}
+
+// emitDocumentEvent emits a document event including the documents metadata.
+func (api *API) emitDocumentEvent(ctx context.Context, userID string, event webhookEvent, documentID string, data gin.H) {
+	document, err := api.db.Queries.GetDocument(ctx, documentID)
+	if err != nil {
+		log.Error("GetDocument DB Error: ", err)
+		return
+	}
+
+	if data == nil {
+		data = gin.H{}
+	}
+	data["document"] = gin.H{
+		"id":     document.ID,
+		"title":  document.Title,
+		"author": document.Author,
+	}
+	api.emitWebhookEvent(ctx, userID, event, data)
+}
+
+// emitProgressEvents emits document.finished when progress crosses the
+// finished threshold (matching the GetDocumentsWithStats rule).
+func (api *API) emitProgressEvents(ctx context.Context, previous float64, progress *database.DocumentProgress) {
+	if previous > finishedPercentage || progress.Percentage <= finishedPercentage {
+		return
+	}
+
+	api.emitDocumentEvent(ctx, progress.UserID, webhookDocumentFinished, progress.DocumentID, gin.H{
+		"device_id":  progress.DeviceID,
+		"percentage": progress.Percentage * 100,
+	})
+}
+
+// emitStreakEvents compares streaks before and after a refresh, emitting
+// streak.extended for streaks that grew or restarted and streak.broken for
+// streaks that ended.
+func (api *API) emitStreakEvents(ctx context.Context, before []database.UserStreak, after []database.UserStreak) {
+	previousStreaks := make(map[string]database.UserStreak)
+	for _, streak := range before {
+		previousStreaks[streak.UserID+"/"+streak.Window] = streak
+	}
+
+	for _, streak := range after {
+		previous := previousStreaks[streak.UserID+"/"+streak.Window]
+		restarted := streak.CurrentStreakStartDate != previous.CurrentStreakStartDate
+
+		if previous.CurrentStreak > 0 && (streak.CurrentStreak == 0 || restarted) {
+			api.emitWebhookEvent(ctx, streak.UserID, webhookStreakBroken, gin.H{
+				"window":     streak.Window,
+				"streak":     previous.CurrentStreak,
+				"start_date": previous.CurrentStreakStartDate,
+				"end_date":   previous.CurrentStreakEndDate,
+			})
+		}
+
+		if streak.CurrentStreak > 0 && (streak.CurrentStreak > previous.CurrentStreak || restarted) {
+			api.emitWebhookEvent(ctx, streak.UserID, webhookStreakExtended, gin.H{
+				"window":     streak.Window,
+				"streak":     streak.CurrentStreak,
+				"max_streak": streak.MaxStreak,
+				"start_date": streak.CurrentStreakStartDate,
+				"end_date":   streak.CurrentStreakEndDate,
+			})
+		}
+	}
+}
+
+// CacheTempTables refreshes the statistic caches, emitting streak events for
+// the streaks that changed.
+func (api *API) CacheTempTables(ctx context.Context) error {
+	before, err := api.db.Queries.GetAllUserStreaks(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAllUserStreaks DB Error: %w", err)
+	}
+
+	if err := api.db.CacheTempTables(ctx); err != nil {
+		return err
+	}
+
+	after, err := api.db.Queries.GetAllUserStreaks(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAllUserStreaks DB Error: %w", err)
+	}
+
+	api.emitStreakEvents(ctx, before, after)
+	return nil
+}
+
+// DeliverWebhooks attempts all due webhook deliveries. Failed attempts are
+// retried with exponential backoff until webhookMaxAttempts is reached.
+func (api *API) DeliverWebhooks(ctx context.Context) error {
+	for {
+		deliveries, err := api.db.Queries.GetPendingWebhookDeliveries(ctx, webhookBatchSize)
+		if err != nil {
+			return fmt.Errorf("GetPendingWebhookDeliveries DB Error: %w", err)
+		}
+
+		for _, delivery := range deliveries {
+			if err := api.deliverWebhook(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			break
+		}
+	}
+
+	// Prune Delivery Log
+	if _, err := api.db.Queries.DeleteExpiredWebhookDeliveries(ctx); err != nil {
+		return fmt.Errorf("DeleteExpiredWebhookDeliveries DB Error: %w", err)
+	}
+
+	return nil
+}
+
+func (api *API) deliverWebhook(ctx context.Context, delivery database.GetPendingWebhookDeliveriesRow) error {
+	params := database.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Status:        webhookSuccess,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+
+	responseCode, err := api.sendWebhook(ctx, delivery)
+	params.ResponseCode = responseCode
+	if err != nil {
+		log.Warnf("Webhook delivery %d to %s failed (attempt %d): %v", delivery.ID, delivery.Url, params.Attempts, err)
+
+		errMsg := err.Error()
+		params.Error = &errMsg
+		if params.Attempts >= webhookMaxAttempts {
+			params.Status = webhookFailed
+		} else {
+			params.Status = webhookPending
+			retryDelay := webhookRetryDelay << (params.Attempts - 1)
+			params.NextAttemptAt = time.Now().UTC().Add(retryDelay).Format(time.RFC3339)
+		}
+	}
+
+	if err := api.db.Queries.UpdateWebhookDelivery(ctx, params); err != nil {
+		return fmt.Errorf("UpdateWebhookDelivery DB Error: %w", err)
+	}
+
+	return nil
+}
+
+// sendWebhook POSTs the signed payload, returning the response status code
+// when a response was received. User webhooks can't reach private addresses.
+func (api *API) sendWebhook(ctx context.Context, delivery database.GetPendingWebhookDeliveriesRow) (*int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AnthoLume-Webhook/"+api.cfg.Version)
+	req.Header.Set("X-AnthoLume-Event", delivery.Event)
+	req.Header.Set("X-AnthoLume-Delivery", strconv.FormatInt(delivery.ID, 10))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(delivery.Secret, timestamp, []byte(delivery.Payload)))
+
+	client := webhookClient
+	if delivery.UserID != nil {
+		client = webhookUserClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	responseCode := int64(resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &responseCode, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return &responseCode, nil
+}
+
+// signWebhookPayload returns the signature header value - the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret. Signing the
+// timestamp allows receivers to reject replayed deliveries.
+func signWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookHostAllowed returns whether a user webhook may target the host. IP
+// literals & localhost are checked, resolved hostnames are checked on dial.
+func webhookHostAllowed(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	} else if addr, err := netip.ParseAddr(host); err == nil {
+		return webhookAddrAllowed(addr)
+	}
+	return true
+}
+
+// webhookAddrAllowed returns whether a user webhook may connect to the address
+// - loopback, link-local, private, multicast & unspecified addresses aren't.
+func webhookAddrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsPrivate() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// webhookDialControl refuses user webhook connections to addresses that
+// aren't allowed.
+func webhookDialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	} else if !webhookAddrAllowed(addr) {
+		return fmt.Errorf("%w: %s", errPrivateWebhookURL, addr)
+	}
+
+	return nil
+}
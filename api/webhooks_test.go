@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+type webhookRequest struct {
+	Path    string
+	Header  http.Header
+	Body    []byte
+	Payload webhookPayload
+}
+
+// webhookStandIn is a local HTTP receiver recording webhook requests.
+type webhookStandIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []webhookRequest
+}
+
+func newWebhookStandIn(t *testing.T) *webhookStandIn {
+	standIn := &webhookStandIn{status: http.StatusOK}
+	standIn.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload webhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+
+		standIn.mu.Lock()
+		defer standIn.mu.Unlock()
+		standIn.requests = append(standIn.requests, webhookRequest{Path: r.URL.Path, Header: r.Header, Body: body, Payload: payload})
+		w.WriteHeader(standIn.status)
+	}))
+	t.Cleanup(standIn.Close)
+	return standIn
+}
+
+func (s *webhookStandIn) events() []webhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []webhookEvent
+	for _, request := range s.requests {
+		events = append(events, request.Payload.Event)
+	}
+	return events
+}
+
+func TestWebhookEvents(t *testing.T) {
+	api, server := newTestAPI(t, 1)
+	standIn := newWebhookStandIn(t)
+	now := time.Now().Unix()
+
+	// User Webhooks Can't Target Private Addresses
+	_, err := api.createWebhook(t.Context(), ptr.Of("reader"), standIn.URL+"/user", []string{"document.finished"})
+	assert.ErrorIs(t, err, errPrivateWebhookURL)
+	_, err = api.createWebhook(t.Context(), ptr.Of("reader"), "http://localhost/user", []string{"document.finished"})
+	assert.ErrorIs(t, err, errPrivateWebhookURL)
+
+	// Hostname Resolving To A Private Address - Refused On Delivery
+	_, err = api.db.Queries.CreateWebhook(t.Context(), database.CreateWebhookParams{
+		UserID: ptr.Of("reader"),
+		Url:    standIn.URL + "/user",
+		Secret: webhookSecretPrefix + "user",
+		Events: "document.finished",
+	})
+	require.NoError(t, err)
+
+	adminSecret, err := api.createWebhook(t.Context(), nil, standIn.URL+"/admin", []string{"document.finished", "device.synced"})
+	require.NoError(t, err)
+
+	// Progress Below & Across Threshold
+	code, _ := koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.5, now-60))
+	require.Equal(t, http.StatusOK, code)
+	code, _ = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.98, now-30))
+	require.Equal(t, http.StatusOK, code)
+	code, _ = koRequest(t, server, http.MethodPut, "/api/ko/syncs/progress", koPosition("kindle", 0.99, now))
+	require.Equal(t, http.StatusOK, code)
+
+	// Single Delivery Job Enqueued
+	jobs, err := api.db.Queries.GetJobs(t.Context(), database.GetJobsParams{Timezone: "UTC", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, DeliverWebhooksJob{}.JobType(), jobs[0].Type)
+	assert.Equal(t, string(JobQueued), jobs[0].Status)
+
+	require.NoError(t, api.DeliverWebhooks(t.Context()))
+	assert.ElementsMatch(t, []webhookEvent{
+		webhookDeviceSynced, webhookDeviceSynced, webhookDeviceSynced,
+		webhookDocumentFinished,
+	}, standIn.events())
+
+	userDeliveries, err := api.db.Queries.GetWebhookDeliveries(t.Context(), database.GetWebhookDeliveriesParams{
+		Timezone: "UTC",
+		UserID:   ptr.Of("reader"),
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, userDeliveries, 1)
+	assert.Equal(t, webhookPending, userDeliveries[0].Status)
+	assert.Contains(t, ptr.Deref(userDeliveries[0].Error), errPrivateWebhookURL.Error())
+
+	// Signed Payload & Timestamp
+	var finishedRequests []webhookRequest
+	for _, request := range standIn.requests {
+		if request.Payload.Event == webhookDocumentFinished {
+			finishedRequests = append(finishedRequests, request)
+		}
+	}
+	require.Len(t, finishedRequests, 1)
+	request := finishedRequests[0]
+	timestamp := request.Header.Get(webhookTimestampHeader)
+	assert.Equal(t, signWebhookPayload(adminSecret, timestamp, request.Body), request.Header.Get(webhookSignatureHeader))
+	assert.NotEqual(t, signWebhookPayload(adminSecret, "0", request.Body), request.Header.Get(webhookSignatureHeader))
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), sentAt, 60)
+	assert.Equal(t, "document.finished", request.Header.Get("X-AnthoLume-Event"))
+	assert.NotEmpty(t, request.Header.Get("X-AnthoLume-Delivery"))
+	assert.Equal(t, "reader", request.Payload.UserID)
+
+	data := request.Payload.Data.(map[string]any)
+	assert.EqualValues(t, 98, data["percentage"])
+	assert.Equal(t, "kindle-id", data["device_id"])
+	assert.Equal(t, "document-00", data["document"].(map[string]any)["id"])
+}
+
+func TestWebhookStreakEvents(t *testing.T) {
+	api, _ := newTestAPI(t, 0)
+	standIn := newWebhookStandIn(t)
+
+	_, err := api.createWebhook(t.Context(), nil, standIn.URL, []string{"streak.extended", "streak.broken"})
+	require.NoError(t, err)
+
+	streak := func(userID string, current int64, start string) database.UserStreak {
+		return database.UserStreak{UserID: userID, Window: "DAY", CurrentStreak: current, CurrentStreakStartDate: start}
+	}
+	api.emitStreakEvents(t.Context(), []database.UserStreak{
+		streak("extended", 2, "2026-10-17"),
+		streak("broken", 5, "2026-10-10"),
+		streak("restarted", 3, "2026-10-01"),
+		streak("unchanged", 1, "2026-10-19"),
+	}, []database.UserStreak{
+		streak("new", 1, "2026-10-19"),
+		streak("extended", 3, "2026-10-17"),
+		streak("broken", 0, "N/A"),
+		streak("restarted", 1, "2026-10-19"),
+		streak("unchanged", 1, "2026-10-19"),
+	})
+
+	require.NoError(t, api.DeliverWebhooks(t.Context()))
+	events := map[string][]webhookEvent{}
+	for _, request := range standIn.requests {
+		events[request.Payload.UserID] = append(events[request.Payload.UserID], request.Payload.Event)
+	}
+	assert.Equal(t, map[string][]webhookEvent{
+		"new":       {webhookStreakExtended},
+		"extended":  {webhookStreakExtended},
+		"broken":    {webhookStreakBroken},
+		"restarted": {webhookStreakBroken, webhookStreakExtended},
+	}, events)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	api, _ := newTestAPI(t, 1)
+	standIn := newWebhookStandIn(t)
+	standIn.status = http.StatusInternalServerError
+
+	_, err := api.createWebhook(t.Context(), nil, standIn.URL, []string{"document.added"})
+	require.NoError(t, err)
+	api.emitDocumentEvent(t.Context(), "reader", webhookDocumentAdded, "document-00", nil)
+
+	getDeliveries := func() []database.GetWebhookDeliveriesRow {
+		deliveries, err := api.db.Queries.GetWebhookDeliveries(t.Context(), database.GetWebhookDeliveriesParams{
+			Timezone: "UTC",
+			Limit:    webhookLogSize,
+		})
+		require.NoError(t, err)
+		return deliveries
+	}
+
+	// Backoff - Not Yet Due
+	require.NoError(t, api.DeliverWebhooks(t.Context()))
+	require.NoError(t, api.DeliverWebhooks(t.Context()))
+	deliveries := getDeliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhookPending, deliveries[0].Status)
+	assert.EqualValues(t, 1, deliveries[0].Attempts)
+	assert.EqualValues(t, http.StatusInternalServerError, *deliveries[0].ResponseCode)
+	assert.Contains(t, *deliveries[0].Error, "500")
+
+	// Exhaust Attempts
+	for range webhookMaxAttempts - 1 {
+		_, err := api.db.DB.Exec("UPDATE webhook_deliveries SET next_attempt_at = '1970-01-01T00:00:00Z';")
+		require.NoError(t, err)
+		require.NoError(t, api.DeliverWebhooks(t.Context()))
+	}
+	deliveries = getDeliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhookFailed, deliveries[0].Status)
+	assert.EqualValues(t, webhookMaxAttempts, deliveries[0].Attempts)
+	assert.Len(t, standIn.requests, webhookMaxAttempts)
+
+	// Recovered Receiver
+	standIn.status = http.StatusNoContent
+	api.emitDocumentEvent(t.Context(), "reader", webhookDocumentAdded, "document-00", nil)
+	require.NoError(t, api.DeliverWebhooks(t.Context()))
+	deliveries = getDeliveries()
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, webhookSuccess, deliveries[0].Status)
+	assert.Nil(t, deliveries[0].Error)
+}
+
+func TestWebhookSettings(t *testing.T) {
+	api, server := newTestAPI(t, 0)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.PostForm(server.URL+"/login", url.Values{"username": {"reader"}, "password": {"pass"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	postForm := func(values url.Values) (int, string) {
+		resp, err := client.PostForm(server.URL+"/settings/webhooks", values)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		page, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(page)
+	}
+
+	// Invalid URL
+	code, page := postForm(url.Values{"operation": {"CREATE"}, "url": {"ftp://example.com"}, "events": {"document.added"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, page, "Invalid Webhook URL")
+
+	// Private URL
+	code, page = postForm(url.Values{"operation": {"CREATE"}, "url": {"http://192.168.1.10/hook"}, "events": {"document.added"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, page, "Webhook URL Must Be Public")
+
+	// Create - Secret Shown Once
+	code, page = postForm(url.Values{"operation": {"CREATE"}, "url": {"https://example.com/hook"}, "events": {"document.added"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Regexp(t, regexp.MustCompile(webhookSecretPrefix+`[0-9a-f]{64}`), page)
+
+	webhooks, err := api.db.Queries.GetWebhooks(t.Context(), ptr.Of("reader"))
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "document.added", webhooks[0].Events)
+
+	// Admin Webhooks Out Of Scope
+	_, err = api.createWebhook(t.Context(), nil, "https://example.com/admin", []string{"document.added"})
+	require.NoError(t, err)
+	adminWebhooks, err := api.db.Queries.GetWebhooks(t.Context(), nil)
+	require.NoError(t, err)
+	require.Len(t, adminWebhooks, 1)
+	code, _ = postForm(url.Values{"operation": {"DELETE"}, "webhook": {fmt.Sprint(adminWebhooks[0].ID)}})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Disable & Delete
+	code, _ = postForm(url.Values{"operation": {"UPDATE"}, "webhook": {fmt.Sprint(webhooks[0].ID)}, "enabled": {"false"}})
+	require.Equal(t, http.StatusOK, code)
+	webhooks, err = api.db.Queries.GetWebhooks(t.Context(), ptr.Of("reader"))
+	require.NoError(t, err)
+	assert.False(t, webhooks[0].Enabled)
+
+	code, _ = postForm(url.Values{"operation": {"DELETE"}, "webhook": {fmt.Sprint(webhooks[0].ID)}})
+	require.Equal(t, http.StatusOK, code)
+	webhooks, err = api.db.Queries.GetWebhooks(t.Context(), ptr.Of("reader"))
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
@@ -23,6 +23,8 @@ async function initReader() {
     // Update With Local Cache
     let localCache = await IDB.get("PROGRESS-" + documentID);
     if (localCache) {
+      // Server CFI Only Applies To Server Progress
+      if (localCache.progress != documentData.progress) delete documentData.cfi;
       documentData.progress = localCache.progress;
       documentData.percentage = Math.round(localCache.percentage * 10000) / 100;
     }
@@ -126,6 +128,7 @@ class EBookReader {
     pages: 0,
     percentage: 0,
     progress: "",
+    cfi: "",
     progressElement: null,
     readActivity: [],
     words: 0,
@@ -169,8 +172,10 @@ class EBookReader {
     // Get Word Count
     this.bookState.words = await this.countWords();
 
-    // Load Progress
-    let { cfi } = await this.getCFIFromXPath(this.bookState.progress);
+    // Load Progress - Prefer Server Translated CFI
+    let cfi =
+      this.bookState.cfi ||
+      (await this.getCFIFromXPath(this.bookState.progress)).cfi;
 
     // Update Position
     await this.setPosition(cfi);
@@ -159,7 +159,7 @@ function SyncNinjaClient:add_documents(username, password, documents, callback)
 end
 
 function SyncNinjaClient:check_documents(username, password, device_id, device,
-                                         have, callback)
+                                         have, cursor, callback)
     self.client:reset_middlewares()
     self.client:enable("Format.JSON")
     self.client:enable("GinClient")
@@ -172,7 +172,8 @@ function SyncNinjaClient:check_documents(username, password, device_id, device,
             return self.client:check_documents({
                 device_id = device_id,
                 device = device,
-                have = have
+                have = have,
+                cursor = cursor
             })
         end)
         if ok then
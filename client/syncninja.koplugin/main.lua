@@ -169,6 +169,8 @@ function SyncNinja:addToMainMenu(menu_items)
 						type = "text",
 						callback = function(input)
 							self.settings.server = input ~= "" and input or nil
+							self.settings.document_cursor = nil
+							self.settings.known_documents = nil
 							if menu then
 								menu:updateItems()
 							end
@@ -431,6 +433,8 @@ function SyncNinja:logoutUI(menu)
 	logger.dbg("SyncNinja: logoutUI")
 	self.settings.username = nil
 	self.settings.password = nil
+	self.settings.document_cursor = nil
+	self.settings.known_documents = nil
 	if menu then
 		menu:updateItems()
 	end
@@ -707,9 +711,20 @@ function SyncNinja:checkDocuments(interactive)
 		return
 	end
 
-	-- API Request Data
+	-- API Request Data - With a cursor only new local documents are sent
 	local doc_metadata = self:getLocalDocumentMetadata()
 	local doc_ids = self:getLocalDocumentIDs(doc_metadata)
+	local cursor = self.settings.document_cursor
+	local known_documents = self.settings.known_documents or {}
+	if cursor ~= nil then
+		local new_doc_ids = {}
+		for _, v in pairs(doc_ids) do
+			if known_documents[v] ~= true then
+				table.insert(new_doc_ids, v)
+			end
+		end
+		doc_ids = new_doc_ids
+	end
 
 	-- API Callback Function
 	local callback_func = function(ok, body)
@@ -723,6 +738,28 @@ function SyncNinja:checkDocuments(interactive)
 			return logger.dbg("SyncNinja: checkDocuments Error:", dump(body))
 		end
 
+		-- Server Changelog Restarted - Next Sync Is Full
+		if body.reset == true then
+			self.settings.document_cursor = nil
+			self.settings.known_documents = nil
+		else
+			-- Wanted documents are resent until the server has them
+			local wanted = {}
+			for _, v in pairs(body.want_metadata) do
+				wanted[v] = true
+			end
+			for _, v in pairs(body.want_files) do
+				wanted[v] = true
+			end
+			for _, v in pairs(doc_ids) do
+				if wanted[v] ~= true then
+					known_documents[v] = true
+				end
+			end
+			self.settings.document_cursor = body.cursor
+			self.settings.known_documents = known_documents
+		end
+
 		-- Document Metadata Wanted
 		if not (next(body.want_metadata) == nil) then
 			local hash_want_metadata = {}
@@ -761,6 +798,11 @@ function SyncNinja:checkDocuments(interactive)
 		if not (next(body.give) == nil) then
 			self:downloadDocuments(body.give, interactive)
 		end
+
+		-- Remaining Changes
+		if body.more == true then
+			self:checkDocuments(interactive)
+		end
 	end
 
 	-- API Call
@@ -777,6 +819,7 @@ function SyncNinja:checkDocuments(interactive)
 		self.device_id,
 		Device.model,
 		doc_ids,
+		cursor,
 		callback_func
 	)
 end
@@ -22,11 +22,13 @@ type Config struct {
 
 	// Data Paths
 	ConfigPath string
+	ConfigFile string
 	DataPath   string
 
 	// Miscellaneous Settings
 	RegistrationEnabled bool
 	SearchEnabled       bool
+	MetricsEnabled      bool
 	DemoMode            bool
 	LogLevel            string
 
@@ -35,6 +37,21 @@ type Config struct {
 	CookieEncKey   string
 	CookieSecure   bool
 	CookieHTTPOnly bool
+
+	// Cookie Key Rotation (Days)
+	CookieKeyRotation int
+	CookieKeyGrace    int
+
+	// Minimum Free Disk Space (MB) For Readiness
+	MinFreeDiskMB int
+
+	// Watch Directories (Automatic Import)
+	WatchDirectories []string
+	WatchImportType  string
+	WatchDebounce    int
+
+	// Setting Sources (Key -> Source)
+	sources map[string]string
 }
 
 type customFormatter struct {
@@ -53,25 +70,15 @@ func (cf customFormatter) Format(e *log.Entry) ([]byte, error) {
 // Set at runtime
 var version string = "develop"
 
-func Load() *Config {
-	c := &Config{
-		Version:             version,
-		ConfigPath:          getEnv("CONFIG_PATH", "/config"),
-		DataPath:            getEnv("DATA_PATH", "/data"),
-		ListenPort:          getEnv("LISTEN_PORT", "8585"),
-		DBType:              trimLowerString(getEnv("DATABASE_TYPE", "SQLite")),
-		DBName:              trimLowerString(getEnv("DATABASE_NAME", "antholume")),
-		RegistrationEnabled: trimLowerString(getEnv("REGISTRATION_ENABLED", "false")) == "true",
-		DemoMode:            trimLowerString(getEnv("DEMO_MODE", "false")) == "true",
-		SearchEnabled:       trimLowerString(getEnv("SEARCH_ENABLED", "false")) == "true",
-		CookieAuthKey:       trimLowerString(getEnv("COOKIE_AUTH_KEY", "")),
-		CookieEncKey:        trimLowerString(getEnv("COOKIE_ENC_KEY", "")),
-		LogLevel:            trimLowerString(getEnv("LOG_LEVEL", "info")),
-		CookieSecure:        trimLowerString(getEnv("COOKIE_SECURE", "true")) == "true",
-		CookieHTTPOnly:      trimLowerString(getEnv("COOKIE_HTTP_ONLY", "true")) == "true",
+// Load parses & validates the configuration, then configures logging and
+// ensures the data directories exist.
+func Load() (*Config, error) {
+	c, err := Parse()
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse log level
+	// Parse log level (validated)
 	logLevel, err := log.ParseLevel(c.LogLevel)
 	if err != nil {
 		logLevel = log.InfoLevel
@@ -107,7 +114,7 @@ func Load() *Config {
 	// Ensure directories exist
 	c.EnsureDirectories()
 
-	return c
+	return c, nil
 }
 
 // Ensures needed directories exist
@@ -1,14 +1,18 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadConfig(t *testing.T) {
-	conf := Load()
+	conf, err := Load()
+	require.NoError(t, err)
 	assert.Equal(t, "sqlite", conf.DBType)
 }
 
@@ -33,5 +37,105 @@ func TestPrettyCaller(t *testing.T) {
 	functionName, fileName := prettyCaller(&f)
 
 	assert.Equal(t, "TestPrettyCaller", functionName, "should have current function name")
-	assert.Equal(t, "config/config_test.go@30", fileName, "should have current file path and line number")
+	assert.Equal(t, "config/config_test.go@34", fileName, "should have current file path and line number")
+}
+
+func TestParseConfigFile(t *testing.T) {
+	configPath := t.TempDir()
+	t.Setenv("CONFIG_PATH", configPath)
+	t.Setenv("LOG_LEVEL", "debug")
+	writeFile(t, filepath.Join(configPath, "antholume.yaml"), `
+log_level: warn
+listen_port: 9090
+search_enabled: true
+cookie_auth_key: secret
+cookie_key_grace_days: 3
+watch_directories:
+  - /books/syncthing
+  - /books/calibre
+`)
+
+	conf, err := Parse()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(configPath, "antholume.yaml"), conf.ConfigFile)
+	assert.Equal(t, "debug", conf.LogLevel, "env should override file")
+	assert.Equal(t, "9090", conf.ListenPort)
+	assert.True(t, conf.SearchEnabled)
+	assert.Equal(t, 3, conf.CookieKeyGrace)
+	assert.Equal(t, 90, conf.CookieKeyRotation, "should use default")
+	assert.Equal(t, []string{"/books/syncthing", "/books/calibre"}, conf.WatchDirectories)
+	assert.Equal(t, "copy", conf.WatchImportType)
+
+	settings := map[string]Setting{}
+	for _, setting := range conf.Settings() {
+		settings[setting.Key] = setting
+	}
+	assert.Equal(t, Setting{Key: "LOG_LEVEL", Value: "debug", Source: SourceEnv}, settings["LOG_LEVEL"])
+	assert.Equal(t, Setting{Key: "LISTEN_PORT", Value: "9090", Source: SourceFile}, settings["LISTEN_PORT"])
+	assert.Equal(t, Setting{Key: "DATA_PATH", Value: "/data", Source: SourceDefault}, settings["DATA_PATH"])
+	assert.Equal(t, redactedValue, settings["COOKIE_AUTH_KEY"].Value, "should redact secrets")
+	assert.Empty(t, settings["COOKIE_ENC_KEY"].Value, "should not redact empty secrets")
+}
+
+func TestParseConfigTOML(t *testing.T) {
+	configPath := t.TempDir()
+	t.Setenv("CONFIG_PATH", configPath)
+	writeFile(t, filepath.Join(configPath, "antholume.toml"), `
+database_name = "library"
+registration_enabled = true
+min_free_disk_mb = 250
+watch_directories = "/books/a, /books/b,"
+watch_import_type = "DIRECT"
+`)
+
+	conf, err := Parse()
+	require.NoError(t, err)
+	assert.Equal(t, "library", conf.DBName)
+	assert.True(t, conf.RegistrationEnabled)
+	assert.Equal(t, 250, conf.MinFreeDiskMB)
+	assert.Equal(t, []string{"/books/a", "/books/b"}, conf.WatchDirectories)
+	assert.Equal(t, "direct", conf.WatchImportType)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	configPath := t.TempDir()
+	t.Setenv("CONFIG_PATH", configPath)
+	t.Setenv("COOKIE_SECURE", "yes")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("COOKIE_KEY_ROTATION_DAYS", "-1")
+	writeFile(t, filepath.Join(configPath, "antholume.yml"), `
+log_levl: debug
+config_path: /elsewhere
+database_type: postgres
+listen_port: http
+cookie_enc_key: short
+data_path: [a, b]
+log_level: {a: b}
+`)
+
+	_, err := Parse()
+	require.Error(t, err)
+	for _, message := range []string{
+		`unknown key "log_levl"`,
+		`key "config_path" can only be set via the CONFIG_PATH environment variable`,
+		`key "data_path" must be a scalar value`,
+		`key "log_level" must be a scalar value`,
+		`LOG_LEVEL (env): invalid log level "verbose"`,
+		`DATABASE_TYPE (file): invalid value "postgres" (must be one of: sqlite, memory)`,
+		`LISTEN_PORT (file): invalid port "http"`,
+		`COOKIE_ENC_KEY (file): invalid cookie encryption key`,
+		`COOKIE_SECURE (env): invalid boolean "yes"`,
+		`COOKIE_KEY_ROTATION_DAYS (env): invalid value "-1"`,
+	} {
+		assert.ErrorContains(t, err, message)
+	}
+
+	// Multiple Files
+	writeFile(t, filepath.Join(configPath, "antholume.toml"), "")
+	_, err = Parse()
+	assert.ErrorContains(t, err, "multiple config files found")
+}
+
+func writeFile(t *testing.T, filePath, data string) {
+	require.NoError(t, os.WriteFile(filePath, []byte(data), 0644))
 }
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting sources, in increasing precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+const redactedValue = "<REDACTED>"
+
+// configFileNames are the optional config files looked up in the config path
+var configFileNames = []string{"antholume.yaml", "antholume.yml", "antholume.toml"}
+
+// setting describes a configuration value. The environment variable is the
+// key, while the config file uses the lowercase key (e.g. log_level).
+type setting struct {
+	key       string
+	fallback  string
+	secret    bool
+	envOnly   bool
+	field     func(c *Config) any
+	normalize func(value string) string
+	validate  func(value string) error
+}
+
+var settings = []setting{
+	{key: "CONFIG_PATH", fallback: "/config", envOnly: true, field: func(c *Config) any { return &c.ConfigPath }},
+	{key: "DATA_PATH", fallback: "/data", field: func(c *Config) any { return &c.DataPath }},
+	{key: "LISTEN_PORT", fallback: "8585", field: func(c *Config) any { return &c.ListenPort }, normalize: strings.TrimSpace, validate: validatePort},
+	{key: "DATABASE_TYPE", fallback: "SQLite", field: func(c *Config) any { return &c.DBType }, normalize: trimLowerString, validate: validateOneOf("sqlite", "memory")},
+	{key: "DATABASE_NAME", fallback: "antholume", field: func(c *Config) any { return &c.DBName }, normalize: trimLowerString},
+	{key: "LOG_LEVEL", fallback: "info", field: func(c *Config) any { return &c.LogLevel }, normalize: trimLowerString, validate: validateLogLevel},
+	{key: "REGISTRATION_ENABLED", fallback: "false", field: func(c *Config) any { return &c.RegistrationEnabled }},
+	{key: "SEARCH_ENABLED", fallback: "false", field: func(c *Config) any { return &c.SearchEnabled }},
+	{key: "METRICS_ENABLED", fallback: "false", field: func(c *Config) any { return &c.MetricsEnabled }},
+	{key: "DEMO_MODE", fallback: "false", field: func(c *Config) any { return &c.DemoMode }},
+	{key: "COOKIE_AUTH_KEY", secret: true, field: func(c *Config) any { return &c.CookieAuthKey }, normalize: trimLowerString},
+	{key: "COOKIE_ENC_KEY", secret: true, field: func(c *Config) any { return &c.CookieEncKey }, normalize: trimLowerString, validate: validateCookieEncKey},
+	{key: "COOKIE_SECURE", fallback: "true", field: func(c *Config) any { return &c.CookieSecure }},
+	{key: "COOKIE_HTTP_ONLY", fallback: "true", field: func(c *Config) any { return &c.CookieHTTPOnly }},
+	{key: "COOKIE_KEY_ROTATION_DAYS", fallback: "90", field: func(c *Config) any { return &c.CookieKeyRotation }},
+	{key: "COOKIE_KEY_GRACE_DAYS", fallback: "7", field: func(c *Config) any { return &c.CookieKeyGrace }},
+	{key: "MIN_FREE_DISK_MB", fallback: "100", field: func(c *Config) any { return &c.MinFreeDiskMB }},
+	{key: "WATCH_DIRECTORIES", field: func(c *Config) any { return &c.WatchDirectories }},
+	{key: "WATCH_IMPORT_TYPE", fallback: "copy", field: func(c *Config) any { return &c.WatchImportType }, normalize: trimLowerString, validate: validateOneOf("copy", "direct")},
+	{key: "WATCH_DEBOUNCE_SECONDS", fallback: "30", field: func(c *Config) any { return &c.WatchDebounce }},
+}
+
+// Setting is a resolved configuration value
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Parse resolves the configuration from the defaults, the optional config
+// file and the environment (in increasing precedence). All invalid values are
+// reported together.
+func Parse() (*Config, error) {
+	c := &Config{Version: version, sources: map[string]string{}}
+
+	// Read Config File
+	configPath := getEnv("CONFIG_PATH", "/config")
+	configFile, fileValues, errs := readConfigFile(configPath)
+	c.ConfigFile = configFile
+
+	// Resolve Settings
+	for _, s := range settings {
+		value, source := s.fallback, SourceDefault
+		if fileValue, ok := fileValues[s.key]; ok {
+			value, source = fileValue, SourceFile
+		}
+		if envValue, ok := os.LookupEnv(s.key); ok {
+			value, source = envValue, SourceEnv
+		}
+
+		c.sources[s.key] = source
+		if err := s.apply(c, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", s.key, source, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Settings returns the effective configuration with secrets redacted
+func (c *Config) Settings() []Setting {
+	var allSettings []Setting
+	for _, s := range settings {
+		value := s.format(c)
+		if s.secret && value != "" {
+			value = redactedValue
+		}
+		allSettings = append(allSettings, Setting{Key: s.key, Value: value, Source: c.sources[s.key]})
+	}
+	return allSettings
+}
+
+// apply validates & stores the value in the setting field
+func (s setting) apply(c *Config, value string) error {
+	switch field := s.field(c).(type) {
+	case *string:
+		if s.normalize != nil {
+			value = s.normalize(value)
+		}
+		if s.validate != nil {
+			if err := s.validate(value); err != nil {
+				return err
+			}
+		}
+		*field = value
+	case *bool:
+		switch trimLowerString(value) {
+		case "true":
+			*field = true
+		case "false":
+			*field = false
+		default:
+			return fmt.Errorf("invalid boolean %q (must be true or false)", value)
+		}
+	case *int:
+		intValue, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || intValue < 0 {
+			return fmt.Errorf("invalid value %q (must be a non-negative integer)", value)
+		}
+		*field = intValue
+	case *[]string:
+		*field = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+// format returns the string representation of the setting field
+func (s setting) format(c *Config) string {
+	switch field := s.field(c).(type) {
+	case *string:
+		return *field
+	case *bool:
+		return strconv.FormatBool(*field)
+	case *int:
+		return strconv.Itoa(*field)
+	case *[]string:
+		return strings.Join(*field, ",")
+	default:
+		return ""
+	}
+}
+
+// readConfigFile reads the optional config file in the config path, returning
+// its path and values keyed by setting key.
+func readConfigFile(configPath string) (string, map[string]string, []error) {
+	// Find Config File
+	var configFiles []string
+	for _, fileName := range configFileNames {
+		filePath := filepath.Join(configPath, fileName)
+		if _, err := os.Stat(filePath); err == nil {
+			configFiles = append(configFiles, filePath)
+		}
+	}
+	if len(configFiles) == 0 {
+		return "", nil, nil
+	} else if len(configFiles) > 1 {
+		return "", nil, []error{fmt.Errorf("multiple config files found: %s", strings.Join(configFiles, ", "))}
+	}
+	configFile := configFiles[0]
+
+	// Decode Config File
+	rawFile, err := os.ReadFile(configFile)
+	if err != nil {
+		return configFile, nil, []error{fmt.Errorf("unable to read config file: %w", err)}
+	}
+
+	rawValues := map[string]any{}
+	if filepath.Ext(configFile) == ".toml" {
+		err = toml.Unmarshal(rawFile, &rawValues)
+	} else {
+		err = yaml.Unmarshal(rawFile, &rawValues)
+	}
+	if err != nil {
+		return configFile, nil, []error{fmt.Errorf("unable to parse config file %s: %w", configFile, err)}
+	}
+
+	// Map Setting Keys
+	settingsByName := map[string]setting{}
+	for _, s := range settings {
+		settingsByName[strings.ToLower(s.key)] = s
+	}
+
+	var errs []error
+	values := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(rawValues)) {
+		rawValue := rawValues[name]
+		s, ok := settingsByName[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", configFile, name))
+			continue
+		} else if s.envOnly {
+			errs = append(errs, fmt.Errorf("%s: key %q can only be set via the %s environment variable", configFile, name, s.key))
+			continue
+		}
+
+		switch rawValue.(type) {
+		case nil:
+			values[s.key] = ""
+		case string, bool, int, int64, uint64, float64:
+			values[s.key] = fmt.Sprint(rawValue)
+		case []any:
+			if _, isList := s.field(&Config{}).(*[]string); !isList {
+				errs = append(errs, fmt.Errorf("%s: key %q must be a scalar value", configFile, name))
+				continue
+			}
+			var items []string
+			for _, item := range rawValue.([]any) {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[s.key] = strings.Join(items, ",")
+		default:
+			errs = append(errs, fmt.Errorf("%s: key %q must be a scalar value", configFile, name))
+		}
+	}
+
+	return configFile, values, errs
+}
+
+func validateOneOf(options ...string) func(string) error {
+	return func(value string) error {
+		for _, option := range options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q (must be one of: %s)", value, strings.Join(options, ", "))
+	}
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q", value)
+	}
+	return nil
+}
+
+func validateLogLevel(value string) error {
+	if _, err := log.ParseLevel(value); err != nil {
+		return fmt.Errorf("invalid log level %q", value)
+	}
+	return nil
+}
+
+func validateCookieEncKey(value string) error {
+	if value != "" && len(value) != 16 && len(value) != 32 {
+		return errors.New("invalid cookie encryption key (must be 16 or 32 bytes)")
+	}
+	return nil
+}
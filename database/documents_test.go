@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"reichard.io/antholume/config"
+	"reichard.io/antholume/pkg/ptr"
 )
 
 type DocumentsTestSuite struct {
@@ -26,8 +27,17 @@ func (suite *DocumentsTestSuite) SetupTest() {
 
 	suite.dbm = NewMgr(&cfg)
 
+	// Create User
+	_, err := suite.dbm.Queries.CreateUser(context.Background(), CreateUserParams{
+		ID:       testUserID,
+		Pass:     &testUserPass,
+		AuthHash: &testUserPass,
+		Role:     "user",
+	})
+	suite.NoError(err)
+
 	// Create Document
-	_, err := suite.dbm.Queries.UpsertDocument(context.Background(), UpsertDocumentParams{
+	_, err = suite.dbm.Queries.UpsertDocument(context.Background(), UpsertDocumentParams{
 		ID:     documentID,
 		Title:  &documentTitle,
 		Author: &documentAuthor,
@@ -71,6 +81,10 @@ func (suite *DocumentsTestSuite) TestDeleteDocument() {
 	doc, err := suite.dbm.Queries.GetDocument(context.Background(), documentID)
 	suite.Nil(err, "should have nil err")
 	suite.True(doc.Deleted, "should have deleted the document")
+
+	length, err := suite.dbm.Queries.GetDocumentsSize(context.Background(), GetDocumentsSizeParams{UserID: testUserID})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(0), length, "should not count deleted document")
 }
 
 func (suite *DocumentsTestSuite) TestGetDeletedDocuments() {
@@ -78,14 +92,27 @@ func (suite *DocumentsTestSuite) TestGetDeletedDocuments() {
 	suite.Nil(err, "should have nil err")
 	suite.Equal(int64(1), changed, "should have changed the document")
 
-	deletedDocs, err := suite.dbm.Queries.GetDeletedDocuments(context.Background(), []string{documentID})
+	deletedDocs, err := suite.dbm.Queries.GetDeletedDocuments(context.Background(), GetDeletedDocumentsParams{
+		UserID:      testUserID,
+		DocumentIds: []string{documentID},
+	})
 	suite.Nil(err, "should have nil err")
 	suite.Len(deletedDocs, 1, "should have one deleted document")
+
+	deletedDocs, err = suite.dbm.Queries.GetDeletedDocuments(context.Background(), GetDeletedDocumentsParams{
+		UserID:      "unknownUser",
+		DocumentIds: []string{documentID},
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Empty(deletedDocs, "should only have documents visible to the user")
 }
 
 // TODO - Convert GetWantedDocuments -> (sqlc.slice('document_ids'));
 func (suite *DocumentsTestSuite) TestGetWantedDocuments() {
-	wantedDocs, err := suite.dbm.Queries.GetWantedDocuments(context.Background(), fmt.Sprintf("[\"%s\"]", documentID))
+	wantedDocs, err := suite.dbm.Queries.GetWantedDocuments(context.Background(), GetWantedDocumentsParams{
+		DocumentIds: fmt.Sprintf("[\"%s\"]", documentID),
+		UserID:      testUserID,
+	})
 	suite.Nil(err, "should have nil err")
 	suite.Len(wantedDocs, 1, "should have one wanted document")
 }
@@ -98,11 +125,17 @@ func (suite *DocumentsTestSuite) TestGetMissingDocuments() {
 	})
 	suite.NoError(err)
 
-	missingDocs, err := suite.dbm.Queries.GetMissingDocuments(context.Background(), []string{documentID})
+	missingDocs, err := suite.dbm.Queries.GetMissingDocuments(context.Background(), GetMissingDocumentsParams{
+		UserID:      testUserID,
+		DocumentIds: []string{documentID},
+	})
 	suite.Nil(err, "should have nil err")
 	suite.Len(missingDocs, 0, "should have no wanted document")
 
-	missingDocs, err = suite.dbm.Queries.GetMissingDocuments(context.Background(), []string{"other"})
+	missingDocs, err = suite.dbm.Queries.GetMissingDocuments(context.Background(), GetMissingDocumentsParams{
+		UserID:      testUserID,
+		DocumentIds: []string{"other"},
+	})
 	suite.Nil(err, "should have nil err")
 	suite.Len(missingDocs, 1, "should have one missing document")
 	suite.Equal(documentID, missingDocs[0].ID, "should have missing doc")
@@ -113,3 +146,220 @@ func (suite *DocumentsTestSuite) TestGetMissingDocuments() {
 	// suite.Len(missingDocs, 1, "should have one missing document")
 	// suite.Equal(documentID, missingDocs[0].ID, "should have missing doc")
 }
+
+func (suite *DocumentsTestSuite) TestGetDocumentsProgress() {
+	ctx := context.Background()
+	_, err := suite.dbm.Queries.UpsertDocument(ctx, UpsertDocumentParams{ID: "otherdoc"})
+	suite.NoError(err)
+	for _, device := range []string{"device1", "device2"} {
+		_, err := suite.dbm.Queries.UpsertDevice(ctx, UpsertDeviceParams{ID: device, UserID: testUserID, DeviceName: device})
+		suite.NoError(err)
+	}
+
+	// Furthest on device1, Newest on device2
+	for _, progress := range []UpdateProgressParams{
+		{DocumentID: documentID, DeviceID: "device1", Percentage: 0.8, Progress: "8", CreatedAt: "2024-01-01T00:00:00Z"},
+		{DocumentID: documentID, DeviceID: "device2", Percentage: 0.2, Progress: "2", CreatedAt: "2024-01-02T00:00:00Z"},
+		{DocumentID: "otherdoc", DeviceID: "device1", Percentage: 0.5, Progress: "5", CreatedAt: "2024-01-01T00:00:00Z"},
+	} {
+		progress.UserID = testUserID
+		_, err := suite.dbm.Queries.UpdateProgress(ctx, progress)
+		suite.NoError(err)
+	}
+
+	getProgress := func() map[string]string {
+		rows, err := suite.dbm.Queries.GetDocumentsProgress(ctx, GetDocumentsProgressParams{
+			UserID:      testUserID,
+			DocumentIds: []string{documentID, "otherdoc", "unknown"},
+		})
+		suite.Nil(err, "should have nil err")
+		progress := make(map[string]string)
+		for _, row := range rows {
+			progress[row.DocumentID] = row.Progress
+		}
+		return progress
+	}
+	suite.Equal(map[string]string{documentID: "2", "otherdoc": "5"}, getProgress(), "should have newest progress per document")
+
+	_, err = suite.dbm.Queries.UpdateUser(ctx, UpdateUserParams{UserID: testUserID, ProgressStrategy: ptr.Of("furthest")})
+	suite.NoError(err)
+	suite.Equal(map[string]string{documentID: "8", "otherdoc": "5"}, getProgress(), "should have furthest progress per document")
+}
+
+func (suite *DocumentsTestSuite) TestDocumentVisibility() {
+	ownerID, otherID := "owner", "other"
+	for _, userID := range []string{ownerID, otherID} {
+		_, err := suite.dbm.Queries.CreateUser(context.Background(), CreateUserParams{
+			ID:       userID,
+			Pass:     &testUserPass,
+			AuthHash: &testUserPass,
+			Role:     "user",
+		})
+		suite.NoError(err)
+	}
+
+	// Create Private Document
+	privateDocID := "privatedoc"
+	doc, err := suite.dbm.Queries.UpsertDocument(context.Background(), UpsertDocumentParams{
+		ID:       privateDocID,
+		Title:    &documentTitle,
+		Filepath: &documentFilepath,
+		OwnerID:  &ownerID,
+	})
+	suite.NoError(err)
+	suite.Equal("private", doc.Visibility, "owned document should default to private")
+
+	// Owner Access
+	access, err := suite.dbm.Queries.GetDocumentAccess(context.Background(), GetDocumentAccessParams{
+		UserID:     ownerID,
+		DocumentID: privateDocID,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(ownerID, *access.OwnerID, "should have owner")
+	suite.True(access.HasAccess, "owner should have access")
+
+	// Other Access
+	access, err = suite.dbm.Queries.GetDocumentAccess(context.Background(), GetDocumentAccessParams{
+		UserID:     otherID,
+		DocumentID: privateDocID,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.False(access.HasAccess, "other should not have access")
+
+	docs, err := suite.dbm.Queries.GetDocumentsWithStats(context.Background(), GetDocumentsWithStatsParams{
+		UserID: otherID,
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Len(docs, 1, "should only have public document")
+
+	missingDocs, err := suite.dbm.Queries.GetMissingDocuments(context.Background(), GetMissingDocumentsParams{
+		UserID:      otherID,
+		DocumentIds: []string{"unknown"},
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Len(missingDocs, 0, "should not sync private document")
+
+	// Share With Role
+	_, err = suite.dbm.Queries.UpdateDocumentVisibility(context.Background(), UpdateDocumentVisibilityParams{
+		Visibility: "shared",
+		DocumentID: privateDocID,
+	})
+	suite.NoError(err)
+	_, err = suite.dbm.Queries.UpsertDocumentShare(context.Background(), UpsertDocumentShareParams{
+		DocumentID: privateDocID,
+		ShareType:  "role",
+		ShareWith:  "user",
+	})
+	suite.NoError(err)
+
+	access, err = suite.dbm.Queries.GetDocumentAccess(context.Background(), GetDocumentAccessParams{
+		UserID:     otherID,
+		DocumentID: privateDocID,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(ownerID, *access.OwnerID, "should retain owner")
+	suite.True(access.HasAccess, "shared role should have access")
+
+	length, err := suite.dbm.Queries.GetDocumentsSize(context.Background(), GetDocumentsSizeParams{UserID: otherID})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(2), length, "should include shared document")
+}
+
+func (suite *DocumentsTestSuite) TestDocumentFilters() {
+	series := "Series"
+	for i, title := range []string{"b title", "A title", "c title"} {
+		seriesIndex := int64(3 - i)
+		_, err := suite.dbm.Queries.UpsertDocument(context.Background(), UpsertDocumentParams{
+			ID:          fmt.Sprintf("seriesdoc%d", i),
+			Title:       &title,
+			Author:      &documentAuthor,
+			Series:      &series,
+			SeriesIndex: &seriesIndex,
+		})
+		suite.NoError(err)
+	}
+
+	// Reading & Finished Statistics
+	for docID, percentage := range map[string]float64{"seriesdoc0": 0.5, "seriesdoc1": 1.0} {
+		_, err := suite.dbm.DB.Exec(`
+			INSERT INTO document_user_statistics (
+				document_id, user_id, percentage, last_read, last_seen, read_percentage,
+				total_time_seconds, total_words_read, total_wpm,
+				yearly_time_seconds, yearly_words_read, yearly_wpm,
+				monthly_time_seconds, monthly_words_read, monthly_wpm,
+				weekly_time_seconds, weekly_words_read, weekly_wpm
+			) VALUES (?, ?, ?, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z', ?, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)`,
+			docID, testUserID, percentage, percentage)
+		suite.NoError(err)
+	}
+
+	// Series Order
+	docs, err := suite.dbm.Queries.GetDocumentsWithStats(context.Background(), GetDocumentsWithStatsParams{
+		UserID: testUserID,
+		Series: &series,
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Len(docs, 3, "should filter by series")
+	suite.Equal("seriesdoc2", docs[0].ID, "should order by series index")
+
+	// Title Sort
+	docs, err = suite.dbm.Queries.GetDocumentsWithStats(context.Background(), GetDocumentsWithStatsParams{
+		UserID: testUserID,
+		Author: &documentAuthor,
+		Sort:   "title",
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Len(docs, 4, "should filter by author")
+	suite.Equal("A title", *docs[0].Title, "should sort by title case insensitive")
+
+	// Status
+	for status, docID := range map[string]string{"reading": "seriesdoc0", "finished": "seriesdoc1"} {
+		docs, err = suite.dbm.Queries.GetDocumentsWithStats(context.Background(), GetDocumentsWithStatsParams{
+			UserID: testUserID,
+			Status: status,
+			Limit:  10,
+		})
+		suite.Nil(err, "should have nil err")
+		suite.Len(docs, 1, "should filter by %s", status)
+		suite.Equal(docID, docs[0].ID)
+
+		size, err := suite.dbm.Queries.GetDocumentsSize(context.Background(), GetDocumentsSizeParams{
+			UserID: testUserID,
+			Status: status,
+		})
+		suite.Nil(err, "should have nil err")
+		suite.Equal(int64(1), size, "should count %s", status)
+	}
+
+	// Unread Status
+	docs, err = suite.dbm.Queries.GetDocumentsWithStats(context.Background(), GetDocumentsWithStatsParams{
+		UserID: testUserID,
+		Status: "unread",
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Len(docs, 2, "should filter by unread")
+	for _, doc := range docs {
+		suite.NotContains([]string{"seriesdoc0", "seriesdoc1"}, doc.ID, "should not have read documents")
+	}
+
+	// Groups
+	groups, err := suite.dbm.Queries.GetDocumentGroups(context.Background(), GetDocumentGroupsParams{
+		Field:  "series",
+		UserID: testUserID,
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal([]GetDocumentGroupsRow{{Value: series, Count: 3}}, groups, "should group by series")
+
+	groups, err = suite.dbm.Queries.GetDocumentGroups(context.Background(), GetDocumentGroupsParams{
+		Field:  "author",
+		UserID: testUserID,
+		Limit:  10,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal([]GetDocumentGroupsRow{{Value: documentAuthor, Count: 4}}, groups, "should group by author")
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"reichard.io/antholume/config"
+)
+
+type InvitesTestSuite struct {
+	suite.Suite
+	dbm *DBManager
+}
+
+func TestInvites(t *testing.T) {
+	suite.Run(t, new(InvitesTestSuite))
+}
+
+func (suite *InvitesTestSuite) SetupTest() {
+	cfg := config.Config{
+		DBType: "memory",
+	}
+
+	suite.dbm = NewMgr(&cfg)
+}
+
+func (suite *InvitesTestSuite) createInvite(code string, maxUses int64, expiresAt time.Time) {
+	invite, err := suite.dbm.Queries.CreateInvite(context.Background(), CreateInviteParams{
+		Code:      code,
+		Role:      "guest",
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		CreatedBy: testUserID,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(0), invite.Uses, "should have no uses")
+}
+
+func (suite *InvitesTestSuite) TestUseInvite() {
+	suite.createInvite("limited", 2, time.Now().Add(time.Hour))
+
+	for i := 1; i <= 2; i++ {
+		invite, err := suite.dbm.Queries.UseInvite(context.Background(), "limited")
+		suite.Nil(err, "should have nil err")
+		suite.Equal(int64(i), invite.Uses, "should increment uses")
+		suite.Equal("guest", invite.Role, "should return preset role")
+	}
+
+	_, err := suite.dbm.Queries.UseInvite(context.Background(), "limited")
+	suite.ErrorIs(err, sql.ErrNoRows, "should reject exhausted invite")
+
+	_, err = suite.dbm.Queries.GetInvite(context.Background(), "limited")
+	suite.ErrorIs(err, sql.ErrNoRows, "should not return exhausted invite")
+}
+
+func (suite *InvitesTestSuite) TestExpiredInvite() {
+	suite.createInvite("expired", 1, time.Now().Add(-time.Hour))
+
+	_, err := suite.dbm.Queries.GetInvite(context.Background(), "expired")
+	suite.ErrorIs(err, sql.ErrNoRows, "should not return expired invite")
+
+	_, err = suite.dbm.Queries.UseInvite(context.Background(), "expired")
+	suite.ErrorIs(err, sql.ErrNoRows, "should reject expired invite")
+}
+
+func (suite *InvitesTestSuite) TestInviteUseAudit() {
+	suite.createInvite("audited", 1, time.Now().Add(time.Hour))
+
+	err := suite.dbm.Queries.AddInviteUse(context.Background(), AddInviteUseParams{
+		InviteCode: "audited",
+		UserID:     testUserID,
+		UserAgent:  "KOReader/2024.04",
+		Ip:         "127.0.0.1",
+	})
+	suite.Nil(err, "should have nil err")
+
+	changed, err := suite.dbm.Queries.DeleteInvite(context.Background(), "audited")
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(1), changed, "should delete invite")
+
+	uses, err := suite.dbm.Queries.GetInviteUses(context.Background(), 10)
+	suite.Nil(err, "should have nil err")
+	suite.Len(uses, 1, "should retain audit after invite deletion")
+	suite.Equal(testUserID, uses[0].UserID)
+}
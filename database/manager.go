@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/pressly/goose/v3"
@@ -15,12 +16,15 @@ import (
 	sqlite "modernc.org/sqlite"
 	"reichard.io/antholume/config"
 	_ "reichard.io/antholume/database/migrations"
+	"reichard.io/antholume/metrics"
 )
 
 type DBManager struct {
 	DB      *sql.DB
 	Queries *Queries
 	cfg     *config.Config
+
+	reloading atomic.Bool
 }
 
 //go:embed schema.sql
@@ -63,27 +67,13 @@ func NewMgr(c *config.Config) *DBManager {
 
 // init loads the DB manager
 func (dbm *DBManager) init(ctx context.Context) error {
-	// Build DB Location
-	var dbLocation string
-	switch dbm.cfg.DBType {
-	case "sqlite":
-		dbLocation = filepath.Join(dbm.cfg.ConfigPath, fmt.Sprintf("%s.db", dbm.cfg.DBName))
-	case "memory":
-		dbLocation = ":memory:"
-	default:
-		return fmt.Errorf("unsupported database")
-	}
-
 	var err error
-	dbm.DB, err = sql.Open("sqlite", dbLocation)
+	dbm.DB, err = OpenDB(dbm.cfg)
 	if err != nil {
 		log.Panicf("Unable to open DB: %v", err)
 		return err
 	}
 
-	// Single open connection
-	dbm.DB.SetMaxOpenConns(1)
-
 	// Check if DB is new
 	isNew, err := isEmpty(dbm.DB)
 	if err != nil {
@@ -92,7 +82,7 @@ func (dbm *DBManager) init(ctx context.Context) error {
 	}
 
 	// Init SQLc
-	dbm.Queries = New(dbm.DB)
+	dbm.Queries = New(instrumentedDB{dbm.DB})
 
 	// Execute schema
 	if _, err := dbm.DB.Exec(ddl, nil); err != nil {
@@ -101,7 +91,7 @@ func (dbm *DBManager) init(ctx context.Context) error {
 	}
 
 	// Perform migrations
-	err = dbm.performMigrations(isNew)
+	err = performMigrations(ctx, dbm.DB, isNew)
 	if err != nil && err != goose.ErrNoMigrationFiles {
 		log.Panicf("Error running DB migrations: %v", err)
 		return err
@@ -124,6 +114,9 @@ func (dbm *DBManager) init(ctx context.Context) error {
 
 // Reload closes the DB & reinits
 func (dbm *DBManager) Reload(ctx context.Context) error {
+	dbm.reloading.Store(true)
+	defer dbm.reloading.Store(false)
+
 	// Close handle
 	err := dbm.DB.Close()
 	if err != nil {
@@ -138,8 +131,49 @@ func (dbm *DBManager) Reload(ctx context.Context) error {
 	return nil
 }
 
+// Reloading returns whether the DB is currently being reloaded, during which
+// the handle may be closed.
+func (dbm *DBManager) Reloading() bool {
+	return dbm.reloading.Load()
+}
+
+// Ping verifies the DB is reachable with a trivial query.
+func (dbm *DBManager) Ping(ctx context.Context) error {
+	var result int
+	return dbm.DB.QueryRowContext(ctx, "SELECT 1;").Scan(&result)
+}
+
+// MigrationVersion returns the current goose version of the DB along with
+// the latest embedded migration version.
+func (dbm *DBManager) MigrationVersion(ctx context.Context) (current int64, expected int64, err error) {
+	if err := setupMigrations(); err != nil {
+		return 0, 0, err
+	}
+
+	current, err = goose.GetDBVersionContext(ctx, dbm.DB)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	allMigrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return current, 0, err
+	}
+
+	lastMigration, err := allMigrations.Last()
+	if err != nil {
+		return current, 0, err
+	}
+
+	return current, lastMigration.Version, nil
+}
+
 // CacheTempTables clears existing statistics and recalculates
 func (dbm *DBManager) CacheTempTables(ctx context.Context) error {
+	defer func(start time.Time) {
+		metrics.CacheTempTablesDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	start := time.Now()
 	if _, err := dbm.DB.ExecContext(ctx, user_streaks); err != nil {
 		return err
@@ -180,21 +214,49 @@ func (dbm *DBManager) updateSettings(ctx context.Context) error {
 	return nil
 }
 
+// OpenDB opens the configured DB without initializing the schema or running
+// migrations.
+func OpenDB(c *config.Config) (*sql.DB, error) {
+	// Build DB Location
+	var dbLocation string
+	switch c.DBType {
+	case "sqlite":
+		dbLocation = filepath.Join(c.ConfigPath, fmt.Sprintf("%s.db", c.DBName))
+	case "memory":
+		dbLocation = ":memory:"
+	default:
+		return nil, fmt.Errorf("unsupported database")
+	}
+
+	db, err := sql.Open("sqlite", dbLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single open connection
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
 // performMigrations runs all migrations
-func (dbm *DBManager) performMigrations(isNew bool) error {
+func performMigrations(ctx context.Context, db *sql.DB, isNew bool) error {
 	// Create context
-	ctx := context.WithValue(context.Background(), "isNew", isNew) // nolint
+	ctx = context.WithValue(ctx, "isNew", isNew) // nolint
 
 	// Set DB migration
-	goose.SetBaseFS(migrations)
-
-	// Run migrations
-	goose.SetLogger(log.StandardLogger())
-	if err := goose.SetDialect("sqlite"); err != nil {
+	if err := setupMigrations(); err != nil {
 		return err
 	}
 
-	return goose.UpContext(ctx, dbm.DB, "migrations")
+	return goose.UpContext(ctx, db, "migrations")
+}
+
+// setupMigrations configures goose to use the embedded migrations
+func setupMigrations() error {
+	goose.SetBaseFS(migrations)
+	goose.SetLogger(log.StandardLogger())
+	return goose.SetDialect("sqlite")
 }
 
 // isEmpty determines whether the database is empty
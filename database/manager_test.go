@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
@@ -100,6 +101,51 @@ func (suite *DatabaseTestSuite) SetupTest() {
 	suite.NoError(err)
 }
 
+// HEALTH:
+//   - 󰊕  (dbm *DBManager) Ping
+//   - 󰊕  (dbm *DBManager) MigrationVersion
+//   - 󰊕  (dbm *DBManager) Reloading
+func (suite *DatabaseTestSuite) TestHealth() {
+	ctx := context.Background()
+	suite.NoError(suite.dbm.Ping(ctx), "should be reachable")
+
+	current, expected, err := suite.dbm.MigrationVersion(ctx)
+	suite.NoError(err)
+	suite.Positive(expected, "should have embedded migrations")
+	suite.Equal(expected, current, "should be fully migrated")
+
+	suite.NoError(suite.dbm.Reload(ctx))
+	suite.False(suite.dbm.Reloading(), "should have finished reloading")
+	suite.NoError(suite.dbm.Ping(ctx), "should be reachable after reload")
+}
+
+// MIGRATIONS:
+//   - 󰊕  GetMigrationStatus
+//   - 󰊕  MigrateDown
+//   - 󰊕  MigrateUp
+func (suite *DatabaseTestSuite) TestMigrations() {
+	ctx := context.Background()
+	allStatus, err := GetMigrationStatus(ctx, suite.dbm.DB)
+	suite.NoError(err)
+	suite.NotEmpty(allStatus, "should have embedded migrations")
+	for _, status := range allStatus {
+		suite.True(status.Applied, "should have applied %s", status.Name)
+	}
+
+	// Roll Back Latest
+	suite.NoError(MigrateDown(ctx, suite.dbm.DB))
+	allStatus, err = GetMigrationStatus(ctx, suite.dbm.DB)
+	suite.NoError(err)
+	suite.False(allStatus[len(allStatus)-1].Applied, "should have rolled back latest")
+	suite.True(allStatus[len(allStatus)-2].Applied, "should have kept previous")
+
+	// Reapply
+	suite.NoError(MigrateUp(ctx, suite.dbm.DB))
+	allStatus, err = GetMigrationStatus(ctx, suite.dbm.DB)
+	suite.NoError(err)
+	suite.True(allStatus[len(allStatus)-1].Applied, "should have reapplied latest")
+}
+
 // DEVICES - TODO:
 //   - 󰊕  (q *Queries) GetDevice
 //   - 󰊕  (q *Queries) GetDevices
@@ -146,6 +192,51 @@ func (suite *DatabaseTestSuite) TestActivity() {
 	suite.Len(doesntExistsRows, 0, "should have no rows")
 }
 
+// PROGRESS HISTORY
+//   - 󰊕  (q *Queries) GetDocumentProgressHistory
+//   - 󰊕  (q *Queries) GetProgressHistoryEntry
+func (suite *DatabaseTestSuite) TestProgressHistory() {
+	// Replace Progress Of Same Device
+	for i, progress := range []string{"/body/DocFragment[1]", "/body/DocFragment[2]"} {
+		_, err := suite.dbm.Queries.UpdateProgress(context.Background(), UpdateProgressParams{
+			UserID:     userID,
+			DocumentID: documentID,
+			DeviceID:   deviceID,
+			Percentage: float64(i+1) / 10.0,
+			Progress:   progress,
+			CreatedAt:  fmt.Sprintf("2024-01-0%dT00:00:00Z", i+1),
+		})
+		suite.NoError(err)
+	}
+
+	history, err := suite.dbm.Queries.GetDocumentProgressHistory(context.Background(), GetDocumentProgressHistoryParams{
+		UserID:     userID,
+		DocumentID: documentID,
+		Limit:      50,
+	})
+	suite.NoError(err)
+	suite.Len(history, 2, "should keep replaced progress")
+	suite.Equal("/body/DocFragment[2]", history[0].Progress, "should have newest first")
+	suite.Equal(20.0, history[0].Percentage, "should have percentage")
+	suite.Equal(deviceName, history[0].DeviceName, "should have device name")
+
+	entry, err := suite.dbm.Queries.GetProgressHistoryEntry(context.Background(), GetProgressHistoryEntryParams{
+		ID:     history[1].ID,
+		UserID: userID,
+	})
+	suite.NoError(err)
+	suite.Equal("/body/DocFragment[1]", entry.Progress, "should have raw progress")
+	suite.Equal(0.1, entry.Percentage, "should have raw percentage")
+	suite.Equal("2024-01-01T00:00:00Z", entry.CreatedAt, "should have raw created at")
+
+	// Other Users Entry
+	_, err = suite.dbm.Queries.GetProgressHistoryEntry(context.Background(), GetProgressHistoryEntryParams{
+		ID:     history[1].ID,
+		UserID: "otherUser",
+	})
+	suite.ErrorIs(err, sql.ErrNoRows, "should not find other users entry")
+}
+
 // MISC - TODO:
 //   - 󰊕  (q *Queries) AddMetadata
 //   - 󰊕  (q *Queries) GetDailyReadStats
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"reichard.io/antholume/metrics"
+)
+
+// instrumentedDB records the duration of sqlc queries, named by their
+// "-- name:" annotation. Queries within transactions (WithTx) aren't recorded.
+type instrumentedDB struct {
+	DBTX
+}
+
+func (db instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return db.DBTX.ExecContext(ctx, query, args...)
+}
+
+func (db instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	defer observeQuery(query, time.Now())
+	return db.DBTX.PrepareContext(ctx, query)
+}
+
+func (db instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return db.DBTX.QueryContext(ctx, query, args...)
+}
+
+func (db instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return db.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+func observeQuery(query string, start time.Time) {
+	metrics.DBQueryDuration.WithLabelValues(queryName(query)).Observe(time.Since(start).Seconds())
+}
+
+// queryName returns the sqlc name of a query (e.g. "GetDocument").
+func queryName(query string) string {
+	annotation, found := strings.CutPrefix(query, "-- name: ")
+	if !found {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(annotation, " ")
+	return name
+}
+
+// totalsCollector reports library totals, queried on every scrape.
+type totalsCollector struct {
+	dbm       *DBManager
+	documents *prometheus.Desc
+	users     *prometheus.Desc
+	activity  *prometheus.Desc
+}
+
+// TotalsCollector returns a collector of the document, user and activity totals.
+func (dbm *DBManager) TotalsCollector() prometheus.Collector {
+	return &totalsCollector{
+		dbm:       dbm,
+		documents: metrics.Desc("documents", "Documents in the library (excluding deleted)."),
+		users:     metrics.Desc("users", "Registered users."),
+		activity:  metrics.Desc("activity_records", "Recorded reading activity."),
+	}
+}
+
+func (c *totalsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.documents
+	ch <- c.users
+	ch <- c.activity
+}
+
+func (c *totalsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	totals, err := c.dbm.Queries.GetTotals(ctx)
+	if err != nil {
+		log.Error("GetTotals DB Error: ", err)
+		ch <- prometheus.NewInvalidMetric(c.documents, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.documents, prometheus.GaugeValue, float64(totals.Documents))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(totals.Users))
+	ch <- prometheus.MustNewConstMetric(c.activity, prometheus.GaugeValue, float64(totals.Activity))
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+
+	"github.com/pressly/goose/v3"
+)
+
+// MigrationStatus is the state of an embedded migration
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// GetMigrationStatus returns the embedded migrations and whether they've been
+// applied to the DB.
+func GetMigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	if err := setupMigrations(); err != nil {
+		return nil, err
+	}
+
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	allMigrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var allStatus []MigrationStatus
+	for _, migration := range allMigrations {
+		allStatus = append(allStatus, MigrationStatus{
+			Version: migration.Version,
+			Name:    filepath.Base(migration.Source),
+			Applied: migration.Version <= current,
+		})
+	}
+
+	return allStatus, nil
+}
+
+// MigrateUp applies all pending migrations. Note that the server also applies
+// pending migrations on startup.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	isNew, err := isEmpty(db)
+	if err != nil {
+		return err
+	}
+	return performMigrations(ctx, db, isNew)
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func MigrateDown(ctx context.Context, db *sql.DB) error {
+	if err := setupMigrations(); err != nil {
+		return err
+	}
+	return goose.DownContext(ctx, db, "migrations")
+}
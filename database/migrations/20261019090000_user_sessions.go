@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upUserSessions, downUserSessions)
+}
+
+func upUserSessions(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Recreate user deletion trigger (sessions table created by schema)
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downUserSessions(ctx context.Context, tx *sql.Tx) error {
+	// Restore trigger & drop sessions
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  END;
+	  DROP TABLE IF EXISTS sessions;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upUserRoles, downUserRoles)
+}
+
+func upUserRoles(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Add role column (roles table created & seeded by schema)
+	_, err := tx.Exec(`
+	  ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downUserRoles(ctx context.Context, tx *sql.Tx) error {
+	// Drop column & roles
+	_, err := tx.Exec(`
+	  ALTER TABLE users DROP COLUMN role;
+	  DROP TABLE IF EXISTS roles;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upDocumentVisibility, downDocumentVisibility)
+}
+
+func upDocumentVisibility(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Add owner & visibility columns (existing documents remain public) and
+	// recreate user deletion trigger (shares table created by schema)
+	_, err := tx.Exec(`
+	  ALTER TABLE documents ADD COLUMN owner_id TEXT;
+	  ALTER TABLE documents ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('private', 'shared', 'public'));
+
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downDocumentVisibility(ctx context.Context, tx *sql.Tx) error {
+	// Restore trigger, drop columns & shares
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  END;
+
+	  ALTER TABLE documents DROP COLUMN visibility;
+	  ALTER TABLE documents DROP COLUMN owner_id;
+	  DROP TABLE IF EXISTS document_shares;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
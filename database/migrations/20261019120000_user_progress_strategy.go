@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upUserProgressStrategy, downUserProgressStrategy)
+}
+
+func upUserProgressStrategy(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Add progress conflict strategy column
+	_, err := tx.Exec(`
+	  ALTER TABLE users ADD COLUMN progress_strategy TEXT NOT NULL DEFAULT 'newest' CHECK (progress_strategy IN ('newest', 'furthest'));
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downUserProgressStrategy(ctx context.Context, tx *sql.Tx) error {
+	// Drop column
+	_, err := tx.Exec(`
+	  ALTER TABLE users DROP COLUMN progress_strategy;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
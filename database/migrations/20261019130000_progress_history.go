@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upProgressHistory, downProgressHistory)
+}
+
+func upProgressHistory(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Seed history with current progress and recreate user deletion trigger
+	// (history table & trigger created by schema)
+	_, err := tx.Exec(`
+	  INSERT INTO document_progress_history (user_id, document_id, device_id, percentage, progress, created_at)
+	  SELECT user_id, document_id, device_id, percentage, progress, created_at
+	  FROM document_progress
+	  ORDER BY created_at;
+
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downProgressHistory(ctx context.Context, tx *sql.Tx) error {
+	// Restore trigger & drop history
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+
+	  DROP TRIGGER IF EXISTS document_progress_history_insert;
+	  DROP TABLE IF EXISTS document_progress_history;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
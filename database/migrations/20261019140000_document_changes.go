@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upDocumentChanges, downDocumentChanges)
+}
+
+func upDocumentChanges(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Seed changelog with existing documents (changelog table & triggers
+	// created by schema)
+	_, err := tx.Exec(`
+	  INSERT OR IGNORE INTO document_changes (document_id, change_type, created_at)
+	  SELECT id, IIF(deleted, 'delete', 'add'), updated_at
+	  FROM documents
+	  ORDER BY updated_at;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downDocumentChanges(ctx context.Context, tx *sql.Tx) error {
+	// Drop changelog & triggers
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS document_changes_insert;
+	  DROP TRIGGER IF EXISTS document_changes_update;
+	  DROP TRIGGER IF EXISTS document_changes_share_insert;
+	  DROP TRIGGER IF EXISTS document_changes_share_delete;
+	  DROP TRIGGER IF EXISTS document_changes_user_access;
+	  DROP TABLE IF EXISTS document_changes;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAPITokens, downAPITokens)
+}
+
+func upAPITokens(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Recreate user deletion trigger (api_tokens table created by schema)
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downAPITokens(ctx context.Context, tx *sql.Tx) error {
+	// Restore trigger & drop api tokens
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+
+	  DROP INDEX IF EXISTS api_tokens_user_id;
+	  DROP TABLE IF EXISTS api_tokens;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upWebhooks, downWebhooks)
+}
+
+func upWebhooks(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Recreate user deletion trigger (webhook tables created by schema)
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+	  DELETE FROM webhooks WHERE webhooks.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downWebhooks(ctx context.Context, tx *sql.Tx) error {
+	// Restore trigger & drop webhooks
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+
+	  DROP TRIGGER IF EXISTS webhook_deleted;
+	  DROP INDEX IF EXISTS webhook_deliveries_status_next_attempt_at;
+	  DROP INDEX IF EXISTS webhook_deliveries_webhook_id;
+	  DROP INDEX IF EXISTS webhooks_user_id;
+	  DROP TABLE IF EXISTS webhook_deliveries;
+	  DROP TABLE IF EXISTS webhooks;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
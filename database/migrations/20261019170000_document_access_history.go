@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upDocumentAccessHistory, downDocumentAccessHistory)
+}
+
+func upDocumentAccessHistory(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Recreate user access & deletion triggers (access history table & triggers
+	// created by schema)
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS document_changes_user_access;
+	  CREATE TRIGGER document_changes_user_access
+	  AFTER UPDATE OF admin, role ON users
+	  WHEN OLD.admin IS NOT NEW.admin OR OLD.role IS NOT NEW.role
+	  BEGIN
+	  DELETE FROM document_changes WHERE document_id IN (
+	      SELECT id FROM documents
+	      WHERE
+	          visibility != 'public'
+	          AND owner_id IS NOT NEW.id
+	          AND NOT (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'user'
+	                  AND shares.share_with = NEW.id
+	          ))
+	          AND (OLD.admin = 1 OR (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'role'
+	                  AND shares.share_with = OLD.role
+	          ))) != (NEW.admin = 1 OR (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'role'
+	                  AND shares.share_with = NEW.role
+	          )))
+	  );
+	  INSERT INTO document_changes (document_id, change_type)
+	  SELECT id, IIF(deleted, 'delete', 'update') FROM documents WHERE id IN (
+	      SELECT id FROM documents
+	      WHERE
+	          visibility != 'public'
+	          AND owner_id IS NOT NEW.id
+	          AND NOT (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'user'
+	                  AND shares.share_with = NEW.id
+	          ))
+	          AND (OLD.admin = 1 OR (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'role'
+	                  AND shares.share_with = OLD.role
+	          ))) != (NEW.admin = 1 OR (visibility = 'shared' AND EXISTS (
+	              SELECT 1 FROM document_shares AS shares
+	              WHERE
+	                  shares.document_id = documents.id
+	                  AND shares.share_type = 'role'
+	                  AND shares.share_with = NEW.role
+	          )))
+	  );
+	  END;
+
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+	  DELETE FROM webhooks WHERE webhooks.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  DELETE FROM document_access_history WHERE document_access_history.user_id=OLD.id;
+	  END;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downDocumentAccessHistory(ctx context.Context, tx *sql.Tx) error {
+	// Restore triggers & drop access history
+	_, err := tx.Exec(`
+	  DROP TRIGGER IF EXISTS document_changes_user_access;
+	  CREATE TRIGGER document_changes_user_access
+	  AFTER UPDATE OF admin, role ON users
+	  WHEN OLD.admin IS NOT NEW.admin OR OLD.role IS NOT NEW.role
+	  BEGIN
+	  DELETE FROM document_changes WHERE document_id IN (
+	      SELECT id FROM documents WHERE visibility != 'public'
+	  );
+	  INSERT INTO document_changes (document_id, change_type)
+	  SELECT id, IIF(deleted, 'delete', 'update') FROM documents WHERE visibility != 'public';
+	  END;
+
+	  DROP TRIGGER IF EXISTS user_deleted;
+	  CREATE TRIGGER user_deleted
+	  BEFORE DELETE ON users BEGIN
+	  DELETE FROM activity WHERE activity.user_id=OLD.id;
+	  DELETE FROM devices WHERE devices.user_id=OLD.id;
+	  DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+	  DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+	  DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+	  DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+	  DELETE FROM webhooks WHERE webhooks.user_id=OLD.id;
+	  DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+	  UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+	  END;
+
+	  DROP TRIGGER IF EXISTS document_access_history_update;
+	  DROP TRIGGER IF EXISTS document_access_history_share_delete;
+	  DROP TRIGGER IF EXISTS document_access_history_user_access;
+	  DROP TABLE IF EXISTS document_access_history;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
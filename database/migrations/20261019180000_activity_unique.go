@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upActivityUnique, downActivityUnique)
+}
+
+func upActivityUnique(ctx context.Context, tx *sql.Tx) error {
+	// Runs on new DBs too, as the schema index can't be unique until existing
+	// duplicates are merged.
+	//
+	// Merge duplicate activity into the earliest row (longest duration & widest
+	// percentage range), then enforce uniqueness
+	_, err := tx.Exec(`
+	  UPDATE activity
+	  SET
+	      duration = merged.duration,
+	      start_percentage = merged.start_percentage,
+	      end_percentage = merged.end_percentage
+	  FROM (
+	      SELECT
+	          MIN(id) AS id,
+	          MAX(duration) AS duration,
+	          MIN(start_percentage) AS start_percentage,
+	          MAX(end_percentage) AS end_percentage
+	      FROM activity
+	      GROUP BY user_id, device_id, document_id, start_time
+	      HAVING COUNT(*) > 1
+	  ) AS merged
+	  WHERE activity.id = merged.id;
+
+	  DELETE FROM activity
+	  WHERE id NOT IN (
+	      SELECT MIN(id)
+	      FROM activity
+	      GROUP BY user_id, device_id, document_id, start_time
+	  );
+
+	  DROP INDEX IF EXISTS activity_user_id_device_id_document_id_start_time;
+	  CREATE UNIQUE INDEX activity_user_id_device_id_document_id_start_time ON activity (
+	      user_id,
+	      device_id,
+	      document_id,
+	      start_time
+	  );
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downActivityUnique(ctx context.Context, tx *sql.Tx) error {
+	// Restore non-unique index (merged activity isn't restored)
+	_, err := tx.Exec(`
+	  DROP INDEX IF EXISTS activity_user_id_device_id_document_id_start_time;
+	  CREATE INDEX activity_user_id_device_id_document_id_start_time ON activity (
+	      user_id,
+	      device_id,
+	      document_id,
+	      start_time
+	  );
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upDocumentPages, downDocumentPages)
+}
+
+func upDocumentPages(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Add page count column (existing documents are counted on first stream)
+	_, err := tx.Exec(`
+	  ALTER TABLE documents ADD COLUMN pages INTEGER;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func downDocumentPages(ctx context.Context, tx *sql.Tx) error {
+	// Drop column
+	_, err := tx.Exec(`
+	  ALTER TABLE documents DROP COLUMN pages;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upSessionTokenHash, downSessionTokenHash)
+}
+
+func upSessionTokenHash(ctx context.Context, tx *sql.Tx) error {
+	// Determine if we have a new DB or not
+	isNew := ctx.Value("isNew").(bool)
+	if isNew {
+		return nil
+	}
+
+	// Skip sessions created with hashed tokens by the schema
+	var hasToken bool
+	err := tx.QueryRow(`
+	  SELECT COUNT(*) > 0 FROM pragma_table_info('sessions') WHERE name = 'token';
+	`).Scan(&hasToken)
+	if err != nil {
+		return err
+	} else if !hasToken {
+		return nil
+	}
+
+	// Rename token column
+	_, err = tx.Exec(`
+	  ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Get existing tokens
+	rows, err := tx.Query(`SELECT id, token_hash FROM sessions;`)
+	if err != nil {
+		return err
+	}
+	tokens := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var token string
+		if err := rows.Scan(&id, &token); err != nil {
+			rows.Close()
+			return err
+		}
+		tokens[id] = token
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Hash existing tokens (SQLite has no SHA256 function)
+	for id, token := range tokens {
+		tokenHash := fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+		if _, err := tx.Exec(`UPDATE sessions SET token_hash = ? WHERE id = ?;`, tokenHash, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func downSessionTokenHash(ctx context.Context, tx *sql.Tx) error {
+	// Hashes aren't reversible, so drop sessions & rename token column
+	_, err := tx.Exec(`
+	  DELETE FROM sessions;
+	  ALTER TABLE sessions RENAME COLUMN token_hash TO token;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
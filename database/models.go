@@ -16,6 +16,15 @@ type Activity struct {
 	CreatedAt       string  `json:"created_at"`
 }
 
+type ApiToken struct {
+	ID        int64   `json:"id"`
+	UserID    string  `json:"user_id"`
+	Name      string  `json:"name"`
+	TokenHash string  `json:"-"`
+	LastUsed  *string `json:"last_used"`
+	CreatedAt string  `json:"created_at"`
+}
+
 type Device struct {
 	ID         string `json:"id"`
 	UserID     string `json:"user_id"`
@@ -38,16 +47,31 @@ type Document struct {
 	Lang        *string `json:"lang"`
 	Description *string `json:"description"`
 	Words       *int64  `json:"words"`
+	Pages       *int64  `json:"pages"`
 	Gbid        *string `json:"gbid"`
 	Olid        *string `json:"-"`
 	Isbn10      *string `json:"isbn10"`
 	Isbn13      *string `json:"isbn13"`
+	OwnerID     *string `json:"owner_id"`
+	Visibility  string  `json:"visibility"`
 	Synced      bool    `json:"-"`
 	Deleted     bool    `json:"-"`
 	UpdatedAt   string  `json:"updated_at"`
 	CreatedAt   string  `json:"created_at"`
 }
 
+type DocumentAccessHistory struct {
+	UserID     string `json:"user_id"`
+	DocumentID string `json:"document_id"`
+}
+
+type DocumentChange struct {
+	Seq        int64  `json:"seq"`
+	DocumentID string `json:"document_id"`
+	ChangeType string `json:"change_type"`
+	CreatedAt  string `json:"created_at"`
+}
+
 type DocumentProgress struct {
 	UserID     string  `json:"user_id"`
 	DocumentID string  `json:"document_id"`
@@ -57,6 +81,23 @@ type DocumentProgress struct {
 	CreatedAt  string  `json:"created_at"`
 }
 
+type DocumentProgressHistory struct {
+	ID         int64   `json:"id"`
+	UserID     string  `json:"user_id"`
+	DocumentID string  `json:"document_id"`
+	DeviceID   string  `json:"device_id"`
+	Percentage float64 `json:"percentage"`
+	Progress   string  `json:"progress"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type DocumentShare struct {
+	DocumentID string `json:"document_id"`
+	ShareType  string `json:"share_type"`
+	ShareWith  string `json:"share_with"`
+	CreatedAt  string `json:"created_at"`
+}
+
 type DocumentUserStatistic struct {
 	DocumentID         string  `json:"document_id"`
 	UserID             string  `json:"user_id"`
@@ -78,6 +119,73 @@ type DocumentUserStatistic struct {
 	WeeklyWpm          float64 `json:"weekly_wpm"`
 }
 
+type ImportJob struct {
+	ID         int64   `json:"id"`
+	JobID      *int64  `json:"job_id"`
+	Directory  string  `json:"directory"`
+	ImportType string  `json:"import_type"`
+	TotalFiles *int64  `json:"total_files"`
+	CreatedBy  *string `json:"created_by"`
+	UpdatedAt  string  `json:"updated_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type ImportJobResult struct {
+	ID         int64   `json:"id"`
+	JobID      int64   `json:"job_id"`
+	Path       string  `json:"path"`
+	DocumentID *string `json:"document_id"`
+	Name       *string `json:"name"`
+	Status     string  `json:"status"`
+	Error      *string `json:"error"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type ImportLog struct {
+	ID         int64   `json:"id"`
+	Directory  string  `json:"directory"`
+	Path       string  `json:"path"`
+	DocumentID *string `json:"document_id"`
+	Status     string  `json:"status"`
+	Error      *string `json:"error"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type Invite struct {
+	Code      string `json:"code"`
+	Role      string `json:"role"`
+	MaxUses   int64  `json:"max_uses"`
+	Uses      int64  `json:"uses"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+type InviteUse struct {
+	ID         int64  `json:"id"`
+	InviteCode string `json:"invite_code"`
+	UserID     string `json:"user_id"`
+	UserAgent  string `json:"user_agent"`
+	Ip         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type Job struct {
+	ID          int64   `json:"id"`
+	Type        string  `json:"type"`
+	Payload     string  `json:"payload"`
+	Status      string  `json:"status"`
+	Attempts    int64   `json:"attempts"`
+	MaxAttempts int64   `json:"max_attempts"`
+	Error       *string `json:"error"`
+	CreatedBy   *string `json:"created_by"`
+	RunAt       string  `json:"run_at"`
+	StartedAt   *string `json:"started_at"`
+	FinishedAt  *string `json:"finished_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	CreatedAt   string  `json:"created_at"`
+}
+
 type Metadata struct {
 	ID          int64   `json:"id"`
 	DocumentID  string  `json:"document_id"`
@@ -91,6 +199,29 @@ type Metadata struct {
 	CreatedAt   string  `json:"created_at"`
 }
 
+type Role struct {
+	Name        string `json:"name"`
+	CanUpload   bool   `json:"can_upload"`
+	CanDelete   bool   `json:"can_delete"`
+	CanEdit     bool   `json:"can_edit"`
+	CanSearch   bool   `json:"can_search"`
+	CanDownload bool   `json:"can_download"`
+	CanSync     bool   `json:"can_sync"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type Session struct {
+	ID        int64  `json:"id"`
+	UserID    string `json:"user_id"`
+	TokenHash string `json:"-"`
+	AuthHash  string `json:"-"`
+	UserAgent string `json:"user_agent"`
+	Ip        string `json:"ip"`
+	ExpiresAt string `json:"expires_at"`
+	LastSeen  string `json:"last_seen"`
+	CreatedAt string `json:"created_at"`
+}
+
 type Setting struct {
 	ID        int64  `json:"id"`
 	Name      string `json:"name"`
@@ -99,12 +230,14 @@ type Setting struct {
 }
 
 type User struct {
-	ID        string  `json:"id"`
-	Pass      *string `json:"-"`
-	AuthHash  *string `json:"auth_hash"`
-	Admin     bool    `json:"-"`
-	Timezone  *string `json:"timezone"`
-	CreatedAt string  `json:"created_at"`
+	ID               string  `json:"id"`
+	Pass             *string `json:"-"`
+	AuthHash         *string `json:"auth_hash"`
+	Admin            bool    `json:"-"`
+	Role             string  `json:"role"`
+	Timezone         *string `json:"timezone"`
+	ProgressStrategy string  `json:"progress_strategy"`
+	CreatedAt        string  `json:"created_at"`
 }
 
 type UserStreak struct {
@@ -121,3 +254,27 @@ type UserStreak struct {
 	LastRecord             string `json:"last_record"`
 	LastCalculated         string `json:"last_calculated"`
 }
+
+type Webhook struct {
+	ID        int64   `json:"id"`
+	UserID    *string `json:"user_id"`
+	Url       string  `json:"url"`
+	Secret    string  `json:"-"`
+	Events    string  `json:"events"`
+	Enabled   bool    `json:"enabled"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int64   `json:"id"`
+	WebhookID     int64   `json:"webhook_id"`
+	Event         string  `json:"event"`
+	Payload       string  `json:"payload"`
+	Status        string  `json:"status"`
+	Attempts      int64   `json:"attempts"`
+	ResponseCode  *int64  `json:"response_code"`
+	Error         *string `json:"error"`
+	NextAttemptAt string  `json:"next_attempt_at"`
+	UpdatedAt     string  `json:"updated_at"`
+	CreatedAt     string  `json:"created_at"`
+}
@@ -8,7 +8,16 @@ INSERT INTO activity (
     start_percentage,
     end_percentage
 )
-VALUES (?, ?, ?, ?, ?, ?, ?)
+VALUES (
+    $user_id,
+    $document_id,
+    $device_id,
+    $start_time,
+    $duration,
+    $start_percentage,
+    $end_percentage
+)
+ON CONFLICT DO NOTHING
 RETURNING *;
 
 -- name: AddMetadata :one
@@ -25,20 +34,231 @@ INSERT INTO metadata (
 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: AddImportJobResult :exec
+INSERT INTO import_job_results (job_id, path, document_id, name, status, error)
+VALUES ($job_id, $path, $document_id, $name, $status, $error)
+ON CONFLICT DO NOTHING;
+
+-- name: AddImportLog :exec
+INSERT INTO import_log (directory, path, document_id, status, error)
+VALUES ($directory, $path, $document_id, $status, $error);
+
+-- name: AddInviteUse :exec
+INSERT INTO invite_uses (invite_code, user_id, user_agent, ip)
+VALUES (?, ?, ?, ?);
+
+-- name: CancelJob :execrows
+UPDATE jobs
+SET
+    status = 'cancelled',
+    finished_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id AND status IN ('queued', 'running');
+
+-- name: ClaimJob :one
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    error = NULL,
+    started_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE
+        type = $type
+        AND status = 'queued'
+        AND run_at <= STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+    ORDER BY run_at ASC, id ASC
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET
+    status = $status,
+    error = $error,
+    finished_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id AND status = 'running';
+
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (directory, import_type, created_by)
+VALUES ($directory, $import_type, $created_by)
+RETURNING *;
+
+-- name: CreateJob :one
+INSERT INTO jobs (type, payload, created_by)
+VALUES ($type, $payload, $created_by)
+RETURNING *;
+
+-- name: CreateUniqueJob :execrows
+INSERT INTO jobs (type, payload)
+SELECT $type, $payload
+WHERE NOT EXISTS (
+    SELECT 1 FROM jobs
+    WHERE jobs.type = $type AND jobs.status = 'queued'
+);
+
+-- name: CreateInvite :one
+INSERT INTO invites (code, role, max_uses, expires_at, created_by)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
 -- name: CreateUser :execrows
-INSERT INTO users (id, pass, auth_hash, admin)
-VALUES (?, ?, ?, ?)
+INSERT INTO users (id, pass, auth_hash, admin, role)
+VALUES (?, ?, ?, ?, ?)
 ON CONFLICT DO NOTHING;
 
+-- name: CreateSession :one
+INSERT INTO sessions (
+    user_id,
+    token_hash,
+    auth_hash,
+    user_agent,
+    ip,
+    expires_at
+)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+    user_id,
+    name,
+    token_hash
+)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    user_id,
+    url,
+    secret,
+    events
+)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT id, $event, $payload
+FROM webhooks
+WHERE
+    enabled = TRUE
+    AND (user_id = $user_id OR user_id IS NULL)
+    AND ',' || events || ',' LIKE '%,' || $event || ',%';
+
+-- name: DeleteInvite :execrows
+DELETE FROM invites WHERE code = $code;
+
+-- name: DeleteRole :execrows
+DELETE FROM roles WHERE name = $name;
+
+-- name: DeleteShares :execrows
+DELETE FROM document_shares
+WHERE share_type = $share_type AND share_with = $share_with;
+
 -- name: DeleteUser :execrows
 DELETE FROM users WHERE id = $id;
 
+-- name: DeleteUserSessions :execrows
+DELETE FROM sessions WHERE user_id = $user_id;
+
+-- name: DeleteDevice :execrows
+DELETE FROM devices
+WHERE id = $id AND user_id = $user_id;
+
+-- name: DeleteDuplicateActivity :execrows
+DELETE FROM activity
+WHERE
+    (user_id = sqlc.narg('user_id') OR $user_id IS NULL)
+    AND (device_id = sqlc.narg('device_id') OR $device_id IS NULL)
+    AND id NOT IN (
+        SELECT MIN(id)
+        FROM activity
+        GROUP BY user_id, device_id, document_id, start_time
+    );
+
+-- name: DeleteSupersededDeviceProgress :execrows
+DELETE FROM document_progress
+WHERE
+    device_id = $target_id
+    AND EXISTS (
+        SELECT 1 FROM document_progress AS source
+        WHERE
+            source.device_id = $source_id
+            AND source.user_id = document_progress.user_id
+            AND source.document_id = document_progress.document_id
+            AND source.created_at > document_progress.created_at
+    );
+
+-- name: DeleteInvalidActivity :execrows
+DELETE FROM activity
+WHERE
+    duration <= 0
+    OR duration > $max_duration
+    OR start_percentage < 0
+    OR end_percentage > 1
+    OR start_percentage > end_percentage;
+
 -- name: DeleteDocument :execrows
 UPDATE documents
 SET
     deleted = 1
 WHERE id = $id;
 
+-- name: DeleteDocumentShare :execrows
+DELETE FROM document_shares
+WHERE
+    document_id = $document_id
+    AND share_type = $share_type
+    AND share_with = $share_with;
+
+-- name: DeleteExpiredImportLog :execrows
+DELETE FROM import_log
+WHERE created_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days');
+
+-- name: DeleteExpiredJobs :execrows
+DELETE FROM jobs
+WHERE
+    status IN ('succeeded', 'failed', 'cancelled')
+    AND updated_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days');
+
+-- name: DeletePreviousJobs :execrows
+DELETE FROM jobs
+WHERE type = $type AND status = 'succeeded' AND id < $id;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now');
+
+-- name: DeleteSession :execrows
+DELETE FROM sessions
+WHERE id = $id AND user_id = $user_id;
+
+-- name: DeleteSessionByToken :execrows
+DELETE FROM sessions WHERE token_hash = $token_hash;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE id = $id AND user_id = $user_id;
+
+-- name: DeleteUserAPITokens :execrows
+DELETE FROM api_tokens WHERE user_id = $user_id;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $id AND user_id IS sqlc.narg(user_id);
+
+-- name: DeleteExpiredWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE
+    status != 'pending'
+    AND created_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days');
+
 -- name: GetActivity :many
 WITH filtered_activity AS (
     SELECT
@@ -78,6 +298,42 @@ FROM filtered_activity AS activity
 LEFT JOIN documents ON documents.id = activity.document_id
 LEFT JOIN users ON users.id = activity.user_id;
 
+-- name: GetActivityDuplicates :many
+SELECT
+    activity.user_id,
+    activity.device_id,
+    devices.device_name,
+    activity.document_id,
+    activity.start_time,
+    CAST(COUNT(*) AS INTEGER) AS count
+FROM activity
+JOIN devices ON devices.id = activity.device_id
+GROUP BY activity.user_id, activity.device_id, activity.document_id, activity.start_time
+HAVING COUNT(*) > 1
+ORDER BY count DESC, activity.start_time DESC
+LIMIT $limit;
+
+-- name: GetActivityHealth :one
+WITH duplicate_groups AS (
+    SELECT COUNT(*) AS count
+    FROM activity
+    GROUP BY user_id, device_id, document_id, start_time
+    HAVING COUNT(*) > 1
+)
+SELECT
+    (SELECT CAST(COUNT(*) AS INTEGER) FROM activity) AS total,
+    (SELECT CAST(COALESCE(SUM(count - 1), 0) AS INTEGER) FROM duplicate_groups) AS duplicates,
+    (
+        SELECT CAST(COUNT(*) AS INTEGER)
+        FROM activity
+        WHERE
+            duration <= 0
+            OR duration > $max_duration
+            OR start_percentage < 0
+            OR end_percentage > 1
+            OR start_percentage > end_percentage
+    ) AS invalid;
+
 -- name: GetDailyReadStats :many
 WITH RECURSIVE last_30_days AS (
     SELECT LOCAL_DATE(STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'), timezone) AS date
@@ -126,8 +382,13 @@ LIMIT 1;
 -- name: GetDeletedDocuments :many
 SELECT documents.id
 FROM documents
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = $user_id
+LEFT JOIN document_access_history AS history
+    ON history.document_id = documents.id AND history.user_id = $user_id
 WHERE
-    documents.deleted = true
+    (documents.deleted = true OR visible.document_id IS NULL)
+    AND (visible.document_id IS NOT NULL OR history.document_id IS NOT NULL)
     AND documents.id IN (sqlc.slice('document_ids'));
 
 -- name: GetDevice :one
@@ -139,7 +400,8 @@ SELECT
     devices.id,
     devices.device_name,
     LOCAL_TIME(devices.created_at, users.timezone) AS created_at,
-    LOCAL_TIME(devices.last_synced, users.timezone) AS last_synced
+    LOCAL_TIME(devices.last_synced, users.timezone) AS last_synced,
+    devices.sync
 FROM devices
 JOIN users ON users.id = devices.user_id
 WHERE users.id = $user_id
@@ -149,34 +411,170 @@ ORDER BY devices.last_synced DESC;
 SELECT * FROM documents
 WHERE id = $document_id LIMIT 1;
 
+-- name: GetDocumentAccess :one
+SELECT
+    documents.owner_id,
+    CAST((visible.document_id IS NOT NULL) AS BOOLEAN) AS has_access
+FROM documents
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = $user_id
+WHERE documents.id = $document_id
+LIMIT 1;
+
+-- name: GetDocumentGroups :many
+SELECT
+    CAST(CASE $field
+        WHEN 'author' THEN docs.author
+        WHEN 'series' THEN docs.series
+        WHEN 'lang' THEN docs.lang
+    END AS TEXT) AS value,
+    COUNT(*) AS count
+FROM documents AS docs
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = $user_id
+WHERE
+    docs.deleted = false
+GROUP BY value
+HAVING value IS NOT NULL AND value != ''
+ORDER BY value COLLATE NOCASE
+LIMIT $limit
+OFFSET $offset;
+
+-- name: GetDocumentChanges :many
+SELECT
+    document_changes.seq,
+    document_changes.change_type,
+    CAST((visible.document_id IS NOT NULL) AS BOOLEAN) AS visible,
+    CAST((history.document_id IS NOT NULL) AS BOOLEAN) AS previously_visible,
+    sqlc.embed(documents)
+FROM document_changes
+JOIN documents ON documents.id = document_changes.document_id
+JOIN users ON users.id = $user_id
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = users.id
+LEFT JOIN document_access_history AS history
+    ON history.document_id = documents.id AND history.user_id = users.id
+WHERE document_changes.seq > $cursor
+ORDER BY document_changes.seq
+LIMIT $limit;
+
+-- name: GetDocumentChangesCursor :one
+SELECT CAST(COALESCE(MAX(seq), 0) AS INTEGER) AS cursor
+FROM document_changes;
+
+-- name: GetDocumentDevicesProgress :many
+SELECT
+    document_progress.*,
+    devices.device_name
+FROM document_progress
+JOIN devices ON document_progress.device_id = devices.id
+WHERE
+    document_progress.user_id = $user_id
+    AND document_progress.document_id = $document_id
+ORDER BY document_progress.created_at DESC;
+
+-- name: GetDocumentFiles :many
+SELECT id, title, author, basepath, filepath FROM documents
+WHERE filepath IS NOT NULL AND deleted = false
+ORDER BY id;
+
 -- name: GetDocumentProgress :one
 SELECT
     document_progress.*,
     devices.device_name
 FROM document_progress
 JOIN devices ON document_progress.device_id = devices.id
+JOIN users ON document_progress.user_id = users.id
 WHERE
     document_progress.user_id = $user_id
     AND document_progress.document_id = $document_id
 ORDER BY
-    document_progress.created_at
-    DESC
+    CASE WHEN users.progress_strategy = 'furthest' THEN document_progress.percentage END DESC,
+    document_progress.created_at DESC,
+    document_progress.rowid DESC
 LIMIT 1;
 
+-- name: GetDocumentProgressHistory :many
+SELECT
+    history.id,
+    history.device_id,
+    devices.device_name,
+    ROUND(CAST(history.percentage AS REAL) * 100, 2) AS percentage,
+    history.progress,
+    LOCAL_TIME(history.created_at, users.timezone) AS created_at
+FROM document_progress_history AS history
+LEFT JOIN users ON history.user_id = users.id
+LEFT JOIN devices ON history.device_id = devices.id
+WHERE
+    history.user_id = $user_id
+    AND history.document_id = $document_id
+ORDER BY history.created_at DESC, history.id DESC
+LIMIT $limit
+OFFSET $offset;
+
+-- name: GetDocumentShares :many
+SELECT * FROM document_shares
+WHERE document_id = $document_id
+ORDER BY share_type, share_with;
+
 -- name: GetDocuments :many
 SELECT * FROM documents
 ORDER BY created_at DESC
 LIMIT $limit
 OFFSET $offset;
 
+-- name: GetDocumentsProgress :many
+SELECT
+    user_id,
+    document_id,
+    device_id,
+    percentage,
+    progress,
+    created_at
+FROM (
+    SELECT
+        document_progress.*,
+        ROW_NUMBER() OVER (
+            PARTITION BY document_progress.document_id
+            ORDER BY
+                CASE WHEN users.progress_strategy = 'furthest' THEN document_progress.percentage END DESC,
+                document_progress.created_at DESC,
+                document_progress.rowid DESC
+        ) AS progress_rank
+    FROM document_progress
+    JOIN devices ON document_progress.device_id = devices.id
+    JOIN users ON document_progress.user_id = users.id
+    WHERE
+        document_progress.user_id = $user_id
+        AND document_progress.document_id IN (sqlc.slice('document_ids'))
+)
+WHERE progress_rank = 1;
+
 -- name: GetDocumentsSize :one
 SELECT
-    COUNT(rowid) AS length
+    COUNT(docs.rowid) AS length
 FROM documents AS docs
-WHERE $query IS NULL OR (
-    docs.title LIKE $query OR
-    docs.author LIKE $query
-)
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = $user_id
+LEFT JOIN
+    document_user_statistics AS dus
+    ON dus.document_id = docs.id AND dus.user_id = $user_id
+WHERE
+    docs.deleted = false
+    AND ($query IS NULL OR (
+        docs.title LIKE $query OR
+        docs.author LIKE $query
+    ))
+    AND (docs.author = sqlc.narg('author') OR $author IS NULL)
+    AND (docs.series = sqlc.narg('series') OR $series IS NULL)
+    AND (docs.lang = sqlc.narg('lang') OR $lang IS NULL)
+    AND (
+        $status IS NULL
+        OR ($status = 'reading' AND dus.percentage > 0.0 AND dus.percentage <= 0.97)
+        OR ($status = 'finished' AND dus.percentage > 0.97)
+        OR ($status = 'unread' AND COALESCE(dus.percentage, 0.0) = 0.0)
+    )
+    AND ((docs.filepath IS NOT NULL) = sqlc.narg('has_file') OR $has_file IS NULL)
 LIMIT 1;
 
 -- name: GetDocumentsWithStats :many
@@ -188,7 +586,14 @@ SELECT
     docs.isbn10,
     docs.isbn13,
     docs.filepath,
+    docs.basepath,
+    docs.series,
+    docs.series_index,
+    docs.lang,
     docs.words,
+    docs.pages,
+    docs.owner_id,
+    docs.visibility,
 
     CAST(COALESCE(dus.total_wpm, 0.0) AS INTEGER) AS wpm,
     COALESCE(dus.read_percentage, 0) AS read_percentage,
@@ -208,6 +613,8 @@ SELECT
     END AS INTEGER) AS seconds_per_percent
 FROM documents AS docs
 LEFT JOIN users ON users.id = $user_id
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = $user_id
 LEFT JOIN
     document_user_statistics AS dus
     ON dus.document_id = docs.id AND dus.user_id = $user_id
@@ -220,10 +627,127 @@ WHERE
             docs.author LIKE $query
         ) OR $query IS NULL
     )
-ORDER BY dus.last_read DESC, docs.created_at DESC
+    AND (docs.author = sqlc.narg('author') OR $author IS NULL)
+    AND (docs.series = sqlc.narg('series') OR $series IS NULL)
+    AND (docs.lang = sqlc.narg('lang') OR $lang IS NULL)
+    AND (
+        $status IS NULL
+        OR ($status = 'reading' AND dus.percentage > 0.0 AND dus.percentage <= 0.97)
+        OR ($status = 'finished' AND dus.percentage > 0.97)
+        OR ($status = 'unread' AND COALESCE(dus.percentage, 0.0) = 0.0)
+    )
+    AND ((docs.filepath IS NOT NULL) = sqlc.narg('has_file') OR $has_file IS NULL)
+ORDER BY
+    CASE WHEN $series IS NOT NULL THEN docs.series_index END ASC,
+    CASE WHEN sqlc.narg('sort') = 'title' THEN docs.title END COLLATE NOCASE ASC,
+    CASE WHEN $sort = 'author' THEN docs.author END COLLATE NOCASE ASC,
+    CASE WHEN $sort = 'added' THEN docs.created_at END DESC,
+    dus.last_read DESC,
+    docs.created_at DESC
 LIMIT $limit
 OFFSET $offset;
 
+-- name: GetImportJob :one
+SELECT
+    import_jobs.id,
+    import_jobs.job_id,
+    import_jobs.directory,
+    import_jobs.import_type,
+    jobs.status,
+    import_jobs.total_files,
+    jobs.error,
+    import_jobs.created_by,
+    CAST(COUNT(import_job_results.id) AS INTEGER) AS processed_files,
+    CAST(COALESCE(SUM(import_job_results.status = 'FAILED'), 0) AS INTEGER) AS failed_files,
+    LOCAL_TIME(import_jobs.created_at, CAST($timezone AS TEXT)) AS created_at
+FROM import_jobs
+JOIN jobs ON jobs.id = import_jobs.job_id
+LEFT JOIN import_job_results ON import_job_results.job_id = import_jobs.id
+WHERE import_jobs.id = $id
+GROUP BY import_jobs.id;
+
+-- name: GetImportJobResults :many
+SELECT * FROM import_job_results
+WHERE job_id = $job_id
+ORDER BY path ASC;
+
+-- name: GetImportJobs :many
+SELECT
+    import_jobs.id,
+    import_jobs.job_id,
+    import_jobs.directory,
+    import_jobs.import_type,
+    jobs.status,
+    import_jobs.total_files,
+    jobs.error,
+    import_jobs.created_by,
+    CAST(COUNT(import_job_results.id) AS INTEGER) AS processed_files,
+    CAST(COALESCE(SUM(import_job_results.status = 'FAILED'), 0) AS INTEGER) AS failed_files,
+    LOCAL_TIME(import_jobs.created_at, CAST($timezone AS TEXT)) AS created_at
+FROM import_jobs
+JOIN jobs ON jobs.id = import_jobs.job_id
+LEFT JOIN import_job_results ON import_job_results.job_id = import_jobs.id
+GROUP BY import_jobs.id
+ORDER BY import_jobs.id DESC
+LIMIT $limit;
+
+-- name: GetImportLog :many
+SELECT
+    id,
+    directory,
+    path,
+    document_id,
+    status,
+    error,
+    LOCAL_TIME(created_at, CAST($timezone AS TEXT)) AS created_at
+FROM import_log
+ORDER BY id DESC
+LIMIT $limit;
+
+-- name: GetInvite :one
+SELECT * FROM invites
+WHERE
+    code = $code
+    AND uses < max_uses
+    AND expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+LIMIT 1;
+
+-- name: GetInviteUses :many
+SELECT * FROM invite_uses
+ORDER BY created_at DESC, id DESC
+LIMIT $limit;
+
+-- name: GetInvites :many
+SELECT * FROM invites
+ORDER BY created_at DESC;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $id LIMIT 1;
+
+-- name: GetJobs :many
+SELECT
+    id,
+    type,
+    payload,
+    status,
+    attempts,
+    max_attempts,
+    error,
+    created_by,
+    LOCAL_TIME(run_at, CAST($timezone AS TEXT)) AS run_at,
+    LOCAL_TIME(updated_at, CAST($timezone AS TEXT)) AS updated_at
+FROM jobs
+ORDER BY
+    CASE status
+        WHEN 'running' THEN 1
+        WHEN 'queued' THEN 2
+        WHEN 'failed' THEN 3
+        ELSE 4
+    END,
+    id DESC
+LIMIT $limit;
+
 -- name: GetLastActivity :one
 SELECT start_time
 FROM activity
@@ -233,6 +757,8 @@ ORDER BY start_time DESC LIMIT 1;
 
 -- name: GetMissingDocuments :many
 SELECT documents.* FROM documents
+JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = $user_id
 WHERE
     documents.filepath IS NOT NULL
     AND documents.deleted = false
@@ -263,6 +789,80 @@ ORDER BY created_at DESC
 LIMIT $limit
 OFFSET $offset;
 
+-- name: GetProgressHistoryEntry :one
+SELECT * FROM document_progress_history
+WHERE id = $id AND user_id = $user_id LIMIT 1;
+
+-- name: GetRole :one
+SELECT * FROM roles
+WHERE name = $name LIMIT 1;
+
+-- name: GetRoles :many
+SELECT * FROM roles
+ORDER BY name;
+
+-- name: GetSession :one
+SELECT
+    sessions.*,
+    users.admin,
+    users.role,
+    users.auth_hash AS user_auth_hash
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE
+    sessions.token_hash = $token_hash
+    AND sessions.expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+LIMIT 1;
+
+-- name: GetSessions :many
+SELECT
+    sessions.id,
+    sessions.user_agent,
+    sessions.ip,
+    LOCAL_TIME(sessions.created_at, users.timezone) AS created_at,
+    LOCAL_TIME(sessions.last_seen, users.timezone) AS last_seen
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE
+    sessions.user_id = $user_id
+    AND sessions.expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+ORDER BY sessions.last_seen DESC;
+
+-- name: GetAPIToken :one
+SELECT
+    api_tokens.*,
+    users.admin,
+    users.role,
+    users.auth_hash AS user_auth_hash
+FROM api_tokens
+JOIN users ON users.id = api_tokens.user_id
+WHERE api_tokens.token_hash = $token_hash
+LIMIT 1;
+
+-- name: GetAPITokens :many
+SELECT
+    api_tokens.id,
+    api_tokens.name,
+    CASE
+        WHEN api_tokens.last_used IS NOT NULL
+        THEN LOCAL_TIME(api_tokens.last_used, users.timezone)
+    END AS last_used,
+    LOCAL_TIME(api_tokens.created_at, users.timezone) AS created_at
+FROM api_tokens
+JOIN users ON users.id = api_tokens.user_id
+WHERE api_tokens.user_id = $user_id
+ORDER BY api_tokens.created_at DESC, api_tokens.id DESC;
+
+-- name: GetAllUserStreaks :many
+SELECT * FROM user_streaks;
+
+-- name: GetTotals :one
+SELECT
+    (SELECT COUNT(rowid) FROM documents WHERE documents.deleted = false) AS documents,
+    (SELECT COUNT(rowid) FROM users) AS users,
+    (SELECT COUNT(rowid) FROM activity) AS activity
+LIMIT 1;
+
 -- name: GetUser :one
 SELECT * FROM users
 WHERE id = $user_id LIMIT 1;
@@ -311,35 +911,256 @@ SELECT
 FROM json_each(?1)
 LEFT JOIN documents
 ON value = documents.id
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = $user_id
 WHERE (
     documents.id IS NOT NULL
     AND documents.deleted = false
     AND documents.filepath IS NULL
+    AND visible.document_id IS NOT NULL
 )
 OR (documents.id IS NULL)
 OR CAST($document_ids AS TEXT) != CAST($document_ids AS TEXT);
 
+-- name: GetWebhooks :many
+SELECT * FROM webhooks
+WHERE user_id IS sqlc.narg(user_id)
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetWebhookDeliveries :many
+SELECT
+    webhook_deliveries.id,
+    webhook_deliveries.webhook_id,
+    webhooks.url,
+    webhook_deliveries.event,
+    webhook_deliveries.status,
+    webhook_deliveries.attempts,
+    webhook_deliveries.response_code,
+    webhook_deliveries.error,
+    LOCAL_TIME(webhook_deliveries.created_at, CAST($timezone AS TEXT)) AS created_at
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhooks.user_id IS sqlc.narg(user_id)
+ORDER BY webhook_deliveries.id DESC
+LIMIT $limit;
+
+-- name: GetPendingWebhookDeliveries :many
+SELECT
+    webhook_deliveries.*,
+    webhooks.url,
+    webhooks.secret,
+    webhooks.user_id
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE
+    webhooks.enabled = TRUE
+    AND webhook_deliveries.status = 'pending'
+    AND webhook_deliveries.next_attempt_at <= STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+ORDER BY webhook_deliveries.next_attempt_at ASC, webhook_deliveries.id ASC
+LIMIT $limit;
+
+-- name: MoveDeviceActivity :execrows
+UPDATE OR IGNORE activity
+SET device_id = $target_id
+WHERE device_id = $source_id;
+
+-- name: MoveDeviceProgress :execrows
+UPDATE document_progress
+SET device_id = $target_id
+WHERE
+    device_id = $source_id
+    AND NOT EXISTS (
+        SELECT 1 FROM document_progress AS target
+        WHERE
+            target.device_id = $target_id
+            AND target.user_id = document_progress.user_id
+            AND target.document_id = document_progress.document_id
+    );
+
+-- name: MoveDeviceProgressHistory :execrows
+UPDATE document_progress_history
+SET device_id = $target_id
+WHERE device_id = $source_id;
+
+-- name: MergeDeviceActivity :execrows
+UPDATE activity
+SET
+    duration = MAX(activity.duration, source.duration),
+    start_percentage = MIN(activity.start_percentage, source.start_percentage),
+    end_percentage = MAX(activity.end_percentage, source.end_percentage)
+FROM activity AS source
+WHERE
+    activity.device_id = $target_id
+    AND source.device_id = $source_id
+    AND source.user_id = activity.user_id
+    AND source.document_id = activity.document_id
+    AND source.start_time = activity.start_time;
+
+-- name: MergeDuplicateActivity :execrows
+UPDATE activity
+SET
+    duration = merged.duration,
+    start_percentage = merged.start_percentage,
+    end_percentage = merged.end_percentage
+FROM (
+    SELECT
+        MIN(id) AS id,
+        MAX(duration) AS duration,
+        MIN(start_percentage) AS start_percentage,
+        MAX(end_percentage) AS end_percentage
+    FROM activity
+    WHERE
+        (user_id = sqlc.narg('user_id') OR $user_id IS NULL)
+        AND (device_id = sqlc.narg('device_id') OR $device_id IS NULL)
+    GROUP BY user_id, device_id, document_id, start_time
+    HAVING COUNT(*) > 1
+) AS merged
+WHERE activity.id = merged.id;
+
+-- name: LinkImportJob :exec
+UPDATE import_jobs
+SET
+    job_id = $job_id,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id;
+
+-- name: RecoverJobs :execrows
+UPDATE jobs
+SET
+    status = 'queued',
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE status = 'running';
+
+-- name: RequeueJob :execrows
+UPDATE jobs
+SET
+    status = 'queued',
+    attempts = 0,
+    error = NULL,
+    run_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    finished_at = NULL,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id AND status IN ('failed', 'cancelled');
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET
+    status = 'queued',
+    error = $error,
+    run_at = $run_at,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id AND status = 'running';
+
+-- name: ScheduleJob :execrows
+INSERT INTO jobs (type, payload)
+SELECT $type, $payload
+WHERE NOT EXISTS (
+    SELECT 1 FROM jobs
+    WHERE
+        jobs.type = $type
+        AND (
+            jobs.status IN ('queued', 'running')
+            OR jobs.created_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', $interval)
+        )
+);
+
+-- name: StartJob :one
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    error = NULL,
+    started_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id AND status = 'queued'
+RETURNING *;
+
+-- name: UpdateDevice :one
+UPDATE devices
+SET
+    device_name = COALESCE($device_name, device_name),
+    sync = COALESCE($sync, sync)
+WHERE id = $id AND user_id = $user_id
+RETURNING *;
+
+-- name: UpdateDocumentVisibility :execrows
+UPDATE documents
+SET visibility = $visibility
+WHERE id = $document_id;
+
+-- name: UseInvite :one
+UPDATE invites
+SET uses = uses + 1
+WHERE
+    code = $code
+    AND uses < max_uses
+    AND expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+RETURNING *;
+
 -- name: UpdateProgress :one
 INSERT OR REPLACE INTO document_progress (
     user_id,
     document_id,
     device_id,
     percentage,
-    progress
+    progress,
+    created_at
 )
-VALUES (?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: UpdateSession :exec
+UPDATE sessions
+SET
+    last_seen = $last_seen,
+    expires_at = $expires_at
+WHERE id = $id;
+
+-- name: UpdateAPITokenLastUsed :exec
+UPDATE api_tokens
+SET last_used = $last_used
+WHERE id = $id;
+
+-- name: UpdateWebhook :execrows
+UPDATE webhooks
+SET enabled = $enabled
+WHERE id = $id AND user_id IS sqlc.narg(user_id);
+
+-- name: UpdateImportJobTotal :exec
+UPDATE import_jobs
+SET
+    total_files = $total_files,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+    status = $status,
+    attempts = $attempts,
+    response_code = $response_code,
+    error = $error,
+    next_attempt_at = $next_attempt_at,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = $id;
+
 -- name: UpdateUser :one
 UPDATE users
 SET
     pass = COALESCE($password, pass),
     auth_hash = COALESCE($auth_hash, auth_hash),
     timezone = COALESCE($timezone, timezone),
-    admin = COALESCE($admin, admin)
+    progress_strategy = COALESCE($progress_strategy, progress_strategy),
+    admin = COALESCE($admin, admin),
+    role = COALESCE($role, role)
 WHERE id = $user_id
 RETURNING *;
 
+-- name: UpdateRoleUsers :execrows
+UPDATE users
+SET role = $role
+WHERE role = $current_role;
+
 -- name: UpdateSettings :one
 INSERT INTO settings (name, value)
 VALUES (?, ?)
@@ -349,12 +1170,32 @@ SET
     value = COALESCE(excluded.value, value)
 RETURNING *;
 
+-- name: UpsertRole :one
+INSERT INTO roles (
+    name,
+    can_upload,
+    can_delete,
+    can_edit,
+    can_search,
+    can_download,
+    can_sync
+)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO UPDATE
+SET
+    can_upload = excluded.can_upload,
+    can_delete = excluded.can_delete,
+    can_edit = excluded.can_edit,
+    can_search = excluded.can_search,
+    can_download = excluded.can_download,
+    can_sync = excluded.can_sync
+RETURNING *;
+
 -- name: UpsertDevice :one
 INSERT INTO devices (id, user_id, last_synced, device_name)
 VALUES (?, ?, ?, ?)
 ON CONFLICT DO UPDATE
 SET
-    device_name = COALESCE(excluded.device_name, device_name),
     last_synced = COALESCE(excluded.last_synced, last_synced)
 RETURNING *;
 
@@ -372,12 +1213,15 @@ INSERT INTO documents (
     lang,
     description,
     words,
+    pages,
     olid,
     gbid,
     isbn10,
-    isbn13
+    isbn13,
+    owner_id,
+    visibility
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, IIF(?18 IS NULL, 'public', 'private'))
 ON CONFLICT DO UPDATE
 SET
     md5 =           COALESCE(excluded.md5, md5),
@@ -391,8 +1235,15 @@ SET
     lang =          COALESCE(excluded.lang, lang),
     description =   COALESCE(excluded.description, description),
     words =         COALESCE(excluded.words, words),
+    pages =         COALESCE(excluded.pages, pages),
     olid =          COALESCE(excluded.olid, olid),
     gbid =          COALESCE(excluded.gbid, gbid),
     isbn10 =        COALESCE(excluded.isbn10, isbn10),
-    isbn13 =        COALESCE(excluded.isbn13, isbn13)
+    isbn13 =        COALESCE(excluded.isbn13, isbn13),
+    owner_id =      COALESCE(owner_id, excluded.owner_id)
 RETURNING *;
+
+-- name: UpsertDocumentShare :execrows
+INSERT INTO document_shares (document_id, share_type, share_with)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;
@@ -20,7 +20,16 @@ INSERT INTO activity (
     start_percentage,
     end_percentage
 )
-VALUES (?, ?, ?, ?, ?, ?, ?)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7
+)
+ON CONFLICT DO NOTHING
 RETURNING id, user_id, document_id, device_id, start_time, start_percentage, end_percentage, duration, created_at
 `
 
@@ -59,6 +68,79 @@ func (q *Queries) AddActivity(ctx context.Context, arg AddActivityParams) (Activ
 	return i, err
 }
 
+const addImportJobResult = `-- name: AddImportJobResult :exec
+INSERT INTO import_job_results (job_id, path, document_id, name, status, error)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT DO NOTHING
+`
+
+type AddImportJobResultParams struct {
+	JobID      int64   `json:"job_id"`
+	Path       string  `json:"path"`
+	DocumentID *string `json:"document_id"`
+	Name       *string `json:"name"`
+	Status     string  `json:"status"`
+	Error      *string `json:"error"`
+}
+
+func (q *Queries) AddImportJobResult(ctx context.Context, arg AddImportJobResultParams) error {
+	_, err := q.db.ExecContext(ctx, addImportJobResult,
+		arg.JobID,
+		arg.Path,
+		arg.DocumentID,
+		arg.Name,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const addImportLog = `-- name: AddImportLog :exec
+INSERT INTO import_log (directory, path, document_id, status, error)
+VALUES (?1, ?2, ?3, ?4, ?5)
+`
+
+type AddImportLogParams struct {
+	Directory  string  `json:"directory"`
+	Path       string  `json:"path"`
+	DocumentID *string `json:"document_id"`
+	Status     string  `json:"status"`
+	Error      *string `json:"error"`
+}
+
+func (q *Queries) AddImportLog(ctx context.Context, arg AddImportLogParams) error {
+	_, err := q.db.ExecContext(ctx, addImportLog,
+		arg.Directory,
+		arg.Path,
+		arg.DocumentID,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const addInviteUse = `-- name: AddInviteUse :exec
+INSERT INTO invite_uses (invite_code, user_id, user_agent, ip)
+VALUES (?, ?, ?, ?)
+`
+
+type AddInviteUseParams struct {
+	InviteCode string `json:"invite_code"`
+	UserID     string `json:"user_id"`
+	UserAgent  string `json:"user_agent"`
+	Ip         string `json:"ip"`
+}
+
+func (q *Queries) AddInviteUse(ctx context.Context, arg AddInviteUseParams) error {
+	_, err := q.db.ExecContext(ctx, addInviteUse,
+		arg.InviteCode,
+		arg.UserID,
+		arg.UserAgent,
+		arg.Ip,
+	)
+	return err
+}
+
 const addMetadata = `-- name: AddMetadata :one
 INSERT INTO metadata (
     document_id,
@@ -112,9 +194,282 @@ func (q *Queries) AddMetadata(ctx context.Context, arg AddMetadataParams) (Metad
 	return i, err
 }
 
+const cancelJob = `-- name: CancelJob :execrows
+UPDATE jobs
+SET
+    status = 'cancelled',
+    finished_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?1 AND status IN ('queued', 'running')
+`
+
+func (q *Queries) CancelJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimJob = `-- name: ClaimJob :one
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    error = NULL,
+    started_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE
+        type = ?1
+        AND status = 'queued'
+        AND run_at <= STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+    ORDER BY run_at ASC, id ASC
+    LIMIT 1
+)
+RETURNING id, type, payload, status, attempts, max_attempts, error, created_by, run_at, started_at, finished_at, updated_at, created_at
+`
+
+func (q *Queries) ClaimJob(ctx context.Context, type_ string) (Job, error) {
+	row := q.db.QueryRowContext(ctx, claimJob, type_)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.Error,
+		&i.CreatedBy,
+		&i.RunAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const completeJob = `-- name: CompleteJob :exec
+UPDATE jobs
+SET
+    status = ?1,
+    error = ?2,
+    finished_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?3 AND status = 'running'
+`
+
+type CompleteJobParams struct {
+	Status string  `json:"status"`
+	Error  *string `json:"error"`
+	ID     int64   `json:"id"`
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) error {
+	_, err := q.db.ExecContext(ctx, completeJob, arg.Status, arg.Error, arg.ID)
+	return err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+    user_id,
+    name,
+    token_hash
+)
+VALUES (?, ?, ?)
+RETURNING id, user_id, name, token_hash, last_used, created_at
+`
+
+type CreateAPITokenParams struct {
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	TokenHash string `json:"-"`
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken, arg.UserID, arg.Name, arg.TokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.LastUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createImportJob = `-- name: CreateImportJob :one
+INSERT INTO import_jobs (directory, import_type, created_by)
+VALUES (?1, ?2, ?3)
+RETURNING id, job_id, directory, import_type, total_files, created_by, updated_at, created_at
+`
+
+type CreateImportJobParams struct {
+	Directory  string  `json:"directory"`
+	ImportType string  `json:"import_type"`
+	CreatedBy  *string `json:"created_by"`
+}
+
+func (q *Queries) CreateImportJob(ctx context.Context, arg CreateImportJobParams) (ImportJob, error) {
+	row := q.db.QueryRowContext(ctx, createImportJob, arg.Directory, arg.ImportType, arg.CreatedBy)
+	var i ImportJob
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.Directory,
+		&i.ImportType,
+		&i.TotalFiles,
+		&i.CreatedBy,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInvite = `-- name: CreateInvite :one
+INSERT INTO invites (code, role, max_uses, expires_at, created_by)
+VALUES (?, ?, ?, ?, ?)
+RETURNING code, role, max_uses, uses, expires_at, created_by, created_at
+`
+
+type CreateInviteParams struct {
+	Code      string `json:"code"`
+	Role      string `json:"role"`
+	MaxUses   int64  `json:"max_uses"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedBy string `json:"created_by"`
+}
+
+func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, createInvite,
+		arg.Code,
+		arg.Role,
+		arg.MaxUses,
+		arg.ExpiresAt,
+		arg.CreatedBy,
+	)
+	var i Invite
+	err := row.Scan(
+		&i.Code,
+		&i.Role,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createJob = `-- name: CreateJob :one
+INSERT INTO jobs (type, payload, created_by)
+VALUES (?1, ?2, ?3)
+RETURNING id, type, payload, status, attempts, max_attempts, error, created_by, run_at, started_at, finished_at, updated_at, created_at
+`
+
+type CreateJobParams struct {
+	Type      string  `json:"type"`
+	Payload   string  `json:"payload"`
+	CreatedBy *string `json:"created_by"`
+}
+
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, createJob, arg.Type, arg.Payload, arg.CreatedBy)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.Error,
+		&i.CreatedBy,
+		&i.RunAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (
+    user_id,
+    token_hash,
+    auth_hash,
+    user_agent,
+    ip,
+    expires_at
+)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, token_hash, auth_hash, user_agent, ip, expires_at, last_seen, created_at
+`
+
+type CreateSessionParams struct {
+	UserID    string `json:"user_id"`
+	TokenHash string `json:"-"`
+	AuthHash  string `json:"-"`
+	UserAgent string `json:"user_agent"`
+	Ip        string `json:"ip"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.UserID,
+		arg.TokenHash,
+		arg.AuthHash,
+		arg.UserAgent,
+		arg.Ip,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.AuthHash,
+		&i.UserAgent,
+		&i.Ip,
+		&i.ExpiresAt,
+		&i.LastSeen,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUniqueJob = `-- name: CreateUniqueJob :execrows
+INSERT INTO jobs (type, payload)
+SELECT ?1, ?2
+WHERE NOT EXISTS (
+    SELECT 1 FROM jobs
+    WHERE jobs.type = ?1 AND jobs.status = 'queued'
+)
+`
+
+type CreateUniqueJobParams struct {
+	Type    string `json:"type"`
+	Payload string `json:"payload"`
+}
+
+func (q *Queries) CreateUniqueJob(ctx context.Context, arg CreateUniqueJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createUniqueJob, arg.Type, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :execrows
-INSERT INTO users (id, pass, auth_hash, admin)
-VALUES (?, ?, ?, ?)
+INSERT INTO users (id, pass, auth_hash, admin, role)
+VALUES (?, ?, ?, ?, ?)
 ON CONFLICT DO NOTHING
 `
 
@@ -123,6 +478,7 @@ type CreateUserParams struct {
 	Pass     *string `json:"-"`
 	AuthHash *string `json:"auth_hash"`
 	Admin    bool    `json:"-"`
+	Role     string  `json:"role"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int64, error) {
@@ -131,7 +487,106 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int64,
 		arg.Pass,
 		arg.AuthHash,
 		arg.Admin,
+		arg.Role,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    user_id,
+    url,
+    secret,
+    events
+)
+VALUES (?, ?, ?, ?)
+RETURNING id, user_id, url, secret, events, enabled, created_at
+`
+
+type CreateWebhookParams struct {
+	UserID *string `json:"user_id"`
+	Url    string  `json:"url"`
+	Secret string  `json:"-"`
+	Events string  `json:"events"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Enabled,
+		&i.CreatedAt,
 	)
+	return i, err
+}
+
+const createWebhookDeliveries = `-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT id, ?1, ?2
+FROM webhooks
+WHERE
+    enabled = TRUE
+    AND (user_id = ?3 OR user_id IS NULL)
+    AND ',' || events || ',' LIKE '%,' || ?1 || ',%'
+`
+
+type CreateWebhookDeliveriesParams struct {
+	Event   string  `json:"event"`
+	Payload string  `json:"payload"`
+	UserID  *string `json:"user_id"`
+}
+
+func (q *Queries) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createWebhookDeliveries, arg.Event, arg.Payload, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteAPIToken = `-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE id = ?1 AND user_id = ?2
+`
+
+type DeleteAPITokenParams struct {
+	ID     int64  `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAPIToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteDevice = `-- name: DeleteDevice :execrows
+DELETE FROM devices
+WHERE id = ?1 AND user_id = ?2
+`
+
+type DeleteDeviceParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteDevice(ctx context.Context, arg DeleteDeviceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDevice, arg.ID, arg.UserID)
 	if err != nil {
 		return 0, err
 	}
@@ -153,67 +608,438 @@ func (q *Queries) DeleteDocument(ctx context.Context, id string) (int64, error)
 	return result.RowsAffected()
 }
 
-const deleteUser = `-- name: DeleteUser :execrows
-DELETE FROM users WHERE id = ?1
+const deleteDocumentShare = `-- name: DeleteDocumentShare :execrows
+DELETE FROM document_shares
+WHERE
+    document_id = ?1
+    AND share_type = ?2
+    AND share_with = ?3
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id string) (int64, error) {
-	result, err := q.db.ExecContext(ctx, deleteUser, id)
+type DeleteDocumentShareParams struct {
+	DocumentID string `json:"document_id"`
+	ShareType  string `json:"share_type"`
+	ShareWith  string `json:"share_with"`
+}
+
+func (q *Queries) DeleteDocumentShare(ctx context.Context, arg DeleteDocumentShareParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDocumentShare, arg.DocumentID, arg.ShareType, arg.ShareWith)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
-const getActivity = `-- name: GetActivity :many
-WITH filtered_activity AS (
-    SELECT
-        document_id,
-        device_id,
-        user_id,
-        start_time,
-        duration,
-        ROUND(CAST(start_percentage AS REAL) * 100, 2) AS start_percentage,
-        ROUND(CAST(end_percentage AS REAL) * 100, 2) AS end_percentage,
-        ROUND(CAST(end_percentage - start_percentage AS REAL) * 100, 2) AS read_percentage
-    FROM activity
-    WHERE
-        activity.user_id = ?1
-        AND (
-            (
-                CAST(?2 AS BOOLEAN) = TRUE
-                AND document_id = ?3
-            ) OR ?2 = FALSE
-        )
-    ORDER BY start_time DESC
-    LIMIT ?5
-    OFFSET ?4
-)
+const deleteDuplicateActivity = `-- name: DeleteDuplicateActivity :execrows
+DELETE FROM activity
+WHERE
+    (user_id = ?1 OR ?1 IS NULL)
+    AND (device_id = ?2 OR ?2 IS NULL)
+    AND id NOT IN (
+        SELECT MIN(id)
+        FROM activity
+        GROUP BY user_id, device_id, document_id, start_time
+    )
+`
 
-SELECT
-    document_id,
-    device_id,
-    LOCAL_TIME(activity.start_time, users.timezone) AS start_time,
-    title,
-    author,
-    duration,
-    start_percentage,
-    end_percentage,
-    read_percentage
-FROM filtered_activity AS activity
-LEFT JOIN documents ON documents.id = activity.document_id
-LEFT JOIN users ON users.id = activity.user_id
+type DeleteDuplicateActivityParams struct {
+	UserID   *string `json:"user_id"`
+	DeviceID *string `json:"device_id"`
+}
+
+func (q *Queries) DeleteDuplicateActivity(ctx context.Context, arg DeleteDuplicateActivityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDuplicateActivity, arg.UserID, arg.DeviceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredImportLog = `-- name: DeleteExpiredImportLog :execrows
+DELETE FROM import_log
+WHERE created_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days')
 `
 
-type GetActivityParams struct {
-	UserID     string `json:"user_id"`
-	DocFilter  bool   `json:"doc_filter"`
-	DocumentID string `json:"document_id"`
-	Offset     int64  `json:"offset"`
-	Limit      int64  `json:"limit"`
+func (q *Queries) DeleteExpiredImportLog(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredImportLog)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-type GetActivityRow struct {
+const deleteExpiredJobs = `-- name: DeleteExpiredJobs :execrows
+DELETE FROM jobs
+WHERE
+    status IN ('succeeded', 'failed', 'cancelled')
+    AND updated_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days')
+`
+
+func (q *Queries) DeleteExpiredJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredWebhookDeliveries = `-- name: DeleteExpiredWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE
+    status != 'pending'
+    AND created_at < STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', '-30 days')
+`
+
+func (q *Queries) DeleteExpiredWebhookDeliveries(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredWebhookDeliveries)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteInvalidActivity = `-- name: DeleteInvalidActivity :execrows
+DELETE FROM activity
+WHERE
+    duration <= 0
+    OR duration > ?1
+    OR start_percentage < 0
+    OR end_percentage > 1
+    OR start_percentage > end_percentage
+`
+
+func (q *Queries) DeleteInvalidActivity(ctx context.Context, maxDuration int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteInvalidActivity, maxDuration)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteInvite = `-- name: DeleteInvite :execrows
+DELETE FROM invites WHERE code = ?1
+`
+
+func (q *Queries) DeleteInvite(ctx context.Context, code string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteInvite, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePreviousJobs = `-- name: DeletePreviousJobs :execrows
+DELETE FROM jobs
+WHERE type = ?1 AND status = 'succeeded' AND id < ?2
+`
+
+type DeletePreviousJobsParams struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) DeletePreviousJobs(ctx context.Context, arg DeletePreviousJobsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePreviousJobs, arg.Type, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRole = `-- name: DeleteRole :execrows
+DELETE FROM roles WHERE name = ?1
+`
+
+func (q *Queries) DeleteRole(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRole, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSession = `-- name: DeleteSession :execrows
+DELETE FROM sessions
+WHERE id = ?1 AND user_id = ?2
+`
+
+type DeleteSessionParams struct {
+	ID     int64  `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteSession(ctx context.Context, arg DeleteSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSessionByToken = `-- name: DeleteSessionByToken :execrows
+DELETE FROM sessions WHERE token_hash = ?1
+`
+
+func (q *Queries) DeleteSessionByToken(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSessionByToken, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteShares = `-- name: DeleteShares :execrows
+DELETE FROM document_shares
+WHERE share_type = ?1 AND share_with = ?2
+`
+
+type DeleteSharesParams struct {
+	ShareType string `json:"share_type"`
+	ShareWith string `json:"share_with"`
+}
+
+func (q *Queries) DeleteShares(ctx context.Context, arg DeleteSharesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteShares, arg.ShareType, arg.ShareWith)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSupersededDeviceProgress = `-- name: DeleteSupersededDeviceProgress :execrows
+DELETE FROM document_progress
+WHERE
+    device_id = ?1
+    AND EXISTS (
+        SELECT 1 FROM document_progress AS source
+        WHERE
+            source.device_id = ?2
+            AND source.user_id = document_progress.user_id
+            AND source.document_id = document_progress.document_id
+            AND source.created_at > document_progress.created_at
+    )
+`
+
+type DeleteSupersededDeviceProgressParams struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) DeleteSupersededDeviceProgress(ctx context.Context, arg DeleteSupersededDeviceProgressParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSupersededDeviceProgress, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = ?1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserAPITokens = `-- name: DeleteUserAPITokens :execrows
+DELETE FROM api_tokens WHERE user_id = ?1
+`
+
+func (q *Queries) DeleteUserAPITokens(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserAPITokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :execrows
+DELETE FROM sessions WHERE user_id = ?1
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = ?1 AND user_id IS ?2
+`
+
+type DeleteWebhookParams struct {
+	ID     int64   `json:"id"`
+	UserID *string `json:"user_id"`
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAPIToken = `-- name: GetAPIToken :one
+SELECT
+    api_tokens.id, api_tokens.user_id, api_tokens.name, api_tokens.token_hash, api_tokens.last_used, api_tokens.created_at,
+    users.admin,
+    users.role,
+    users.auth_hash AS user_auth_hash
+FROM api_tokens
+JOIN users ON users.id = api_tokens.user_id
+WHERE api_tokens.token_hash = ?1
+LIMIT 1
+`
+
+type GetAPITokenRow struct {
+	ID           int64   `json:"id"`
+	UserID       string  `json:"user_id"`
+	Name         string  `json:"name"`
+	TokenHash    string  `json:"-"`
+	LastUsed     *string `json:"last_used"`
+	CreatedAt    string  `json:"created_at"`
+	Admin        bool    `json:"-"`
+	Role         string  `json:"role"`
+	UserAuthHash *string `json:"user_auth_hash"`
+}
+
+func (q *Queries) GetAPIToken(ctx context.Context, tokenHash string) (GetAPITokenRow, error) {
+	row := q.db.QueryRowContext(ctx, getAPIToken, tokenHash)
+	var i GetAPITokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.LastUsed,
+		&i.CreatedAt,
+		&i.Admin,
+		&i.Role,
+		&i.UserAuthHash,
+	)
+	return i, err
+}
+
+const getAPITokens = `-- name: GetAPITokens :many
+SELECT
+    api_tokens.id,
+    api_tokens.name,
+    CASE
+        WHEN api_tokens.last_used IS NOT NULL
+        THEN LOCAL_TIME(api_tokens.last_used, users.timezone)
+    END AS last_used,
+    LOCAL_TIME(api_tokens.created_at, users.timezone) AS created_at
+FROM api_tokens
+JOIN users ON users.id = api_tokens.user_id
+WHERE api_tokens.user_id = ?1
+ORDER BY api_tokens.created_at DESC, api_tokens.id DESC
+`
+
+type GetAPITokensRow struct {
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	LastUsed  interface{} `json:"last_used"`
+	CreatedAt interface{} `json:"created_at"`
+}
+
+func (q *Queries) GetAPITokens(ctx context.Context, userID string) ([]GetAPITokensRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAPITokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAPITokensRow
+	for rows.Next() {
+		var i GetAPITokensRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.LastUsed,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActivity = `-- name: GetActivity :many
+WITH filtered_activity AS (
+    SELECT
+        document_id,
+        device_id,
+        user_id,
+        start_time,
+        duration,
+        ROUND(CAST(start_percentage AS REAL) * 100, 2) AS start_percentage,
+        ROUND(CAST(end_percentage AS REAL) * 100, 2) AS end_percentage,
+        ROUND(CAST(end_percentage - start_percentage AS REAL) * 100, 2) AS read_percentage
+    FROM activity
+    WHERE
+        activity.user_id = ?1
+        AND (
+            (
+                CAST(?2 AS BOOLEAN) = TRUE
+                AND document_id = ?3
+            ) OR ?2 = FALSE
+        )
+    ORDER BY start_time DESC
+    LIMIT ?5
+    OFFSET ?4
+)
+
+SELECT
+    document_id,
+    device_id,
+    LOCAL_TIME(activity.start_time, users.timezone) AS start_time,
+    title,
+    author,
+    duration,
+    start_percentage,
+    end_percentage,
+    read_percentage
+FROM filtered_activity AS activity
+LEFT JOIN documents ON documents.id = activity.document_id
+LEFT JOIN users ON users.id = activity.user_id
+`
+
+type GetActivityParams struct {
+	UserID     string `json:"user_id"`
+	DocFilter  bool   `json:"doc_filter"`
+	DocumentID string `json:"document_id"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+type GetActivityRow struct {
 	DocumentID      string      `json:"document_id"`
 	DeviceID        string      `json:"device_id"`
 	StartTime       interface{} `json:"start_time"`
@@ -264,59 +1090,1273 @@ func (q *Queries) GetActivity(ctx context.Context, arg GetActivityParams) ([]Get
 	return items, nil
 }
 
-const getDailyReadStats = `-- name: GetDailyReadStats :many
-WITH RECURSIVE last_30_days AS (
-    SELECT LOCAL_DATE(STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'), timezone) AS date
-    FROM users WHERE users.id = ?1
-    UNION ALL
-    SELECT DATE(date, '-1 days')
-    FROM last_30_days
-    LIMIT 30
-),
-filtered_activity AS (
-    SELECT
-        user_id,
-        start_time,
-        duration
-    FROM activity
-    WHERE start_time > DATE('now', '-31 days')
-    AND activity.user_id = ?1
-),
-activity_days AS (
-    SELECT
-        SUM(duration) AS seconds_read,
-        LOCAL_DATE(start_time, timezone) AS day
-    FROM filtered_activity AS activity
-    LEFT JOIN users ON users.id = activity.user_id
-    GROUP BY day
-)
+const getActivityDuplicates = `-- name: GetActivityDuplicates :many
+SELECT
+    activity.user_id,
+    activity.device_id,
+    devices.device_name,
+    activity.document_id,
+    activity.start_time,
+    CAST(COUNT(*) AS INTEGER) AS count
+FROM activity
+JOIN devices ON devices.id = activity.device_id
+GROUP BY activity.user_id, activity.device_id, activity.document_id, activity.start_time
+HAVING COUNT(*) > 1
+ORDER BY count DESC, activity.start_time DESC
+LIMIT ?1
+`
+
+type GetActivityDuplicatesRow struct {
+	UserID     string `json:"user_id"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	DocumentID string `json:"document_id"`
+	StartTime  string `json:"start_time"`
+	Count      int64  `json:"count"`
+}
+
+func (q *Queries) GetActivityDuplicates(ctx context.Context, limit int64) ([]GetActivityDuplicatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getActivityDuplicates, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActivityDuplicatesRow
+	for rows.Next() {
+		var i GetActivityDuplicatesRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DeviceID,
+			&i.DeviceName,
+			&i.DocumentID,
+			&i.StartTime,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActivityHealth = `-- name: GetActivityHealth :one
+WITH duplicate_groups AS (
+    SELECT COUNT(*) AS count
+    FROM activity
+    GROUP BY user_id, device_id, document_id, start_time
+    HAVING COUNT(*) > 1
+)
+SELECT
+    (SELECT CAST(COUNT(*) AS INTEGER) FROM activity) AS total,
+    (SELECT CAST(COALESCE(SUM(count - 1), 0) AS INTEGER) FROM duplicate_groups) AS duplicates,
+    (
+        SELECT CAST(COUNT(*) AS INTEGER)
+        FROM activity
+        WHERE
+            duration <= 0
+            OR duration > ?1
+            OR start_percentage < 0
+            OR end_percentage > 1
+            OR start_percentage > end_percentage
+    ) AS invalid
+`
+
+type GetActivityHealthRow struct {
+	Total      int64 `json:"total"`
+	Duplicates int64 `json:"duplicates"`
+	Invalid    int64 `json:"invalid"`
+}
+
+func (q *Queries) GetActivityHealth(ctx context.Context, maxDuration int64) (GetActivityHealthRow, error) {
+	row := q.db.QueryRowContext(ctx, getActivityHealth, maxDuration)
+	var i GetActivityHealthRow
+	err := row.Scan(
+		&i.Total,
+		&i.Duplicates,
+		&i.Invalid,
+	)
+	return i, err
+}
+
+const getAllUserStreaks = `-- name: GetAllUserStreaks :many
+SELECT user_id, "window", max_streak, max_streak_start_date, max_streak_end_date, current_streak, current_streak_start_date, current_streak_end_date, last_timezone, last_seen, last_record, last_calculated FROM user_streaks
+`
+
+func (q *Queries) GetAllUserStreaks(ctx context.Context) ([]UserStreak, error) {
+	rows, err := q.db.QueryContext(ctx, getAllUserStreaks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserStreak
+	for rows.Next() {
+		var i UserStreak
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Window,
+			&i.MaxStreak,
+			&i.MaxStreakStartDate,
+			&i.MaxStreakEndDate,
+			&i.CurrentStreak,
+			&i.CurrentStreakStartDate,
+			&i.CurrentStreakEndDate,
+			&i.LastTimezone,
+			&i.LastSeen,
+			&i.LastRecord,
+			&i.LastCalculated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyReadStats = `-- name: GetDailyReadStats :many
+WITH RECURSIVE last_30_days AS (
+    SELECT LOCAL_DATE(STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'), timezone) AS date
+    FROM users WHERE users.id = ?1
+    UNION ALL
+    SELECT DATE(date, '-1 days')
+    FROM last_30_days
+    LIMIT 30
+),
+filtered_activity AS (
+    SELECT
+        user_id,
+        start_time,
+        duration
+    FROM activity
+    WHERE start_time > DATE('now', '-31 days')
+    AND activity.user_id = ?1
+),
+activity_days AS (
+    SELECT
+        SUM(duration) AS seconds_read,
+        LOCAL_DATE(start_time, timezone) AS day
+    FROM filtered_activity AS activity
+    LEFT JOIN users ON users.id = activity.user_id
+    GROUP BY day
+)
+SELECT
+    CAST(date AS TEXT),
+    CAST(CASE
+      WHEN seconds_read IS NULL THEN 0
+      ELSE seconds_read / 60
+    END AS INTEGER) AS minutes_read
+FROM last_30_days
+LEFT JOIN activity_days ON activity_days.day == last_30_days.date
+ORDER BY date DESC
+LIMIT 30
+`
+
+type GetDailyReadStatsRow struct {
+	Date        string `json:"date"`
+	MinutesRead int64  `json:"minutes_read"`
+}
+
+func (q *Queries) GetDailyReadStats(ctx context.Context, userID string) ([]GetDailyReadStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyReadStats, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyReadStatsRow
+	for rows.Next() {
+		var i GetDailyReadStatsRow
+		if err := rows.Scan(&i.Date, &i.MinutesRead); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDatabaseInfo = `-- name: GetDatabaseInfo :one
+SELECT
+    (SELECT COUNT(rowid) FROM activity WHERE activity.user_id = ?1) AS activity_size,
+    (SELECT COUNT(rowid) FROM documents) AS documents_size,
+    (SELECT COUNT(rowid) FROM document_progress WHERE document_progress.user_id = ?1) AS progress_size,
+    (SELECT COUNT(rowid) FROM devices WHERE devices.user_id = ?1) AS devices_size
+LIMIT 1
+`
+
+type GetDatabaseInfoRow struct {
+	ActivitySize  int64 `json:"activity_size"`
+	DocumentsSize int64 `json:"documents_size"`
+	ProgressSize  int64 `json:"progress_size"`
+	DevicesSize   int64 `json:"devices_size"`
+}
+
+func (q *Queries) GetDatabaseInfo(ctx context.Context, userID string) (GetDatabaseInfoRow, error) {
+	row := q.db.QueryRowContext(ctx, getDatabaseInfo, userID)
+	var i GetDatabaseInfoRow
+	err := row.Scan(
+		&i.ActivitySize,
+		&i.DocumentsSize,
+		&i.ProgressSize,
+		&i.DevicesSize,
+	)
+	return i, err
+}
+
+const getDeletedDocuments = `-- name: GetDeletedDocuments :many
+SELECT documents.id
+FROM documents
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = ?1
+LEFT JOIN document_access_history AS history
+    ON history.document_id = documents.id AND history.user_id = ?1
+WHERE
+    (documents.deleted = true OR visible.document_id IS NULL)
+    AND (visible.document_id IS NOT NULL OR history.document_id IS NOT NULL)
+    AND documents.id IN (/*SLICE:document_ids*/?)
+`
+
+type GetDeletedDocumentsParams struct {
+	UserID      string   `json:"user_id"`
+	DocumentIds []string `json:"document_ids"`
+}
+
+func (q *Queries) GetDeletedDocuments(ctx context.Context, arg GetDeletedDocumentsParams) ([]string, error) {
+	query := getDeletedDocuments
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.DocumentIds) > 0 {
+		for _, v := range arg.DocumentIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:document_ids*/?", strings.Repeat(",?", len(arg.DocumentIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:document_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDevice = `-- name: GetDevice :one
+SELECT id, user_id, device_name, last_synced, created_at, sync FROM devices
+WHERE id = ?1 LIMIT 1
+`
+
+func (q *Queries) GetDevice(ctx context.Context, deviceID string) (Device, error) {
+	row := q.db.QueryRowContext(ctx, getDevice, deviceID)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DeviceName,
+		&i.LastSynced,
+		&i.CreatedAt,
+		&i.Sync,
+	)
+	return i, err
+}
+
+const getDevices = `-- name: GetDevices :many
+SELECT
+    devices.id,
+    devices.device_name,
+    LOCAL_TIME(devices.created_at, users.timezone) AS created_at,
+    LOCAL_TIME(devices.last_synced, users.timezone) AS last_synced,
+    devices.sync
+FROM devices
+JOIN users ON users.id = devices.user_id
+WHERE users.id = ?1
+ORDER BY devices.last_synced DESC
+`
+
+type GetDevicesRow struct {
+	ID         string      `json:"id"`
+	DeviceName string      `json:"device_name"`
+	CreatedAt  interface{} `json:"created_at"`
+	LastSynced interface{} `json:"last_synced"`
+	Sync       bool        `json:"sync"`
+}
+
+func (q *Queries) GetDevices(ctx context.Context, userID string) ([]GetDevicesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDevices, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDevicesRow
+	for rows.Next() {
+		var i GetDevicesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceName,
+			&i.CreatedAt,
+			&i.LastSynced,
+			&i.Sync,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocument = `-- name: GetDocument :one
+SELECT id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, pages, gbid, olid, isbn10, isbn13, owner_id, visibility, synced, deleted, updated_at, created_at FROM documents
+WHERE id = ?1 LIMIT 1
+`
+
+func (q *Queries) GetDocument(ctx context.Context, documentID string) (Document, error) {
+	row := q.db.QueryRowContext(ctx, getDocument, documentID)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.Md5,
+		&i.Basepath,
+		&i.Filepath,
+		&i.Coverfile,
+		&i.Title,
+		&i.Author,
+		&i.Series,
+		&i.SeriesIndex,
+		&i.Lang,
+		&i.Description,
+		&i.Words,
+		&i.Pages,
+		&i.Gbid,
+		&i.Olid,
+		&i.Isbn10,
+		&i.Isbn13,
+		&i.OwnerID,
+		&i.Visibility,
+		&i.Synced,
+		&i.Deleted,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDocumentAccess = `-- name: GetDocumentAccess :one
+SELECT
+    documents.owner_id,
+    CAST((visible.document_id IS NOT NULL) AS BOOLEAN) AS has_access
+FROM documents
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = ?1
+WHERE documents.id = ?2
+LIMIT 1
+`
+
+type GetDocumentAccessParams struct {
+	UserID     string `json:"user_id"`
+	DocumentID string `json:"document_id"`
+}
+
+type GetDocumentAccessRow struct {
+	OwnerID   *string `json:"owner_id"`
+	HasAccess bool    `json:"has_access"`
+}
+
+func (q *Queries) GetDocumentAccess(ctx context.Context, arg GetDocumentAccessParams) (GetDocumentAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getDocumentAccess, arg.UserID, arg.DocumentID)
+	var i GetDocumentAccessRow
+	err := row.Scan(&i.OwnerID, &i.HasAccess)
+	return i, err
+}
+
+const getDocumentChanges = `-- name: GetDocumentChanges :many
+SELECT
+    document_changes.seq,
+    document_changes.change_type,
+    CAST((visible.document_id IS NOT NULL) AS BOOLEAN) AS visible,
+    CAST((history.document_id IS NOT NULL) AS BOOLEAN) AS previously_visible,
+    documents.id, documents.md5, documents.basepath, documents.filepath, documents.coverfile, documents.title, documents.author, documents.series, documents.series_index, documents.lang, documents.description, documents.words, documents.pages, documents.gbid, documents.olid, documents.isbn10, documents.isbn13, documents.owner_id, documents.visibility, documents.synced, documents.deleted, documents.updated_at, documents.created_at
+FROM document_changes
+JOIN documents ON documents.id = document_changes.document_id
+JOIN users ON users.id = ?1
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = users.id
+LEFT JOIN document_access_history AS history
+    ON history.document_id = documents.id AND history.user_id = users.id
+WHERE document_changes.seq > ?2
+ORDER BY document_changes.seq
+LIMIT ?3
+`
+
+type GetDocumentChangesParams struct {
+	UserID string `json:"user_id"`
+	Cursor int64  `json:"cursor"`
+	Limit  int64  `json:"limit"`
+}
+
+type GetDocumentChangesRow struct {
+	Seq               int64    `json:"seq"`
+	ChangeType        string   `json:"change_type"`
+	Visible           bool     `json:"visible"`
+	PreviouslyVisible bool     `json:"previously_visible"`
+	Document          Document `json:"document"`
+}
+
+func (q *Queries) GetDocumentChanges(ctx context.Context, arg GetDocumentChangesParams) ([]GetDocumentChangesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentChanges, arg.UserID, arg.Cursor, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentChangesRow
+	for rows.Next() {
+		var i GetDocumentChangesRow
+		if err := rows.Scan(
+			&i.Seq,
+			&i.ChangeType,
+			&i.Visible,
+			&i.PreviouslyVisible,
+			&i.Document.ID,
+			&i.Document.Md5,
+			&i.Document.Basepath,
+			&i.Document.Filepath,
+			&i.Document.Coverfile,
+			&i.Document.Title,
+			&i.Document.Author,
+			&i.Document.Series,
+			&i.Document.SeriesIndex,
+			&i.Document.Lang,
+			&i.Document.Description,
+			&i.Document.Words,
+			&i.Document.Pages,
+			&i.Document.Gbid,
+			&i.Document.Olid,
+			&i.Document.Isbn10,
+			&i.Document.Isbn13,
+			&i.Document.OwnerID,
+			&i.Document.Visibility,
+			&i.Document.Synced,
+			&i.Document.Deleted,
+			&i.Document.UpdatedAt,
+			&i.Document.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentChangesCursor = `-- name: GetDocumentChangesCursor :one
+SELECT CAST(COALESCE(MAX(seq), 0) AS INTEGER) AS cursor
+FROM document_changes
+`
+
+func (q *Queries) GetDocumentChangesCursor(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getDocumentChangesCursor)
+	var cursor int64
+	err := row.Scan(&cursor)
+	return cursor, err
+}
+
+const getDocumentDevicesProgress = `-- name: GetDocumentDevicesProgress :many
+SELECT
+    document_progress.user_id, document_progress.document_id, document_progress.device_id, document_progress.percentage, document_progress.progress, document_progress.created_at,
+    devices.device_name
+FROM document_progress
+JOIN devices ON document_progress.device_id = devices.id
+WHERE
+    document_progress.user_id = ?1
+    AND document_progress.document_id = ?2
+ORDER BY document_progress.created_at DESC
+`
+
+type GetDocumentDevicesProgressParams struct {
+	UserID     string `json:"user_id"`
+	DocumentID string `json:"document_id"`
+}
+
+type GetDocumentDevicesProgressRow struct {
+	UserID     string  `json:"user_id"`
+	DocumentID string  `json:"document_id"`
+	DeviceID   string  `json:"device_id"`
+	Percentage float64 `json:"percentage"`
+	Progress   string  `json:"progress"`
+	CreatedAt  string  `json:"created_at"`
+	DeviceName string  `json:"device_name"`
+}
+
+func (q *Queries) GetDocumentDevicesProgress(ctx context.Context, arg GetDocumentDevicesProgressParams) ([]GetDocumentDevicesProgressRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentDevicesProgress, arg.UserID, arg.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentDevicesProgressRow
+	for rows.Next() {
+		var i GetDocumentDevicesProgressRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DocumentID,
+			&i.DeviceID,
+			&i.Percentage,
+			&i.Progress,
+			&i.CreatedAt,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentFiles = `-- name: GetDocumentFiles :many
+SELECT id, title, author, basepath, filepath FROM documents
+WHERE filepath IS NOT NULL AND deleted = false
+ORDER BY id
+`
+
+type GetDocumentFilesRow struct {
+	ID       string  `json:"id"`
+	Title    *string `json:"title"`
+	Author   *string `json:"author"`
+	Basepath *string `json:"basepath"`
+	Filepath *string `json:"filepath"`
+}
+
+func (q *Queries) GetDocumentFiles(ctx context.Context) ([]GetDocumentFilesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentFilesRow
+	for rows.Next() {
+		var i GetDocumentFilesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Author,
+			&i.Basepath,
+			&i.Filepath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentGroups = `-- name: GetDocumentGroups :many
+SELECT
+    CAST(CASE ?1
+        WHEN 'author' THEN docs.author
+        WHEN 'series' THEN docs.series
+        WHEN 'lang' THEN docs.lang
+    END AS TEXT) AS value,
+    COUNT(*) AS count
+FROM documents AS docs
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = ?2
+WHERE
+    docs.deleted = false
+GROUP BY value
+HAVING value IS NOT NULL AND value != ''
+ORDER BY value COLLATE NOCASE
+LIMIT ?4
+OFFSET ?3
+`
+
+type GetDocumentGroupsParams struct {
+	Field  interface{} `json:"field"`
+	UserID string      `json:"user_id"`
+	Offset int64       `json:"offset"`
+	Limit  int64       `json:"limit"`
+}
+
+type GetDocumentGroupsRow struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+func (q *Queries) GetDocumentGroups(ctx context.Context, arg GetDocumentGroupsParams) ([]GetDocumentGroupsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentGroups,
+		arg.Field,
+		arg.UserID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentGroupsRow
+	for rows.Next() {
+		var i GetDocumentGroupsRow
+		if err := rows.Scan(&i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentProgress = `-- name: GetDocumentProgress :one
+SELECT
+    document_progress.user_id, document_progress.document_id, document_progress.device_id, document_progress.percentage, document_progress.progress, document_progress.created_at,
+    devices.device_name
+FROM document_progress
+JOIN devices ON document_progress.device_id = devices.id
+JOIN users ON document_progress.user_id = users.id
+WHERE
+    document_progress.user_id = ?1
+    AND document_progress.document_id = ?2
+ORDER BY
+    CASE WHEN users.progress_strategy = 'furthest' THEN document_progress.percentage END DESC,
+    document_progress.created_at DESC,
+    document_progress.rowid DESC
+LIMIT 1
+`
+
+type GetDocumentProgressParams struct {
+	UserID     string `json:"user_id"`
+	DocumentID string `json:"document_id"`
+}
+
+type GetDocumentProgressRow struct {
+	UserID     string  `json:"user_id"`
+	DocumentID string  `json:"document_id"`
+	DeviceID   string  `json:"device_id"`
+	Percentage float64 `json:"percentage"`
+	Progress   string  `json:"progress"`
+	CreatedAt  string  `json:"created_at"`
+	DeviceName string  `json:"device_name"`
+}
+
+func (q *Queries) GetDocumentProgress(ctx context.Context, arg GetDocumentProgressParams) (GetDocumentProgressRow, error) {
+	row := q.db.QueryRowContext(ctx, getDocumentProgress, arg.UserID, arg.DocumentID)
+	var i GetDocumentProgressRow
+	err := row.Scan(
+		&i.UserID,
+		&i.DocumentID,
+		&i.DeviceID,
+		&i.Percentage,
+		&i.Progress,
+		&i.CreatedAt,
+		&i.DeviceName,
+	)
+	return i, err
+}
+
+const getDocumentProgressHistory = `-- name: GetDocumentProgressHistory :many
+SELECT
+    history.id,
+    history.device_id,
+    devices.device_name,
+    ROUND(CAST(history.percentage AS REAL) * 100, 2) AS percentage,
+    history.progress,
+    LOCAL_TIME(history.created_at, users.timezone) AS created_at
+FROM document_progress_history AS history
+LEFT JOIN users ON history.user_id = users.id
+LEFT JOIN devices ON history.device_id = devices.id
+WHERE
+    history.user_id = ?1
+    AND history.document_id = ?2
+ORDER BY history.created_at DESC, history.id DESC
+LIMIT ?3
+OFFSET ?4
+`
+
+type GetDocumentProgressHistoryParams struct {
+	UserID     string `json:"user_id"`
+	DocumentID string `json:"document_id"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type GetDocumentProgressHistoryRow struct {
+	ID         int64       `json:"id"`
+	DeviceID   string      `json:"device_id"`
+	DeviceName string      `json:"device_name"`
+	Percentage float64     `json:"percentage"`
+	Progress   string      `json:"progress"`
+	CreatedAt  interface{} `json:"created_at"`
+}
+
+func (q *Queries) GetDocumentProgressHistory(ctx context.Context, arg GetDocumentProgressHistoryParams) ([]GetDocumentProgressHistoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentProgressHistory,
+		arg.UserID,
+		arg.DocumentID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentProgressHistoryRow
+	for rows.Next() {
+		var i GetDocumentProgressHistoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.DeviceName,
+			&i.Percentage,
+			&i.Progress,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentShares = `-- name: GetDocumentShares :many
+SELECT document_id, share_type, share_with, created_at FROM document_shares
+WHERE document_id = ?1
+ORDER BY share_type, share_with
+`
+
+func (q *Queries) GetDocumentShares(ctx context.Context, documentID string) ([]DocumentShare, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentShares, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DocumentShare
+	for rows.Next() {
+		var i DocumentShare
+		if err := rows.Scan(
+			&i.DocumentID,
+			&i.ShareType,
+			&i.ShareWith,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocuments = `-- name: GetDocuments :many
+SELECT id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, pages, gbid, olid, isbn10, isbn13, owner_id, visibility, synced, deleted, updated_at, created_at FROM documents
+ORDER BY created_at DESC
+LIMIT ?2
+OFFSET ?1
+`
+
+type GetDocumentsParams struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+func (q *Queries) GetDocuments(ctx context.Context, arg GetDocumentsParams) ([]Document, error) {
+	rows, err := q.db.QueryContext(ctx, getDocuments, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Document
+	for rows.Next() {
+		var i Document
+		if err := rows.Scan(
+			&i.ID,
+			&i.Md5,
+			&i.Basepath,
+			&i.Filepath,
+			&i.Coverfile,
+			&i.Title,
+			&i.Author,
+			&i.Series,
+			&i.SeriesIndex,
+			&i.Lang,
+			&i.Description,
+			&i.Words,
+			&i.Pages,
+			&i.Gbid,
+			&i.Olid,
+			&i.Isbn10,
+			&i.Isbn13,
+			&i.OwnerID,
+			&i.Visibility,
+			&i.Synced,
+			&i.Deleted,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentsProgress = `-- name: GetDocumentsProgress :many
+SELECT
+    user_id,
+    document_id,
+    device_id,
+    percentage,
+    progress,
+    created_at
+FROM (
+    SELECT
+        document_progress.user_id, document_progress.document_id, document_progress.device_id, document_progress.percentage, document_progress.progress, document_progress.created_at,
+        ROW_NUMBER() OVER (
+            PARTITION BY document_progress.document_id
+            ORDER BY
+                CASE WHEN users.progress_strategy = 'furthest' THEN document_progress.percentage END DESC,
+                document_progress.created_at DESC,
+                document_progress.rowid DESC
+        ) AS progress_rank
+    FROM document_progress
+    JOIN devices ON document_progress.device_id = devices.id
+    JOIN users ON document_progress.user_id = users.id
+    WHERE
+        document_progress.user_id = ?1
+        AND document_progress.document_id IN (/*SLICE:document_ids*/?)
+)
+WHERE progress_rank = 1
+`
+
+type GetDocumentsProgressParams struct {
+	UserID      string   `json:"user_id"`
+	DocumentIds []string `json:"document_ids"`
+}
+
+type GetDocumentsProgressRow struct {
+	UserID     string  `json:"user_id"`
+	DocumentID string  `json:"document_id"`
+	DeviceID   string  `json:"device_id"`
+	Percentage float64 `json:"percentage"`
+	Progress   string  `json:"progress"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+func (q *Queries) GetDocumentsProgress(ctx context.Context, arg GetDocumentsProgressParams) ([]GetDocumentsProgressRow, error) {
+	query := getDocumentsProgress
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.DocumentIds) > 0 {
+		for _, v := range arg.DocumentIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:document_ids*/?", strings.Repeat(",?", len(arg.DocumentIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:document_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentsProgressRow
+	for rows.Next() {
+		var i GetDocumentsProgressRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DocumentID,
+			&i.DeviceID,
+			&i.Percentage,
+			&i.Progress,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentsSize = `-- name: GetDocumentsSize :one
+SELECT
+    COUNT(docs.rowid) AS length
+FROM documents AS docs
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = ?1
+LEFT JOIN
+    document_user_statistics AS dus
+    ON dus.document_id = docs.id AND dus.user_id = ?1
+WHERE
+    docs.deleted = false
+    AND (?2 IS NULL OR (
+        docs.title LIKE ?2 OR
+        docs.author LIKE ?2
+    ))
+    AND (docs.author = ?3 OR ?3 IS NULL)
+    AND (docs.series = ?4 OR ?4 IS NULL)
+    AND (docs.lang = ?5 OR ?5 IS NULL)
+    AND (
+        ?6 IS NULL
+        OR (?6 = 'reading' AND dus.percentage > 0.0 AND dus.percentage <= 0.97)
+        OR (?6 = 'finished' AND dus.percentage > 0.97)
+        OR (?6 = 'unread' AND COALESCE(dus.percentage, 0.0) = 0.0)
+    )
+    AND ((docs.filepath IS NOT NULL) = ?7 OR ?7 IS NULL)
+LIMIT 1
+`
+
+type GetDocumentsSizeParams struct {
+	UserID  string      `json:"user_id"`
+	Query   interface{} `json:"query"`
+	Author  *string     `json:"author"`
+	Series  *string     `json:"series"`
+	Lang    *string     `json:"lang"`
+	Status  interface{} `json:"status"`
+	HasFile *bool       `json:"has_file"`
+}
+
+func (q *Queries) GetDocumentsSize(ctx context.Context, arg GetDocumentsSizeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getDocumentsSize,
+		arg.UserID,
+		arg.Query,
+		arg.Author,
+		arg.Series,
+		arg.Lang,
+		arg.Status,
+		arg.HasFile,
+	)
+	var length int64
+	err := row.Scan(&length)
+	return length, err
+}
+
+const getDocumentsWithStats = `-- name: GetDocumentsWithStats :many
+SELECT
+    docs.id,
+    docs.title,
+    docs.author,
+    docs.description,
+    docs.isbn10,
+    docs.isbn13,
+    docs.filepath,
+    docs.basepath,
+    docs.series,
+    docs.series_index,
+    docs.lang,
+    docs.words,
+    docs.pages,
+    docs.owner_id,
+    docs.visibility,
+
+    CAST(COALESCE(dus.total_wpm, 0.0) AS INTEGER) AS wpm,
+    COALESCE(dus.read_percentage, 0) AS read_percentage,
+    COALESCE(dus.total_time_seconds, 0) AS total_time_seconds,
+    STRFTIME('%Y-%m-%d %H:%M:%S', LOCAL_TIME(COALESCE(dus.last_read, STRFTIME('%Y-%m-%dT%H:%M:%SZ', 0, 'unixepoch')), users.timezone))
+        AS last_read,
+    ROUND(CAST(CASE
+        WHEN dus.percentage IS NULL THEN 0.0
+        WHEN (dus.percentage * 100.0) > 97.0 THEN 100.0
+        ELSE dus.percentage * 100.0
+    END AS REAL), 2) AS percentage,
+    CAST(CASE
+        WHEN dus.total_time_seconds IS NULL THEN 0.0
+        ELSE
+            CAST(dus.total_time_seconds AS REAL)
+            / (dus.read_percentage * 100.0)
+    END AS INTEGER) AS seconds_per_percent
+FROM documents AS docs
+LEFT JOIN users ON users.id = ?1
+JOIN user_visible_documents AS visible
+    ON visible.document_id = docs.id AND visible.user_id = ?1
+LEFT JOIN
+    document_user_statistics AS dus
+    ON dus.document_id = docs.id AND dus.user_id = ?1
+WHERE
+    (docs.id = ?2 OR ?2 IS NULL)
+    AND (docs.deleted = ?3 OR ?3 IS NULL)
+    AND (
+        (
+            docs.title LIKE ?4 OR
+            docs.author LIKE ?4
+        ) OR ?4 IS NULL
+    )
+    AND (docs.author = ?5 OR ?5 IS NULL)
+    AND (docs.series = ?6 OR ?6 IS NULL)
+    AND (docs.lang = ?7 OR ?7 IS NULL)
+    AND (
+        ?8 IS NULL
+        OR (?8 = 'reading' AND dus.percentage > 0.0 AND dus.percentage <= 0.97)
+        OR (?8 = 'finished' AND dus.percentage > 0.97)
+        OR (?8 = 'unread' AND COALESCE(dus.percentage, 0.0) = 0.0)
+    )
+    AND ((docs.filepath IS NOT NULL) = ?9 OR ?9 IS NULL)
+ORDER BY
+    CASE WHEN ?6 IS NOT NULL THEN docs.series_index END ASC,
+    CASE WHEN ?10 = 'title' THEN docs.title END COLLATE NOCASE ASC,
+    CASE WHEN ?10 = 'author' THEN docs.author END COLLATE NOCASE ASC,
+    CASE WHEN ?10 = 'added' THEN docs.created_at END DESC,
+    dus.last_read DESC,
+    docs.created_at DESC
+LIMIT ?12
+OFFSET ?11
+`
+
+type GetDocumentsWithStatsParams struct {
+	UserID  string      `json:"user_id"`
+	ID      *string     `json:"id"`
+	Deleted *bool       `json:"-"`
+	Query   *string     `json:"query"`
+	Author  *string     `json:"author"`
+	Series  *string     `json:"series"`
+	Lang    *string     `json:"lang"`
+	Status  interface{} `json:"status"`
+	HasFile *bool       `json:"has_file"`
+	Sort    interface{} `json:"sort"`
+	Offset  int64       `json:"offset"`
+	Limit   int64       `json:"limit"`
+}
+
+type GetDocumentsWithStatsRow struct {
+	ID                string      `json:"id"`
+	Title             *string     `json:"title"`
+	Author            *string     `json:"author"`
+	Description       *string     `json:"description"`
+	Isbn10            *string     `json:"isbn10"`
+	Isbn13            *string     `json:"isbn13"`
+	Filepath          *string     `json:"filepath"`
+	Basepath          *string     `json:"basepath"`
+	Series            *string     `json:"series"`
+	SeriesIndex       *int64      `json:"series_index"`
+	Lang              *string     `json:"lang"`
+	Words             *int64      `json:"words"`
+	Pages             *int64      `json:"pages"`
+	OwnerID           *string     `json:"owner_id"`
+	Visibility        string      `json:"visibility"`
+	Wpm               int64       `json:"wpm"`
+	ReadPercentage    float64     `json:"read_percentage"`
+	TotalTimeSeconds  int64       `json:"total_time_seconds"`
+	LastRead          interface{} `json:"last_read"`
+	Percentage        float64     `json:"percentage"`
+	SecondsPerPercent int64       `json:"seconds_per_percent"`
+}
+
+func (q *Queries) GetDocumentsWithStats(ctx context.Context, arg GetDocumentsWithStatsParams) ([]GetDocumentsWithStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentsWithStats,
+		arg.UserID,
+		arg.ID,
+		arg.Deleted,
+		arg.Query,
+		arg.Author,
+		arg.Series,
+		arg.Lang,
+		arg.Status,
+		arg.HasFile,
+		arg.Sort,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDocumentsWithStatsRow
+	for rows.Next() {
+		var i GetDocumentsWithStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Author,
+			&i.Description,
+			&i.Isbn10,
+			&i.Isbn13,
+			&i.Filepath,
+			&i.Basepath,
+			&i.Series,
+			&i.SeriesIndex,
+			&i.Lang,
+			&i.Words,
+			&i.Pages,
+			&i.OwnerID,
+			&i.Visibility,
+			&i.Wpm,
+			&i.ReadPercentage,
+			&i.TotalTimeSeconds,
+			&i.LastRead,
+			&i.Percentage,
+			&i.SecondsPerPercent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImportJob = `-- name: GetImportJob :one
 SELECT
-    CAST(date AS TEXT),
-    CAST(CASE
-      WHEN seconds_read IS NULL THEN 0
-      ELSE seconds_read / 60
-    END AS INTEGER) AS minutes_read
-FROM last_30_days
-LEFT JOIN activity_days ON activity_days.day == last_30_days.date
-ORDER BY date DESC
-LIMIT 30
+    import_jobs.id,
+    import_jobs.job_id,
+    import_jobs.directory,
+    import_jobs.import_type,
+    jobs.status,
+    import_jobs.total_files,
+    jobs.error,
+    import_jobs.created_by,
+    CAST(COUNT(import_job_results.id) AS INTEGER) AS processed_files,
+    CAST(COALESCE(SUM(import_job_results.status = 'FAILED'), 0) AS INTEGER) AS failed_files,
+    LOCAL_TIME(import_jobs.created_at, CAST(?1 AS TEXT)) AS created_at
+FROM import_jobs
+JOIN jobs ON jobs.id = import_jobs.job_id
+LEFT JOIN import_job_results ON import_job_results.job_id = import_jobs.id
+WHERE import_jobs.id = ?2
+GROUP BY import_jobs.id
 `
 
-type GetDailyReadStatsRow struct {
-	Date        string `json:"date"`
-	MinutesRead int64  `json:"minutes_read"`
+type GetImportJobParams struct {
+	Timezone string `json:"timezone"`
+	ID       int64  `json:"id"`
 }
 
-func (q *Queries) GetDailyReadStats(ctx context.Context, userID string) ([]GetDailyReadStatsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDailyReadStats, userID)
+type GetImportJobRow struct {
+	ID             int64       `json:"id"`
+	JobID          *int64      `json:"job_id"`
+	Directory      string      `json:"directory"`
+	ImportType     string      `json:"import_type"`
+	Status         string      `json:"status"`
+	TotalFiles     *int64      `json:"total_files"`
+	Error          *string     `json:"error"`
+	CreatedBy      *string     `json:"created_by"`
+	ProcessedFiles int64       `json:"processed_files"`
+	FailedFiles    int64       `json:"failed_files"`
+	CreatedAt      interface{} `json:"created_at"`
+}
+
+func (q *Queries) GetImportJob(ctx context.Context, arg GetImportJobParams) (GetImportJobRow, error) {
+	row := q.db.QueryRowContext(ctx, getImportJob, arg.Timezone, arg.ID)
+	var i GetImportJobRow
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.Directory,
+		&i.ImportType,
+		&i.Status,
+		&i.TotalFiles,
+		&i.Error,
+		&i.CreatedBy,
+		&i.ProcessedFiles,
+		&i.FailedFiles,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImportJobResults = `-- name: GetImportJobResults :many
+SELECT id, job_id, path, document_id, name, status, error, created_at FROM import_job_results
+WHERE job_id = ?1
+ORDER BY path ASC
+`
+
+func (q *Queries) GetImportJobResults(ctx context.Context, jobID int64) ([]ImportJobResult, error) {
+	rows, err := q.db.QueryContext(ctx, getImportJobResults, jobID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetDailyReadStatsRow
+	var items []ImportJobResult
 	for rows.Next() {
-		var i GetDailyReadStatsRow
-		if err := rows.Scan(&i.Date, &i.MinutesRead); err != nil {
+		var i ImportJobResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Path,
+			&i.DocumentID,
+			&i.Name,
+			&i.Status,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -330,65 +2370,71 @@ func (q *Queries) GetDailyReadStats(ctx context.Context, userID string) ([]GetDa
 	return items, nil
 }
 
-const getDatabaseInfo = `-- name: GetDatabaseInfo :one
+const getImportJobs = `-- name: GetImportJobs :many
 SELECT
-    (SELECT COUNT(rowid) FROM activity WHERE activity.user_id = ?1) AS activity_size,
-    (SELECT COUNT(rowid) FROM documents) AS documents_size,
-    (SELECT COUNT(rowid) FROM document_progress WHERE document_progress.user_id = ?1) AS progress_size,
-    (SELECT COUNT(rowid) FROM devices WHERE devices.user_id = ?1) AS devices_size
-LIMIT 1
+    import_jobs.id,
+    import_jobs.job_id,
+    import_jobs.directory,
+    import_jobs.import_type,
+    jobs.status,
+    import_jobs.total_files,
+    jobs.error,
+    import_jobs.created_by,
+    CAST(COUNT(import_job_results.id) AS INTEGER) AS processed_files,
+    CAST(COALESCE(SUM(import_job_results.status = 'FAILED'), 0) AS INTEGER) AS failed_files,
+    LOCAL_TIME(import_jobs.created_at, CAST(?1 AS TEXT)) AS created_at
+FROM import_jobs
+JOIN jobs ON jobs.id = import_jobs.job_id
+LEFT JOIN import_job_results ON import_job_results.job_id = import_jobs.id
+GROUP BY import_jobs.id
+ORDER BY import_jobs.id DESC
+LIMIT ?2
 `
 
-type GetDatabaseInfoRow struct {
-	ActivitySize  int64 `json:"activity_size"`
-	DocumentsSize int64 `json:"documents_size"`
-	ProgressSize  int64 `json:"progress_size"`
-	DevicesSize   int64 `json:"devices_size"`
+type GetImportJobsParams struct {
+	Timezone string `json:"timezone"`
+	Limit    int64  `json:"limit"`
 }
 
-func (q *Queries) GetDatabaseInfo(ctx context.Context, userID string) (GetDatabaseInfoRow, error) {
-	row := q.db.QueryRowContext(ctx, getDatabaseInfo, userID)
-	var i GetDatabaseInfoRow
-	err := row.Scan(
-		&i.ActivitySize,
-		&i.DocumentsSize,
-		&i.ProgressSize,
-		&i.DevicesSize,
-	)
-	return i, err
+type GetImportJobsRow struct {
+	ID             int64       `json:"id"`
+	JobID          *int64      `json:"job_id"`
+	Directory      string      `json:"directory"`
+	ImportType     string      `json:"import_type"`
+	Status         string      `json:"status"`
+	TotalFiles     *int64      `json:"total_files"`
+	Error          *string     `json:"error"`
+	CreatedBy      *string     `json:"created_by"`
+	ProcessedFiles int64       `json:"processed_files"`
+	FailedFiles    int64       `json:"failed_files"`
+	CreatedAt      interface{} `json:"created_at"`
 }
 
-const getDeletedDocuments = `-- name: GetDeletedDocuments :many
-SELECT documents.id
-FROM documents
-WHERE
-    documents.deleted = true
-    AND documents.id IN (/*SLICE:document_ids*/?)
-`
-
-func (q *Queries) GetDeletedDocuments(ctx context.Context, documentIds []string) ([]string, error) {
-	query := getDeletedDocuments
-	var queryParams []interface{}
-	if len(documentIds) > 0 {
-		for _, v := range documentIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:document_ids*/?", strings.Repeat(",?", len(documentIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:document_ids*/?", "NULL", 1)
-	}
-	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+func (q *Queries) GetImportJobs(ctx context.Context, arg GetImportJobsParams) ([]GetImportJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getImportJobs, arg.Timezone, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetImportJobsRow
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var i GetImportJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Directory,
+			&i.ImportType,
+			&i.Status,
+			&i.TotalFiles,
+			&i.Error,
+			&i.CreatedBy,
+			&i.ProcessedFiles,
+			&i.FailedFiles,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -399,58 +2445,52 @@ func (q *Queries) GetDeletedDocuments(ctx context.Context, documentIds []string)
 	return items, nil
 }
 
-const getDevice = `-- name: GetDevice :one
-SELECT id, user_id, device_name, last_synced, created_at, sync FROM devices
-WHERE id = ?1 LIMIT 1
+const getImportLog = `-- name: GetImportLog :many
+SELECT
+    id,
+    directory,
+    path,
+    document_id,
+    status,
+    error,
+    LOCAL_TIME(created_at, CAST(?1 AS TEXT)) AS created_at
+FROM import_log
+ORDER BY id DESC
+LIMIT ?2
 `
 
-func (q *Queries) GetDevice(ctx context.Context, deviceID string) (Device, error) {
-	row := q.db.QueryRowContext(ctx, getDevice, deviceID)
-	var i Device
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.DeviceName,
-		&i.LastSynced,
-		&i.CreatedAt,
-		&i.Sync,
-	)
-	return i, err
+type GetImportLogParams struct {
+	Timezone string `json:"timezone"`
+	Limit    int64  `json:"limit"`
 }
 
-const getDevices = `-- name: GetDevices :many
-SELECT
-    devices.id,
-    devices.device_name,
-    LOCAL_TIME(devices.created_at, users.timezone) AS created_at,
-    LOCAL_TIME(devices.last_synced, users.timezone) AS last_synced
-FROM devices
-JOIN users ON users.id = devices.user_id
-WHERE users.id = ?1
-ORDER BY devices.last_synced DESC
-`
-
-type GetDevicesRow struct {
-	ID         string      `json:"id"`
-	DeviceName string      `json:"device_name"`
+type GetImportLogRow struct {
+	ID         int64       `json:"id"`
+	Directory  string      `json:"directory"`
+	Path       string      `json:"path"`
+	DocumentID *string     `json:"document_id"`
+	Status     string      `json:"status"`
+	Error      *string     `json:"error"`
 	CreatedAt  interface{} `json:"created_at"`
-	LastSynced interface{} `json:"last_synced"`
 }
 
-func (q *Queries) GetDevices(ctx context.Context, userID string) ([]GetDevicesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDevices, userID)
+func (q *Queries) GetImportLog(ctx context.Context, arg GetImportLogParams) ([]GetImportLogRow, error) {
+	rows, err := q.db.QueryContext(ctx, getImportLog, arg.Timezone, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetDevicesRow
+	var items []GetImportLogRow
 	for rows.Next() {
-		var i GetDevicesRow
+		var i GetImportLogRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.DeviceName,
+			&i.Directory,
+			&i.Path,
+			&i.DocumentID,
+			&i.Status,
+			&i.Error,
 			&i.CreatedAt,
-			&i.LastSynced,
 		); err != nil {
 			return nil, err
 		}
@@ -465,125 +2505,87 @@ func (q *Queries) GetDevices(ctx context.Context, userID string) ([]GetDevicesRo
 	return items, nil
 }
 
-const getDocument = `-- name: GetDocument :one
-SELECT id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, gbid, olid, isbn10, isbn13, synced, deleted, updated_at, created_at FROM documents
-WHERE id = ?1 LIMIT 1
-`
-
-func (q *Queries) GetDocument(ctx context.Context, documentID string) (Document, error) {
-	row := q.db.QueryRowContext(ctx, getDocument, documentID)
-	var i Document
-	err := row.Scan(
-		&i.ID,
-		&i.Md5,
-		&i.Basepath,
-		&i.Filepath,
-		&i.Coverfile,
-		&i.Title,
-		&i.Author,
-		&i.Series,
-		&i.SeriesIndex,
-		&i.Lang,
-		&i.Description,
-		&i.Words,
-		&i.Gbid,
-		&i.Olid,
-		&i.Isbn10,
-		&i.Isbn13,
-		&i.Synced,
-		&i.Deleted,
-		&i.UpdatedAt,
-		&i.CreatedAt,
-	)
-	return i, err
-}
-
-const getDocumentProgress = `-- name: GetDocumentProgress :one
-SELECT
-    document_progress.user_id, document_progress.document_id, document_progress.device_id, document_progress.percentage, document_progress.progress, document_progress.created_at,
-    devices.device_name
-FROM document_progress
-JOIN devices ON document_progress.device_id = devices.id
+const getInvite = `-- name: GetInvite :one
+SELECT code, role, max_uses, uses, expires_at, created_by, created_at FROM invites
 WHERE
-    document_progress.user_id = ?1
-    AND document_progress.document_id = ?2
-ORDER BY
-    document_progress.created_at
-    DESC
+    code = ?1
+    AND uses < max_uses
+    AND expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
 LIMIT 1
 `
 
-type GetDocumentProgressParams struct {
-	UserID     string `json:"user_id"`
-	DocumentID string `json:"document_id"`
-}
-
-type GetDocumentProgressRow struct {
-	UserID     string  `json:"user_id"`
-	DocumentID string  `json:"document_id"`
-	DeviceID   string  `json:"device_id"`
-	Percentage float64 `json:"percentage"`
-	Progress   string  `json:"progress"`
-	CreatedAt  string  `json:"created_at"`
-	DeviceName string  `json:"device_name"`
-}
-
-func (q *Queries) GetDocumentProgress(ctx context.Context, arg GetDocumentProgressParams) (GetDocumentProgressRow, error) {
-	row := q.db.QueryRowContext(ctx, getDocumentProgress, arg.UserID, arg.DocumentID)
-	var i GetDocumentProgressRow
+func (q *Queries) GetInvite(ctx context.Context, code string) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, getInvite, code)
+	var i Invite
 	err := row.Scan(
-		&i.UserID,
-		&i.DocumentID,
-		&i.DeviceID,
-		&i.Percentage,
-		&i.Progress,
+		&i.Code,
+		&i.Role,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedBy,
 		&i.CreatedAt,
-		&i.DeviceName,
 	)
 	return i, err
 }
 
-const getDocuments = `-- name: GetDocuments :many
-SELECT id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, gbid, olid, isbn10, isbn13, synced, deleted, updated_at, created_at FROM documents
-ORDER BY created_at DESC
-LIMIT ?2
-OFFSET ?1
+const getInviteUses = `-- name: GetInviteUses :many
+SELECT id, invite_code, user_id, user_agent, ip, created_at FROM invite_uses
+ORDER BY created_at DESC, id DESC
+LIMIT ?1
 `
 
-type GetDocumentsParams struct {
-	Offset int64 `json:"offset"`
-	Limit  int64 `json:"limit"`
+func (q *Queries) GetInviteUses(ctx context.Context, limit int64) ([]InviteUse, error) {
+	rows, err := q.db.QueryContext(ctx, getInviteUses, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InviteUse
+	for rows.Next() {
+		var i InviteUse
+		if err := rows.Scan(
+			&i.ID,
+			&i.InviteCode,
+			&i.UserID,
+			&i.UserAgent,
+			&i.Ip,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetDocuments(ctx context.Context, arg GetDocumentsParams) ([]Document, error) {
-	rows, err := q.db.QueryContext(ctx, getDocuments, arg.Offset, arg.Limit)
+const getInvites = `-- name: GetInvites :many
+SELECT code, role, max_uses, uses, expires_at, created_by, created_at FROM invites
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetInvites(ctx context.Context) ([]Invite, error) {
+	rows, err := q.db.QueryContext(ctx, getInvites)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Document
+	var items []Invite
 	for rows.Next() {
-		var i Document
+		var i Invite
 		if err := rows.Scan(
-			&i.ID,
-			&i.Md5,
-			&i.Basepath,
-			&i.Filepath,
-			&i.Coverfile,
-			&i.Title,
-			&i.Author,
-			&i.Series,
-			&i.SeriesIndex,
-			&i.Lang,
-			&i.Description,
-			&i.Words,
-			&i.Gbid,
-			&i.Olid,
-			&i.Isbn10,
-			&i.Isbn13,
-			&i.Synced,
-			&i.Deleted,
-			&i.UpdatedAt,
+			&i.Code,
+			&i.Role,
+			&i.MaxUses,
+			&i.Uses,
+			&i.ExpiresAt,
+			&i.CreatedBy,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -599,127 +2601,94 @@ func (q *Queries) GetDocuments(ctx context.Context, arg GetDocumentsParams) ([]D
 	return items, nil
 }
 
-const getDocumentsSize = `-- name: GetDocumentsSize :one
-SELECT
-    COUNT(rowid) AS length
-FROM documents AS docs
-WHERE ?1 IS NULL OR (
-    docs.title LIKE ?1 OR
-    docs.author LIKE ?1
-)
-LIMIT 1
+const getJob = `-- name: GetJob :one
+SELECT id, type, payload, status, attempts, max_attempts, error, created_by, run_at, started_at, finished_at, updated_at, created_at FROM jobs
+WHERE id = ?1 LIMIT 1
 `
 
-func (q *Queries) GetDocumentsSize(ctx context.Context, query interface{}) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getDocumentsSize, query)
-	var length int64
-	err := row.Scan(&length)
-	return length, err
+func (q *Queries) GetJob(ctx context.Context, id int64) (Job, error) {
+	row := q.db.QueryRowContext(ctx, getJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.Error,
+		&i.CreatedBy,
+		&i.RunAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getDocumentsWithStats = `-- name: GetDocumentsWithStats :many
+const getJobs = `-- name: GetJobs :many
 SELECT
-    docs.id,
-    docs.title,
-    docs.author,
-    docs.description,
-    docs.isbn10,
-    docs.isbn13,
-    docs.filepath,
-    docs.words,
-
-    CAST(COALESCE(dus.total_wpm, 0.0) AS INTEGER) AS wpm,
-    COALESCE(dus.read_percentage, 0) AS read_percentage,
-    COALESCE(dus.total_time_seconds, 0) AS total_time_seconds,
-    STRFTIME('%Y-%m-%d %H:%M:%S', LOCAL_TIME(COALESCE(dus.last_read, STRFTIME('%Y-%m-%dT%H:%M:%SZ', 0, 'unixepoch')), users.timezone))
-        AS last_read,
-    ROUND(CAST(CASE
-        WHEN dus.percentage IS NULL THEN 0.0
-        WHEN (dus.percentage * 100.0) > 97.0 THEN 100.0
-        ELSE dus.percentage * 100.0
-    END AS REAL), 2) AS percentage,
-    CAST(CASE
-        WHEN dus.total_time_seconds IS NULL THEN 0.0
-        ELSE
-            CAST(dus.total_time_seconds AS REAL)
-            / (dus.read_percentage * 100.0)
-    END AS INTEGER) AS seconds_per_percent
-FROM documents AS docs
-LEFT JOIN users ON users.id = ?1
-LEFT JOIN
-    document_user_statistics AS dus
-    ON dus.document_id = docs.id AND dus.user_id = ?1
-WHERE
-    (docs.id = ?2 OR ?2 IS NULL)
-    AND (docs.deleted = ?3 OR ?3 IS NULL)
-    AND (
-        (
-            docs.title LIKE ?4 OR
-            docs.author LIKE ?4
-        ) OR ?4 IS NULL
-    )
-ORDER BY dus.last_read DESC, docs.created_at DESC
-LIMIT ?6
-OFFSET ?5
+    id,
+    type,
+    payload,
+    status,
+    attempts,
+    max_attempts,
+    error,
+    created_by,
+    LOCAL_TIME(run_at, CAST(?1 AS TEXT)) AS run_at,
+    LOCAL_TIME(updated_at, CAST(?1 AS TEXT)) AS updated_at
+FROM jobs
+ORDER BY
+    CASE status
+        WHEN 'running' THEN 1
+        WHEN 'queued' THEN 2
+        WHEN 'failed' THEN 3
+        ELSE 4
+    END,
+    id DESC
+LIMIT ?2
 `
 
-type GetDocumentsWithStatsParams struct {
-	UserID  string  `json:"user_id"`
-	ID      *string `json:"id"`
-	Deleted *bool   `json:"-"`
-	Query   *string `json:"query"`
-	Offset  int64   `json:"offset"`
-	Limit   int64   `json:"limit"`
+type GetJobsParams struct {
+	Timezone string `json:"timezone"`
+	Limit    int64  `json:"limit"`
 }
 
-type GetDocumentsWithStatsRow struct {
-	ID                string      `json:"id"`
-	Title             *string     `json:"title"`
-	Author            *string     `json:"author"`
-	Description       *string     `json:"description"`
-	Isbn10            *string     `json:"isbn10"`
-	Isbn13            *string     `json:"isbn13"`
-	Filepath          *string     `json:"filepath"`
-	Words             *int64      `json:"words"`
-	Wpm               int64       `json:"wpm"`
-	ReadPercentage    float64     `json:"read_percentage"`
-	TotalTimeSeconds  int64       `json:"total_time_seconds"`
-	LastRead          interface{} `json:"last_read"`
-	Percentage        float64     `json:"percentage"`
-	SecondsPerPercent int64       `json:"seconds_per_percent"`
+type GetJobsRow struct {
+	ID          int64       `json:"id"`
+	Type        string      `json:"type"`
+	Payload     string      `json:"payload"`
+	Status      string      `json:"status"`
+	Attempts    int64       `json:"attempts"`
+	MaxAttempts int64       `json:"max_attempts"`
+	Error       *string     `json:"error"`
+	CreatedBy   *string     `json:"created_by"`
+	RunAt       interface{} `json:"run_at"`
+	UpdatedAt   interface{} `json:"updated_at"`
 }
 
-func (q *Queries) GetDocumentsWithStats(ctx context.Context, arg GetDocumentsWithStatsParams) ([]GetDocumentsWithStatsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDocumentsWithStats,
-		arg.UserID,
-		arg.ID,
-		arg.Deleted,
-		arg.Query,
-		arg.Offset,
-		arg.Limit,
-	)
+func (q *Queries) GetJobs(ctx context.Context, arg GetJobsParams) ([]GetJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getJobs, arg.Timezone, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetDocumentsWithStatsRow
+	var items []GetJobsRow
 	for rows.Next() {
-		var i GetDocumentsWithStatsRow
+		var i GetJobsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
-			&i.Author,
-			&i.Description,
-			&i.Isbn10,
-			&i.Isbn13,
-			&i.Filepath,
-			&i.Words,
-			&i.Wpm,
-			&i.ReadPercentage,
-			&i.TotalTimeSeconds,
-			&i.LastRead,
-			&i.Percentage,
-			&i.SecondsPerPercent,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.Error,
+			&i.CreatedBy,
+			&i.RunAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -755,21 +2724,29 @@ func (q *Queries) GetLastActivity(ctx context.Context, arg GetLastActivityParams
 }
 
 const getMissingDocuments = `-- name: GetMissingDocuments :many
-SELECT documents.id, documents.md5, documents.basepath, documents.filepath, documents.coverfile, documents.title, documents.author, documents.series, documents.series_index, documents.lang, documents.description, documents.words, documents.gbid, documents.olid, documents.isbn10, documents.isbn13, documents.synced, documents.deleted, documents.updated_at, documents.created_at FROM documents
+SELECT documents.id, documents.md5, documents.basepath, documents.filepath, documents.coverfile, documents.title, documents.author, documents.series, documents.series_index, documents.lang, documents.description, documents.words, documents.pages, documents.gbid, documents.olid, documents.isbn10, documents.isbn13, documents.owner_id, documents.visibility, documents.synced, documents.deleted, documents.updated_at, documents.created_at FROM documents
+JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = ?1
 WHERE
     documents.filepath IS NOT NULL
     AND documents.deleted = false
     AND documents.id NOT IN (/*SLICE:document_ids*/?)
 `
 
-func (q *Queries) GetMissingDocuments(ctx context.Context, documentIds []string) ([]Document, error) {
+type GetMissingDocumentsParams struct {
+	UserID      string   `json:"user_id"`
+	DocumentIds []string `json:"document_ids"`
+}
+
+func (q *Queries) GetMissingDocuments(ctx context.Context, arg GetMissingDocumentsParams) ([]Document, error) {
 	query := getMissingDocuments
 	var queryParams []interface{}
-	if len(documentIds) > 0 {
-		for _, v := range documentIds {
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.DocumentIds) > 0 {
+		for _, v := range arg.DocumentIds {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:document_ids*/?", strings.Repeat(",?", len(documentIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:document_ids*/?", strings.Repeat(",?", len(arg.DocumentIds))[1:], 1)
 	} else {
 		query = strings.Replace(query, "/*SLICE:document_ids*/?", "NULL", 1)
 	}
@@ -794,10 +2771,13 @@ func (q *Queries) GetMissingDocuments(ctx context.Context, documentIds []string)
 			&i.Lang,
 			&i.Description,
 			&i.Words,
+			&i.Pages,
 			&i.Gbid,
 			&i.Olid,
 			&i.Isbn10,
 			&i.Isbn13,
+			&i.OwnerID,
+			&i.Visibility,
 			&i.Synced,
 			&i.Deleted,
 			&i.UpdatedAt,
@@ -816,6 +2796,77 @@ func (q *Queries) GetMissingDocuments(ctx context.Context, documentIds []string)
 	return items, nil
 }
 
+const getPendingWebhookDeliveries = `-- name: GetPendingWebhookDeliveries :many
+SELECT
+    webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.response_code, webhook_deliveries.error, webhook_deliveries.next_attempt_at, webhook_deliveries.updated_at, webhook_deliveries.created_at,
+    webhooks.url,
+    webhooks.secret,
+    webhooks.user_id
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE
+    webhooks.enabled = TRUE
+    AND webhook_deliveries.status = 'pending'
+    AND webhook_deliveries.next_attempt_at <= STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+ORDER BY webhook_deliveries.next_attempt_at ASC, webhook_deliveries.id ASC
+LIMIT ?1
+`
+
+type GetPendingWebhookDeliveriesRow struct {
+	ID            int64   `json:"id"`
+	WebhookID     int64   `json:"webhook_id"`
+	Event         string  `json:"event"`
+	Payload       string  `json:"payload"`
+	Status        string  `json:"status"`
+	Attempts      int64   `json:"attempts"`
+	ResponseCode  *int64  `json:"response_code"`
+	Error         *string `json:"error"`
+	NextAttemptAt string  `json:"next_attempt_at"`
+	UpdatedAt     string  `json:"updated_at"`
+	CreatedAt     string  `json:"created_at"`
+	Url           string  `json:"url"`
+	Secret        string  `json:"-"`
+	UserID        *string `json:"user_id"`
+}
+
+func (q *Queries) GetPendingWebhookDeliveries(ctx context.Context, limit int64) ([]GetPendingWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingWebhookDeliveries, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPendingWebhookDeliveriesRow
+	for rows.Next() {
+		var i GetPendingWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.NextAttemptAt,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.Url,
+			&i.Secret,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProgress = `-- name: GetProgress :many
 SELECT
     documents.title,
@@ -872,17 +2923,201 @@ func (q *Queries) GetProgress(ctx context.Context, arg GetProgressParams) ([]Get
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetProgressRow
+	var items []GetProgressRow
+	for rows.Next() {
+		var i GetProgressRow
+		if err := rows.Scan(
+			&i.Title,
+			&i.Author,
+			&i.DeviceName,
+			&i.Percentage,
+			&i.DocumentID,
+			&i.UserID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProgressHistoryEntry = `-- name: GetProgressHistoryEntry :one
+SELECT id, user_id, document_id, device_id, percentage, progress, created_at FROM document_progress_history
+WHERE id = ?1 AND user_id = ?2 LIMIT 1
+`
+
+type GetProgressHistoryEntryParams struct {
+	ID     int64  `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) GetProgressHistoryEntry(ctx context.Context, arg GetProgressHistoryEntryParams) (DocumentProgressHistory, error) {
+	row := q.db.QueryRowContext(ctx, getProgressHistoryEntry, arg.ID, arg.UserID)
+	var i DocumentProgressHistory
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DocumentID,
+		&i.DeviceID,
+		&i.Percentage,
+		&i.Progress,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRole = `-- name: GetRole :one
+SELECT name, can_upload, can_delete, can_edit, can_search, can_download, can_sync, created_at FROM roles
+WHERE name = ?1 LIMIT 1
+`
+
+func (q *Queries) GetRole(ctx context.Context, name string) (Role, error) {
+	row := q.db.QueryRowContext(ctx, getRole, name)
+	var i Role
+	err := row.Scan(
+		&i.Name,
+		&i.CanUpload,
+		&i.CanDelete,
+		&i.CanEdit,
+		&i.CanSearch,
+		&i.CanDownload,
+		&i.CanSync,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRoles = `-- name: GetRoles :many
+SELECT name, can_upload, can_delete, can_edit, can_search, can_download, can_sync, created_at FROM roles
+ORDER BY name
+`
+
+func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.QueryContext(ctx, getRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.Name,
+			&i.CanUpload,
+			&i.CanDelete,
+			&i.CanEdit,
+			&i.CanSearch,
+			&i.CanDownload,
+			&i.CanSync,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSession = `-- name: GetSession :one
+SELECT
+    sessions.id, sessions.user_id, sessions.token_hash, sessions.auth_hash, sessions.user_agent, sessions.ip, sessions.expires_at, sessions.last_seen, sessions.created_at,
+    users.admin,
+    users.role,
+    users.auth_hash AS user_auth_hash
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE
+    sessions.token_hash = ?1
+    AND sessions.expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+LIMIT 1
+`
+
+type GetSessionRow struct {
+	ID           int64   `json:"id"`
+	UserID       string  `json:"user_id"`
+	TokenHash    string  `json:"-"`
+	AuthHash     string  `json:"-"`
+	UserAgent    string  `json:"user_agent"`
+	Ip           string  `json:"ip"`
+	ExpiresAt    string  `json:"expires_at"`
+	LastSeen     string  `json:"last_seen"`
+	CreatedAt    string  `json:"created_at"`
+	Admin        bool    `json:"-"`
+	Role         string  `json:"role"`
+	UserAuthHash *string `json:"user_auth_hash"`
+}
+
+func (q *Queries) GetSession(ctx context.Context, tokenHash string) (GetSessionRow, error) {
+	row := q.db.QueryRowContext(ctx, getSession, tokenHash)
+	var i GetSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.AuthHash,
+		&i.UserAgent,
+		&i.Ip,
+		&i.ExpiresAt,
+		&i.LastSeen,
+		&i.CreatedAt,
+		&i.Admin,
+		&i.Role,
+		&i.UserAuthHash,
+	)
+	return i, err
+}
+
+const getSessions = `-- name: GetSessions :many
+SELECT
+    sessions.id,
+    sessions.user_agent,
+    sessions.ip,
+    LOCAL_TIME(sessions.created_at, users.timezone) AS created_at,
+    LOCAL_TIME(sessions.last_seen, users.timezone) AS last_seen
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE
+    sessions.user_id = ?1
+    AND sessions.expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+ORDER BY sessions.last_seen DESC
+`
+
+type GetSessionsRow struct {
+	ID        int64       `json:"id"`
+	UserAgent string      `json:"user_agent"`
+	Ip        string      `json:"ip"`
+	CreatedAt interface{} `json:"created_at"`
+	LastSeen  interface{} `json:"last_seen"`
+}
+
+func (q *Queries) GetSessions(ctx context.Context, userID string) ([]GetSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSessionsRow
 	for rows.Next() {
-		var i GetProgressRow
+		var i GetSessionsRow
 		if err := rows.Scan(
-			&i.Title,
-			&i.Author,
-			&i.DeviceName,
-			&i.Percentage,
-			&i.DocumentID,
-			&i.UserID,
+			&i.ID,
+			&i.UserAgent,
+			&i.Ip,
 			&i.CreatedAt,
+			&i.LastSeen,
 		); err != nil {
 			return nil, err
 		}
@@ -897,8 +3132,29 @@ func (q *Queries) GetProgress(ctx context.Context, arg GetProgressParams) ([]Get
 	return items, nil
 }
 
+const getTotals = `-- name: GetTotals :one
+SELECT
+    (SELECT COUNT(rowid) FROM documents WHERE documents.deleted = false) AS documents,
+    (SELECT COUNT(rowid) FROM users) AS users,
+    (SELECT COUNT(rowid) FROM activity) AS activity
+LIMIT 1
+`
+
+type GetTotalsRow struct {
+	Documents int64 `json:"documents"`
+	Users     int64 `json:"users"`
+	Activity  int64 `json:"activity"`
+}
+
+func (q *Queries) GetTotals(ctx context.Context) (GetTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getTotals)
+	var i GetTotalsRow
+	err := row.Scan(&i.Documents, &i.Users, &i.Activity)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, pass, auth_hash, admin, timezone, created_at FROM users
+SELECT id, pass, auth_hash, admin, role, timezone, progress_strategy, created_at FROM users
 WHERE id = ?1 LIMIT 1
 `
 
@@ -910,7 +3166,9 @@ func (q *Queries) GetUser(ctx context.Context, userID string) (User, error) {
 		&i.Pass,
 		&i.AuthHash,
 		&i.Admin,
+		&i.Role,
 		&i.Timezone,
+		&i.ProgressStrategy,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -996,128 +3254,596 @@ func (q *Queries) GetUserStatistics(ctx context.Context) ([]GetUserStatisticsRow
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return items, nil
+	return items, nil
+}
+
+const getUserStreaks = `-- name: GetUserStreaks :many
+SELECT user_id, "window", max_streak, max_streak_start_date, max_streak_end_date, current_streak, current_streak_start_date, current_streak_end_date, last_timezone, last_seen, last_record, last_calculated FROM user_streaks
+WHERE user_id = ?1
+`
+
+func (q *Queries) GetUserStreaks(ctx context.Context, userID string) ([]UserStreak, error) {
+	rows, err := q.db.QueryContext(ctx, getUserStreaks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserStreak
+	for rows.Next() {
+		var i UserStreak
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Window,
+			&i.MaxStreak,
+			&i.MaxStreakStartDate,
+			&i.MaxStreakEndDate,
+			&i.CurrentStreak,
+			&i.CurrentStreakStartDate,
+			&i.CurrentStreakEndDate,
+			&i.LastTimezone,
+			&i.LastSeen,
+			&i.LastRecord,
+			&i.LastCalculated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsers = `-- name: GetUsers :many
+SELECT id, pass, auth_hash, admin, role, timezone, progress_strategy, created_at FROM users
+`
+
+func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pass,
+			&i.AuthHash,
+			&i.Admin,
+			&i.Role,
+			&i.Timezone,
+			&i.ProgressStrategy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWantedDocuments = `-- name: GetWantedDocuments :many
+SELECT
+    CAST(value AS TEXT) AS id,
+    CAST((documents.filepath IS NULL) AS BOOLEAN) AS want_file,
+    CAST((documents.id IS NULL) AS BOOLEAN) AS want_metadata
+FROM json_each(?1)
+LEFT JOIN documents
+ON value = documents.id
+LEFT JOIN user_visible_documents AS visible
+    ON visible.document_id = documents.id AND visible.user_id = ?2
+WHERE (
+    documents.id IS NOT NULL
+    AND documents.deleted = false
+    AND documents.filepath IS NULL
+    AND visible.document_id IS NOT NULL
+)
+OR (documents.id IS NULL)
+OR CAST(?1 AS TEXT) != CAST(?1 AS TEXT)
+`
+
+type GetWantedDocumentsRow struct {
+	ID           string `json:"id"`
+	WantFile     bool   `json:"want_file"`
+	WantMetadata bool   `json:"want_metadata"`
+}
+
+type GetWantedDocumentsParams struct {
+	DocumentIds string `json:"document_ids"`
+	UserID      string `json:"user_id"`
+}
+
+func (q *Queries) GetWantedDocuments(ctx context.Context, arg GetWantedDocumentsParams) ([]GetWantedDocumentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWantedDocuments, arg.DocumentIds, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWantedDocumentsRow
+	for rows.Next() {
+		var i GetWantedDocumentsRow
+		if err := rows.Scan(&i.ID, &i.WantFile, &i.WantMetadata); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookDeliveries = `-- name: GetWebhookDeliveries :many
+SELECT
+    webhook_deliveries.id,
+    webhook_deliveries.webhook_id,
+    webhooks.url,
+    webhook_deliveries.event,
+    webhook_deliveries.status,
+    webhook_deliveries.attempts,
+    webhook_deliveries.response_code,
+    webhook_deliveries.error,
+    LOCAL_TIME(webhook_deliveries.created_at, CAST(?1 AS TEXT)) AS created_at
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhooks.user_id IS ?2
+ORDER BY webhook_deliveries.id DESC
+LIMIT ?3
+`
+
+type GetWebhookDeliveriesParams struct {
+	Timezone string  `json:"timezone"`
+	UserID   *string `json:"user_id"`
+	Limit    int64   `json:"limit"`
+}
+
+type GetWebhookDeliveriesRow struct {
+	ID           int64       `json:"id"`
+	WebhookID    int64       `json:"webhook_id"`
+	Url          string      `json:"url"`
+	Event        string      `json:"event"`
+	Status       string      `json:"status"`
+	Attempts     int64       `json:"attempts"`
+	ResponseCode *int64      `json:"response_code"`
+	Error        *string     `json:"error"`
+	CreatedAt    interface{} `json:"created_at"`
+}
+
+func (q *Queries) GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]GetWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhookDeliveries, arg.Timezone, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWebhookDeliveriesRow
+	for rows.Next() {
+		var i GetWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Url,
+			&i.Event,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhooks = `-- name: GetWebhooks :many
+SELECT id, user_id, url, secret, events, enabled, created_at FROM webhooks
+WHERE user_id IS ?1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) GetWebhooks(ctx context.Context, userID *string) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const linkImportJob = `-- name: LinkImportJob :exec
+UPDATE import_jobs
+SET
+    job_id = ?1,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?2
+`
+
+type LinkImportJobParams struct {
+	JobID *int64 `json:"job_id"`
+	ID    int64  `json:"id"`
+}
+
+func (q *Queries) LinkImportJob(ctx context.Context, arg LinkImportJobParams) error {
+	_, err := q.db.ExecContext(ctx, linkImportJob, arg.JobID, arg.ID)
+	return err
+}
+
+const mergeDeviceActivity = `-- name: MergeDeviceActivity :execrows
+UPDATE activity
+SET
+    duration = MAX(activity.duration, source.duration),
+    start_percentage = MIN(activity.start_percentage, source.start_percentage),
+    end_percentage = MAX(activity.end_percentage, source.end_percentage)
+FROM activity AS source
+WHERE
+    activity.device_id = ?1
+    AND source.device_id = ?2
+    AND source.user_id = activity.user_id
+    AND source.document_id = activity.document_id
+    AND source.start_time = activity.start_time
+`
+
+type MergeDeviceActivityParams struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) MergeDeviceActivity(ctx context.Context, arg MergeDeviceActivityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, mergeDeviceActivity, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const mergeDuplicateActivity = `-- name: MergeDuplicateActivity :execrows
+UPDATE activity
+SET
+    duration = merged.duration,
+    start_percentage = merged.start_percentage,
+    end_percentage = merged.end_percentage
+FROM (
+    SELECT
+        MIN(id) AS id,
+        MAX(duration) AS duration,
+        MIN(start_percentage) AS start_percentage,
+        MAX(end_percentage) AS end_percentage
+    FROM activity
+    WHERE
+        (user_id = ?1 OR ?1 IS NULL)
+        AND (device_id = ?2 OR ?2 IS NULL)
+    GROUP BY user_id, device_id, document_id, start_time
+    HAVING COUNT(*) > 1
+) AS merged
+WHERE activity.id = merged.id
+`
+
+type MergeDuplicateActivityParams struct {
+	UserID   *string `json:"user_id"`
+	DeviceID *string `json:"device_id"`
+}
+
+func (q *Queries) MergeDuplicateActivity(ctx context.Context, arg MergeDuplicateActivityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, mergeDuplicateActivity, arg.UserID, arg.DeviceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveDeviceActivity = `-- name: MoveDeviceActivity :execrows
+UPDATE OR IGNORE activity
+SET device_id = ?1
+WHERE device_id = ?2
+`
+
+type MoveDeviceActivityParams struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) MoveDeviceActivity(ctx context.Context, arg MoveDeviceActivityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveDeviceActivity, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveDeviceProgress = `-- name: MoveDeviceProgress :execrows
+UPDATE document_progress
+SET device_id = ?1
+WHERE
+    device_id = ?2
+    AND NOT EXISTS (
+        SELECT 1 FROM document_progress AS target
+        WHERE
+            target.device_id = ?1
+            AND target.user_id = document_progress.user_id
+            AND target.document_id = document_progress.document_id
+    )
+`
+
+type MoveDeviceProgressParams struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) MoveDeviceProgress(ctx context.Context, arg MoveDeviceProgressParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveDeviceProgress, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveDeviceProgressHistory = `-- name: MoveDeviceProgressHistory :execrows
+UPDATE document_progress_history
+SET device_id = ?1
+WHERE device_id = ?2
+`
+
+type MoveDeviceProgressHistoryParams struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) MoveDeviceProgressHistory(ctx context.Context, arg MoveDeviceProgressHistoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveDeviceProgressHistory, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getUserStreaks = `-- name: GetUserStreaks :many
-SELECT user_id, "window", max_streak, max_streak_start_date, max_streak_end_date, current_streak, current_streak_start_date, current_streak_end_date, last_timezone, last_seen, last_record, last_calculated FROM user_streaks
-WHERE user_id = ?1
+const recoverJobs = `-- name: RecoverJobs :execrows
+UPDATE jobs
+SET
+    status = 'queued',
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE status = 'running'
 `
 
-func (q *Queries) GetUserStreaks(ctx context.Context, userID string) ([]UserStreak, error) {
-	rows, err := q.db.QueryContext(ctx, getUserStreaks, userID)
+func (q *Queries) RecoverJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recoverJobs)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []UserStreak
-	for rows.Next() {
-		var i UserStreak
-		if err := rows.Scan(
-			&i.UserID,
-			&i.Window,
-			&i.MaxStreak,
-			&i.MaxStreakStartDate,
-			&i.MaxStreakEndDate,
-			&i.CurrentStreak,
-			&i.CurrentStreakStartDate,
-			&i.CurrentStreakEndDate,
-			&i.LastTimezone,
-			&i.LastSeen,
-			&i.LastRecord,
-			&i.LastCalculated,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getUsers = `-- name: GetUsers :many
-SELECT id, pass, auth_hash, admin, timezone, created_at FROM users
+const requeueJob = `-- name: RequeueJob :execrows
+UPDATE jobs
+SET
+    status = 'queued',
+    attempts = 0,
+    error = NULL,
+    run_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    finished_at = NULL,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?1 AND status IN ('failed', 'cancelled')
 `
 
-func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getUsers)
+func (q *Queries) RequeueJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requeueJob, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []User
-	for rows.Next() {
-		var i User
-		if err := rows.Scan(
-			&i.ID,
-			&i.Pass,
-			&i.AuthHash,
-			&i.Admin,
-			&i.Timezone,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getWantedDocuments = `-- name: GetWantedDocuments :many
-SELECT
-    CAST(value AS TEXT) AS id,
-    CAST((documents.filepath IS NULL) AS BOOLEAN) AS want_file,
-    CAST((documents.id IS NULL) AS BOOLEAN) AS want_metadata
-FROM json_each(?1)
-LEFT JOIN documents
-ON value = documents.id
-WHERE (
-    documents.id IS NOT NULL
-    AND documents.deleted = false
-    AND documents.filepath IS NULL
+const retryJob = `-- name: RetryJob :exec
+UPDATE jobs
+SET
+    status = 'queued',
+    error = ?1,
+    run_at = ?2,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?3 AND status = 'running'
+`
+
+type RetryJobParams struct {
+	Error *string `json:"error"`
+	RunAt string  `json:"run_at"`
+	ID    int64   `json:"id"`
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) error {
+	_, err := q.db.ExecContext(ctx, retryJob, arg.Error, arg.RunAt, arg.ID)
+	return err
+}
+
+const scheduleJob = `-- name: ScheduleJob :execrows
+INSERT INTO jobs (type, payload)
+SELECT ?1, ?2
+WHERE NOT EXISTS (
+    SELECT 1 FROM jobs
+    WHERE
+        jobs.type = ?1
+        AND (
+            jobs.status IN ('queued', 'running')
+            OR jobs.created_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now', ?3)
+        )
 )
-OR (documents.id IS NULL)
-OR CAST(?1 AS TEXT) != CAST(?1 AS TEXT)
 `
 
-type GetWantedDocumentsRow struct {
-	ID           string `json:"id"`
-	WantFile     bool   `json:"want_file"`
-	WantMetadata bool   `json:"want_metadata"`
+type ScheduleJobParams struct {
+	Type     string `json:"type"`
+	Payload  string `json:"payload"`
+	Interval string `json:"interval"`
 }
 
-func (q *Queries) GetWantedDocuments(ctx context.Context, documentIds string) ([]GetWantedDocumentsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getWantedDocuments, documentIds)
+func (q *Queries) ScheduleJob(ctx context.Context, arg ScheduleJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, scheduleJob, arg.Type, arg.Payload, arg.Interval)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetWantedDocumentsRow
-	for rows.Next() {
-		var i GetWantedDocumentsRow
-		if err := rows.Scan(&i.ID, &i.WantFile, &i.WantMetadata); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const startJob = `-- name: StartJob :one
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    error = NULL,
+    started_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?1 AND status = 'queued'
+RETURNING id, type, payload, status, attempts, max_attempts, error, created_by, run_at, started_at, finished_at, updated_at, created_at
+`
+
+func (q *Queries) StartJob(ctx context.Context, id int64) (Job, error) {
+	row := q.db.QueryRowContext(ctx, startJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.Error,
+		&i.CreatedBy,
+		&i.RunAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateAPITokenLastUsed = `-- name: UpdateAPITokenLastUsed :exec
+UPDATE api_tokens
+SET last_used = ?1
+WHERE id = ?2
+`
+
+type UpdateAPITokenLastUsedParams struct {
+	LastUsed *string `json:"last_used"`
+	ID       int64   `json:"id"`
+}
+
+func (q *Queries) UpdateAPITokenLastUsed(ctx context.Context, arg UpdateAPITokenLastUsedParams) error {
+	_, err := q.db.ExecContext(ctx, updateAPITokenLastUsed, arg.LastUsed, arg.ID)
+	return err
+}
+
+const updateDevice = `-- name: UpdateDevice :one
+UPDATE devices
+SET
+    device_name = COALESCE(?1, device_name),
+    sync = COALESCE(?2, sync)
+WHERE id = ?3 AND user_id = ?4
+RETURNING id, user_id, device_name, last_synced, created_at, sync
+`
+
+type UpdateDeviceParams struct {
+	DeviceName *string `json:"device_name"`
+	Sync       *bool   `json:"sync"`
+	ID         string  `json:"id"`
+	UserID     string  `json:"user_id"`
+}
+
+func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Device, error) {
+	row := q.db.QueryRowContext(ctx, updateDevice,
+		arg.DeviceName,
+		arg.Sync,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DeviceName,
+		&i.LastSynced,
+		&i.CreatedAt,
+		&i.Sync,
+	)
+	return i, err
+}
+
+const updateDocumentVisibility = `-- name: UpdateDocumentVisibility :execrows
+UPDATE documents
+SET visibility = ?1
+WHERE id = ?2
+`
+
+type UpdateDocumentVisibilityParams struct {
+	Visibility string `json:"visibility"`
+	DocumentID string `json:"document_id"`
+}
+
+func (q *Queries) UpdateDocumentVisibility(ctx context.Context, arg UpdateDocumentVisibilityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateDocumentVisibility, arg.Visibility, arg.DocumentID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
+}
+
+const updateImportJobTotal = `-- name: UpdateImportJobTotal :exec
+UPDATE import_jobs
+SET
+    total_files = ?1,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?2
+`
+
+type UpdateImportJobTotalParams struct {
+	TotalFiles *int64 `json:"total_files"`
+	ID         int64  `json:"id"`
+}
+
+func (q *Queries) UpdateImportJobTotal(ctx context.Context, arg UpdateImportJobTotalParams) error {
+	_, err := q.db.ExecContext(ctx, updateImportJobTotal, arg.TotalFiles, arg.ID)
+	return err
 }
 
 const updateProgress = `-- name: UpdateProgress :one
@@ -1126,9 +3852,10 @@ INSERT OR REPLACE INTO document_progress (
     document_id,
     device_id,
     percentage,
-    progress
+    progress,
+    created_at
 )
-VALUES (?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?)
 RETURNING user_id, document_id, device_id, percentage, progress, created_at
 `
 
@@ -1138,6 +3865,7 @@ type UpdateProgressParams struct {
 	DeviceID   string  `json:"device_id"`
 	Percentage float64 `json:"percentage"`
 	Progress   string  `json:"progress"`
+	CreatedAt  string  `json:"created_at"`
 }
 
 func (q *Queries) UpdateProgress(ctx context.Context, arg UpdateProgressParams) (DocumentProgress, error) {
@@ -1147,6 +3875,7 @@ func (q *Queries) UpdateProgress(ctx context.Context, arg UpdateProgressParams)
 		arg.DeviceID,
 		arg.Percentage,
 		arg.Progress,
+		arg.CreatedAt,
 	)
 	var i DocumentProgress
 	err := row.Scan(
@@ -1160,6 +3889,44 @@ func (q *Queries) UpdateProgress(ctx context.Context, arg UpdateProgressParams)
 	return i, err
 }
 
+const updateRoleUsers = `-- name: UpdateRoleUsers :execrows
+UPDATE users
+SET role = ?1
+WHERE role = ?2
+`
+
+type UpdateRoleUsersParams struct {
+	Role        string `json:"role"`
+	CurrentRole string `json:"current_role"`
+}
+
+func (q *Queries) UpdateRoleUsers(ctx context.Context, arg UpdateRoleUsersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateRoleUsers, arg.Role, arg.CurrentRole)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateSession = `-- name: UpdateSession :exec
+UPDATE sessions
+SET
+    last_seen = ?1,
+    expires_at = ?2
+WHERE id = ?3
+`
+
+type UpdateSessionParams struct {
+	LastSeen  string `json:"last_seen"`
+	ExpiresAt string `json:"expires_at"`
+	ID        int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) error {
+	_, err := q.db.ExecContext(ctx, updateSession, arg.LastSeen, arg.ExpiresAt, arg.ID)
+	return err
+}
+
 const updateSettings = `-- name: UpdateSettings :one
 INSERT INTO settings (name, value)
 VALUES (?, ?)
@@ -1193,17 +3960,21 @@ SET
     pass = COALESCE(?1, pass),
     auth_hash = COALESCE(?2, auth_hash),
     timezone = COALESCE(?3, timezone),
-    admin = COALESCE(?4, admin)
-WHERE id = ?5
-RETURNING id, pass, auth_hash, admin, timezone, created_at
+    progress_strategy = COALESCE(?4, progress_strategy),
+    admin = COALESCE(?5, admin),
+    role = COALESCE(?6, role)
+WHERE id = ?7
+RETURNING id, pass, auth_hash, admin, role, timezone, progress_strategy, created_at
 `
 
 type UpdateUserParams struct {
-	Password *string `json:"-"`
-	AuthHash *string `json:"auth_hash"`
-	Timezone *string `json:"timezone"`
-	Admin    bool    `json:"-"`
-	UserID   string  `json:"user_id"`
+	Password         *string `json:"-"`
+	AuthHash         *string `json:"auth_hash"`
+	Timezone         *string `json:"timezone"`
+	ProgressStrategy *string `json:"progress_strategy"`
+	Admin            bool    `json:"-"`
+	Role             *string `json:"role"`
+	UserID           string  `json:"user_id"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -1211,7 +3982,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.Password,
 		arg.AuthHash,
 		arg.Timezone,
+		arg.ProgressStrategy,
 		arg.Admin,
+		arg.Role,
 		arg.UserID,
 	)
 	var i User
@@ -1220,18 +3993,72 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Pass,
 		&i.AuthHash,
 		&i.Admin,
+		&i.Role,
 		&i.Timezone,
+		&i.ProgressStrategy,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const updateWebhook = `-- name: UpdateWebhook :execrows
+UPDATE webhooks
+SET enabled = ?1
+WHERE id = ?2 AND user_id IS ?3
+`
+
+type UpdateWebhookParams struct {
+	Enabled bool    `json:"enabled"`
+	ID      int64   `json:"id"`
+	UserID  *string `json:"user_id"`
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWebhook, arg.Enabled, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+    status = ?1,
+    attempts = ?2,
+    response_code = ?3,
+    error = ?4,
+    next_attempt_at = ?5,
+    updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+WHERE id = ?6
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status        string  `json:"status"`
+	Attempts      int64   `json:"attempts"`
+	ResponseCode  *int64  `json:"response_code"`
+	Error         *string `json:"error"`
+	NextAttemptAt string  `json:"next_attempt_at"`
+	ID            int64   `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.ResponseCode,
+		arg.Error,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
+
 const upsertDevice = `-- name: UpsertDevice :one
 INSERT INTO devices (id, user_id, last_synced, device_name)
 VALUES (?, ?, ?, ?)
 ON CONFLICT DO UPDATE
 SET
-    device_name = COALESCE(excluded.device_name, device_name),
     last_synced = COALESCE(excluded.last_synced, last_synced)
 RETURNING id, user_id, device_name, last_synced, created_at, sync
 `
@@ -1276,12 +4103,15 @@ INSERT INTO documents (
     lang,
     description,
     words,
+    pages,
     olid,
     gbid,
     isbn10,
-    isbn13
+    isbn13,
+    owner_id,
+    visibility
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, IIF(?18 IS NULL, 'public', 'private'))
 ON CONFLICT DO UPDATE
 SET
     md5 =           COALESCE(excluded.md5, md5),
@@ -1295,11 +4125,13 @@ SET
     lang =          COALESCE(excluded.lang, lang),
     description =   COALESCE(excluded.description, description),
     words =         COALESCE(excluded.words, words),
+    pages =         COALESCE(excluded.pages, pages),
     olid =          COALESCE(excluded.olid, olid),
     gbid =          COALESCE(excluded.gbid, gbid),
     isbn10 =        COALESCE(excluded.isbn10, isbn10),
-    isbn13 =        COALESCE(excluded.isbn13, isbn13)
-RETURNING id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, gbid, olid, isbn10, isbn13, synced, deleted, updated_at, created_at
+    isbn13 =        COALESCE(excluded.isbn13, isbn13),
+    owner_id =      COALESCE(owner_id, excluded.owner_id)
+RETURNING id, md5, basepath, filepath, coverfile, title, author, series, series_index, lang, description, words, pages, gbid, olid, isbn10, isbn13, owner_id, visibility, synced, deleted, updated_at, created_at
 `
 
 type UpsertDocumentParams struct {
@@ -1315,10 +4147,12 @@ type UpsertDocumentParams struct {
 	Lang        *string `json:"lang"`
 	Description *string `json:"description"`
 	Words       *int64  `json:"words"`
+	Pages       *int64  `json:"pages"`
 	Olid        *string `json:"-"`
 	Gbid        *string `json:"gbid"`
 	Isbn10      *string `json:"isbn10"`
 	Isbn13      *string `json:"isbn13"`
+	OwnerID     *string `json:"owner_id"`
 }
 
 func (q *Queries) UpsertDocument(ctx context.Context, arg UpsertDocumentParams) (Document, error) {
@@ -1335,10 +4169,12 @@ func (q *Queries) UpsertDocument(ctx context.Context, arg UpsertDocumentParams)
 		arg.Lang,
 		arg.Description,
 		arg.Words,
+		arg.Pages,
 		arg.Olid,
 		arg.Gbid,
 		arg.Isbn10,
 		arg.Isbn13,
+		arg.OwnerID,
 	)
 	var i Document
 	err := row.Scan(
@@ -1354,10 +4190,13 @@ func (q *Queries) UpsertDocument(ctx context.Context, arg UpsertDocumentParams)
 		&i.Lang,
 		&i.Description,
 		&i.Words,
+		&i.Pages,
 		&i.Gbid,
 		&i.Olid,
 		&i.Isbn10,
 		&i.Isbn13,
+		&i.OwnerID,
+		&i.Visibility,
 		&i.Synced,
 		&i.Deleted,
 		&i.UpdatedAt,
@@ -1365,3 +4204,104 @@ func (q *Queries) UpsertDocument(ctx context.Context, arg UpsertDocumentParams)
 	)
 	return i, err
 }
+
+const upsertDocumentShare = `-- name: UpsertDocumentShare :execrows
+INSERT INTO document_shares (document_id, share_type, share_with)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type UpsertDocumentShareParams struct {
+	DocumentID string `json:"document_id"`
+	ShareType  string `json:"share_type"`
+	ShareWith  string `json:"share_with"`
+}
+
+func (q *Queries) UpsertDocumentShare(ctx context.Context, arg UpsertDocumentShareParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertDocumentShare, arg.DocumentID, arg.ShareType, arg.ShareWith)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertRole = `-- name: UpsertRole :one
+INSERT INTO roles (
+    name,
+    can_upload,
+    can_delete,
+    can_edit,
+    can_search,
+    can_download,
+    can_sync
+)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO UPDATE
+SET
+    can_upload = excluded.can_upload,
+    can_delete = excluded.can_delete,
+    can_edit = excluded.can_edit,
+    can_search = excluded.can_search,
+    can_download = excluded.can_download,
+    can_sync = excluded.can_sync
+RETURNING name, can_upload, can_delete, can_edit, can_search, can_download, can_sync, created_at
+`
+
+type UpsertRoleParams struct {
+	Name        string `json:"name"`
+	CanUpload   bool   `json:"can_upload"`
+	CanDelete   bool   `json:"can_delete"`
+	CanEdit     bool   `json:"can_edit"`
+	CanSearch   bool   `json:"can_search"`
+	CanDownload bool   `json:"can_download"`
+	CanSync     bool   `json:"can_sync"`
+}
+
+func (q *Queries) UpsertRole(ctx context.Context, arg UpsertRoleParams) (Role, error) {
+	row := q.db.QueryRowContext(ctx, upsertRole,
+		arg.Name,
+		arg.CanUpload,
+		arg.CanDelete,
+		arg.CanEdit,
+		arg.CanSearch,
+		arg.CanDownload,
+		arg.CanSync,
+	)
+	var i Role
+	err := row.Scan(
+		&i.Name,
+		&i.CanUpload,
+		&i.CanDelete,
+		&i.CanEdit,
+		&i.CanSearch,
+		&i.CanDownload,
+		&i.CanSync,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useInvite = `-- name: UseInvite :one
+UPDATE invites
+SET uses = uses + 1
+WHERE
+    code = ?1
+    AND uses < max_uses
+    AND expires_at > STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
+RETURNING code, role, max_uses, uses, expires_at, created_by, created_at
+`
+
+func (q *Queries) UseInvite(ctx context.Context, code string) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, useInvite, code)
+	var i Invite
+	err := row.Scan(
+		&i.Code,
+		&i.Role,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
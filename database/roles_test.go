@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"reichard.io/antholume/config"
+)
+
+type RolesTestSuite struct {
+	suite.Suite
+	dbm *DBManager
+}
+
+func TestRoles(t *testing.T) {
+	suite.Run(t, new(RolesTestSuite))
+}
+
+func (suite *RolesTestSuite) SetupTest() {
+	cfg := config.Config{
+		DBType: "memory",
+	}
+
+	suite.dbm = NewMgr(&cfg)
+
+	// Create User
+	_, err := suite.dbm.Queries.CreateUser(context.Background(), CreateUserParams{
+		ID:       testUserID,
+		Pass:     &testUserPass,
+		AuthHash: &testUserPass,
+		Role:     "user",
+	})
+	suite.NoError(err)
+}
+
+func (suite *RolesTestSuite) TestBuiltInRoles() {
+	roles, err := suite.dbm.Queries.GetRoles(context.Background())
+	suite.Nil(err, "should have nil err")
+	suite.Len(roles, 2, "should have built-in roles")
+
+	guest, err := suite.dbm.Queries.GetRole(context.Background(), "guest")
+	suite.Nil(err, "should have nil err")
+	suite.False(guest.CanUpload, "guest should not upload")
+	suite.True(guest.CanDownload, "guest should download")
+}
+
+func (suite *RolesTestSuite) TestUpsertRole() {
+	role, err := suite.dbm.Queries.UpsertRole(context.Background(), UpsertRoleParams{
+		Name:    "editor",
+		CanEdit: true,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.True(role.CanEdit, "should have edit permission")
+
+	role, err = suite.dbm.Queries.UpsertRole(context.Background(), UpsertRoleParams{
+		Name:      "editor",
+		CanUpload: true,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.False(role.CanEdit, "should have replaced edit permission")
+	suite.True(role.CanUpload, "should have upload permission")
+}
+
+func (suite *RolesTestSuite) TestDeleteRole() {
+	_, err := suite.dbm.Queries.UpsertRole(context.Background(), UpsertRoleParams{Name: "editor"})
+	suite.Nil(err, "should have nil err")
+
+	role := "editor"
+	_, err = suite.dbm.Queries.UpdateUser(context.Background(), UpdateUserParams{
+		UserID: testUserID,
+		Role:   &role,
+	})
+	suite.Nil(err, "should have nil err")
+
+	// Reassign Users
+	changed, err := suite.dbm.Queries.UpdateRoleUsers(context.Background(), UpdateRoleUsersParams{
+		Role:        "guest",
+		CurrentRole: "editor",
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(1), changed, "should have one changed row")
+
+	changed, err = suite.dbm.Queries.DeleteRole(context.Background(), "editor")
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(1), changed, "should have one changed row")
+
+	_, err = suite.dbm.Queries.GetRole(context.Background(), "editor")
+	suite.ErrorIs(err, sql.ErrNoRows, "should have no rows error")
+
+	user, err := suite.dbm.Queries.GetUser(context.Background(), testUserID)
+	suite.Nil(err, "should have nil err")
+	suite.Equal("guest", user.Role, "should have guest role")
+}
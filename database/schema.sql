@@ -9,11 +9,33 @@ CREATE TABLE IF NOT EXISTS users (
     pass TEXT NOT NULL,
     auth_hash TEXT NOT NULL,
     admin BOOLEAN NOT NULL DEFAULT 0 CHECK (admin IN (0, 1)),
+    role TEXT NOT NULL DEFAULT 'user',
     timezone TEXT NOT NULL DEFAULT 'Europe/London',
+    progress_strategy TEXT NOT NULL DEFAULT 'newest' CHECK (progress_strategy IN ('newest', 'furthest')),
 
     created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
+-- Roles & Permissions (Admins Bypass)
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT NOT NULL PRIMARY KEY,
+
+    can_upload BOOLEAN NOT NULL DEFAULT 0 CHECK (can_upload IN (0, 1)),
+    can_delete BOOLEAN NOT NULL DEFAULT 0 CHECK (can_delete IN (0, 1)),
+    can_edit BOOLEAN NOT NULL DEFAULT 0 CHECK (can_edit IN (0, 1)),
+    can_search BOOLEAN NOT NULL DEFAULT 0 CHECK (can_search IN (0, 1)),
+    can_download BOOLEAN NOT NULL DEFAULT 0 CHECK (can_download IN (0, 1)),
+    can_sync BOOLEAN NOT NULL DEFAULT 0 CHECK (can_sync IN (0, 1)),
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+-- Built-In Roles
+INSERT OR IGNORE INTO roles (name, can_upload, can_delete, can_edit, can_search, can_download, can_sync)
+VALUES
+    ('user', 1, 1, 1, 1, 1, 1),
+    ('guest', 0, 0, 0, 0, 1, 1);
+
 -- Books / Documents
 CREATE TABLE IF NOT EXISTS documents (
     id TEXT NOT NULL PRIMARY KEY,
@@ -29,12 +51,16 @@ CREATE TABLE IF NOT EXISTS documents (
     lang TEXT,
     description TEXT,
     words INTEGER,
+    pages INTEGER,
 
     gbid TEXT,
     olid TEXT,
     isbn10 TEXT,
     isbn13 TEXT,
 
+    owner_id TEXT,
+    visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('private', 'shared', 'public')),
+
     synced BOOLEAN NOT NULL DEFAULT 0 CHECK (synced IN (0, 1)),
     deleted BOOLEAN NOT NULL DEFAULT 0 CHECK (deleted IN (0, 1)),
 
@@ -42,6 +68,18 @@ CREATE TABLE IF NOT EXISTS documents (
     created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
+-- Document Shares (Shared Visibility)
+CREATE TABLE IF NOT EXISTS document_shares (
+    document_id TEXT NOT NULL,
+    share_type TEXT NOT NULL CHECK (share_type IN ('user', 'role')),
+    share_with TEXT NOT NULL,
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    PRIMARY KEY (document_id, share_type, share_with),
+    FOREIGN KEY (document_id) REFERENCES documents (id)
+);
+
 -- Metadata
 CREATE TABLE IF NOT EXISTS metadata (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -89,6 +127,43 @@ CREATE TABLE IF NOT EXISTS document_progress (
     PRIMARY KEY (user_id, document_id, device_id)
 );
 
+-- User Document Progress History (append-only, populated by trigger)
+CREATE TABLE IF NOT EXISTS document_progress_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    document_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+
+    percentage REAL NOT NULL,
+    progress TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (user_id) REFERENCES users (id),
+    FOREIGN KEY (document_id) REFERENCES documents (id),
+    FOREIGN KEY (device_id) REFERENCES devices (id)
+);
+
+-- Document Changelog (one row per document, populated by trigger)
+CREATE TABLE IF NOT EXISTS document_changes (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    document_id TEXT NOT NULL UNIQUE,
+    change_type TEXT NOT NULL CHECK (change_type IN ('add', 'update', 'delete')),
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (document_id) REFERENCES documents (id)
+);
+
+-- Document Access History (users that could see a document prior to an access
+-- change, populated by trigger)
+CREATE TABLE IF NOT EXISTS document_access_history (
+    user_id TEXT NOT NULL,
+    document_id TEXT NOT NULL,
+
+    PRIMARY KEY (user_id, document_id),
+    FOREIGN KEY (user_id) REFERENCES users (id),
+    FOREIGN KEY (document_id) REFERENCES documents (id)
+);
+
 -- Read Activity
 CREATE TABLE IF NOT EXISTS activity (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -118,6 +193,161 @@ CREATE TABLE IF NOT EXISTS settings (
     created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
+-- User Sessions (only the SHA256 token hash is stored)
+CREATE TABLE IF NOT EXISTS sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+
+    token_hash TEXT NOT NULL UNIQUE,
+    auth_hash TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+
+    expires_at DATETIME NOT NULL,
+    last_seen DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- User API Tokens (only the SHA256 hash is stored)
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+
+    last_used DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- Webhooks (NULL user receives the events of all users)
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT,
+
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1 CHECK (enabled IN (0, 1)),
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- Webhook Delivery Log
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'success', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER,
+    error TEXT,
+
+    next_attempt_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id)
+);
+
+-- Import Log (Watch Directories)
+CREATE TABLE IF NOT EXISTS import_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    directory TEXT NOT NULL,
+    path TEXT NOT NULL,
+    document_id TEXT,
+    status TEXT NOT NULL CHECK (status IN ('SUCCESS', 'EXISTS', 'FAILED')),
+    error TEXT,
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+-- Import Jobs (Status In Background Job)
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER,
+
+    directory TEXT NOT NULL,
+    import_type TEXT NOT NULL CHECK (import_type IN ('DIRECT', 'COPY')),
+    total_files INTEGER,
+    created_by TEXT,
+
+    updated_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    FOREIGN KEY (job_id) REFERENCES jobs (id)
+);
+
+-- Import Job Results
+CREATE TABLE IF NOT EXISTS import_job_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+
+    path TEXT NOT NULL,
+    document_id TEXT,
+    name TEXT,
+    status TEXT NOT NULL CHECK (status IN ('SUCCESS', 'EXISTS', 'FAILED')),
+    error TEXT,
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    UNIQUE (job_id, path),
+    FOREIGN KEY (job_id) REFERENCES import_jobs (id)
+);
+
+-- Background Jobs
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    error TEXT,
+    created_by TEXT,
+
+    run_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    started_at DATETIME,
+    finished_at DATETIME,
+    updated_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+-- Registration Invites
+CREATE TABLE IF NOT EXISTS invites (
+    code TEXT NOT NULL PRIMARY KEY,
+    role TEXT NOT NULL DEFAULT 'user',
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_by TEXT NOT NULL,
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    CHECK (max_uses > 0)
+);
+
+-- Registration Invite Audit (retained after invite deletion)
+CREATE TABLE IF NOT EXISTS invite_uses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invite_code TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+
+    created_at DATETIME NOT NULL DEFAULT (STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
 -- Document User Statistics Table
 CREATE TABLE IF NOT EXISTS document_user_statistics (
     document_id TEXT NOT NULL,
@@ -167,6 +397,35 @@ CREATE TABLE IF NOT EXISTS user_streaks (
     UNIQUE(user_id, window) ON CONFLICT REPLACE
 );
 
+---------------------------------------------------------------
+---------------------------- Views ----------------------------
+---------------------------------------------------------------
+
+-- Document Visibility - Admins see all documents, otherwise public, owned and
+-- shared (with the user or their role) documents are visible
+CREATE VIEW IF NOT EXISTS user_visible_documents AS
+SELECT
+    users.id AS user_id,
+    documents.id AS document_id
+FROM users
+JOIN documents ON (
+    users.admin = 1
+    OR documents.visibility = 'public'
+    OR documents.owner_id = users.id
+    OR (
+        documents.visibility = 'shared'
+        AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND (
+                    (shares.share_type = 'user' AND shares.share_with = users.id)
+                    OR (shares.share_type = 'role' AND shares.share_with = users.role)
+                )
+        )
+    )
+);
+
 ---------------------------------------------------------------
 --------------------------- Indexes ---------------------------
 ---------------------------------------------------------------
@@ -178,6 +437,31 @@ CREATE INDEX IF NOT EXISTS activity_user_id_document_id ON activity (
     user_id,
     document_id
 );
+-- Made unique by the activity_unique migration once duplicates are merged
+CREATE INDEX IF NOT EXISTS activity_user_id_device_id_document_id_start_time ON activity (
+    user_id,
+    device_id,
+    document_id,
+    start_time
+);
+CREATE INDEX IF NOT EXISTS sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS api_tokens_user_id ON api_tokens (user_id);
+CREATE INDEX IF NOT EXISTS webhooks_user_id ON webhooks (user_id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_next_attempt_at ON webhook_deliveries (
+    status,
+    next_attempt_at
+);
+CREATE INDEX IF NOT EXISTS jobs_type_status_run_at ON jobs (
+    type,
+    status,
+    run_at
+);
+CREATE INDEX IF NOT EXISTS import_jobs_job_id ON import_jobs (job_id);
+CREATE INDEX IF NOT EXISTS document_progress_history_user_id_document_id ON document_progress_history (
+    user_id,
+    document_id
+);
 
 ---------------------------------------------------------------
 --------------------------- Triggers --------------------------
@@ -191,10 +475,181 @@ SET updated_at = STRFTIME('%Y-%m-%dT%H:%M:%SZ', 'now')
 WHERE id = old.id;
 END;
 
+-- Progress History
+CREATE TRIGGER IF NOT EXISTS document_progress_history_insert
+AFTER INSERT ON document_progress BEGIN
+INSERT INTO document_progress_history (user_id, document_id, device_id, percentage, progress, created_at)
+VALUES (NEW.user_id, NEW.document_id, NEW.device_id, NEW.percentage, NEW.progress, NEW.created_at);
+END;
+
+-- Document Changelog - Reinserting resequences the documents change (OR
+-- REPLACE is overridden by the conflict clause of upserts)
+CREATE TRIGGER IF NOT EXISTS document_changes_insert
+AFTER INSERT ON documents BEGIN
+DELETE FROM document_changes WHERE document_id = NEW.id;
+INSERT INTO document_changes (document_id, change_type)
+VALUES (NEW.id, IIF(NEW.deleted, 'delete', 'add'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS document_changes_update
+AFTER UPDATE ON documents
+WHEN
+    OLD.md5 IS NOT NEW.md5
+    OR OLD.filepath IS NOT NEW.filepath
+    OR OLD.title IS NOT NEW.title
+    OR OLD.author IS NOT NEW.author
+    OR OLD.series IS NOT NEW.series
+    OR OLD.series_index IS NOT NEW.series_index
+    OR OLD.lang IS NOT NEW.lang
+    OR OLD.description IS NOT NEW.description
+    OR OLD.words IS NOT NEW.words
+    OR OLD.isbn10 IS NOT NEW.isbn10
+    OR OLD.isbn13 IS NOT NEW.isbn13
+    OR OLD.owner_id IS NOT NEW.owner_id
+    OR OLD.visibility IS NOT NEW.visibility
+    OR OLD.deleted IS NOT NEW.deleted
+BEGIN
+DELETE FROM document_changes WHERE document_id = NEW.id;
+INSERT INTO document_changes (document_id, change_type)
+VALUES (NEW.id, IIF(NEW.deleted, 'delete', 'update'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS document_changes_share_insert
+AFTER INSERT ON document_shares BEGIN
+DELETE FROM document_changes WHERE document_id = NEW.document_id;
+INSERT INTO document_changes (document_id, change_type)
+SELECT id, IIF(deleted, 'delete', 'update') FROM documents WHERE id = NEW.document_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS document_changes_share_delete
+AFTER DELETE ON document_shares BEGIN
+DELETE FROM document_changes WHERE document_id = OLD.document_id;
+INSERT INTO document_changes (document_id, change_type)
+SELECT id, IIF(deleted, 'delete', 'update') FROM documents WHERE id = OLD.document_id;
+END;
+
+-- Only resequences the documents whose visibility changed for the user
+CREATE TRIGGER IF NOT EXISTS document_changes_user_access
+AFTER UPDATE OF admin, role ON users
+WHEN OLD.admin IS NOT NEW.admin OR OLD.role IS NOT NEW.role
+BEGIN
+DELETE FROM document_changes WHERE document_id IN (
+    SELECT id FROM documents
+    WHERE
+        visibility != 'public'
+        AND owner_id IS NOT NEW.id
+        AND NOT (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'user'
+                AND shares.share_with = NEW.id
+        ))
+        AND (OLD.admin = 1 OR (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'role'
+                AND shares.share_with = OLD.role
+        ))) != (NEW.admin = 1 OR (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'role'
+                AND shares.share_with = NEW.role
+        )))
+);
+INSERT INTO document_changes (document_id, change_type)
+SELECT id, IIF(deleted, 'delete', 'update') FROM documents WHERE id IN (
+    SELECT id FROM documents
+    WHERE
+        visibility != 'public'
+        AND owner_id IS NOT NEW.id
+        AND NOT (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'user'
+                AND shares.share_with = NEW.id
+        ))
+        AND (OLD.admin = 1 OR (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'role'
+                AND shares.share_with = OLD.role
+        ))) != (NEW.admin = 1 OR (visibility = 'shared' AND EXISTS (
+            SELECT 1 FROM document_shares AS shares
+            WHERE
+                shares.document_id = documents.id
+                AND shares.share_type = 'role'
+                AND shares.share_with = NEW.role
+        )))
+);
+END;
+
+-- Document Access History - Record the users that could see the document
+-- prior to an access change, so delta sync only deletes previously visible
+-- documents
+CREATE TRIGGER IF NOT EXISTS document_access_history_update
+BEFORE UPDATE OF owner_id, visibility ON documents
+WHEN OLD.owner_id IS NOT NEW.owner_id OR OLD.visibility IS NOT NEW.visibility
+BEGIN
+INSERT OR IGNORE INTO document_access_history (user_id, document_id)
+SELECT user_id, document_id FROM user_visible_documents WHERE document_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS document_access_history_share_delete
+BEFORE DELETE ON document_shares BEGIN
+INSERT OR IGNORE INTO document_access_history (user_id, document_id)
+SELECT user_id, document_id FROM user_visible_documents WHERE document_id = OLD.document_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS document_access_history_user_access
+BEFORE UPDATE OF admin, role ON users
+WHEN OLD.admin IS NOT NEW.admin OR OLD.role IS NOT NEW.role
+BEGIN
+INSERT OR IGNORE INTO document_access_history (user_id, document_id)
+SELECT visible.user_id, visible.document_id
+FROM user_visible_documents AS visible
+JOIN documents ON documents.id = visible.document_id
+WHERE visible.user_id = OLD.id AND documents.visibility != 'public';
+END;
+
+-- Delete Device
+CREATE TRIGGER IF NOT EXISTS device_deleted
+BEFORE DELETE ON devices BEGIN
+DELETE FROM activity WHERE activity.device_id=OLD.id;
+DELETE FROM document_progress WHERE document_progress.device_id=OLD.id;
+DELETE FROM document_progress_history WHERE document_progress_history.device_id=OLD.id;
+END;
+
+-- Delete Webhook
+CREATE TRIGGER IF NOT EXISTS webhook_deleted
+BEFORE DELETE ON webhooks BEGIN
+DELETE FROM webhook_deliveries WHERE webhook_deliveries.webhook_id=OLD.id;
+END;
+
+-- Delete Job
+CREATE TRIGGER IF NOT EXISTS job_deleted
+BEFORE DELETE ON jobs BEGIN
+DELETE FROM import_job_results WHERE import_job_results.job_id IN (
+    SELECT id FROM import_jobs WHERE import_jobs.job_id=OLD.id
+);
+DELETE FROM import_jobs WHERE import_jobs.job_id=OLD.id;
+END;
+
 -- Delete User
 CREATE TRIGGER IF NOT EXISTS user_deleted
 BEFORE DELETE ON users BEGIN
 DELETE FROM activity WHERE activity.user_id=OLD.id;
 DELETE FROM devices WHERE devices.user_id=OLD.id;
 DELETE FROM document_progress WHERE document_progress.user_id=OLD.id;
+DELETE FROM document_progress_history WHERE document_progress_history.user_id=OLD.id;
+DELETE FROM sessions WHERE sessions.user_id=OLD.id;
+DELETE FROM api_tokens WHERE api_tokens.user_id=OLD.id;
+DELETE FROM webhooks WHERE webhooks.user_id=OLD.id;
+DELETE FROM document_shares WHERE document_shares.share_type='user' AND document_shares.share_with=OLD.id;
+UPDATE documents SET owner_id=NULL WHERE documents.owner_id=OLD.id;
+DELETE FROM document_access_history WHERE document_access_history.user_id=OLD.id;
 END;
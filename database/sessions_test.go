@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/utils"
+)
+
+type SessionsTestSuite struct {
+	suite.Suite
+	dbm      *DBManager
+	authHash string
+}
+
+func TestSessions(t *testing.T) {
+	suite.Run(t, new(SessionsTestSuite))
+}
+
+func (suite *SessionsTestSuite) SetupTest() {
+	cfg := config.Config{
+		DBType: "memory",
+	}
+
+	suite.dbm = NewMgr(&cfg)
+
+	// Create User
+	rawAuthHash, _ := utils.GenerateToken(64)
+	suite.authHash = fmt.Sprintf("%x", rawAuthHash)
+	_, err := suite.dbm.Queries.CreateUser(context.Background(), CreateUserParams{
+		ID:       testUserID,
+		Pass:     &testUserPass,
+		AuthHash: &suite.authHash,
+	})
+	suite.NoError(err)
+}
+
+func (suite *SessionsTestSuite) createSession(token string, expiresAt time.Time) Session {
+	session, err := suite.dbm.Queries.CreateSession(context.Background(), CreateSessionParams{
+		UserID:    testUserID,
+		TokenHash: token,
+		AuthHash:  suite.authHash,
+		UserAgent: "testAgent",
+		Ip:        "127.0.0.1",
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	})
+	suite.Nil(err, "should have nil err")
+	return session
+}
+
+func (suite *SessionsTestSuite) TestGetSession() {
+	created := suite.createSession("token1", time.Now().Add(time.Hour))
+
+	session, err := suite.dbm.Queries.GetSession(context.Background(), "token1")
+	suite.Nil(err, "should have nil err")
+	suite.Equal(created.ID, session.ID, "should have same session")
+	suite.Equal(testUserID, session.UserID, "should have correct user")
+	suite.Equal(suite.authHash, *session.UserAuthHash, "should have user auth hash")
+}
+
+func (suite *SessionsTestSuite) TestGetExpiredSession() {
+	suite.createSession("token1", time.Now().Add(-time.Hour))
+
+	_, err := suite.dbm.Queries.GetSession(context.Background(), "token1")
+	suite.ErrorIs(err, sql.ErrNoRows, "should have no rows error")
+
+	changed, err := suite.dbm.Queries.DeleteExpiredSessions(context.Background())
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(1), changed, "should have one changed row")
+}
+
+func (suite *SessionsTestSuite) TestGetSessions() {
+	suite.createSession("token1", time.Now().Add(time.Hour))
+	suite.createSession("token2", time.Now().Add(time.Hour))
+	suite.createSession("token3", time.Now().Add(-time.Hour))
+
+	sessions, err := suite.dbm.Queries.GetSessions(context.Background(), testUserID)
+	suite.Nil(err, "should have nil err")
+	suite.Len(sessions, 2, "should only have active sessions")
+}
+
+func (suite *SessionsTestSuite) TestDeleteSession() {
+	created := suite.createSession("token1", time.Now().Add(time.Hour))
+
+	// Different User
+	changed, err := suite.dbm.Queries.DeleteSession(context.Background(), DeleteSessionParams{
+		ID:     created.ID,
+		UserID: "otherUser",
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(0), changed, "should not delete other users session")
+
+	// Same User
+	changed, err = suite.dbm.Queries.DeleteSession(context.Background(), DeleteSessionParams{
+		ID:     created.ID,
+		UserID: testUserID,
+	})
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(1), changed, "should have one changed row")
+}
+
+func (suite *SessionsTestSuite) TestDeleteUserSessions() {
+	suite.createSession("token1", time.Now().Add(time.Hour))
+	suite.createSession("token2", time.Now().Add(time.Hour))
+
+	changed, err := suite.dbm.Queries.DeleteUserSessions(context.Background(), testUserID)
+	suite.Nil(err, "should have nil err")
+	suite.Equal(int64(2), changed, "should have two changed rows")
+}
+
+func (suite *SessionsTestSuite) TestDeleteUserCascade() {
+	suite.createSession("token1", time.Now().Add(time.Hour))
+
+	_, err := suite.dbm.Queries.DeleteUser(context.Background(), testUserID)
+	suite.Nil(err, "should have nil err")
+
+	_, err = suite.dbm.Queries.GetSession(context.Background(), "token1")
+	suite.ErrorIs(err, sql.ErrNoRows, "should have no rows error")
+}
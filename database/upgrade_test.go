@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"reichard.io/antholume/config"
+)
+
+// baselineSQL is a dump of a database created by the last release, prior to
+// the sessions, roles, sharing & sync changes.
+//
+//go:embed testdata/baseline.sql
+var baselineSQL string
+
+func TestBaselineUpgrade(t *testing.T) {
+	cfg := config.Config{DBType: "sqlite", DBName: "antholume", ConfigPath: t.TempDir()}
+
+	// Seed Baseline DB
+	db, err := OpenDB(&cfg)
+	require.NoError(t, err)
+	_, err = db.Exec(baselineSQL)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO users (id, pass, auth_hash) VALUES ('reader', 'pass', 'hash');
+		INSERT INTO devices (id, user_id, device_name) VALUES ('kobo-id', 'reader', 'kobo');
+		INSERT INTO documents (id, title) VALUES ('document-00', 'Title 00');
+		INSERT INTO document_progress (user_id, document_id, device_id, percentage, progress)
+		VALUES ('reader', 'document-00', 'kobo-id', 0.5, 'progress');
+		INSERT INTO activity (user_id, document_id, device_id, start_time, start_percentage, end_percentage, duration)
+		VALUES
+		    ('reader', 'document-00', 'kobo-id', '2024-01-01T00:00:00Z', 0.1, 0.2, 30),
+		    ('reader', 'document-00', 'kobo-id', '2024-01-01T00:00:00Z', 0.1, 0.3, 45);
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Upgrade
+	dbm := NewMgr(&cfg)
+	t.Cleanup(func() { dbm.DB.Close() })
+	ctx := context.Background()
+
+	current, expected, err := dbm.MigrationVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, current, "should be fully migrated")
+
+	// Duplicate Activity Merged
+	var count, duration int
+	var endPercentage float64
+	require.NoError(t, dbm.DB.QueryRow("SELECT COUNT(*), MAX(duration), MAX(end_percentage) FROM activity").Scan(&count, &duration, &endPercentage))
+	assert.Equal(t, 1, count, "should merge duplicate activity")
+	assert.Equal(t, 45, duration, "should keep longest duration")
+	assert.Equal(t, 0.3, endPercentage, "should keep widest range")
+
+	// Existing Data Usable
+	progress, err := dbm.Queries.GetDocumentProgress(ctx, GetDocumentProgressParams{UserID: "reader", DocumentID: "document-00"})
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, progress.Percentage)
+
+	session, err := dbm.Queries.CreateSession(ctx, CreateSessionParams{
+		UserID:    "reader",
+		TokenHash: "token-hash",
+		AuthHash:  "hash",
+		ExpiresAt: "2100-01-01T00:00:00Z",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "token-hash", session.TokenHash)
+}
+
+func TestActivityUniqueIndex(t *testing.T) {
+	for _, dbType := range []string{"new", "baseline"} {
+		t.Run(dbType, func(t *testing.T) {
+			cfg := config.Config{DBType: "sqlite", DBName: "antholume", ConfigPath: t.TempDir()}
+			if dbType == "baseline" {
+				db, err := OpenDB(&cfg)
+				require.NoError(t, err)
+				_, err = db.Exec(baselineSQL)
+				require.NoError(t, err)
+				require.NoError(t, db.Close())
+			}
+
+			dbm := NewMgr(&cfg)
+			t.Cleanup(func() { dbm.DB.Close() })
+
+			var unique bool
+			require.NoError(t, dbm.DB.QueryRow(`
+				SELECT "unique" FROM pragma_index_list('activity')
+				WHERE name = 'activity_user_id_device_id_document_id_start_time'
+			`).Scan(&unique))
+			assert.True(t, unique, "should enforce unique activity")
+		})
+	}
+}
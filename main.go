@@ -2,10 +2,12 @@ package main
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
 	"os"
 	"os/signal"
 	"syscall"
+	"text/tabwriter"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -21,14 +23,25 @@ func main() {
 		Name:                 "AnthoLume",
 		Usage:                "A self hosted e-book progress tracker.",
 		EnableBashCompletion: true,
-		Commands: []*cli.Command{
+		Commands: append([]*cli.Command{
 			{
 				Name:    "serve",
 				Aliases: []string{"s"},
 				Usage:   "Start AnthoLume web server.",
 				Action:  cmdServer,
 			},
-		},
+			{
+				Name:  "config",
+				Usage: "Manage AnthoLume configuration.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "check",
+						Usage:  "Validate the configuration and print the effective values (secrets redacted).",
+						Action: cmdConfigCheck,
+					},
+				},
+			},
+		}, adminCommands...),
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -40,7 +53,10 @@ func cmdServer(ctx *cli.Context) error {
 	var assets fs.FS = embeddedAssets
 
 	// Load config
-	c := config.Load()
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
 	if c.Version == "develop" {
 		assets = os.DirFS("./")
 	}
@@ -64,3 +80,25 @@ func cmdServer(ctx *cli.Context) error {
 
 	return nil
 }
+
+func cmdConfigCheck(ctx *cli.Context) error {
+	// Parse config
+	c, err := config.Parse()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid configuration:\n%v", err), 1)
+	}
+
+	// Print effective config
+	configFile := c.ConfigFile
+	if configFile == "" {
+		configFile = "<NONE>"
+	}
+	fmt.Fprintf(ctx.App.Writer, "Config File: %s\n\n", configFile)
+
+	w := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range c.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,220 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var cbzPageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+}
+
+// comicInfo is the subset of the ComicRack ComicInfo.xml we use
+type comicInfo struct {
+	Title   string `xml:"Title"`
+	Series  string `xml:"Series"`
+	Number  string `xml:"Number"`
+	Writer  string `xml:"Writer"`
+	Summary string `xml:"Summary"`
+}
+
+func init() {
+	// CBZ archives are plain ZIP files, so we extend the ZIP detection by
+	// inspecting the first local file headers for page images.
+	mimetype.Lookup("application/zip").Extend(isCBZ, "application/vnd.comicbook+zip", string(TYPE_CBZ))
+}
+
+// isCBZ detects whether the raw ZIP header starts with page images, skipping
+// directories and ComicInfo.xml.
+func isCBZ(raw []byte, _ uint32) bool {
+	for len(raw) >= 30 && string(raw[:4]) == "PK\x03\x04" {
+		flags := binary.LittleEndian.Uint16(raw[6:8])
+		compressedSize := int(binary.LittleEndian.Uint32(raw[18:22]))
+		nameLength := int(binary.LittleEndian.Uint16(raw[26:28]))
+		extraLength := int(binary.LittleEndian.Uint16(raw[28:30]))
+		if len(raw) < 30+nameLength {
+			return false
+		}
+
+		name := string(raw[30 : 30+nameLength])
+		if _, ok := cbzPageTypes[strings.ToLower(path.Ext(name))]; ok {
+			return true
+		} else if !strings.HasSuffix(name, "/") && !strings.EqualFold(path.Base(name), "ComicInfo.xml") {
+			return false
+		}
+
+		next := 30 + nameLength + extraLength + compressedSize
+		if next > len(raw) {
+			return false
+		}
+
+		// Unknown Size (Data Descriptor) - Seek Next Header
+		if flags&0x08 != 0 && compressedSize == 0 {
+			offset := bytes.Index(raw[next:], []byte("PK\x03\x04"))
+			if offset < 0 {
+				return false
+			}
+			next += offset
+		}
+		raw = raw[next:]
+	}
+	return false
+}
+
+func getCBZMetadata(filepath string) (*MetadataInfo, error) {
+	rc, err := zip.OpenReader(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	pageCount := int64(len(cbzPages(&rc.Reader)))
+	if pageCount == 0 {
+		return nil, fmt.Errorf("no pages found")
+	}
+
+	// Default Title - Filename
+	title := strings.TrimSuffix(path.Base(filepath), path.Ext(filepath))
+	parsedMetadata := &MetadataInfo{
+		Type:      TYPE_CBZ,
+		Title:     &title,
+		PageCount: &pageCount,
+	}
+
+	// Parse Optional ComicInfo
+	for _, file := range rc.File {
+		if !strings.EqualFold(path.Base(file.Name), "ComicInfo.xml") {
+			continue
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		var info comicInfo
+		if err := xml.NewDecoder(f).Decode(&info); err != nil {
+			return parsedMetadata, nil
+		}
+
+		if info.Title != "" {
+			parsedMetadata.Title = &info.Title
+		} else if info.Series != "" {
+			seriesTitle := strings.TrimSpace(fmt.Sprintf("%s %s", info.Series, info.Number))
+			parsedMetadata.Title = &seriesTitle
+		}
+		if info.Writer != "" {
+			parsedMetadata.Author = &info.Writer
+		}
+		if info.Summary != "" {
+			parsedMetadata.Description = &info.Summary
+		}
+		break
+	}
+
+	return parsedMetadata, nil
+}
+
+// Returns the number of pages of the provided image based document (CBZ).
+func GetPageCount(filepath string) (int, error) {
+	rc, err := zip.OpenReader(filepath)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return len(cbzPages(&rc.Reader)), nil
+}
+
+// Returns the raw image and its mime type of the provided zero indexed page of
+// an image based document (CBZ).
+func GetPage(filepath string, page int) ([]byte, string, error) {
+	rc, err := zip.OpenReader(filepath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+
+	pages := cbzPages(&rc.Reader)
+	if page < 0 || page >= len(pages) {
+		return nil, "", fmt.Errorf("invalid page %d", page)
+	}
+
+	f, err := pages[page].Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, cbzPageTypes[strings.ToLower(path.Ext(pages[page].Name))], nil
+}
+
+// cbzPages returns the page images of the archive in reading (natural) order.
+func cbzPages(r *zip.Reader) []*zip.File {
+	var pages []*zip.File
+	for _, file := range r.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(filepath.Base(file.Name), ".") {
+			continue
+		}
+		if _, ok := cbzPageTypes[strings.ToLower(path.Ext(file.Name))]; ok {
+			pages = append(pages, file)
+		}
+	}
+
+	slices.SortFunc(pages, func(a, b *zip.File) int {
+		return naturalCompare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return pages
+}
+
+// naturalCompare compares strings treating digit runs as numbers, so that
+// "page2" sorts before "page10".
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if unicode.IsDigit(rune(a[0])) && unicode.IsDigit(rune(b[0])) {
+			aDigits, bDigits := digitPrefix(a), digitPrefix(b)
+			aNumber, bNumber := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+			if len(aNumber) != len(bNumber) {
+				return len(aNumber) - len(bNumber)
+			} else if c := strings.Compare(aNumber, bNumber); c != 0 {
+				return c
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func digitPrefix(s string) string {
+	end := 0
+	for end < len(s) && unicode.IsDigit(rune(s[end])) {
+		end++
+	}
+	return s[:end]
+}
@@ -0,0 +1,108 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCBZ(t *testing.T, comicInfo string, pages ...string) string {
+	cbzPath := filepath.Join(t.TempDir(), "Test Comic.cbz")
+	f, err := os.Create(cbzPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	_, err = zw.Create("Test Comic/")
+	require.NoError(t, err)
+	if comicInfo != "" {
+		w, err := zw.Create("ComicInfo.xml")
+		require.NoError(t, err)
+		_, err = w.Write([]byte(comicInfo))
+		require.NoError(t, err)
+	}
+	for i, page := range pages {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, i+1, 1))))
+		w, err := zw.Create("Test Comic/" + page)
+		require.NoError(t, err)
+		_, err = w.Write(buf.Bytes())
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	return cbzPath
+}
+
+func TestCBZDocumentType(t *testing.T) {
+	cbzPath := createTestCBZ(t, "", "page1.png")
+
+	docType, err := GetDocumentType(cbzPath)
+	assert.Nil(t, err, "should have no error")
+	assert.Equal(t, TYPE_CBZ, *docType)
+
+	file, _ := os.Open(cbzPath)
+	defer file.Close()
+	docType, err = GetDocumentTypeReader(file)
+	assert.Nil(t, err, "should have no error")
+	assert.Equal(t, TYPE_CBZ, *docType)
+
+	// Plain ZIP
+	zipPath := filepath.Join(t.TempDir(), "plain.zip")
+	f, _ := os.Create(zipPath)
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("notes.txt")
+	_, _ = w.Write([]byte("notes"))
+	zw.Close()
+	f.Close()
+
+	_, err = GetDocumentType(zipPath)
+	assert.NotNil(t, err, "should not support plain zip")
+}
+
+func TestCBZMetadata(t *testing.T) {
+	// Filename Fallback
+	metadataInfo, err := GetMetadata(createTestCBZ(t, "", "page1.png"))
+	assert.Nil(t, err, "should have no error")
+	assert.Equal(t, "Test Comic", *metadataInfo.Title, "should be filename title")
+	assert.Equal(t, int64(0), *metadataInfo.WordCount, "should have no words")
+	assert.Equal(t, int64(1), *metadataInfo.PageCount, "should have one page")
+	assert.Equal(t, TYPE_CBZ, metadataInfo.Type, "should be correct type")
+
+	// ComicInfo
+	comicInfo := `<ComicInfo><Series>Comic Series</Series><Number>2</Number><Writer>Comic Writer</Writer><Summary>Comic Summary</Summary></ComicInfo>`
+	metadataInfo, err = GetMetadata(createTestCBZ(t, comicInfo, "page1.png"))
+	assert.Nil(t, err, "should have no error")
+	assert.Equal(t, "Comic Series 2", *metadataInfo.Title, "should be series title")
+	assert.Equal(t, "Comic Writer", *metadataInfo.Author, "should be writer")
+	assert.Equal(t, "Comic Summary", *metadataInfo.Description, "should be summary")
+}
+
+func TestCBZPages(t *testing.T) {
+	cbzPath := createTestCBZ(t, "", "page10.png", "page2.png", "page1.png")
+
+	pageCount, err := GetPageCount(cbzPath)
+	assert.Nil(t, err, "should have no error")
+	assert.Equal(t, 3, pageCount)
+
+	// Natural Order - Image Width Encodes Creation Index
+	for page, wantWidth := range []int{3, 2, 1} {
+		data, mimeType, err := GetPage(cbzPath, page)
+		assert.Nil(t, err, "should have no error")
+		assert.Equal(t, "image/png", mimeType)
+
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		assert.Nil(t, err, "should decode page")
+		assert.Equal(t, wantWidth, config.Width, "page %d out of order", page)
+	}
+
+	_, _, err = GetPage(cbzPath, 3)
+	assert.NotNil(t, err, "should error on invalid page")
+}
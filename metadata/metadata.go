@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
 	"reichard.io/antholume/utils"
@@ -16,10 +17,12 @@ type DocumentType string
 
 const (
 	TYPE_EPUB DocumentType = ".epub"
+	TYPE_CBZ  DocumentType = ".cbz"
 )
 
 var extensionHandlerMap = map[DocumentType]MetadataHandler{
 	TYPE_EPUB: getEPUBMetadata,
+	TYPE_CBZ:  getCBZMetadata,
 }
 
 type Source int
@@ -34,6 +37,7 @@ type MetadataInfo struct {
 	MD5        *string
 	PartialMD5 *string
 	WordCount  *int64
+	PageCount  *int64
 
 	Title       *string
 	Author      *string
@@ -86,11 +90,21 @@ func GetWordCount(filepath string) (*int64, error) {
 			return nil, err
 		}
 		return &totalWords, nil
+	} else if fileExtension == ".cbz" {
+		// Image Based - No Words
+		var totalWords int64
+		return &totalWords, nil
 	} else {
 		return nil, fmt.Errorf("invalid extension: %s", fileExtension)
 	}
 }
 
+// IsSupported returns whether the file extension has a metadata handler.
+func IsSupported(filePath string) bool {
+	_, ok := extensionHandlerMap[DocumentType(strings.ToLower(filepath.Ext(filePath)))]
+	return ok
+}
+
 // Returns embedded metadata of the provided file. An error will be returned if
 // the file is not supported.
 func GetMetadata(filepath string) (*MetadataInfo, error) {
@@ -180,6 +194,7 @@ func GetDocumentTypeReader(r io.Reader) (*DocumentType, error) {
 func ParseDocumentType(input string) (DocumentType, bool) {
 	validTypes := map[string]DocumentType{
 		string(TYPE_EPUB): TYPE_EPUB,
+		string(TYPE_CBZ):  TYPE_CBZ,
 	}
 	found, ok := validTypes[input]
 	return found, ok
@@ -0,0 +1,347 @@
+package metadata
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+var (
+	xpointerRE     = regexp.MustCompile(`^/body/DocFragment\[(\d+)\](.*)$`)
+	xpointerStepRE = regexp.MustCompile(`^(\w+)(?:\[(\d+)\])?$`)
+	cfiRE          = regexp.MustCompile(`^epubcfi\((.*)\)$`)
+	cfiStepRE      = regexp.MustCompile(`/(\d+)(?:\[([^\]]*)\])?`)
+	xpointerTextRE = regexp.MustCompile(`(/text\(\)(\[\d+\])?)?(\.\d+)?$`)
+)
+
+// positionNode is an element of a spine items (X)HTML DOM. Text nodes are
+// omitted as positions are translated at the element level.
+type positionNode struct {
+	name     string
+	id       string
+	parent   *positionNode
+	children []*positionNode
+}
+
+// epubSpine is the reading order of an EPUB along with the position of the
+// spine element in the package document (the first CFI step).
+type epubSpine struct {
+	rc       *epub.ReadCloser
+	step     int
+	itemrefs []epub.Itemref
+}
+
+// Translates a KOReader (CREngine) xpointer (e.g. "/body/DocFragment[3]/body/div/p[5]/text().0")
+// to an EPUB CFI (e.g. "epubcfi(/6/6[chapter]!/4/2/10)") using the EPUBs spine & DOM.
+// Positions are translated to the containing element.
+func GetCFIFromXPointer(filepath, xpointer string) (string, error) {
+	fragMatch := xpointerRE.FindStringSubmatch(xpointer)
+	if fragMatch == nil {
+		return "", fmt.Errorf("invalid xpointer: %s", xpointer)
+	}
+
+	spine, err := openEPUBSpine(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer spine.rc.Close()
+
+	spineIndex, _ := strconv.Atoi(fragMatch[1])
+	spineIndex--
+	if spineIndex < 0 || spineIndex >= len(spine.itemrefs) {
+		return "", fmt.Errorf("invalid xpointer fragment: %d", spineIndex+1)
+	}
+
+	root, err := parseSpineItem(spine.itemrefs[spineIndex])
+	if err != nil {
+		return "", err
+	}
+
+	// Strip Text Node & Character Offset
+	remainingPath := strings.Trim(xpointerTextRE.ReplaceAllString(fragMatch[2], ""), "/")
+	if remainingPath == "" {
+		remainingPath = "body"
+	}
+
+	element := resolveXPointer(root, strings.Split(remainingPath, "/"))
+
+	// Element Path (Excluding Root)
+	var cfiPath string
+	for node := element; node.parent != nil; node = node.parent {
+		step := fmt.Sprintf("/%d", (slices.Index(node.parent.children, node)+1)*2)
+		if node.id != "" {
+			step += fmt.Sprintf("[%s]", node.id)
+		}
+		cfiPath = step + cfiPath
+	}
+
+	return fmt.Sprintf(
+		"epubcfi(/%d/%d[%s]!%s)",
+		spine.step,
+		(spineIndex+1)*2,
+		spine.itemrefs[spineIndex].IDREF,
+		cfiPath,
+	), nil
+}
+
+// Translates an EPUB CFI to a KOReader (CREngine) xpointer using the EPUBs
+// spine & DOM. Positions are translated to the containing element, and ranges
+// to their common parent.
+func GetXPointerFromCFI(filepath, cfi string) (string, error) {
+	cfiMatch := cfiRE.FindStringSubmatch(cfi)
+	if cfiMatch == nil {
+		return "", fmt.Errorf("invalid cfi: %s", cfi)
+	}
+
+	// Range Parent, Package & Document Paths
+	cfiPath, _, _ := strings.Cut(cfiMatch[1], ",")
+	packagePath, documentPath, found := strings.Cut(cfiPath, "!")
+	if !found {
+		return "", fmt.Errorf("invalid cfi: %s", cfi)
+	}
+
+	spine, err := openEPUBSpine(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer spine.rc.Close()
+
+	// Spine Item - Prefer ID Assertion
+	packageSteps := cfiStepRE.FindAllStringSubmatch(packagePath, -1)
+	if len(packageSteps) < 2 {
+		return "", fmt.Errorf("invalid cfi spine: %s", cfi)
+	}
+	itemStep, _ := strconv.Atoi(packageSteps[len(packageSteps)-1][1])
+	spineIndex := slices.IndexFunc(spine.itemrefs, func(item epub.Itemref) bool {
+		return item.IDREF == packageSteps[len(packageSteps)-1][2]
+	})
+	if spineIndex < 0 {
+		spineIndex = itemStep/2 - 1
+	}
+	if spineIndex < 0 || spineIndex >= len(spine.itemrefs) {
+		return "", fmt.Errorf("invalid cfi spine: %s", cfi)
+	}
+
+	root, err := parseSpineItem(spine.itemrefs[spineIndex])
+	if err != nil {
+		return "", err
+	}
+
+	// Walk Element Steps - Odd Steps Reference Text
+	element := root
+	documentPath, _, _ = strings.Cut(documentPath, ":")
+	for _, step := range cfiStepRE.FindAllStringSubmatch(documentPath, -1) {
+		index, _ := strconv.Atoi(step[1])
+		if index == 0 || index%2 != 0 || index/2 > len(element.children) {
+			break
+		}
+		element = element.children[index/2-1]
+	}
+
+	// Walk Upwards - Matches The Web Reader, which (like CREngine) skips over A
+	// tags and indexes by document order.
+	var xpointerPath string
+	for element.parent != nil && element.name != "body" {
+		parent := element.parent
+		for parent.name == "a" && parent.parent != nil {
+			parent = parent.parent
+		}
+
+		index := slices.Index(findDescendants(parent, element.name), element) + 1
+		xpointerPath = fmt.Sprintf("/%s[%d]", element.name, index) + xpointerPath
+		element = parent
+	}
+
+	return fmt.Sprintf("/body/DocFragment[%d]/body%s", spineIndex+1, xpointerPath), nil
+}
+
+// resolveXPointer resolves the xpointer steps (starting at "body") relative to
+// the root. KOReader doesn't always reference inline elements (e.g. omitting an
+// "a" parent), so when a step isn't a direct child we fall back to a document
+// order lookup (same as the web reader). Otherwise the deepest resolved element
+// is returned.
+func resolveXPointer(root *positionNode, steps []string) *positionNode {
+	deepest := root
+
+	// Direct Children
+	element := root
+	for _, step := range steps {
+		stepMatch := xpointerStepRE.FindStringSubmatch(step)
+		if stepMatch == nil {
+			element = nil
+			break
+		}
+
+		index := 1
+		if stepMatch[2] != "" {
+			index, _ = strconv.Atoi(stepMatch[2])
+		}
+
+		var next *positionNode
+		for _, child := range element.children {
+			if child.name != stepMatch[1] {
+				continue
+			}
+			if index--; index == 0 {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			element = nil
+			break
+		}
+		element, deepest = next, next
+	}
+	if element != nil {
+		return element
+	}
+
+	// Document Order
+	element = &positionNode{children: []*positionNode{root}}
+	for _, step := range steps {
+		stepMatch := xpointerStepRE.FindStringSubmatch(step)
+		if stepMatch == nil {
+			return deepest
+		}
+
+		index := 1
+		if stepMatch[2] != "" {
+			index, _ = strconv.Atoi(stepMatch[2])
+		}
+
+		descendants := findDescendants(element, stepMatch[1])
+		if index < 1 || index > len(descendants) {
+			return deepest
+		}
+		element = descendants[index-1]
+	}
+
+	return element
+}
+
+// findDescendants returns the descendants with the provided name in document
+// order.
+func findDescendants(node *positionNode, name string) []*positionNode {
+	var found []*positionNode
+	for _, child := range node.children {
+		if child.name == name {
+			found = append(found, child)
+		}
+		found = append(found, findDescendants(child, name)...)
+	}
+	return found
+}
+
+// openEPUBSpine opens the EPUB and determines the position of the spine within
+// the package document.
+func openEPUBSpine(filepath string) (*epubSpine, error) {
+	rc, err := epub.OpenReader(filepath)
+	if err != nil {
+		return nil, err
+	}
+	if len(rc.Rootfiles) == 0 {
+		rc.Close()
+		return nil, epub.ErrNoRootfile
+	}
+	rf := rc.Rootfiles[0]
+
+	zr, err := zip.OpenReader(filepath)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	defer zr.Close()
+
+	f, err := zr.Open(rf.FullPath)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	defer f.Close()
+
+	// Spine Element Index
+	var depth, index int
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("spine not found: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				index++
+				if t.Name.Local == "spine" {
+					return &epubSpine{rc: rc, step: index * 2, itemrefs: rf.Itemrefs}, nil
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// parseSpineItem parses the XHTML spine item into an element tree, returning
+// the root (html) element.
+func parseSpineItem(item epub.Itemref) (*positionNode, error) {
+	f, err := item.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var root, current *positionNode
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &positionNode{name: strings.ToLower(t.Name.Local), parent: current}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "id" {
+					node.id = attr.Value
+				}
+			}
+
+			if current != nil {
+				current.children = append(current.children, node)
+			} else if root == nil {
+				root = node
+			}
+			current = node
+		case xml.EndElement:
+			if current != nil {
+				current = current.parent
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}
@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+func createTestEPUB(t *testing.T, chapters map[string]string) string {
+	epubPath := filepath.Join(t.TempDir(), "Test Book.epub")
+	f, err := os.Create(epubPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	files := map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Test Book</dc:title></metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="ch1"/><itemref idref="ch2"/></spine>
+</package>`,
+	}
+	for name, content := range chapters {
+		files["OEBPS/"+name] = content
+	}
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	return epubPath
+}
+
+func TestPositionTranslation(t *testing.T) {
+	epubPath := createTestEPUB(t, map[string]string{
+		"ch1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>One</title></head><body><p>Start</p></body></html>`,
+		"ch2.xhtml": `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head><title>Two</title></head>
+  <body>
+    <div id="main">
+      <p>First</p>
+      <p>Second <a href="#note"><img src="note.png"/></a></p>
+      <h1><a href="#top"><img src="title.png"/></a></h1>
+      <p>Third&nbsp;paragraph<br/>continued</p>
+    </div>
+  </body>
+</html>`,
+	})
+
+	t.Run("xpointer to cfi", func(t *testing.T) {
+		for xpointer, want := range map[string]string{
+			"/body/DocFragment[2]/body/div/p[3]/text().5":  "epubcfi(/6/4[ch2]!/4/2[main]/8)",
+			"/body/DocFragment[2]/body/div/p[2]/text()[2]": "epubcfi(/6/4[ch2]!/4/2[main]/4)",
+			"/body/DocFragment[1]/body/p/text().0":         "epubcfi(/6/2[ch1]!/4/2)",
+			"/body/DocFragment[1]":                         "epubcfi(/6/2[ch1]!/4)",
+
+			// KOReader Omits Inline Parent (h1/a/img)
+			"/body/DocFragment[2]/body/div/h1/img.0": "epubcfi(/6/4[ch2]!/4/2[main]/6/2/2)",
+
+			// Unknown Element - Deepest Match
+			"/body/DocFragment[2]/body/div/p[9]": "epubcfi(/6/4[ch2]!/4/2[main])",
+		} {
+			cfi, err := GetCFIFromXPointer(epubPath, xpointer)
+			assert.NoError(t, err, xpointer)
+			assert.Equal(t, want, cfi, xpointer)
+		}
+	})
+
+	t.Run("cfi to xpointer", func(t *testing.T) {
+		for cfi, want := range map[string]string{
+			"epubcfi(/6/4[ch2]!/4/2[main]/8/1:3)":       "/body/DocFragment[2]/body/div[1]/p[3]",
+			"epubcfi(/6/4[ch2]!/4/2[main]/6/2/2)":       "/body/DocFragment[2]/body/div[1]/h1[1]/img[1]",
+			"epubcfi(/6/4[ch2]!/4/2[main]/4/2/2)":       "/body/DocFragment[2]/body/div[1]/p[2]/img[1]",
+			"epubcfi(/6/4[ch2]!/4/2[main]/2,/1:0,/1:3)": "/body/DocFragment[2]/body/div[1]/p[1]",
+			"epubcfi(/6/2!/4/2)":                        "/body/DocFragment[1]/body/p[1]",
+			"epubcfi(/6/99[ch2]!/4)":                    "/body/DocFragment[2]/body",
+			"epubcfi(/6/4[ch2]!/4/2[main]/8/99)":        "/body/DocFragment[2]/body/div[1]/p[3]",
+		} {
+			xpointer, err := GetXPointerFromCFI(epubPath, cfi)
+			assert.NoError(t, err, cfi)
+			assert.Equal(t, want, xpointer, cfi)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		cfi, err := GetCFIFromXPointer(epubPath, "/body/DocFragment[2]/body/div[1]/p[3]")
+		require.NoError(t, err)
+		xpointer, err := GetXPointerFromCFI(epubPath, cfi)
+		require.NoError(t, err)
+		assert.Equal(t, "/body/DocFragment[2]/body/div[1]/p[3]", xpointer)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := GetCFIFromXPointer(epubPath, "/body/DocFragment[3]/body")
+		assert.Error(t, err, "should error on unknown fragment")
+		_, err = GetCFIFromXPointer(epubPath, "12")
+		assert.Error(t, err, "should error on invalid xpointer")
+		_, err = GetXPointerFromCFI(epubPath, "epubcfi(/6/4)")
+		assert.Error(t, err, "should error on missing document path")
+		_, err = GetXPointerFromCFI(epubPath, "/6/4!/4")
+		assert.Error(t, err, "should error on invalid cfi")
+	})
+
+	t.Run("missing rootfile", func(t *testing.T) {
+		emptyPath := filepath.Join(t.TempDir(), "Empty.epub")
+		f, err := os.Create(emptyPath)
+		require.NoError(t, err)
+		zw := zip.NewWriter(f)
+		w, err := zw.Create("META-INF/container.xml")
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles/></container>`))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		require.NoError(t, f.Close())
+
+		_, err = GetCFIFromXPointer(emptyPath, "/body/DocFragment[1]/body")
+		assert.ErrorIs(t, err, epub.ErrNoRootfile)
+	})
+}
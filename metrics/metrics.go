@@ -0,0 +1,106 @@
+// Package metrics defines the Prometheus metrics served at /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "antholume"
+
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Registry holds the process wide metrics. Collectors bound to a specific
+// instance (e.g. database totals) are passed to Handler instead.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	KOSyncOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kosync_operations_total",
+		Help:      "KOSync API operations by operation and result.",
+	}, []string{"operation", "result"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by sqlc query name.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query"})
+
+	CacheTempTablesDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cache_temp_tables_duration_seconds",
+		Help:      "Duration of the statistics cache (streaks & document statistics) refresh.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	})
+
+	SearchRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "search_requests_total",
+		Help:      "Book searches by source and result.",
+	}, []string{"source", "result"})
+
+	Downloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "search_downloads_total",
+		Help:      "Book downloads by source and result.",
+	}, []string{"source", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		KOSyncOperations,
+		DBQueryDuration,
+		CacheTempTablesDuration,
+		SearchRequests,
+		Downloads,
+	)
+}
+
+// Handler serves the Registry metrics together with the provided collectors
+// in the Prometheus exposition format.
+func Handler(extraCollectors ...prometheus.Collector) http.Handler {
+	extraRegistry := prometheus.NewRegistry()
+	extraRegistry.MustRegister(extraCollectors...)
+
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, extraRegistry}, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// Result returns the result label value of an operation.
+func Result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
+
+// Desc returns the description of an instance bound metric.
+func Desc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+}
@@ -6,16 +6,23 @@ import (
 	"time"
 )
 
+const (
+	PSENamespace = "http://vaemendis.net/opds-pse/namespace"
+	PSERelStream = "http://vaemendis.net/opds-pse/stream"
+)
+
 // Feed root element for acquisition or navigation feed
 type Feed struct {
 	ID           string    `xml:"id,omitempty"`
 	XMLName      xml.Name  `xml:"feed"`
+	PSENamespace string    `xml:"xmlns:pse,attr,omitempty"`
 	Title        string    `xml:"title,omitempty"`
 	Updated      time.Time `xml:"updated,omitempty"`
 	Entries      []Entry   `xml:"entry,omitempty"`
 	Links        []Link    `xml:"link,omitempty"`
-	TotalResults int       `xml:"totalResults,omitempty"`
-	ItemsPerPage int       `xml:"itemsPerPage,omitempty"`
+	TotalResults int       `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults,omitempty"`
+	ItemsPerPage int       `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage,omitempty"`
+	StartIndex   int       `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex,omitempty"`
 }
 
 // Link link to different resources
@@ -25,9 +32,15 @@ type Link struct {
 	TypeLink            string                `xml:"type,attr"`
 	Title               string                `xml:"title,attr,omitempty"`
 	FacetGroup          string                `xml:"facetGroup,attr,omitempty"`
+	ActiveFacet         bool                  `xml:"activeFacet,attr,omitempty"`
 	Count               int                   `xml:"count,attr,omitempty"`
 	Price               *Price                `xml:"price,omitempty"`
 	IndirectAcquisition []IndirectAcquisition `xml:"indirectAcquisition"`
+
+	// OPDS Page Streaming Extension (https://github.com/anansi-project/opds-pse)
+	PSECount        int        `xml:"pse:count,attr,omitempty"`
+	PSELastRead     *int       `xml:"pse:lastRead,attr,omitempty"`
+	PSELastReadDate *time.Time `xml:"pse:lastReadDate,attr,omitempty"`
 }
 
 // Author represent the feed author or the entry author
@@ -80,6 +93,11 @@ type Price struct {
 type IndirectAcquisition struct {
 	TypeAcquisition     string                `xml:"type,attr"`
 	IndirectAcquisition []IndirectAcquisition `xml:"indirectAcquisition"`
+
+	// OPDS Page Streaming Extension (https://github.com/anansi-project/opds-pse)
+	PSECount        int        `xml:"pse:count,attr,omitempty"`
+	PSELastRead     *int       `xml:"pse:lastRead,attr,omitempty"`
+	PSELastReadDate *time.Time `xml:"pse:lastReadDate,attr,omitempty"`
 }
 
 // Serie store serie information from schema.org
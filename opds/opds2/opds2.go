@@ -0,0 +1,93 @@
+// https://github.com/opds-community/libopds2-go/blob/master/opds2/opds2.go
+package opds2
+
+import (
+	"time"
+)
+
+const (
+	MediaTypeFeed        = "application/opds+json"
+	MediaTypePublication = "application/opds-publication+json"
+
+	RelSelf        = "self"
+	RelStart       = "start"
+	RelFirst       = "first"
+	RelPrevious    = "previous"
+	RelNext        = "next"
+	RelLast        = "last"
+	RelSearch      = "search"
+	RelSubsection  = "subsection"
+	RelFacet       = "http://opds-spec.org/facet"
+	RelAcquisition = "http://opds-spec.org/acquisition"
+	RelImage       = "http://opds-spec.org/image"
+
+	TypeBook = "http://schema.org/Book"
+)
+
+// Feed is the root of a navigation or publication collection
+type Feed struct {
+	Metadata     Metadata      `json:"metadata"`
+	Links        []Link        `json:"links"`
+	Navigation   []Link        `json:"navigation,omitzero"`
+	Publications []Publication `json:"publications,omitzero"`
+	Facets       []Facet       `json:"facets,omitempty"`
+}
+
+// Facet is a group of links (e.g. sort order) applied to the current feed
+type Facet struct {
+	Metadata Metadata `json:"metadata"`
+	Links    []Link   `json:"links"`
+}
+
+// Metadata for a feed, including pagination information
+type Metadata struct {
+	Title         string     `json:"title"`
+	Modified      *time.Time `json:"modified,omitempty"`
+	NumberOfItems int64      `json:"numberOfItems,omitempty"`
+	ItemsPerPage  int64      `json:"itemsPerPage,omitempty"`
+	CurrentPage   int64      `json:"currentPage,omitempty"`
+}
+
+// Link to a resource, href is a URI template when templated is set
+type Link struct {
+	Href       string          `json:"href"`
+	TypeLink   string          `json:"type,omitempty"`
+	Rel        string          `json:"rel,omitempty"`
+	Title      string          `json:"title,omitempty"`
+	Templated  bool            `json:"templated,omitempty"`
+	Properties *LinkProperties `json:"properties,omitempty"`
+}
+
+// LinkProperties are additional link properties (e.g. facet item counts)
+type LinkProperties struct {
+	NumberOfItems int64 `json:"numberOfItems,omitempty"`
+}
+
+// Publication is a single entry in a publication collection
+type Publication struct {
+	Metadata PublicationMetadata `json:"metadata"`
+	Links    []Link              `json:"links"`
+	Images   []Link              `json:"images,omitempty"`
+}
+
+// PublicationMetadata follows the Readium Web Publication Manifest metadata
+type PublicationMetadata struct {
+	RDFType     string        `json:"@type,omitempty"`
+	Identifier  string        `json:"identifier,omitempty"`
+	Title       string        `json:"title"`
+	Author      []Contributor `json:"author,omitempty"`
+	Subject     []Subject     `json:"subject,omitempty"`
+	Description string        `json:"description,omitempty"`
+}
+
+// Subject of a publication, optionally within a scheme
+type Subject struct {
+	Name   string `json:"name"`
+	Scheme string `json:"scheme,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// Contributor is an author or other contributor of a publication
+type Contributor struct {
+	Name string `json:"name"`
+}
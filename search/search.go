@@ -11,6 +11,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"reichard.io/antholume/metadata"
+	"reichard.io/antholume/metrics"
 )
 
 const userAgent string = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
@@ -59,7 +60,10 @@ func SearchBook(query string, source Source) ([]SearchItem, error) {
 		return nil, fmt.Errorf("invalid source: %s", source)
 	}
 	log.Debug("Source: ", source)
-	return searchFunc(query)
+
+	searchResults, err := searchFunc(query)
+	metrics.SearchRequests.WithLabelValues(string(source), metrics.Result(err)).Inc()
+	return searchResults, err
 }
 
 func SaveBook(md5 string, source Source, progressFunc func(float32)) (string, *metadata.MetadataInfo, error) {
@@ -86,10 +90,12 @@ func SaveBook(md5 string, source Source, progressFunc func(float32)) (string, *m
 				continue
 			}
 
+			metrics.Downloads.WithLabelValues(string(source), metrics.ResultSuccess).Inc()
 			return fileName, metadata, nil
 		}
 	}
 
+	metrics.Downloads.WithLabelValues(string(source), metrics.ResultError).Inc()
 	return "", nil, errors.New("failed to download book")
 }
 
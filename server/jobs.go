@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"reichard.io/antholume/api"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+const (
+	jobPollInterval = 5 * time.Second
+	jobRetryDelay   = 30 * time.Second
+)
+
+// jobOptions configure how the jobs of a type are run
+type jobOptions struct {
+	// Maximum number of jobs of the type running at once (defaults to 1)
+	concurrency int
+	// Maximum duration of a single attempt (0 is unlimited)
+	timeout time.Duration
+	// Interval at which the job is enqueued (0 is unscheduled)
+	schedule time.Duration
+}
+
+type jobDefinition struct {
+	jobOptions
+	jobType string
+	payload string
+	run     func(ctx context.Context, payload string) error
+}
+
+type runningJob struct {
+	jobType string
+	cancel  context.CancelFunc
+}
+
+// jobRunner runs the persisted background jobs. Jobs are claimed per type up
+// to the type concurrency, failed attempts are retried with exponential
+// backoff until the job max attempts is reached, and scheduled jobs are
+// enqueued when no job of the type ran within the schedule interval.
+type jobRunner struct {
+	db          *database.DBManager
+	definitions []*jobDefinition
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[int64]*runningJob
+}
+
+func newJobRunner(db *database.DBManager) *jobRunner {
+	return &jobRunner{
+		db:      db,
+		running: make(map[int64]*runningJob),
+	}
+}
+
+// registerJob registers the handler of the typed job T
+func registerJob[T api.Job](r *jobRunner, opts jobOptions, handler func(ctx context.Context, job T) error) {
+	var zeroJob T
+	payload, err := json.Marshal(zeroJob)
+	if err != nil {
+		panic(fmt.Sprintf("invalid job payload %T: %v", zeroJob, err))
+	}
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+
+	r.definitions = append(r.definitions, &jobDefinition{
+		jobOptions: opts,
+		jobType:    zeroJob.JobType(),
+		payload:    string(payload),
+		run: func(ctx context.Context, payload string) error {
+			var job T
+			if err := json.Unmarshal([]byte(payload), &job); err != nil {
+				return fmt.Errorf("invalid job payload: %w", err)
+			}
+			return handler(ctx, job)
+		},
+	})
+}
+
+// start requeues the jobs interrupted by a previous shutdown, then runs jobs
+// until done is closed.
+func (r *jobRunner) start(done <-chan int, queue <-chan struct{}) {
+	ctx := context.Background()
+	if count, err := r.db.Queries.RecoverJobs(ctx); err != nil {
+		log.Warn("Recovering jobs failed: ", err)
+	} else if count > 0 {
+		log.Infof("requeued %d interrupted jobs", count)
+	}
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-queue:
+		case <-done:
+			log.Info("Stopping job runner...")
+			r.stop()
+			return
+		}
+	}
+}
+
+// poll enqueues the due scheduled jobs, cancels the jobs cancelled by an
+// admin, and claims queued jobs up to each type concurrency.
+func (r *jobRunner) poll(ctx context.Context) {
+	for _, def := range r.definitions {
+		if def.schedule == 0 {
+			continue
+		}
+		if _, err := r.db.Queries.ScheduleJob(ctx, database.ScheduleJobParams{
+			Type:     def.jobType,
+			Payload:  def.payload,
+			Interval: fmt.Sprintf("-%d seconds", int(def.schedule.Seconds())),
+		}); err != nil {
+			log.Warnf("scheduling %s job failed: %v", def.jobType, err)
+		}
+	}
+
+	r.cancelStoppedJobs(ctx)
+
+	for _, def := range r.definitions {
+		for r.runningCount(def.jobType) < def.concurrency {
+			job, err := r.db.Queries.ClaimJob(ctx, def.jobType)
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			} else if err != nil {
+				log.Warnf("claiming %s job failed: %v", def.jobType, err)
+				break
+			}
+			r.runJob(def, job)
+		}
+	}
+}
+
+// cancelStoppedJobs cancels the running jobs which are no longer running in
+// the DB (i.e. cancelled by an admin).
+func (r *jobRunner) cancelStoppedJobs(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for jobID, job := range r.running {
+		dbJob, err := r.db.Queries.GetJob(ctx, jobID)
+		if err != nil {
+			log.Warnf("getting job %d failed: %v", jobID, err)
+		} else if dbJob.Status != string(api.JobRunning) {
+			log.Infof("cancelling %s job %d", job.jobType, jobID)
+			job.cancel()
+		}
+	}
+}
+
+func (r *jobRunner) runningCount(jobType string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, job := range r.running {
+		if job.jobType == jobType {
+			count++
+		}
+	}
+	return count
+}
+
+// runJob runs the claimed job in the background and records the result
+func (r *jobRunner) runJob(def *jobDefinition, job database.Job) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if def.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), def.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	r.mu.Lock()
+	r.running[job.ID] = &runningJob{jobType: def.jobType, cancel: cancel}
+	r.mu.Unlock()
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, job.ID)
+			r.mu.Unlock()
+			cancel()
+		}()
+
+		start := time.Now()
+		err := runJobHandler(ctx, def, job.Payload)
+		log.Debugf("%s job %d attempt %d completed in %s", def.jobType, job.ID, job.Attempts, time.Since(start))
+
+		// Stopped Runner - Requeued On Start
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			return
+		}
+
+		r.recordResult(def, job, err)
+	}()
+}
+
+// runJobHandler runs the job handler, converting panics to errors
+func runJobHandler(ctx context.Context, def *jobDefinition, payload string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return def.run(ctx, payload)
+}
+
+// recordResult completes the job, or requeues it with backoff if attempts
+// remain. Scheduled jobs only keep their latest successful run.
+func (r *jobRunner) recordResult(def *jobDefinition, job database.Job, jobErr error) {
+	ctx := context.Background()
+
+	var err error
+	if jobErr == nil {
+		err = r.db.Queries.CompleteJob(ctx, database.CompleteJobParams{Status: string(api.JobSucceeded), ID: job.ID})
+		if err == nil && def.schedule > 0 {
+			_, err = r.db.Queries.DeletePreviousJobs(ctx, database.DeletePreviousJobsParams{Type: job.Type, ID: job.ID})
+		}
+	} else if job.Attempts < job.MaxAttempts {
+		log.Warnf("%s job %d attempt %d failed: %v", job.Type, job.ID, job.Attempts, jobErr)
+		retryDelay := jobRetryDelay << (job.Attempts - 1)
+		err = r.db.Queries.RetryJob(ctx, database.RetryJobParams{
+			Error: ptr.Of(jobErr.Error()),
+			RunAt: time.Now().UTC().Add(retryDelay).Format(time.RFC3339),
+			ID:    job.ID,
+		})
+	} else {
+		log.Errorf("%s job %d failed: %v", job.Type, job.ID, jobErr)
+		err = r.db.Queries.CompleteJob(ctx, database.CompleteJobParams{
+			Status: string(api.JobFailed),
+			Error:  ptr.Of(jobErr.Error()),
+			ID:     job.ID,
+		})
+	}
+
+	if err != nil {
+		log.Errorf("recording %s job %d result failed: %v", job.Type, job.ID, err)
+	}
+}
+
+// stop cancels the running jobs and waits for them to return. They remain
+// running in the DB, and are requeued on next start.
+func (r *jobRunner) stop() {
+	r.mu.Lock()
+	for _, job := range r.running {
+		job.cancel()
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reichard.io/antholume/config"
+	"reichard.io/antholume/database"
+	"reichard.io/antholume/pkg/ptr"
+)
+
+type testJob struct {
+	Fail bool `json:"fail"`
+}
+
+func (testJob) JobType() string { return "test" }
+
+type testScheduledJob struct{}
+
+func (testScheduledJob) JobType() string { return "test_scheduled" }
+
+func TestJobRunner(t *testing.T) {
+	db := database.NewMgr(&config.Config{DBType: "memory"})
+	ctx := t.Context()
+
+	runner := newJobRunner(db)
+	var ranJobs []testJob
+	registerJob(runner, jobOptions{}, func(_ context.Context, job testJob) error {
+		ranJobs = append(ranJobs, job)
+		if job.Fail {
+			return errors.New("job failure")
+		}
+		return nil
+	})
+
+	createJob := func(payload string) database.Job {
+		job, err := db.Queries.CreateJob(ctx, database.CreateJobParams{Type: "test", Payload: payload, CreatedBy: ptr.Of("admin")})
+		require.NoError(t, err)
+		return job
+	}
+	getJob := func(jobID int64) database.Job {
+		job, err := db.Queries.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		return job
+	}
+	pollJobs := func() {
+		runner.poll(ctx)
+		runner.wg.Wait()
+	}
+
+	// Success
+	job := createJob(`{"fail":false}`)
+	pollJobs()
+	job = getJob(job.ID)
+	assert.Equal(t, "succeeded", job.Status)
+	assert.Equal(t, int64(1), job.Attempts)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, []testJob{{Fail: false}}, ranJobs)
+
+	// Retry With Backoff
+	job = createJob(`{"fail":true}`)
+	pollJobs()
+	job = getJob(job.ID)
+	assert.Equal(t, "queued", job.Status)
+	assert.Equal(t, "job failure", ptr.Deref(job.Error))
+	assert.Greater(t, job.RunAt, time.Now().UTC().Format(time.RFC3339))
+
+	pollJobs()
+	assert.Equal(t, int64(1), getJob(job.ID).Attempts)
+
+	// Max Attempts - Failed
+	for range job.MaxAttempts - 1 {
+		_, err := db.DB.ExecContext(ctx, "UPDATE jobs SET run_at = created_at WHERE id = ?", job.ID)
+		require.NoError(t, err)
+		pollJobs()
+	}
+	job = getJob(job.ID)
+	assert.Equal(t, "failed", job.Status)
+	assert.Equal(t, job.MaxAttempts, job.Attempts)
+	assert.Len(t, ranJobs, 4)
+
+	// Requeue
+	count, err := db.Queries.RequeueJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	job = getJob(job.ID)
+	assert.Equal(t, "queued", job.Status)
+	assert.Equal(t, int64(0), job.Attempts)
+
+	// Cancel
+	count, err = db.Queries.CancelJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	pollJobs()
+	assert.Equal(t, "cancelled", getJob(job.ID).Status)
+	assert.Len(t, ranJobs, 4)
+
+	// Unknown Type - Not Claimed
+	unknownJob, err := db.Queries.CreateJob(ctx, database.CreateJobParams{Type: "unknown", Payload: "{}"})
+	require.NoError(t, err)
+	pollJobs()
+	assert.Equal(t, "queued", getJob(unknownJob.ID).Status)
+}
+
+func TestJobRunnerCancel(t *testing.T) {
+	db := database.NewMgr(&config.Config{DBType: "memory"})
+	ctx := t.Context()
+
+	runner := newJobRunner(db)
+	started := make(chan struct{})
+	registerJob(runner, jobOptions{}, func(ctx context.Context, _ testJob) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	job, err := db.Queries.CreateJob(ctx, database.CreateJobParams{Type: "test", Payload: "{}"})
+	require.NoError(t, err)
+	runner.poll(ctx)
+	<-started
+
+	// Admin Cancel - Handler Context Cancelled
+	_, err = db.Queries.CancelJob(ctx, job.ID)
+	require.NoError(t, err)
+	runner.poll(ctx)
+	runner.wg.Wait()
+
+	job, err = db.Queries.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", job.Status)
+}
+
+func TestJobRunnerSchedule(t *testing.T) {
+	db := database.NewMgr(&config.Config{DBType: "memory"})
+	ctx := t.Context()
+
+	runner := newJobRunner(db)
+	var runCount int
+	registerJob(runner, jobOptions{schedule: time.Hour}, func(_ context.Context, _ testScheduledJob) error {
+		runCount++
+		return nil
+	})
+
+	// Scheduled Once Per Interval
+	for range 3 {
+		runner.poll(ctx)
+		runner.wg.Wait()
+	}
+	assert.Equal(t, 1, runCount)
+
+	jobs, err := db.Queries.GetJobs(ctx, database.GetJobsParams{Timezone: "UTC", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "test_scheduled", jobs[0].Type)
+	assert.Equal(t, "succeeded", jobs[0].Status)
+
+	// Enqueued Run - Only Latest Success Kept
+	job, err := db.Queries.CreateJob(ctx, database.CreateJobParams{Type: "test_scheduled", Payload: "{}"})
+	require.NoError(t, err)
+	runner.poll(ctx)
+	runner.wg.Wait()
+	assert.Equal(t, 2, runCount)
+
+	jobs, err = db.Queries.GetJobs(ctx, database.GetJobsParams{Timezone: "UTC", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+}
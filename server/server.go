@@ -15,6 +15,7 @@ import (
 )
 
 type server struct {
+	cfg  *config.Config
 	db   *database.DBManager
 	api  *api.API
 	done chan int
@@ -27,6 +28,7 @@ func New(c *config.Config, assets fs.FS) *server {
 	api := api.NewApi(db, c, assets)
 
 	return &server{
+		cfg:  c,
 		db:   db,
 		api:  api,
 		done: make(chan int),
@@ -50,20 +52,26 @@ func (s *server) Start() {
 	go func() {
 		defer s.wg.Done()
 
-		ticker := time.NewTicker(15 * time.Minute)
-		defer ticker.Stop()
-
-		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(5*time.Minute))
-		for {
-			select {
-			case <-ticker.C:
-				s.runScheduledTasks(ctx)
-			case <-s.done:
-				log.Info("Stopping task runner...")
-				cancel()
-				return
-			}
+		runner := newJobRunner(s.db)
+		registerJob(runner, jobOptions{schedule: 15 * time.Minute, timeout: 5 * time.Minute}, func(ctx context.Context, _ api.CacheTablesJob) error {
+			return s.api.CacheTempTables(ctx)
+		})
+		registerJob(runner, jobOptions{schedule: 15 * time.Minute, timeout: 5 * time.Minute}, func(ctx context.Context, _ api.CleanupJob) error {
+			return s.api.DeleteExpiredData(ctx)
+		})
+		registerJob(runner, jobOptions{schedule: 30 * time.Second, timeout: 5 * time.Minute}, func(ctx context.Context, _ api.DeliverWebhooksJob) error {
+			return s.api.DeliverWebhooks(ctx)
+		})
+		registerJob(runner, jobOptions{schedule: time.Hour}, func(ctx context.Context, _ api.RotateCookieKeysJob) error {
+			return s.api.RotateCookieKeys(ctx)
+		})
+		if len(s.cfg.WatchDirectories) > 0 {
+			registerJob(runner, jobOptions{schedule: 10 * time.Second}, func(ctx context.Context, _ api.ScanWatchDirectoriesJob) error {
+				return s.api.ScanWatchDirectories(ctx)
+			})
 		}
+		registerJob(runner, jobOptions{}, s.api.RunImportJob)
+		runner.start(s.done, s.api.JobQueue())
 	}()
 
 	log.Info("Server started")
@@ -82,12 +90,3 @@ func (s *server) Stop() {
 
 	log.Info("Server stopped")
 }
-
-// Run normal scheduled tasks
-func (s *server) runScheduledTasks(ctx context.Context) {
-	start := time.Now()
-	if err := s.db.CacheTempTables(ctx); err != nil {
-		log.Warn("Refreshing temp table cache failed: ", err)
-	}
-	log.Debug("Completed in: ", time.Since(start))
-}